@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type ApprovalController struct {
+	logger          lib.Logger
+	approvalService services.ApprovalService
+}
+
+// NewApprovalController creates new approval controller
+func NewApprovalController(
+	logger lib.Logger,
+	approvalService services.ApprovalService,
+) ApprovalController {
+	return ApprovalController{
+		logger:          logger,
+		approvalService: approvalService,
+	}
+}
+
+// @tags Approval
+// @summary ApprovalFlow Query
+// @produce application/json
+// @param data query models.ApprovalFlowQueryParam true "ApprovalFlowQueryParam"
+// @success 200 {object} echox.Response{data=models.ApprovalFlowQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/flows [get]
+func (a ApprovalController) QueryFlow(ctx echo.Context) error {
+	param := new(models.ApprovalFlowQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.approvalService.QueryFlow(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary ApprovalFlow Get By ID
+// @produce application/json
+// @param id path int true "approval flow id"
+// @success 200 {object} echox.Response{data=models.ApprovalFlow} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/flows/{id} [get]
+func (a ApprovalController) GetFlow(ctx echo.Context) error {
+	flow, err := a.approvalService.GetFlow(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusNotFound, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: flow}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary ApprovalFlow Create, one flow per company and document type
+// @produce application/json
+// @param data body models.ApprovalFlow true "ApprovalFlow"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/flows [post]
+func (a ApprovalController) CreateFlow(ctx echo.Context) error {
+	flow := new(models.ApprovalFlow)
+	if err := ctx.Bind(flow); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	flow.CreatedBy = claims.Username
+
+	id, err := a.approvalService.WithTrx(trxHandle).CreateFlow(flow)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: echo.Map{"id": id}}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary ApprovalFlow Update By ID, the steps are replaced
+// @produce application/json
+// @param id path int true "approval flow id"
+// @param data body models.ApprovalFlow true "ApprovalFlow"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/flows/{id} [put]
+func (a ApprovalController) UpdateFlow(ctx echo.Context) error {
+	flow := new(models.ApprovalFlow)
+	if err := ctx.Bind(flow); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	flow.UpdateBy = claims.Username
+
+	if err := a.approvalService.WithTrx(trxHandle).UpdateFlow(ctx.Param("id"), flow); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary ApprovalFlow Delete By ID
+// @produce application/json
+// @param id path int true "approval flow id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/flows/{id} [delete]
+func (a ApprovalController) DeleteFlow(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+
+	if err := a.approvalService.WithTrx(trxHandle).DeleteFlow(ctx.Param("id")); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary Approval Query
+// @produce application/json
+// @param data query models.ApprovalRequestQueryParam true "ApprovalRequestQueryParam"
+// @success 200 {object} echox.Response{data=models.ApprovalRequestQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals [get]
+func (a ApprovalController) Query(ctx echo.Context) error {
+	param := new(models.ApprovalRequestQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.approvalService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary Approval Pending, the documents waiting on the current user
+// @produce application/json
+// @param data query models.ApprovalRequestQueryParam true "ApprovalRequestQueryParam"
+// @success 200 {object} echox.Response{data=models.ApprovalRequestQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/pending [get]
+func (a ApprovalController) QueryPending(ctx echo.Context) error {
+	param := new(models.ApprovalRequestQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	qr, err := a.approvalService.QueryPending(claims.ID, param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary Approval Get of a document with its current step, pending approvers and history
+// @produce application/json
+// @param doc_type path string true "BKK, INV, KBS or TRD"
+// @param doc_id path string true "document id"
+// @success 200 {object} echox.Response{data=models.ApprovalRequest} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/{doc_type}/{doc_id} [get]
+func (a ApprovalController) Get(ctx echo.Context) error {
+	approval, err := a.approvalService.Get(ctx.Param("doc_type"), ctx.Param("doc_id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	} else if approval == nil {
+		return echox.Response{Code: http.StatusNotFound, Message: errors.ApprovalRequestNotFound}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: approval}.JSON(ctx)
+}
+
+// @tags Approval
+// @summary Approval Timeline of a document, every submit, decision and resubmit with user, time, IP and comment
+// @produce application/json
+// @param doc_type path string true "BKK, INV, KBS or TRD"
+// @param doc_id path string true "document id"
+// @success 200 {object} echox.Response{data=models.ApprovalTimeline} "ok"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/approvals/{doc_type}/{doc_id}/timeline [get]
+func (a ApprovalController) Timeline(ctx echo.Context) error {
+	timeline, err := a.approvalService.Timeline(ctx.Param("doc_type"), ctx.Param("doc_id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusNotFound, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: timeline}.JSON(ctx)
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+)
+
+type AttachmentController struct {
+	logger            lib.Logger
+	attachmentService services.AttachmentService
+}
+
+// NewAttachmentController creates new attachment controller
+func NewAttachmentController(
+	logger lib.Logger,
+	attachmentService services.AttachmentService,
+) AttachmentController {
+	return AttachmentController{
+		logger:            logger,
+		attachmentService: attachmentService,
+	}
+}
+
+// @tags Attachment
+// @summary Attachment Query, the attachments of a document
+// @produce application/json
+// @param data query models.AttachmentQueryParam true "AttachmentQueryParam"
+// @success 200 {object} echox.Response{data=models.AttachmentQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/attachments [get]
+func (a AttachmentController) Query(ctx echo.Context) error {
+	param := new(models.AttachmentQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	qr, err := a.attachmentService.Query(param, claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Attachment
+// @summary Attachment Create, uploads a file of a document
+// @accept multipart/form-data
+// @produce application/json
+// @param doc_type formData string true "BKK, INV, KBS or TRD"
+// @param doc_id formData string true "document id"
+// @param file formData file true "file"
+// @success 200 {object} echox.Response{data=models.Attachment} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/attachments [post]
+func (a AttachmentController) Create(ctx echo.Context) error {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+	defer src.Close()
+
+	// one byte over the limit is enough to reject the file
+	data, err := ioutil.ReadAll(io.LimitReader(src, a.attachmentService.MaxSize()+1))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	attachment := &models.Attachment{
+		DocType:  ctx.FormValue("doc_type"),
+		DocID:    ctx.FormValue("doc_id"),
+		FileName: file.Filename,
+	}
+	attachment.CreatedBy = claims.Username
+	attachment.UpdateBy = claims.Username
+
+	attachment, err = a.attachmentService.WithTrx(trxHandle).Create(attachment, data, claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: attachment}.JSON(ctx)
+}
+
+// @tags Attachment
+// @summary Attachment Download By ID
+// @produce application/octet-stream
+// @param id path string true "attachment id"
+// @success 200 {file} file "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/attachments/{id}/download [get]
+func (a AttachmentController) Download(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	attachment, content, err := a.attachmentService.Open(ctx.Param("id"), claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+	defer content.Close()
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	return ctx.Stream(http.StatusOK, attachment.MimeType, content)
+}
+
+// @tags Attachment
+// @summary Attachment Delete By ID
+// @produce application/json
+// @param id path string true "attachment id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/attachments/{id} [delete]
+func (a AttachmentController) Delete(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	attachment, err := a.attachmentService.WithTrx(trxHandle).Delete(ctx.Param("id"), claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	// the content is kept while the delete can still be rolled back
+	echox.AfterCommit(ctx, func() {
+		if err := a.attachmentService.RemoveContent(attachment); err != nil {
+			a.logger.Zap.Errorf("Error to remove attachment %s content: %v", attachment.ID, err)
+		}
+	})
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+)
+
+type AuditLogController struct {
+	logger          lib.Logger
+	auditlogService services.AuditLogService
+}
+
+// NewAuditLogController creates new audit log controller
+func NewAuditLogController(
+	logger lib.Logger,
+	auditlogService services.AuditLogService,
+) AuditLogController {
+	return AuditLogController{
+		logger:          logger,
+		auditlogService: auditlogService,
+	}
+}
+
+// @tags AuditLog
+// @summary AuditLog Query, filtered by entity, user and date range
+// @produce application/json
+// @param data query models.AuditLogQueryParam true "AuditLogQueryParam"
+// @success 200 {object} echox.Response{data=models.AuditLogQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/auditlogs [get]
+func (a AuditLogController) Query(ctx echo.Context) error {
+	param := new(models.AuditLogQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.auditlogService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags AuditLog
+// @summary AuditLog Get By ID
+// @produce application/json
+// @param id path int true "auditlog id"
+// @success 200 {object} echox.Response{data=models.AuditLog} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/auditlogs/{id} [get]
+func (a AuditLogController) Get(ctx echo.Context) error {
+	auditlog, err := a.auditlogService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: auditlog}.JSON(ctx)
+}
@@ -1,9 +1,8 @@
 package controllers
 
 import (
-	"io"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
@@ -11,6 +10,7 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/models"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
 	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/Aguztinus/petty-cash-backend/pkg/xlsx"
 	"github.com/labstack/echo/v4"
 
 	"gorm.io/gorm"
@@ -19,16 +19,19 @@ import (
 type BKKDetailController struct {
 	logger           lib.Logger
 	bkkdetailService services.BKKDetailService
+	reportService    services.ReportService
 }
 
 // NewBKKDetailController creates new bkkdetail controller
 func NewBKKDetailController(
 	logger lib.Logger,
 	bkkdetailService services.BKKDetailService,
+	reportService services.ReportService,
 ) BKKDetailController {
 	return BKKDetailController{
 		logger:           logger,
 		bkkdetailService: bkkdetailService,
+		reportService:    reportService,
 	}
 }
 
@@ -193,80 +196,92 @@ func (a BKKDetailController) Disable(ctx echo.Context) error {
 }
 
 // @tags BKKDetail
-// @summary BKKDetail GetFile
+// @summary BKKDetail Expense Analysis by period, account, cost centre and department
 // @produce application/json
-// @param id path int true "bkkdetail file id"
-// @success 200 {object} echox.Response "ok"
+// @param data query models.ExpenseAnalysisParam true "ExpenseAnalysisParam"
+// @success 200 {object} echox.Response{data=models.ExpenseAnalysisResult} "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/bkkdetails/upload [delete]
-func (a BKKDetailController) GetFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+// @router /api/bkkdetails/analysis [get]
+func (a BKKDetailController) Analysis(ctx echo.Context) error {
+	param := new(models.ExpenseAnalysisParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	analysis, err := a.bkkdetailService.Analysis(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
 
-	img := "./upload/" + claims.Username + "/" + ctx.Param("id")
-	return ctx.File(img)
+	return echox.Response{Code: http.StatusOK, Data: analysis}.JSON(ctx)
 }
 
 // @tags BKKDetail
-// @summary BKKDetail UploadFile By ID
+// @summary BKKDetail Expense Analysis drill-down to the vouchers of an account, cost centre, department or period
 // @produce application/json
-// @param id path int true "bkkdetail file"
-// @success 200 {object} echox.Response "ok"
+// @param data query models.ExpenseAnalysisParam true "ExpenseAnalysisParam"
+// @success 200 {object} echox.Response{data=models.ExpenseVouchers} "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/bkkdetails/upload [post]
-func (a BKKDetailController) UploadFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-
-	// Source
-	file, err := ctx.FormFile("file")
-	if err != nil {
+// @router /api/bkkdetails/analysis/vouchers [get]
+func (a BKKDetailController) AnalysisVouchers(ctx echo.Context) error {
+	param := new(models.ExpenseAnalysisParam)
+	if err := ctx.Bind(param); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	src, err := file.Open()
+
+	vouchers, err := a.bkkdetailService.Vouchers(param)
 	if err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	defer src.Close()
 
-	if _, err := os.Stat("./upload/" + claims.Username); os.IsNotExist(err) {
-		err := os.Mkdir("./upload/"+claims.Username, os.ModePerm)
-		if err != nil {
-			return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-		}
-	}
-	// Destination
-	img := "./upload/" + claims.Username + "/" + claims.Username + "-" + file.Filename
-	dst, err := os.Create(img)
-	if err != nil {
+	return echox.Response{Code: http.StatusOK, Data: vouchers}.JSON(ctx)
+}
+
+// @tags BKKDetail
+// @summary BKKDetail Expense Analysis Report PDF
+// @produce application/pdf
+// @param data query models.ExpenseAnalysisParam true "ExpenseAnalysisParam"
+// @success 200 {file} file "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/bkkdetails/analysis/pdf [get]
+func (a BKKDetailController) AnalysisPdf(ctx echo.Context) error {
+	param := new(models.ExpenseAnalysisParam)
+	if err := ctx.Bind(param); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	defer dst.Close()
 
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	data, err := a.reportService.GenerateExpenseAnalysis(param, claims.Username)
+	if err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	return echox.Response{Code: http.StatusOK, Message: claims.Username + "-" + file.Filename}.JSON(ctx)
+
+	return ctx.Blob(http.StatusOK, "application/pdf", data)
 }
 
 // @tags BKKDetail
-// @summary BKKDetail RemoveFile
-// @produce application/json
-// @param id path int true "bkkdetail file id"
-// @success 200 {object} echox.Response "ok"
+// @summary BKKDetail Expense Analysis Report XLSX
+// @produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @param data query models.ExpenseAnalysisParam true "ExpenseAnalysisParam"
+// @success 200 {file} file "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/bkkdetails/upload [delete]
-func (a BKKDetailController) RemoveFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+// @router /api/bkkdetails/analysis/xlsx [get]
+func (a BKKDetailController) AnalysisXlsx(ctx echo.Context) error {
+	param := new(models.ExpenseAnalysisParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
 
-	// Delete
-	img := "./upload/" + claims.Username + "/" + claims.Username + "-" + ctx.Param("id")
-	err := os.Remove(img)
+	data, err := a.reportService.GenerateExpenseAnalysisXlsx(param)
 	if err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	return echox.Response{Code: http.StatusOK, Message: img}.JSON(ctx)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="expense-analysis-`+time.Now().Format("20060102")+`.xlsx"`)
+	return ctx.Blob(http.StatusOK, xlsx.ContentType, data)
 }
@@ -174,7 +174,9 @@ func (a BKKHeaderController) Update(ctx echo.Context) error {
 // @router /api/bkkheaders/{id} [delete]
 func (a BKKHeaderController) Delete(ctx echo.Context) error {
 	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
-	if err := a.bkkheaderService.WithTrx(trxHandle).Delete(ctx.Param("id")); err != nil {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).Delete(ctx.Param("id"), claims.Username); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -182,7 +184,7 @@ func (a BKKHeaderController) Delete(ctx echo.Context) error {
 }
 
 // @tags BKKHeader
-// @summary BKKHeader StatusPaid By ID
+// @summary BKKHeader StatusPaid By ID, only an approved BKK can be paid and it is posted to Saldo once
 // @produce application/json
 // @param id path int true "bkkheader id"
 // @success 200 {object} echox.Response "ok"
@@ -190,7 +192,10 @@ func (a BKKHeaderController) Delete(ctx echo.Context) error {
 // @failure 500 {object} echox.Response "internal error"
 // @router /api/bkkheaders/{id}/statuspaid [patch]
 func (a BKKHeaderController) StatusPaid(ctx echo.Context) error {
-	if err := a.bkkheaderService.UpdateStatus(ctx.Param("id"), "Paid", 0); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).Pay(ctx.Param("id"), claims.Username); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -211,7 +216,16 @@ func (a BKKHeaderController) StatusApprove(ctx echo.Context) error {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	if err := a.bkkheaderService.UpdateApprove(ids.IDs, 1); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision := &models.ApprovalDecision{
+		Comment:   ids.Comment,
+		UserID:    claims.ID,
+		Username:  claims.Username,
+		IPAddress: ctx.RealIP(),
+	}
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).Approve(ids.IDs, decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -232,9 +246,137 @@ func (a BKKHeaderController) StatusReject(ctx echo.Context) error {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	if err := a.bkkheaderService.UpdateApprove(ids.IDs, 2); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision := &models.ApprovalDecision{
+		Comment:   ids.Comment,
+		UserID:    claims.ID,
+		Username:  claims.Username,
+		IPAddress: ctx.RealIP(),
+	}
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).Reject(ids.IDs, decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags BKKHeader
+// @summary BKKHeader Resubmit By ID, sends a rejected and corrected BKK through the approval again
+// @produce application/json
+// @param id path int true "bkkheader id"
+// @param data body models.ApprovalDecision true "ApprovalDecision"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/bkkheaders/{id}/resubmit [patch]
+func (a BKKHeaderController) Resubmit(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).Resubmit(ctx.Param("id"), decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags BKKHeader
+// @summary BKKHeader Void By ID, keeps the document and a posted BKK gets a reversing journal on the void date
+// @produce application/json
+// @param id path int true "bkkheader id"
+// @param data body models.VoidParam true "VoidParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/bkkheaders/{id}/void [patch]
+func (a BKKHeaderController) Void(ctx echo.Context) error {
+	param := new(models.VoidParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).Void(ctx.Param("id"), param, claims.Username); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
 	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
+
+// @tags BKKHeader
+// @summary BKKHeader possible duplicate lines, the review queue of the approvers
+// @produce application/json
+// @param data query models.BKKDuplicateQueryParam true "BKKDuplicateQueryParam"
+// @success 200 {object} echox.Response{data=models.BKKDuplicateQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/bkkheaders/duplicates [get]
+func (a BKKHeaderController) QueryDuplicates(ctx echo.Context) error {
+	param := new(models.BKKDuplicateQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.bkkheaderService.QueryDuplicates(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags BKKHeader
+// @summary BKKHeader Review a possible duplicate line, Confirmed or Dismissed
+// @produce application/json
+// @param id path string true "bkkduplicate id"
+// @param data body models.BKKDuplicateReviewParam true "BKKDuplicateReviewParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/bkkheaders/duplicates/{id} [patch]
+func (a BKKHeaderController) ReviewDuplicate(ctx echo.Context) error {
+	param := new(models.BKKDuplicateReviewParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.bkkheaderService.WithTrx(trxHandle).ReviewDuplicate(ctx.Param("id"), param, claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags BKKHeader
+// @summary BKKHeader Print the Bukti Kas Keluar voucher By ID
+// @produce application/pdf
+// @param id path string true "bkkheader id"
+// @success 200 {file} file "pdf"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/bkkheaders/{id}/print [get]
+func (a BKKHeaderController) Print(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	data, err := a.reportService.GenerateBKKVoucher(ctx.Param("id"), claims.Username)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return ctx.Blob(http.StatusOK, "application/pdf", data)
+}
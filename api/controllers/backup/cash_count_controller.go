@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type CashCountController struct {
+	logger           lib.Logger
+	cashcountService services.CashCountService
+	reportService    services.ReportService
+}
+
+// NewCashCountController creates new cashcount controller
+func NewCashCountController(
+	logger lib.Logger,
+	cashcountService services.CashCountService,
+	reportService services.ReportService,
+) CashCountController {
+	return CashCountController{
+		logger:           logger,
+		cashcountService: cashcountService,
+		reportService:    reportService,
+	}
+}
+
+// @tags CashCount
+// @summary CashCount Query
+// @produce application/json
+// @param data query models.CashCountQueryParam true "CashCountQueryParam"
+// @success 200 {object} echox.Response{data=models.CashCountQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts [get]
+func (a CashCountController) Query(ctx echo.Context) error {
+	param := new(models.CashCountQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.cashcountService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Denominations And Reason Codes
+// @produce application/json
+// @success 200 {object} echox.Response "ok"
+// @router /api/cashcounts/denominations [get]
+func (a CashCountController) Denominations(ctx echo.Context) error {
+	return echox.Response{Code: http.StatusOK, Data: echo.Map{
+		"denominations": models.CashCountDenominations,
+		"reasons":       models.CashCountReasons,
+	}}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Get By ID
+// @produce application/json
+// @param id path int true "cashcount id"
+// @success 200 {object} echox.Response{data=models.CashCount} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts/{id} [get]
+func (a CashCountController) Get(ctx echo.Context) error {
+	cashcount, err := a.cashcountService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: cashcount}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Create
+// @produce application/json
+// @param data body models.CashCount true "CashCount"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts [post]
+func (a CashCountController) Create(ctx echo.Context) error {
+	cashcount := new(models.CashCount)
+	if err := ctx.Bind(cashcount); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	cashcount.CreatedBy = claims.Username
+
+	id, err := a.cashcountService.WithTrx(trxHandle).Create(cashcount)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: echo.Map{"id": id}}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Update By ID
+// @produce application/json
+// @param id path int true "cashcount id"
+// @param data body models.CashCount true "CashCount"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts/{id} [put]
+func (a CashCountController) Update(ctx echo.Context) error {
+	cashcount := new(models.CashCount)
+	if err := ctx.Bind(cashcount); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	cashcount.UpdateBy = claims.Username
+
+	if err := a.cashcountService.WithTrx(trxHandle).Update(ctx.Param("id"), cashcount); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Delete By ID
+// @produce application/json
+// @param id path int true "cashcount id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts/{id} [delete]
+func (a CashCountController) Delete(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	if err := a.cashcountService.WithTrx(trxHandle).Delete(ctx.Param("id")); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Approve By ID
+// @produce application/json
+// @param id path int true "cashcount id"
+// @param data body models.CashCountApproveParam true "CashCountApproveParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts/{id}/approve [patch]
+func (a CashCountController) Approve(ctx echo.Context) error {
+	param := new(models.CashCountApproveParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.cashcountService.WithTrx(trxHandle).Approve(ctx.Param("id"), param, claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Reject By ID
+// @produce application/json
+// @param id path int true "cashcount id"
+// @param data body models.CashCountApproveParam true "CashCountApproveParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts/{id}/reject [patch]
+func (a CashCountController) Reject(ctx echo.Context) error {
+	param := new(models.CashCountApproveParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.cashcountService.WithTrx(trxHandle).Reject(ctx.Param("id"), param, claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags CashCount
+// @summary CashCount Print By ID
+// @produce application/pdf
+// @param id path int true "cashcount id"
+// @success 200 {file} file "pdf"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/cashcounts/{id}/print [get]
+func (a CashCountController) Print(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	data, err := a.reportService.GenerateCashCount(ctx.Param("id"), claims.Username)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return ctx.Blob(http.StatusOK, "application/pdf", data)
+}
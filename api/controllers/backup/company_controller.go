@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
@@ -189,3 +192,231 @@ func (a CompanyController) Disable(ctx echo.Context) error {
 
 	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
+
+// @tags Company
+// @summary Company Upload the letterhead logo, PNG or JPEG
+// @accept multipart/form-data
+// @produce application/json
+// @param id path string true "company id"
+// @param file formData file true "logo"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/logo [post]
+func (a CompanyController) UploadLogo(ctx echo.Context) error {
+	data, err := a.readImage(ctx, true)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	if err := a.companyService.WithTrx(trxHandle).UploadLogo(ctx.Param("id"), data); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Company
+// @summary Company Get the letterhead logo
+// @produce image/png
+// @param id path string true "company id"
+// @success 200 {file} file "logo"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/logo [get]
+func (a CompanyController) GetLogo(ctx echo.Context) error {
+	data, mimeType, err := a.companyService.OpenLogo(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return ctx.Blob(http.StatusOK, mimeType, data)
+}
+
+// @tags Company
+// @summary Company Query the authorised signatories
+// @produce application/json
+// @param id path string true "company id"
+// @success 200 {object} echox.Response{data=models.CompanySignatureQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/signatures [get]
+func (a CompanyController) QuerySignatures(ctx echo.Context) error {
+	param := new(models.CompanySignatureQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+	param.CompanyID = ctx.Param("id")
+
+	qr, err := a.companyService.QuerySignatures(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Company
+// @summary Company Create the authorised signatory of a role
+// @accept multipart/form-data
+// @produce application/json
+// @param id path string true "company id"
+// @param role_id formData string true "role id"
+// @param name formData string true "signatory name"
+// @param title formData string false "signatory title"
+// @param reports formData bool false "printed under the monitoring reports"
+// @param sequence formData int false "order under the monitoring reports"
+// @param file formData file true "signature image"
+// @success 200 {object} echox.Response{data=models.CompanySignature} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/signatures [post]
+func (a CompanyController) CreateSignature(ctx echo.Context) error {
+	signature, err := a.bindSignature(ctx)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	data, err := a.readImage(ctx, true)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	signature.CreatedBy = claims.Username
+	signature.UpdateBy = claims.Username
+
+	signature, err = a.companyService.WithTrx(trxHandle).CreateSignature(signature, data)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: signature}.JSON(ctx)
+}
+
+// @tags Company
+// @summary Company Update the authorised signatory of a role, the image is kept when no file is sent
+// @accept multipart/form-data
+// @produce application/json
+// @param id path string true "company id"
+// @param signatureId path string true "signature id"
+// @param role_id formData string true "role id"
+// @param name formData string true "signatory name"
+// @param title formData string false "signatory title"
+// @param reports formData bool false "printed under the monitoring reports"
+// @param sequence formData int false "order under the monitoring reports"
+// @param file formData file false "signature image"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/signatures/{signatureId} [put]
+func (a CompanyController) UpdateSignature(ctx echo.Context) error {
+	signature, err := a.bindSignature(ctx)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	data, err := a.readImage(ctx, false)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	signature.UpdateBy = claims.Username
+
+	if err := a.companyService.WithTrx(trxHandle).UpdateSignature(ctx.Param("signatureId"), signature, data); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Company
+// @summary Company Delete the authorised signatory of a role
+// @produce application/json
+// @param id path string true "company id"
+// @param signatureId path string true "signature id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/signatures/{signatureId} [delete]
+func (a CompanyController) DeleteSignature(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+
+	if err := a.companyService.WithTrx(trxHandle).DeleteSignature(ctx.Param("id"), ctx.Param("signatureId")); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Company
+// @summary Company Get the image of an authorised signatory
+// @produce image/png
+// @param id path string true "company id"
+// @param signatureId path string true "signature id"
+// @success 200 {file} file "signature"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/companies/{id}/signatures/{signatureId}/image [get]
+func (a CompanyController) GetSignatureImage(ctx echo.Context) error {
+	data, mimeType, err := a.companyService.OpenSignature(ctx.Param("id"), ctx.Param("signatureId"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return ctx.Blob(http.StatusOK, mimeType, data)
+}
+
+func (a CompanyController) bindSignature(ctx echo.Context) (*models.CompanySignature, error) {
+	signature := &models.CompanySignature{
+		CompanyID: ctx.Param("id"),
+		RoleID:    ctx.FormValue("role_id"),
+		Name:      ctx.FormValue("name"),
+		Title:     ctx.FormValue("title"),
+	}
+
+	if v := ctx.FormValue("reports"); v != "" {
+		reports, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+		signature.Reports = reports
+	}
+
+	if v := ctx.FormValue("sequence"); v != "" {
+		sequence, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, err
+		}
+		signature.Sequence = sequence
+	}
+
+	if err := ctx.Validate(signature); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// readImage reads the uploaded file, without a file it returns nil unless the file is required
+func (a CompanyController) readImage(ctx echo.Context, required bool) ([]byte, error) {
+	file, err := ctx.FormFile("file")
+	if err == http.ErrMissingFile && !required {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	// one byte over the limit is enough to reject the file
+	return ioutil.ReadAll(io.LimitReader(src, a.companyService.MaxImageSize()+1))
+}
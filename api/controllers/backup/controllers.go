@@ -23,4 +23,15 @@ var Module = fx.Options(
 	fx.Provide(NewTarikDanaController),
 	fx.Provide(NewInvoiceHeaderController),
 	fx.Provide(NewInvoiceDetailController),
+	fx.Provide(NewJournalController),
+	fx.Provide(NewCashCountController),
+	fx.Provide(NewReplenishmentController),
+	fx.Provide(NewApprovalController),
+	fx.Provide(NewAuditLogController),
+	fx.Provide(NewAttachmentController),
+	fx.Provide(NewReportJobController),
+	fx.Provide(NewReportSubscriptionController),
+	fx.Provide(NewNotificationController),
+	fx.Provide(NewWebhookController),
+	fx.Provide(NewGLExportController),
 )
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type GLExportController struct {
+	glexportService services.GLExportService
+	logger          lib.Logger
+}
+
+// NewGLExportController creates new glexport controller
+func NewGLExportController(
+	glexportService services.GLExportService,
+	logger lib.Logger,
+) GLExportController {
+	return GLExportController{
+		glexportService: glexportService,
+		logger:          logger,
+	}
+}
+
+// @tags GLExport
+// @summary GLExport Query
+// @produce application/json
+// @param data query models.GLExportQueryParam true "GLExportQueryParam"
+// @success 200 {object} echox.Response{data=models.GLExportQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/glexports [get]
+func (a GLExportController) Query(ctx echo.Context) error {
+	param := new(models.GLExportQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.glexportService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags GLExport
+// @summary GLExport Get By ID
+// @produce application/json
+// @param id path string true "glexport id"
+// @success 200 {object} echox.Response{data=models.GLExport} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/glexports/{id} [get]
+func (a GLExportController) Get(ctx echo.Context) error {
+	export, err := a.glexportService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: export}.JSON(ctx)
+}
+
+// @tags GLExport
+// @summary GLExport Export the posted journals of a period that were not exported yet
+// @produce application/json
+// @param data body models.GLExportParam true "GLExportParam"
+// @success 200 {object} echox.Response{data=models.GLExport} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/glexports [post]
+func (a GLExportController) Export(ctx echo.Context) error {
+	param := new(models.GLExportParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	if err := ctx.Validate(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	export, err := a.glexportService.WithTrx(trxHandle).Export(param, claims.Username)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: export}.JSON(ctx)
+}
+
+// @tags GLExport
+// @summary GLExport Csv downloads the GL_INTERFACE rows of an export
+// @produce text/csv
+// @param id path string true "glexport id"
+// @success 200 {file} file "gl interface"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/glexports/{id}/csv [get]
+func (a GLExportController) Csv(ctx echo.Context) error {
+	export, err := a.glexportService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	} else if export.ResetAt.Valid {
+		return echox.Response{Code: http.StatusBadRequest, Message: errors.GLExportAlreadyReset}.JSON(ctx)
+	}
+
+	name := "gl_interface-" + strconv.FormatInt(export.GroupID, 10) + ".csv"
+	ctx.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+name+`"`)
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	// the status is sent already, a failure can only cut the stream short
+	if err := a.glexportService.WriteCsv(ctx.Response(), export.ID); err != nil {
+		a.logger.Zap.Errorf("Error to stream gl export %s csv: %v", export.ID, err)
+	}
+
+	return nil
+}
+
+// @tags GLExport
+// @summary GLExport Reset By ID, its journals are taken again by the next export of their period
+// @produce application/json
+// @param id path string true "glexport id"
+// @param data body models.GLExportResetParam true "GLExportResetParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/glexports/{id}/reset [post]
+func (a GLExportController) Reset(ctx echo.Context) error {
+	param := new(models.GLExportResetParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	if err := ctx.Validate(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.glexportService.WithTrx(trxHandle).Reset(ctx.Param("id"), param, claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
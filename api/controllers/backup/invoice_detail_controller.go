@@ -1,9 +1,7 @@
 package controllers
 
 import (
-	"io"
 	"net/http"
-	"os"
 
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
@@ -213,82 +211,3 @@ func (a InvoiceDetailController) Disable(ctx echo.Context) error {
 
 	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
-
-// @tags InvoiceDetail
-// @summary InvoiceDetail GetFile
-// @produce application/json
-// @param id path int true "invoicedetail file id"
-// @success 200 {object} echox.Response "ok"
-// @failure 400 {object} echox.Response "bad request"
-// @failure 500 {object} echox.Response "internal error"
-// @router /api/invoicedetails/upload [delete]
-func (a InvoiceDetailController) GetFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-
-	img := "./upload/" + claims.Username + "/" + ctx.Param("id")
-	return ctx.File(img)
-}
-
-// @tags InvoiceDetail
-// @summary InvoiceDetail UploadFile By ID
-// @produce application/json
-// @param id path int true "invoicedetail file"
-// @success 200 {object} echox.Response "ok"
-// @failure 400 {object} echox.Response "bad request"
-// @failure 500 {object} echox.Response "internal error"
-// @router /api/invoicedetails/upload [post]
-func (a InvoiceDetailController) UploadFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-
-	// Source
-	file, err := ctx.FormFile("file")
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	src, err := file.Open()
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	defer src.Close()
-
-	if _, err := os.Stat("./upload/" + claims.Username); os.IsNotExist(err) {
-		err := os.Mkdir("./upload/"+claims.Username, os.ModePerm)
-		if err != nil {
-			return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-		}
-	}
-	// Destination
-	img := "./upload/" + claims.Username + "/" + claims.Username + "-" + file.Filename
-	dst, err := os.Create(img)
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	defer dst.Close()
-
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	return echox.Response{Code: http.StatusOK, Message: claims.Username + "-" + file.Filename}.JSON(ctx)
-}
-
-// @tags InvoiceDetail
-// @summary InvoiceDetail RemoveFile
-// @produce application/json
-// @param id path int true "invoicedetail file id"
-// @success 200 {object} echox.Response "ok"
-// @failure 400 {object} echox.Response "bad request"
-// @failure 500 {object} echox.Response "internal error"
-// @router /api/invoicedetails/upload [delete]
-func (a InvoiceDetailController) RemoveFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-
-	// Delete
-	img := "./upload/" + claims.Username + "/" + claims.Username + "-" + ctx.Param("id")
-	err := os.Remove(img)
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-
-	return echox.Response{Code: http.StatusOK, Message: img}.JSON(ctx)
-}
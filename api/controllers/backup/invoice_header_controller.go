@@ -1,9 +1,7 @@
 package controllers
 
 import (
-	"io"
 	"net/http"
-	"os"
 
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
@@ -153,7 +151,9 @@ func (a InvoiceHeaderController) Update(ctx echo.Context) error {
 // @router /api/invoiceheaders/{id} [delete]
 func (a InvoiceHeaderController) Delete(ctx echo.Context) error {
 	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
-	if err := a.invoiceheaderService.WithTrx(trxHandle).Delete(ctx.Param("id")); err != nil {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.invoiceheaderService.WithTrx(trxHandle).Delete(ctx.Param("id"), claims.Username); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -206,7 +206,16 @@ func (a InvoiceHeaderController) StatusApprove(ctx echo.Context) error {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	if err := a.invoiceheaderService.UpdateApprove(ids.IDs, 1); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision := &models.ApprovalDecision{
+		Comment:   ids.Comment,
+		UserID:    claims.ID,
+		Username:  claims.Username,
+		IPAddress: ctx.RealIP(),
+	}
+
+	if err := a.invoiceheaderService.WithTrx(trxHandle).Approve(ids.IDs, decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -227,7 +236,16 @@ func (a InvoiceHeaderController) StatusReject(ctx echo.Context) error {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	if err := a.invoiceheaderService.UpdateApprove(ids.IDs, 2); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision := &models.ApprovalDecision{
+		Comment:   ids.Comment,
+		UserID:    claims.ID,
+		Username:  claims.Username,
+		IPAddress: ctx.RealIP(),
+	}
+
+	if err := a.invoiceheaderService.WithTrx(trxHandle).Reject(ids.IDs, decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -248,7 +266,16 @@ func (a InvoiceHeaderController) StatusApproveFinal(ctx echo.Context) error {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	if err := a.invoiceheaderService.UpdateApprove(ids.IDs, 3); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision := &models.ApprovalDecision{
+		Comment:   ids.Comment,
+		UserID:    claims.ID,
+		Username:  claims.Username,
+		IPAddress: ctx.RealIP(),
+	}
+
+	if err := a.invoiceheaderService.WithTrx(trxHandle).Approve(ids.IDs, decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -269,88 +296,46 @@ func (a InvoiceHeaderController) StatusRejectFinal(ctx echo.Context) error {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	if err := a.invoiceheaderService.UpdateApprove(ids.IDs, 4); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision := &models.ApprovalDecision{
+		Comment:   ids.Comment,
+		UserID:    claims.ID,
+		Username:  claims.Username,
+		IPAddress: ctx.RealIP(),
+	}
+
+	if err := a.invoiceheaderService.WithTrx(trxHandle).Reject(ids.IDs, decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
 	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
 
-// @tags Invoice
-// @summary Invoice GetFile
-// @produce application/json
-// @param id path int true "invoice file id"
-// @success 200 {object} echox.Response "ok"
-// @failure 400 {object} echox.Response "bad request"
-// @failure 500 {object} echox.Response "internal error"
-// @router /api/invoices/upload [delete]
-func (a InvoiceHeaderController) GetFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-	img := "./upload/invoice/" + claims.Username + "/" + ctx.Param("id")
-
-	return ctx.File(img)
-}
-
-// @tags Invoice
-// @summary Invoice UploadFile By ID
+// @tags InvoiceHeader
+// @summary InvoiceHeader Resubmit By ID, sends a rejected and corrected invoice through the approval again
 // @produce application/json
-// @param id path int true "invoice file"
+// @param id path int true "invoiceheader id"
+// @param data body models.ApprovalDecision true "ApprovalDecision"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/invoices/upload [post]
-func (a InvoiceHeaderController) UploadFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-
-	// Source
-	file, err := ctx.FormFile("file")
-	if err != nil {
+// @router /api/invoiceheaders/{id}/resubmit [patch]
+func (a InvoiceHeaderController) Resubmit(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	src, err := file.Open()
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	defer src.Close()
 
-	if _, err := os.Stat("./upload/invoice/" + claims.Username); os.IsNotExist(err) {
-		err := os.Mkdir("./upload/invoice/"+claims.Username, os.ModePerm)
-		if err != nil {
-			return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-		}
-	}
-	// Destination
-	img := "./upload/invoice/" + claims.Username + "/" + claims.Username + "-" + file.Filename
-	dst, err := os.Create(img)
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	defer dst.Close()
-
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-	return echox.Response{Code: http.StatusOK, Message: claims.Username + "-" + file.Filename}.JSON(ctx)
-}
-
-// @tags Invoice
-// @summary Invoice RemoveFile
-// @produce application/json
-// @param id path int true "invoice file id"
-// @success 200 {object} echox.Response "ok"
-// @failure 400 {object} echox.Response "bad request"
-// @failure 500 {object} echox.Response "internal error"
-// @router /api/invoices/upload [delete]
-func (a InvoiceHeaderController) RemoveFile(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
 	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
 
-	// Delete
-	img := "./upload/invoice/" + claims.Username + "/" + claims.Username + "-" + ctx.Param("id")
-	err := os.Remove(img)
-	if err != nil {
+	if err := a.invoiceheaderService.WithTrx(trxHandle).Resubmit(ctx.Param("id"), decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	return echox.Response{Code: http.StatusOK, Message: img}.JSON(ctx)
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
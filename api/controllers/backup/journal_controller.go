@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+)
+
+type JournalController struct {
+	logger         lib.Logger
+	journalService services.JournalService
+}
+
+// NewJournalController creates new journal controller
+func NewJournalController(
+	logger lib.Logger,
+	journalService services.JournalService,
+) JournalController {
+	return JournalController{
+		logger:         logger,
+		journalService: journalService,
+	}
+}
+
+// @tags Journal
+// @summary Journal Query
+// @produce application/json
+// @param data query models.JournalHeaderQueryParam true "JournalHeaderQueryParam"
+// @success 200 {object} echox.Response{data=models.JournalHeaderQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/journals [get]
+func (a JournalController) Query(ctx echo.Context) error {
+	param := new(models.JournalHeaderQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.journalService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Journal
+// @summary Journal Get By ID
+// @produce application/json
+// @param id path int true "journal id"
+// @success 200 {object} echox.Response{data=models.JournalHeader} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/journals/{id} [get]
+func (a JournalController) Get(ctx echo.Context) error {
+	journal, err := a.journalService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: journal}.JSON(ctx)
+}
@@ -1,9 +1,7 @@
 package controllers
 
 import (
-	"io"
 	"net/http"
-	"os"
 
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
@@ -11,6 +9,7 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/models"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
 	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/Aguztinus/petty-cash-backend/pkg/xlsx"
 	"github.com/labstack/echo/v4"
 
 	"gorm.io/gorm"
@@ -19,16 +18,19 @@ import (
 type KasbonController struct {
 	logger        lib.Logger
 	kasbonService services.KasbonService
+	reportService services.ReportService
 }
 
 // NewKasbonController creates new kasbon controller
 func NewKasbonController(
 	logger lib.Logger,
 	kasbonService services.KasbonService,
+	reportService services.ReportService,
 ) KasbonController {
 	return KasbonController{
 		logger:        logger,
 		kasbonService: kasbonService,
+		reportService: reportService,
 	}
 }
 
@@ -153,7 +155,56 @@ func (a KasbonController) Update(ctx echo.Context) error {
 // @router /api/kasbons/{id} [delete]
 func (a KasbonController) Delete(ctx echo.Context) error {
 	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
-	if err := a.kasbonService.WithTrx(trxHandle).Delete(ctx.Param("id")); err != nil {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.kasbonService.WithTrx(trxHandle).Delete(ctx.Param("id"), claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Kasbon
+// @summary Kasbon Release By ID
+// @produce application/json
+// @param id path int true "kasbon id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/kasbons/{id}/release [patch]
+func (a KasbonController) Release(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.kasbonService.WithTrx(trxHandle).Release(ctx.Param("id"), claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Kasbon
+// @summary Kasbon Approve By ID, records the decision of the current approval step
+// @produce application/json
+// @param id path int true "kasbon id"
+// @param data body models.ApprovalDecision true "ApprovalDecision, comment is required to reject"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/kasbons/{id}/approve [patch]
+func (a KasbonController) Approve(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
+
+	if err := a.kasbonService.WithTrx(trxHandle).Approve(ctx.Param("id"), decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -161,80 +212,171 @@ func (a KasbonController) Delete(ctx echo.Context) error {
 }
 
 // @tags Kasbon
-// @summary Kasbon GetFile
+// @summary Kasbon Reject By ID
 // @produce application/json
-// @param id path int true "kasbon file id"
+// @param id path int true "kasbon id"
+// @param data body models.ApprovalDecision true "ApprovalDecision, comment is required to reject"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/kasbons/upload [delete]
-func (a KasbonController) GetFile(ctx echo.Context) error {
+// @router /api/kasbons/{id}/reject [patch]
+func (a KasbonController) Reject(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
 	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-	img := "./upload/" + claims.Username + "/" + ctx.Param("id")
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
+
+	if err := a.kasbonService.WithTrx(trxHandle).Reject(ctx.Param("id"), decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
 
-	return ctx.File(img)
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
 
 // @tags Kasbon
-// @summary Kasbon UploadFile By ID
+// @summary Kasbon Settle By ID, the full amount is returned to the cash box
 // @produce application/json
-// @param id path int true "kasbon file"
+// @param id path int true "kasbon id"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/kasbons/upload [post]
-func (a KasbonController) UploadFile(ctx echo.Context) error {
+// @router /api/kasbons/{id}/settle [patch]
+func (a KasbonController) Settle(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
 	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
 
-	// Source
-	file, err := ctx.FormFile("file")
+	if err := a.kasbonService.WithTrx(trxHandle).Settle(ctx.Param("id"), claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Kasbon
+// @summary Kasbon Settlement By ID against a BKK with the real receipts, the difference is refunded or paid out
+// @produce application/json
+// @param id path int true "kasbon id"
+// @param data body models.BKKHeader true "BKKHeader"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/kasbons/{id}/settlement [post]
+func (a KasbonController) Settlement(ctx echo.Context) error {
+	bkkheader := new(models.BKKHeader)
+	if err := ctx.Bind(bkkheader); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	settlement, err := a.kasbonService.WithTrx(trxHandle).SettleWithBKK(ctx.Param("id"), bkkheader, claims.Username)
 	if err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	src, err := file.Open()
+
+	return echox.Response{Code: http.StatusOK, Data: settlement}.JSON(ctx)
+}
+
+// @tags Kasbon
+// @summary Kasbon Aging of the released kasbons per employee, branch and company
+// @produce application/json
+// @param data query models.KasbonAgingParam true "KasbonAgingParam"
+// @success 200 {object} echox.Response{data=models.KasbonAgingResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/kasbons/aging [get]
+func (a KasbonController) Aging(ctx echo.Context) error {
+	param := new(models.KasbonAgingParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	aging, err := a.kasbonService.Aging(param)
 	if err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	defer src.Close()
 
-	if _, err := os.Stat("./upload/" + claims.Username); os.IsNotExist(err) {
-		err := os.Mkdir("./upload/"+claims.Username, os.ModePerm)
-		if err != nil {
-			return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-		}
+	return echox.Response{Code: http.StatusOK, Data: aging}.JSON(ctx)
+}
+
+// @tags Kasbon
+// @summary Kasbon Aging Report PDF
+// @produce application/pdf
+// @param data query models.KasbonAgingParam true "KasbonAgingParam"
+// @success 200 {file} file "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/kasbons/aging/pdf [get]
+func (a KasbonController) AgingPdf(ctx echo.Context) error {
+	param := new(models.KasbonAgingParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	// Destination
-	img := "./upload/" + claims.Username + "/" + claims.Username + "-" + file.Filename
-	dst, err := os.Create(img)
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	data, err := a.reportService.GenerateKasbonAging(param, claims.Username)
 	if err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	defer dst.Close()
 
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
+	return ctx.Blob(http.StatusOK, "application/pdf", data)
+}
+
+// @tags Kasbon
+// @summary Kasbon Aging Report XLSX
+// @produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @param data query models.KasbonAgingParam true "KasbonAgingParam"
+// @success 200 {file} file "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/kasbons/aging/xlsx [get]
+func (a KasbonController) AgingXlsx(ctx echo.Context) error {
+	param := new(models.KasbonAgingParam)
+	if err := ctx.Bind(param); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	return echox.Response{Code: http.StatusOK, Message: claims.Username + "-" + file.Filename}.JSON(ctx)
+
+	data, err := a.reportService.GenerateKasbonAgingXlsx(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="kasbon-aging-`+param.AsOf().Format("20060102")+`.xlsx"`)
+	return ctx.Blob(http.StatusOK, xlsx.ContentType, data)
 }
 
 // @tags Kasbon
-// @summary Kasbon RemoveFile
+// @summary Kasbon Resubmit By ID, sends a rejected and corrected kasbon through the approval again
 // @produce application/json
-// @param id path int true "kasbon file id"
+// @param id path int true "kasbon id"
+// @param data body models.ApprovalDecision true "ApprovalDecision"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/kasbons/upload [delete]
-func (a KasbonController) RemoveFile(ctx echo.Context) error {
+// @router /api/kasbons/{id}/resubmit [patch]
+func (a KasbonController) Resubmit(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
 	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
 
-	// Delete
-	img := "./upload/" + claims.Username + "/" + claims.Username + "-" + ctx.Param("id")
-	err := os.Remove(img)
-	if err != nil {
+	if err := a.kasbonService.WithTrx(trxHandle).Resubmit(ctx.Param("id"), decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	return echox.Response{Code: http.StatusOK, Message: img}.JSON(ctx)
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type NotificationController struct {
+	notificationService services.NotificationService
+	logger              lib.Logger
+}
+
+// NewNotificationController creates new notification controller
+func NewNotificationController(
+	notificationService services.NotificationService,
+	logger lib.Logger,
+) NotificationController {
+	return NotificationController{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// @tags Notification
+// @summary Notification Query, the in app notifications of the current user
+// @produce application/json
+// @param data query models.NotificationQueryParam true "NotificationQueryParam"
+// @success 200 {object} echox.Response{data=models.NotificationQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/notifications [get]
+func (a NotificationController) Query(ctx echo.Context) error {
+	param := new(models.NotificationQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	qr, err := a.notificationService.Query(param, claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Notification
+// @summary Notification Unread, the count of unread notifications of the current user
+// @produce application/json
+// @success 200 {object} echox.Response{data=models.NotificationUnread} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/notifications/unread [get]
+func (a NotificationController) Unread(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	unread, err := a.notificationService.Unread(claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: unread}.JSON(ctx)
+}
+
+// @tags Notification
+// @summary Notification Read By ID
+// @produce application/json
+// @param id path string true "notification id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/notifications/{id}/read [patch]
+func (a NotificationController) Read(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.notificationService.WithTrx(trxHandle).Read(ctx.Param("id"), claims.ID); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Notification
+// @summary Notification Read All, returns the count of notifications marked read
+// @produce application/json
+// @success 200 {object} echox.Response{data=models.NotificationUnread} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/notifications/read [patch]
+func (a NotificationController) ReadAll(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	read, err := a.notificationService.WithTrx(trxHandle).ReadAll(claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: read}.JSON(ctx)
+}
+
+// @tags Notification
+// @summary Notification Preferences, the channels of every event for the current user
+// @produce application/json
+// @success 200 {object} echox.Response{data=models.NotificationPreferences} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/notifications/preferences [get]
+func (a NotificationController) Preferences(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	preferences, err := a.notificationService.Preferences(claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: preferences}.JSON(ctx)
+}
+
+// @tags Notification
+// @summary Notification Update Preferences, the events left out keep their channels
+// @produce application/json
+// @param data body models.NotificationPreferences true "NotificationPreferences"
+// @success 200 {object} echox.Response{data=models.NotificationPreferences} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/notifications/preferences [put]
+func (a NotificationController) UpdatePreferences(ctx echo.Context) error {
+	preferences := make(models.NotificationPreferences, 0)
+	if err := ctx.Bind(&preferences); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	preferences, err := a.notificationService.WithTrx(trxHandle).UpdatePreferences(claims.ID, preferences)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: preferences}.JSON(ctx)
+}
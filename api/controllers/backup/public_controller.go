@@ -14,27 +14,24 @@ import (
 )
 
 type PublicController struct {
-	userService   services.UserService
-	authService   services.AuthService
-	reportService services.ReportService
-	captcha       lib.Captcha
-	logger        lib.Logger
+	userService services.UserService
+	authService services.AuthService
+	captcha     lib.Captcha
+	logger      lib.Logger
 }
 
 // NewPublicController creates new public controller
 func NewPublicController(
 	userService services.UserService,
 	authService services.AuthService,
-	reportService services.ReportService,
 	captcha lib.Captcha,
 	logger lib.Logger,
 ) PublicController {
 	return PublicController{
-		userService:   userService,
-		authService:   authService,
-		reportService: reportService,
-		captcha:       captcha,
-		logger:        logger,
+		userService: userService,
+		authService: authService,
+		captcha:     captcha,
+		logger:      logger,
 	}
 }
 
@@ -173,39 +170,3 @@ func (a PublicController) UserLogout(ctx echo.Context) error {
 
 	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
-
-func (a PublicController) LmdpReport(ctx echo.Context) error {
-	reportparams := new(dto.ReportMonitoring)
-
-	if err := ctx.Bind(reportparams); err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-	reportparams.UserId = claims.Username
-
-	err := a.reportService.GenerateLmdp(reportparams)
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-
-	return ctx.File("./pdfs/lmdp.pdf")
-}
-
-func (a PublicController) LrdpReport(ctx echo.Context) error {
-	reportparams := new(dto.ReportMonitoring)
-
-	if err := ctx.Bind(reportparams); err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-	reportparams.UserId = claims.Username
-
-	err := a.reportService.GenerateLrdp(reportparams)
-	if err != nil {
-		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-	}
-
-	return ctx.File("./pdfs/lrdp.pdf")
-}
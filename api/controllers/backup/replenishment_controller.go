@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type ReplenishmentController struct {
+	logger               lib.Logger
+	replenishmentService services.ReplenishmentService
+	tarikdanaService     services.TarikDanaService
+}
+
+// NewReplenishmentController creates new replenishment controller
+func NewReplenishmentController(
+	logger lib.Logger,
+	replenishmentService services.ReplenishmentService,
+	tarikdanaService services.TarikDanaService,
+) ReplenishmentController {
+	return ReplenishmentController{
+		logger:               logger,
+		replenishmentService: replenishmentService,
+		tarikdanaService:     tarikdanaService,
+	}
+}
+
+// @tags Replenishment
+// @summary Replenishment Query
+// @produce application/json
+// @param data query models.ReplenishmentQueryParam true "ReplenishmentQueryParam"
+// @success 200 {object} echox.Response{data=models.ReplenishmentQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/replenishments [get]
+func (a ReplenishmentController) Query(ctx echo.Context) error {
+	param := new(models.ReplenishmentQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.replenishmentService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Replenishment
+// @summary Replenishment Get By ID
+// @produce application/json
+// @param id path int true "replenishment id"
+// @success 200 {object} echox.Response{data=models.Replenishment} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/replenishments/{id} [get]
+func (a ReplenishmentController) Get(ctx echo.Context) error {
+	replenishment, err := a.replenishmentService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: replenishment}.JSON(ctx)
+}
+
+// @tags Replenishment
+// @summary Replenishment Create
+// @produce application/json
+// @param data body models.ReplenishmentParam true "ReplenishmentParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/replenishments [post]
+func (a ReplenishmentController) Create(ctx echo.Context) error {
+	param := new(models.ReplenishmentParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	id, err := a.replenishmentService.WithTrx(trxHandle).Create(param, claims.Username)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: echo.Map{"id": id}}.JSON(ctx)
+}
+
+// @tags Replenishment
+// @summary Replenishment Approve By ID, posts the TarikDana
+// @produce application/json
+// @param id path int true "replenishment id"
+// @param data body models.ReplenishmentApproveParam true "ReplenishmentApproveParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/replenishments/{id}/approve [patch]
+func (a ReplenishmentController) Approve(ctx echo.Context) error {
+	param := new(models.ReplenishmentApproveParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	id, err := a.tarikdanaService.WithTrx(trxHandle).Replenish(ctx.Param("id"), param, claims.Username)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: echo.Map{"tarik_dana_id": id}}.JSON(ctx)
+}
+
+// @tags Replenishment
+// @summary Replenishment Reject By ID
+// @produce application/json
+// @param id path int true "replenishment id"
+// @param data body models.ReplenishmentApproveParam true "ReplenishmentApproveParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/replenishments/{id}/reject [patch]
+func (a ReplenishmentController) Reject(ctx echo.Context) error {
+	param := new(models.ReplenishmentApproveParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.replenishmentService.WithTrx(trxHandle).Reject(ctx.Param("id"), param, claims.Username); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+)
+
+type ReportJobController struct {
+	reportjobService services.ReportJobService
+	logger           lib.Logger
+}
+
+// NewReportJobController creates new reportjob controller
+func NewReportJobController(
+	reportjobService services.ReportJobService,
+	logger lib.Logger,
+) ReportJobController {
+	return ReportJobController{
+		reportjobService: reportjobService,
+		logger:           logger,
+	}
+}
+
+// @tags ReportJob
+// @summary ReportJob Submit, the report is generated in the background
+// @produce application/json
+// @param data body models.ReportJobParam true "ReportJobParam"
+// @success 202 {object} echox.Response{data=jobqueue.Job} "accepted"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportjobs [post]
+func (a ReportJobController) Submit(ctx echo.Context) error {
+	param := new(models.ReportJobParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	job, err := a.reportjobService.Submit(param, claims.ID, claims.Username)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusAccepted, Data: job}.JSON(ctx)
+}
+
+// @tags ReportJob
+// @summary ReportJob Csv streams the report as CSV instead of queueing it
+// @produce text/csv
+// @param data query models.ReportJobParam true "ReportJobParam"
+// @success 200 {file} file "report"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportjobs/csv [get]
+func (a ReportJobController) Csv(ctx echo.Context) error {
+	param := new(models.ReportJobParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.reportjobService.Check(param, claims.ID); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	name := param.Type + "-" + time.Now().Format("20060102150405") + ".csv"
+	ctx.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+name+`"`)
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	// the status is sent already, a failure can only cut the stream short
+	if err := a.reportjobService.WriteCsv(ctx.Response(), param); err != nil {
+		a.logger.Zap.Errorf("Error to stream %s csv: %v", param.Type, err)
+	}
+
+	return nil
+}
+
+// @tags ReportJob
+// @summary ReportJob Status By ID
+// @produce application/json
+// @param id path string true "reportjob id"
+// @success 200 {object} echox.Response{data=jobqueue.Job} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportjobs/{id} [get]
+func (a ReportJobController) Get(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	job, err := a.reportjobService.Get(ctx.Param("id"), claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: job}.JSON(ctx)
+}
+
+// @tags ReportJob
+// @summary ReportJob Download the report of a done job
+// @produce application/pdf,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @param id path string true "reportjob id"
+// @success 200 {file} file "report"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportjobs/{id}/download [get]
+func (a ReportJobController) Download(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	path, name, err := a.reportjobService.Open(ctx.Param("id"), claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return ctx.Attachment(path, name)
+}
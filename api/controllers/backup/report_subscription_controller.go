@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type ReportSubscriptionController struct {
+	reportsubscriptionService services.ReportSubscriptionService
+	logger                    lib.Logger
+}
+
+// NewReportSubscriptionController creates new reportsubscription controller
+func NewReportSubscriptionController(
+	reportsubscriptionService services.ReportSubscriptionService,
+	logger lib.Logger,
+) ReportSubscriptionController {
+	return ReportSubscriptionController{
+		reportsubscriptionService: reportsubscriptionService,
+		logger:                    logger,
+	}
+}
+
+// @tags ReportSubscription
+// @summary ReportSubscription Query, the subscriptions of the current user
+// @produce application/json
+// @param data query models.ReportSubscriptionQueryParam true "ReportSubscriptionQueryParam"
+// @success 200 {object} echox.Response{data=models.ReportSubscriptionQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportsubscriptions [get]
+func (a ReportSubscriptionController) Query(ctx echo.Context) error {
+	param := new(models.ReportSubscriptionQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	qr, err := a.reportsubscriptionService.Query(param, claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags ReportSubscription
+// @summary ReportSubscription Get By ID
+// @produce application/json
+// @param id path string true "reportsubscription id"
+// @success 200 {object} echox.Response{data=models.ReportSubscription} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportsubscriptions/{id} [get]
+func (a ReportSubscriptionController) Get(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	subscription, err := a.reportsubscriptionService.Get(ctx.Param("id"), claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: subscription}.JSON(ctx)
+}
+
+// @tags ReportSubscription
+// @summary ReportSubscription Create, the report is emailed from the next run of the schedule
+// @produce application/json
+// @param data body models.ReportSubscription true "ReportSubscription"
+// @success 200 {object} echox.Response{data=models.ReportSubscription} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportsubscriptions [post]
+func (a ReportSubscriptionController) Create(ctx echo.Context) error {
+	subscription := new(models.ReportSubscription)
+	if err := ctx.Bind(subscription); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	if err := ctx.Validate(subscription); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	subscription.CreatedBy = claims.Username
+	subscription.UpdateBy = claims.Username
+
+	subscription, err := a.reportsubscriptionService.WithTrx(trxHandle).Create(subscription, claims.ID)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: subscription}.JSON(ctx)
+}
+
+// @tags ReportSubscription
+// @summary ReportSubscription Update By ID
+// @produce application/json
+// @param id path string true "reportsubscription id"
+// @param data body models.ReportSubscription true "ReportSubscription"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportsubscriptions/{id} [put]
+func (a ReportSubscriptionController) Update(ctx echo.Context) error {
+	subscription := new(models.ReportSubscription)
+	if err := ctx.Bind(subscription); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	if err := ctx.Validate(subscription); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	subscription.UpdateBy = claims.Username
+
+	if err := a.reportsubscriptionService.WithTrx(trxHandle).Update(ctx.Param("id"), subscription, claims.ID); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags ReportSubscription
+// @summary ReportSubscription Delete By ID
+// @produce application/json
+// @param id path string true "reportsubscription id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportsubscriptions/{id} [delete]
+func (a ReportSubscriptionController) Delete(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.reportsubscriptionService.WithTrx(trxHandle).Delete(ctx.Param("id"), claims.ID); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags ReportSubscription
+// @summary ReportSubscription Send the report by email now, to check the recipients and the SMTP settings
+// @produce application/json
+// @param id path string true "reportsubscription id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/reportsubscriptions/{id}/send [post]
+func (a ReportSubscriptionController) Send(ctx echo.Context) error {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.reportsubscriptionService.Send(ctx.Param("id"), claims.ID); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
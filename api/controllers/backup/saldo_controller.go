@@ -15,18 +15,21 @@ import (
 )
 
 type SaldoController struct {
-	logger       lib.Logger
-	saldoService services.SaldoService
+	logger            lib.Logger
+	saldoService      services.SaldoService
+	saldoReconService services.SaldoReconService
 }
 
 // NewSaldoController creates new saldo controller
 func NewSaldoController(
 	logger lib.Logger,
 	saldoService services.SaldoService,
+	saldoReconService services.SaldoReconService,
 ) SaldoController {
 	return SaldoController{
-		logger:       logger,
-		saldoService: saldoService,
+		logger:            logger,
+		saldoService:      saldoService,
+		saldoReconService: saldoReconService,
 	}
 }
 
@@ -206,3 +209,145 @@ func (a SaldoController) Disable(ctx echo.Context) error {
 
 	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
+
+// @tags Saldo
+// @summary SaldoMonth Query
+// @produce application/json
+// @param data query models.SaldoMonthQueryParam true "SaldoMonthQueryParam"
+// @success 200 {object} echox.Response{data=models.SaldoMonthQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/saldos/months [get]
+func (a SaldoController) QueryMonth(ctx echo.Context) error {
+	param := new(models.SaldoMonthQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.saldoService.QueryMonth(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Saldo
+// @summary SaldoMonth Close Period
+// @produce application/json
+// @param data body models.SaldoMonthCloseParam true "SaldoMonthCloseParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/saldos/months/close [post]
+func (a SaldoController) ClosePeriod(ctx echo.Context) error {
+	param := new(models.SaldoMonthCloseParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.saldoService.WithTrx(trxHandle).ClosePeriod(param, claims.Username, ctx.RealIP()); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Saldo
+// @summary SaldoMonth Reopen Period By ID
+// @produce application/json
+// @param id path int true "saldo month id"
+// @param data body models.SaldoMonthCloseParam true "SaldoMonthCloseParam"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/saldos/months/{id}/reopen [post]
+func (a SaldoController) ReopenPeriod(ctx echo.Context) error {
+	param := new(models.SaldoMonthCloseParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.saldoService.WithTrx(trxHandle).ReopenPeriod(ctx.Param("id"), param.Reason, claims.Username, ctx.RealIP()); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Saldo
+// @summary SaldoMonth Logs By ID
+// @produce application/json
+// @param id path int true "saldo month id"
+// @success 200 {object} echox.Response{data=models.SaldoMonthLogQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/saldos/months/{id}/logs [get]
+func (a SaldoController) QueryMonthLog(ctx echo.Context) error {
+	param := new(models.SaldoMonthLogQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+	param.SaldoMonthID = ctx.Param("id")
+
+	qr, err := a.saldoService.QueryMonthLog(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Saldo
+// @summary Saldo Reconcile Report (dry-run)
+// @produce application/json
+// @param data query models.SaldoReconParam true "SaldoReconParam"
+// @success 200 {object} echox.Response{data=models.SaldoReconResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/saldos/reconcile [get]
+func (a SaldoController) Reconcile(ctx echo.Context) error {
+	param := new(models.SaldoReconParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+	param.Fix = false
+
+	result, err := a.saldoReconService.Reconcile(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: result}.JSON(ctx)
+}
+
+// @tags Saldo
+// @summary Saldo Reconcile And Fix
+// @produce application/json
+// @param data body models.SaldoReconParam true "SaldoReconParam"
+// @success 200 {object} echox.Response{data=models.SaldoReconResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/saldos/reconcile [post]
+func (a SaldoController) ReconcileFix(ctx echo.Context) error {
+	param := new(models.SaldoReconParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+	param.Fix = true
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+
+	result, err := a.saldoReconService.WithTrx(trxHandle).Reconcile(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: result}.JSON(ctx)
+}
@@ -1,9 +1,7 @@
 package controllers
 
 import (
-	"io"
 	"net/http"
-	"os"
 
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
@@ -153,7 +151,9 @@ func (a TarikDanaController) Update(ctx echo.Context) error {
 // @router /api/tarikdanas/{id} [delete]
 func (a TarikDanaController) Delete(ctx echo.Context) error {
 	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
-	if err := a.tarikdanaService.WithTrx(trxHandle).Delete(ctx.Param("id")); err != nil {
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+
+	if err := a.tarikdanaService.WithTrx(trxHandle).Delete(ctx.Param("id"), claims.Username); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
@@ -193,80 +193,110 @@ func (a TarikDanaController) Disable(ctx echo.Context) error {
 }
 
 // @tags TarikDana
-// @summary TarikDana GetFile
+// @summary TarikDana Approve By ID, records the decision of the current approval step
 // @produce application/json
-// @param id path int true "tarikdana file id"
+// @param id path int true "tarikdana id"
+// @param data body models.ApprovalDecision true "ApprovalDecision, comment is required to reject"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/tarikdanas/upload [delete]
-func (a TarikDanaController) GetFile(ctx echo.Context) error {
+// @router /api/tarikdanas/{id}/approve [patch]
+func (a TarikDanaController) Approve(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
 	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-	img := "./upload/dana/" + claims.Username + "/" + ctx.Param("id")
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
 
-	return ctx.File(img)
+	if err := a.tarikdanaService.WithTrx(trxHandle).Approve(ctx.Param("id"), decision); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
 
 // @tags TarikDana
-// @summary TarikDana UploadFile By ID
+// @summary TarikDana Reject By ID
 // @produce application/json
-// @param id path int true "tarikdana file"
+// @param id path int true "tarikdana id"
+// @param data body models.ApprovalDecision true "ApprovalDecision, comment is required to reject"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/tarikdanas/upload [post]
-func (a TarikDanaController) UploadFile(ctx echo.Context) error {
-	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
-
-	// Source
-	file, err := ctx.FormFile("file")
-	if err != nil {
+// @router /api/tarikdanas/{id}/reject [patch]
+func (a TarikDanaController) Reject(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	src, err := file.Open()
-	if err != nil {
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
+
+	if err := a.tarikdanaService.WithTrx(trxHandle).Reject(ctx.Param("id"), decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	defer src.Close()
 
-	if _, err := os.Stat("./upload/dana/" + claims.Username); os.IsNotExist(err) {
-		err := os.Mkdir("./upload/dana/"+claims.Username, os.ModePerm)
-		if err != nil {
-			return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
-		}
-	}
-	// Destination
-	img := "./upload/dana/" + claims.Username + "/" + claims.Username + "-" + file.Filename
-	dst, err := os.Create(img)
-	if err != nil {
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags TarikDana
+// @summary TarikDana Resubmit By ID, sends a rejected and corrected TarikDana through the approval again
+// @produce application/json
+// @param id path int true "tarikdana id"
+// @param data body models.ApprovalDecision true "ApprovalDecision"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/tarikdanas/{id}/resubmit [patch]
+func (a TarikDanaController) Resubmit(ctx echo.Context) error {
+	decision := new(models.ApprovalDecision)
+	if err := ctx.Bind(decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	defer dst.Close()
 
-	// Copy
-	if _, err = io.Copy(dst, src); err != nil {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	decision.UserID = claims.ID
+	decision.Username = claims.Username
+	decision.IPAddress = ctx.RealIP()
+
+	if err := a.tarikdanaService.WithTrx(trxHandle).Resubmit(ctx.Param("id"), decision); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
-	return echox.Response{Code: http.StatusOK, Message: claims.Username + "-" + file.Filename}.JSON(ctx)
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
 
 // @tags TarikDana
-// @summary TarikDana RemoveFile
+// @summary TarikDana Void By ID, keeps the document and its posting is reversed on the void date
 // @produce application/json
-// @param id path int true "tarikdana file id"
+// @param id path int true "tarikdana id"
+// @param data body models.VoidParam true "VoidParam"
 // @success 200 {object} echox.Response "ok"
 // @failure 400 {object} echox.Response "bad request"
 // @failure 500 {object} echox.Response "internal error"
-// @router /api/tarikdanas/upload [delete]
-func (a TarikDanaController) RemoveFile(ctx echo.Context) error {
+// @router /api/tarikdanas/{id}/void [patch]
+func (a TarikDanaController) Void(ctx echo.Context) error {
+	param := new(models.VoidParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
 	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
 
-	// Delete
-	img := "./upload/dana/" + claims.Username + "/" + claims.Username + "-" + ctx.Param("id")
-	err := os.Remove(img)
-	if err != nil {
+	if err := a.tarikdanaService.WithTrx(trxHandle).Void(ctx.Param("id"), param, claims.Username); err != nil {
 		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
 	}
 
-	return echox.Response{Code: http.StatusOK, Message: img}.JSON(ctx)
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
 }
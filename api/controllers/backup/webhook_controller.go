@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
+	"github.com/labstack/echo/v4"
+
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	webhookService services.WebhookService
+	logger         lib.Logger
+}
+
+// NewWebhookController creates new webhook controller
+func NewWebhookController(
+	webhookService services.WebhookService,
+	logger lib.Logger,
+) WebhookController {
+	return WebhookController{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Query
+// @produce application/json
+// @param data query models.WebhookEndpointQueryParam true "WebhookEndpointQueryParam"
+// @success 200 {object} echox.Response{data=models.WebhookEndpointQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks [get]
+func (a WebhookController) Query(ctx echo.Context) error {
+	param := new(models.WebhookEndpointQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.webhookService.Query(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Get By ID
+// @produce application/json
+// @param id path string true "webhook endpoint id"
+// @success 200 {object} echox.Response{data=models.WebhookEndpoint} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/{id} [get]
+func (a WebhookController) Get(ctx echo.Context) error {
+	endpoint, err := a.webhookService.Get(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: endpoint}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Create, the generated signing secret is only returned here
+// @produce application/json
+// @param data body models.WebhookEndpoint true "WebhookEndpoint"
+// @success 200 {object} echox.Response{data=models.WebhookEndpointCreated} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks [post]
+func (a WebhookController) Create(ctx echo.Context) error {
+	endpoint := new(models.WebhookEndpoint)
+	if err := ctx.Bind(endpoint); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	if err := ctx.Validate(endpoint); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	endpoint.CreatedBy = claims.Username
+	endpoint.UpdateBy = claims.Username
+
+	created, err := a.webhookService.WithTrx(trxHandle).Create(endpoint)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: created}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Update By ID, an empty secret keeps the current one
+// @produce application/json
+// @param id path string true "webhook endpoint id"
+// @param data body models.WebhookEndpoint true "WebhookEndpoint"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/{id} [put]
+func (a WebhookController) Update(ctx echo.Context) error {
+	endpoint := new(models.WebhookEndpoint)
+	if err := ctx.Bind(endpoint); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	if err := ctx.Validate(endpoint); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+	claims, _ := ctx.Get(constants.CurrentUser).(*dto.JwtClaims)
+	endpoint.UpdateBy = claims.Username
+
+	if err := a.webhookService.WithTrx(trxHandle).Update(ctx.Param("id"), endpoint); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Enable By ID
+// @produce application/json
+// @param id path string true "webhook endpoint id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/{id}/enable [patch]
+func (a WebhookController) Enable(ctx echo.Context) error {
+	if err := a.webhookService.UpdateActive(ctx.Param("id"), true); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Disable By ID
+// @produce application/json
+// @param id path string true "webhook endpoint id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/{id}/disable [patch]
+func (a WebhookController) Disable(ctx echo.Context) error {
+	if err := a.webhookService.UpdateActive(ctx.Param("id"), false); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Endpoint Delete By ID
+// @produce application/json
+// @param id path string true "webhook endpoint id"
+// @success 200 {object} echox.Response "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/{id} [delete]
+func (a WebhookController) Delete(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+
+	if err := a.webhookService.WithTrx(trxHandle).Delete(ctx.Param("id")); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Delivery Query, the delivery log
+// @produce application/json
+// @param data query models.WebhookDeliveryQueryParam true "WebhookDeliveryQueryParam"
+// @success 200 {object} echox.Response{data=models.WebhookDeliveryQueryResult} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/deliveries [get]
+func (a WebhookController) QueryDeliveries(ctx echo.Context) error {
+	param := new(models.WebhookDeliveryQueryParam)
+	if err := ctx.Bind(param); err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	qr, err := a.webhookService.QueryDeliveries(param)
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: qr}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Delivery Get By ID
+// @produce application/json
+// @param id path string true "webhook delivery id"
+// @success 200 {object} echox.Response{data=models.WebhookDelivery} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/deliveries/{id} [get]
+func (a WebhookController) GetDelivery(ctx echo.Context) error {
+	delivery, err := a.webhookService.GetDelivery(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: delivery}.JSON(ctx)
+}
+
+// @tags Webhook
+// @summary Webhook Delivery Replay, the payload is sent again as a new delivery
+// @produce application/json
+// @param id path string true "webhook delivery id"
+// @success 200 {object} echox.Response{data=models.WebhookDelivery} "ok"
+// @failure 400 {object} echox.Response "bad request"
+// @failure 404 {object} echox.Response "not found"
+// @failure 500 {object} echox.Response "internal error"
+// @router /api/webhooks/deliveries/{id}/replay [post]
+func (a WebhookController) Replay(ctx echo.Context) error {
+	trxHandle := ctx.Get(constants.DBTransaction).(*gorm.DB)
+
+	delivery, err := a.webhookService.WithTrx(trxHandle).Replay(ctx.Param("id"))
+	if err != nil {
+		return echox.Response{Code: http.StatusBadRequest, Message: err}.JSON(ctx)
+	}
+
+	return echox.Response{Code: http.StatusOK, Data: delivery}.JSON(ctx)
+}
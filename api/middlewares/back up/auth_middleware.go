@@ -7,6 +7,7 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/constants"
 	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/pkg/audit"
 	"github.com/Aguztinus/petty-cash-backend/pkg/echox"
 	"github.com/labstack/echo/v4"
 )
@@ -60,6 +61,10 @@ func (a AuthMiddleware) core() echo.MiddlewareFunc {
 			}
 
 			ctx.Set(constants.CurrentUser, claims)
+			if actor := audit.FromContext(request.Context()); actor != nil {
+				actor.UserID = claims.ID
+				actor.Username = claims.Username
+			}
 			return next(ctx)
 		}
 	}
@@ -2,10 +2,13 @@ package middlewares
 
 import (
 	"fmt"
+	"net"
 	"runtime"
 
 	"github.com/Aguztinus/petty-cash-backend/constants"
 	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/pkg/audit"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
 	"github.com/labstack/echo/v4"
 
 	"go.uber.org/zap"
@@ -30,6 +33,37 @@ func statusInList(status int, statusList []int) bool {
 	return false
 }
 
+// validRequestID accepts the request id of the client when it fits the audit log, up to 36 letters,
+// digits, '-', '_' or '.'
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 36 {
+		return false
+	}
+
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// clientIP is the real ip of the client for the audit log, the peer address when the forwarded
+// headers do not hold an ip
+func clientIP(ctx echo.Context) string {
+	if ip := net.ParseIP(ctx.RealIP()); ip != nil {
+		return ip.String()
+	}
+
+	host, _, err := net.SplitHostPort(ctx.Request().RemoteAddr)
+	if err != nil {
+		return ""
+	}
+
+	return host
+}
+
 // NewCoreMiddleware creates new database transactions middleware
 func NewCoreMiddleware(handler lib.HttpHandler, logger lib.Logger, db lib.Database) CoreMiddleware {
 	return CoreMiddleware{
@@ -44,7 +78,18 @@ func (a CoreMiddleware) core() echo.MiddlewareFunc {
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(ctx echo.Context) error {
-			txHandle := a.db.ORM.Begin()
+			request := ctx.Request()
+			requestID := request.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(requestID) {
+				requestID = uuid.MustString()
+			}
+			ctx.Response().Header().Set(echo.HeaderXRequestID, requestID)
+
+			// the auth middleware adds the user, the audit plugin reads it from the transaction context
+			actor := &audit.Actor{RequestID: requestID, IPAddress: clientIP(ctx)}
+			ctx.SetRequest(request.WithContext(audit.NewContext(request.Context(), actor)))
+
+			txHandle := a.db.ORM.WithContext(ctx.Request().Context()).Begin()
 			logger.Info("beginning database transaction")
 
 			defer func() {
@@ -84,6 +129,10 @@ func (a CoreMiddleware) core() echo.MiddlewareFunc {
 				a.logger.DesugarZap.Info("committing transactions")
 				if err := txHandle.Commit().Error; err != nil {
 					logger.Error(fmt.Sprintf("trx commit error: %v", err))
+				} else if fns, ok := ctx.Get(constants.AfterCommit).([]func()); ok {
+					for _, fn := range fns {
+						fn()
+					}
 				}
 			}
 
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// ApprovalFlowRepository database structure
+type ApprovalFlowRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewApprovalFlowRepository creates a new approval flow repository
+func NewApprovalFlowRepository(db lib.Database, logger lib.Logger) ApprovalFlowRepository {
+	return ApprovalFlowRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a ApprovalFlowRepository) WithTrx(trxHandle *gorm.DB) ApprovalFlowRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a ApprovalFlowRepository) Query(param *models.ApprovalFlowQueryParam) (*models.ApprovalFlowQueryResult, error) {
+	db := a.db.ORM.Model(&models.ApprovalFlow{}).Preload("Company").
+		Preload("ApprovalSteps", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") })
+
+	if v := param.IDs; len(v) > 0 {
+		db = db.Where("id IN (?)", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.DocType; v != "" {
+		db = db.Where("doc_type=?", v)
+	}
+
+	if v := param.QueryValue; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("name LIKE ?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.ApprovalFlows, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.ApprovalFlowQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a ApprovalFlowRepository) Get(id string) (*models.ApprovalFlow, error) {
+	flow := new(models.ApprovalFlow)
+
+	db := a.db.ORM.Model(flow).Preload("ApprovalSteps", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") })
+	if ok, err := QueryOne(db.Where("id=?", id), flow); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return flow, nil
+}
+
+// GetByDocType returns the active flow of a company for a document type
+func (a ApprovalFlowRepository) GetByDocType(companyID string, docType string) (*models.ApprovalFlow, error) {
+	flow := new(models.ApprovalFlow)
+
+	db := a.db.ORM.Model(flow).Preload("ApprovalSteps", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") }).
+		Where("company_id=? AND doc_type=? AND active_flag=?", companyID, docType, true)
+	if ok, err := QueryOne(db, flow); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return flow, nil
+}
+
+func (a ApprovalFlowRepository) Create(flow *models.ApprovalFlow) error {
+	result := a.db.ORM.Model(flow).Omit("Company").Create(flow)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ApprovalFlowRepository) Update(id string, flow *models.ApprovalFlow) error {
+	result := a.db.ORM.Model(flow).Where("id=?", id).
+		Select("Name", "ActiveFlag", "UpdatedAt", "UpdateBy").Updates(flow)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ApprovalFlowRepository) Delete(id string) error {
+	flow := new(models.ApprovalFlow)
+
+	result := a.db.ORM.Model(flow).Where("id=?", id).Delete(flow)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ApprovalFlowRepository) CreateSteps(steps models.ApprovalSteps) error {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	result := a.db.ORM.Model(&models.ApprovalStep{}).Create(&steps)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ApprovalFlowRepository) DeleteStepsByFlowID(flowID string) error {
+	step := new(models.ApprovalStep)
+
+	result := a.db.ORM.Model(step).Where("approval_flow_id=?", flowID).Delete(step)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// ApprovalRequestRepository database structure
+type ApprovalRequestRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewApprovalRequestRepository creates a new approval request repository
+func NewApprovalRequestRepository(db lib.Database, logger lib.Logger) ApprovalRequestRepository {
+	return ApprovalRequestRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a ApprovalRequestRepository) WithTrx(trxHandle *gorm.DB) ApprovalRequestRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a ApprovalRequestRepository) Query(param *models.ApprovalRequestQueryParam) (*models.ApprovalRequestQueryResult, error) {
+	db := a.db.ORM.Model(&models.ApprovalRequest{})
+
+	if v := param.IDs; len(v) > 0 {
+		db = db.Where("id IN (?)", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.DocType; v != "" {
+		db = db.Where("doc_type=?", v)
+	}
+
+	if v := param.DocID; v != "" {
+		db = db.Where("doc_id=?", v)
+	}
+
+	if v := param.Status; v != "" {
+		db = db.Where("status=?", v)
+	}
+
+	if v := param.ApproverID; v != "" {
+		step := a.db.ORM.Model(&models.ApprovalStep{}).Select("approval_flow_id, sequence")
+		if roleIDs := param.ApproverRoleIDs; len(roleIDs) > 0 {
+			step = step.Where("user_id=? OR role_id IN (?)", v, roleIDs)
+		} else {
+			step = step.Where("user_id=?", v)
+		}
+
+		db = db.Where("status=? AND (approval_flow_id, current_step) IN (?)", models.ApprovalStatusPending, step)
+	}
+
+	if v := param.QueryValue; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("doc_num LIKE ?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.ApprovalRequests, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.ApprovalRequestQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+// GetByDoc returns the latest request of a document with its history
+func (a ApprovalRequestRepository) GetByDoc(docType string, docID string) (*models.ApprovalRequest, error) {
+	request := new(models.ApprovalRequest)
+
+	db := a.db.ORM.Model(request).
+		Preload("ApprovalHistories", func(db *gorm.DB) *gorm.DB { return db.Order("record_id") }).
+		Where("doc_type=? AND doc_id=?", docType, docID).Order("record_id DESC")
+	if ok, err := QueryOne(db, request); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return request, nil
+}
+
+// GetByDocForUpdate locks the latest request of a document until the transaction ends, so a step is
+// decided once
+func (a ApprovalRequestRepository) GetByDocForUpdate(docType string, docID string) (*models.ApprovalRequest, error) {
+	request := new(models.ApprovalRequest)
+
+	db := a.db.ORM.Model(request).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("doc_type=? AND doc_id=?", docType, docID).Order("record_id DESC")
+	if ok, err := QueryOne(db, request); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return request, nil
+}
+
+// CountPendingByFlow counts the requests still waiting on a step of the flow
+func (a ApprovalRequestRepository) CountPendingByFlow(flowID string) (int64, error) {
+	db := a.db.ORM.Model(&models.ApprovalRequest{}).Where("approval_flow_id=? AND status=?", flowID, models.ApprovalStatusPending)
+
+	n, err := QueryCount(db)
+	if err != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return n, nil
+}
+
+func (a ApprovalRequestRepository) Create(request *models.ApprovalRequest) error {
+	result := a.db.ORM.Model(request).Omit("ApprovalHistories").Create(request)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ApprovalRequestRepository) UpdateStep(id string, request *models.ApprovalRequest) error {
+	result := a.db.ORM.Model(request).Where("id=?", id).
+		Select("Amount", "ApprovalFlowID", "CurrentStep", "Status", "RejectReason", "UpdatedAt", "UpdateBy").Updates(request)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// GetHistoriesByDoc returns the decision trail of every request of a document, oldest first
+func (a ApprovalRequestRepository) GetHistoriesByDoc(docType string, docID string) (models.ApprovalHistories, error) {
+	request := a.db.ORM.Model(&models.ApprovalRequest{}).Select("id").Where("doc_type=? AND doc_id=?", docType, docID)
+
+	histories := make(models.ApprovalHistories, 0)
+	result := a.db.ORM.Model(&models.ApprovalHistory{}).Where("approval_request_id IN (?)", request).Order("record_id").Find(&histories)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return histories, nil
+}
+
+func (a ApprovalRequestRepository) CreateHistory(history *models.ApprovalHistory) error {
+	result := a.db.ORM.Model(history).Create(history)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
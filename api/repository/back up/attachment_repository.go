@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// legacyFileColumn is the column that kept the file name of a document type before attachments were
+// stored, docID is the column of the document the attachment belongs to
+type legacyFileColumn struct {
+	model  interface{}
+	column string
+	docID  string
+	hashes bool
+}
+
+var legacyFileColumns = map[string]legacyFileColumn{
+	models.ApprovalDocBKK:       {model: &models.BKKDetail{}, column: "lines_file", docID: "bkk_header_id", hashes: true},
+	models.ApprovalDocInvoice:   {model: &models.InvoiceHeader{}, column: "file", docID: "id"},
+	models.ApprovalDocKasbon:    {model: &models.Kasbon{}, column: "file", docID: "id"},
+	models.ApprovalDocTarikDana: {model: &models.TarikDana{}, column: "file", docID: "id"},
+}
+
+// AttachmentRepository database structure
+type AttachmentRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db lib.Database, logger lib.Logger) AttachmentRepository {
+	return AttachmentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a AttachmentRepository) WithTrx(trxHandle *gorm.DB) AttachmentRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a AttachmentRepository) Query(param *models.AttachmentQueryParam) (*models.AttachmentQueryResult, error) {
+	db := a.db.ORM.Model(&models.Attachment{})
+
+	if v := param.DocType; v != "" {
+		db = db.Where("doc_type=?", v)
+	}
+
+	if v := param.DocID; v != "" {
+		db = db.Where("doc_id=?", v)
+	}
+
+	if v := param.Hash; v != "" {
+		db = db.Where("hash=?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.Attachments, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.AttachmentQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a AttachmentRepository) Get(id string) (*models.Attachment, error) {
+	attachment := new(models.Attachment)
+
+	if ok, err := QueryOne(a.db.ORM.Model(attachment).Where("id=?", id), attachment); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return attachment, nil
+}
+
+// GetByDocAndHash returns the attachment of a document with the same content
+func (a AttachmentRepository) GetByDocAndHash(docType string, docID string, hash string) (*models.Attachment, error) {
+	attachment := new(models.Attachment)
+
+	db := a.db.ORM.Model(attachment).Where("doc_type=? AND doc_id=? AND hash=?", docType, docID, hash)
+	if ok, err := QueryOne(db, attachment); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return attachment, nil
+}
+
+// CountByHash counts the attachments sharing a stored content
+func (a AttachmentRepository) CountByHash(hash string) (int64, error) {
+	var count int64
+	if result := a.db.ORM.Model(&models.Attachment{}).Where("hash=?", hash).Count(&count); result.Error != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return count, nil
+}
+
+func (a AttachmentRepository) Create(attachment *models.Attachment) error {
+	result := a.db.ORM.Model(attachment).Create(attachment)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a AttachmentRepository) Delete(id string) error {
+	attachment := new(models.Attachment)
+
+	result := a.db.ORM.Model(attachment).Where("id=?", id).Delete(attachment)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// GetLegacyFiles returns the file names kept by the documents of the type that are not an attachment id
+func (a AttachmentRepository) GetLegacyFiles(docType string) (models.AttachmentLegacyFiles, error) {
+	c, ok := legacyFileColumns[docType]
+	if !ok {
+		return nil, errors.AttachmentDocTypeInvalid
+	}
+
+	list := make(models.AttachmentLegacyFiles, 0)
+	db := a.db.ORM.Model(c.model).
+		Select("record_id, ? AS doc_type, "+c.docID+" AS doc_id, "+c.column+" AS file, created_by", docType).
+		Where(c.column+"<>'' AND "+c.column+" NOT IN (?)", a.db.ORM.Model(&models.Attachment{}).Unscoped().Select("id"))
+	if err := db.Scan(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// UpdateLegacyFile replaces the legacy file name by the id of its attachment, a BKK line also takes
+// the hashes of the attachment
+func (a AttachmentRepository) UpdateLegacyFile(file *models.AttachmentLegacyFile, attachment *models.Attachment) error {
+	c, ok := legacyFileColumns[file.DocType]
+	if !ok {
+		return errors.AttachmentDocTypeInvalid
+	}
+
+	values := map[string]interface{}{c.column: attachment.ID}
+	if c.hashes {
+		values["file_hash"] = attachment.Hash
+		values["p_hash"] = attachment.PHash
+	}
+
+	result := a.db.ORM.Model(c.model).Where("record_id=? AND "+c.column+"=?", file.RecordID, file.File).Updates(values)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/audit"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// AuditLogRepository database structure
+type AuditLogRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db lib.Database, logger lib.Logger) AuditLogRepository {
+	return AuditLogRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a AuditLogRepository) WithTrx(trxHandle *gorm.DB) AuditLogRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a AuditLogRepository) Query(param *models.AuditLogQueryParam) (*models.AuditLogQueryResult, error) {
+	db := a.db.ORM.Model(&models.AuditLog{})
+
+	if v := param.Entity; v != "" {
+		db = db.Where("entity=?", v)
+	}
+
+	if v := param.EntityID; v != "" {
+		db = db.Where("entity_id=?", v)
+	}
+
+	if v := param.Action; v != "" {
+		db = db.Where("action=?", v)
+	}
+
+	if v := param.UserID; v != "" {
+		db = db.Where("user_id=?", v)
+	}
+
+	if v := param.Username; v != "" {
+		db = db.Where("username=?", v)
+	}
+
+	if v := param.RequestID; v != "" {
+		db = db.Where("request_id=?", v)
+	}
+
+	if v := param.DateQuery; len(v) == 2 {
+		db = db.Where("created_at BETWEEN ? AND ?", v[0], v[1])
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.AuditLogs, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.AuditLogQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a AuditLogRepository) Get(id string) (*models.AuditLog, error) {
+	auditlog := new(models.AuditLog)
+
+	if ok, err := QueryOne(a.db.ORM.Model(auditlog).Where("id=?", id), auditlog); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return auditlog, nil
+}
+
+// Record is the audit.Store of the audit plugin, tx is the transaction of the audited statement
+func (a AuditLogRepository) Record(tx *gorm.DB, entry *audit.Entry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	auditlog := &models.AuditLog{
+		ID:        uuid.MustString(),
+		Entity:    entry.Entity,
+		EntityID:  entry.EntityID,
+		Action:    entry.Action,
+		UserID:    entry.Actor.UserID,
+		Username:  entry.Actor.Username,
+		RequestID: entry.Actor.RequestID,
+		IPAddress: entry.Actor.IPAddress,
+		Changes:   string(changes),
+	}
+
+	if result := tx.Model(auditlog).Create(auditlog); result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -78,6 +78,47 @@ func (a BKKDetailRepository) Query(param *models.BKKDetailQueryParam) (*models.B
 	return qr, nil
 }
 
+// GetByHeaderID returns every line of a BKK with its Trx, in the order they were entered
+func (a BKKDetailRepository) GetByHeaderID(headerID string) (models.BKKDetails, error) {
+	list := make(models.BKKDetails, 0)
+
+	result := a.db.ORM.Model(&models.BKKDetail{}).Preload("Trx").Where("bkk_header_id=?", headerID).Order("record_id").Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
+// QueryCandidates returns the lines of the other BKKs of the company, that are not voided, which share
+// a file hash or an amount with the given lines, or whose image hash is close to one of theirs
+func (a BKKDetailRepository) QueryCandidates(param *models.BKKDuplicateCandidateParam) (models.BKKDetails, error) {
+	headers := func() *gorm.DB {
+		return a.db.ORM.Model(&models.BKKHeader{}).Select("id").
+			Where("company_id=? AND id<>? AND status<>?", param.CompanyID, param.BKKHeaderID, models.BKKStatusVoid)
+	}
+
+	match := a.db.ORM.Where("lines_amount IN (?)", param.Amounts)
+	if len(param.FileHashes) > 0 {
+		match = match.Or("file_hash IN (?)", param.FileHashes)
+	}
+	if len(param.PHashes) > 0 {
+		// the hamming distance of the 64 bit hashes, only over the BKKs of the window
+		images := a.db.ORM
+		for _, hash := range param.PHashes {
+			images = images.Or("BIT_COUNT(CAST(CONV(p_hash, 16, 10) AS UNSIGNED) ^ CAST(CONV(?, 16, 10) AS UNSIGNED)) <= ?", hash, param.Distance)
+		}
+		match = match.Or(a.db.ORM.Where("p_hash<>'' AND bkk_header_id IN (?)", headers().Where("created_at>=?", param.ImagesSince)).Where(images))
+	}
+
+	list := make(models.BKKDetails, 0)
+	if result := a.db.ORM.Model(&models.BKKDetail{}).Where("bkk_header_id IN (?)", headers()).Where(match).Find(&list); result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
 func (a BKKDetailRepository) Get(id string) (*models.BKKDetail, error) {
 	bkkdetail := new(models.BKKDetail)
 
@@ -90,6 +131,82 @@ func (a BKKDetailRepository) Get(id string) (*models.BKKDetail, error) {
 	return bkkdetail, nil
 }
 
+// SumExpenses sums the lines of the paid and invoiced BKKs of the period by period, account, cost
+// centre and department
+func (a BKKDetailRepository) SumExpenses(param *models.ExpenseAnalysisParam) (models.ExpenseAnalysisLines, error) {
+	list := make(models.ExpenseAnalysisLines, 0)
+
+	result := a.expenses(param).
+		Select("h.period, t.account_id, acc.num AS account_num, acc.name AS account_name, " +
+			"t.cc_id, cc.code AS cc_code, cc.name AS cc_name, t.department_id AS dept_id, dept.num AS dept_num, " +
+			"dept.name AS dept_name, SUM(lines_amount) AS amount, COUNT(*) AS count").
+		Group("h.period, t.account_id, acc.num, acc.name, t.cc_id, cc.code, cc.name, t.department_id, dept.num, dept.name").
+		Order("h.period").Scan(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
+// GetExpenses returns the lines of the paid and invoiced BKKs of the period behind an amount of the
+// expense analysis, in BKK order
+func (a BKKDetailRepository) GetExpenses(param *models.ExpenseAnalysisParam) (models.ExpenseVouchers, error) {
+	list := make(models.ExpenseVouchers, 0)
+
+	result := a.expenses(param).
+		Select("bkk_header_id, h.num, h.created_at AS date, h.period, t.name AS trx_name, acc.num AS account_num, " +
+			"cc.code AS cc_code, dept.num AS dept_num, lines_desc, lines_amount AS amount").
+		Order("h.created_at, h.id, record_id").Scan(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
+// expenses joins the lines with their BKK and Trx, the period and the drill-down filters are applied
+// in the joined queries. Deleted Trx and accounts still name the lines booked on them.
+func (a BKKDetailRepository) expenses(param *models.ExpenseAnalysisParam) *gorm.DB {
+	headers := a.db.ORM.Model(&models.BKKHeader{}).
+		Select("id, num, created_at, DATE_FORMAT(created_at, ?) AS period", param.PeriodFormat()).
+		Where("status IN (?)", []string{models.BKKStatusPaid, models.BKKStatusInvoice}).
+		Where("created_at BETWEEN ? AND ?", param.DateParams[0], param.DateParams[1])
+
+	if v := param.CompanyID; v != "" {
+		headers = headers.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		headers = headers.Where("branch_id=?", v)
+	}
+
+	if v := param.PeriodKey; v != "" {
+		headers = headers.Where("DATE_FORMAT(created_at, ?)=?", param.PeriodFormat(), v)
+	}
+
+	trxs := a.db.ORM.Unscoped().Model(&models.Trx{}).Select("id, name, account_id, cc_id, department_id")
+
+	if v := param.AccountID; v != "" {
+		trxs = trxs.Where("account_id=?", v)
+	}
+
+	if v := param.CCID; v != "" {
+		trxs = trxs.Where("cc_id=?", v)
+	}
+
+	if v := param.DeptID; v != "" {
+		trxs = trxs.Where("department_id=?", v)
+	}
+
+	return a.db.ORM.Model(&models.BKKDetail{}).
+		Joins("JOIN (?) h ON h.id=bkk_header_id", headers).
+		Joins("JOIN (?) t ON t.id=trx_id", trxs).
+		Joins("LEFT JOIN (?) acc ON acc.id=t.account_id", a.db.ORM.Unscoped().Model(&models.Account{}).Select("id, num, name")).
+		Joins("LEFT JOIN (?) cc ON cc.id=t.cc_id", a.db.ORM.Unscoped().Model(&models.CostCentre{}).Select("id, code, name")).
+		Joins("LEFT JOIN (?) dept ON dept.id=t.department_id", a.db.ORM.Unscoped().Model(&models.Department{}).Select("id, num, name"))
+}
+
 func (a BKKDetailRepository) Create(bkkdetail *models.BKKDetail) error {
 	result := a.db.ORM.Model(bkkdetail).Create(bkkdetail)
 	if result.Error != nil {
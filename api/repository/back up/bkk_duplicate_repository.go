@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// BKKDuplicateRepository database structure
+type BKKDuplicateRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewBKKDuplicateRepository creates a new bkkduplicate repository
+func NewBKKDuplicateRepository(db lib.Database, logger lib.Logger) BKKDuplicateRepository {
+	return BKKDuplicateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a BKKDuplicateRepository) WithTrx(trxHandle *gorm.DB) BKKDuplicateRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a BKKDuplicateRepository) Query(param *models.BKKDuplicateQueryParam) (*models.BKKDuplicateQueryResult, error) {
+	db := a.db.ORM.Model(&models.BKKDuplicate{})
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=? OR match_branch_id=?", v, v)
+	}
+
+	if v := param.BKKHeaderID; v != "" {
+		db = db.Where("bkk_header_id=? OR match_bkk_header_id=?", v, v)
+	}
+
+	if v := param.Reason; v != "" {
+		db = db.Where("reason=?", v)
+	}
+
+	if v := param.Status; v != "" {
+		db = db.Where("status=?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.BKKDuplicates, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.BKKDuplicateQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a BKKDuplicateRepository) Get(id string) (*models.BKKDuplicate, error) {
+	duplicate := new(models.BKKDuplicate)
+
+	if ok, err := QueryOne(a.db.ORM.Model(duplicate).Where("id=?", id), duplicate); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return duplicate, nil
+}
+
+// ExistsReviewed tells if the pair of BKKs was already reviewed for the reason, in either direction
+func (a BKKDuplicateRepository) ExistsReviewed(headerID string, matchHeaderID string, reason string) (bool, error) {
+	var count int64
+	result := a.db.ORM.Model(&models.BKKDuplicate{}).
+		Where("(bkk_header_id=? AND match_bkk_header_id=?) OR (bkk_header_id=? AND match_bkk_header_id=?)", headerID, matchHeaderID, matchHeaderID, headerID).
+		Where("reason=? AND status<>?", reason, models.BKKDuplicateOpen).Count(&count)
+	if result.Error != nil {
+		return false, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return count > 0, nil
+}
+
+func (a BKKDuplicateRepository) CreateBatch(duplicates models.BKKDuplicates) error {
+	result := a.db.ORM.Model(duplicates).Create(duplicates)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// DeleteOpenByHeader removes the flags still waiting for review on either side of the BKK
+func (a BKKDuplicateRepository) DeleteOpenByHeader(headerID string) error {
+	duplicate := new(models.BKKDuplicate)
+
+	result := a.db.ORM.Model(duplicate).Unscoped().
+		Where("(bkk_header_id=? OR match_bkk_header_id=?) AND status=?", headerID, headerID, models.BKKDuplicateOpen).Delete(duplicate)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a BKKDuplicateRepository) UpdateReview(id string, duplicate *models.BKKDuplicate) error {
+	result := a.db.ORM.Model(duplicate).Where("id=?", id).Select("Status", "ReviewNote", "ReviewedBy", "ReviewedDate", "UpdatedAt", "UpdateBy").Updates(duplicate)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
@@ -61,6 +62,10 @@ func (a BKKHeaderRepository) Query(param *models.BKKHeaderQueryParam) (*models.B
 		db = db.Where("branch_id=?", v)
 	}
 
+	if v := param.KasbonID; v != "" {
+		db = db.Where("kasbon_id=?", v)
+	}
+
 	if v := param.DateQuery; len(v) != 0 {
 		db = db.Where("created_at BETWEEN ? AND ?", param.DateQuery[0], param.DateQuery[1])
 	}
@@ -86,6 +91,20 @@ func (a BKKHeaderRepository) Query(param *models.BKKHeaderQueryParam) (*models.B
 	return qr, nil
 }
 
+// GetPostings lists the BKKs of a branch, deleted ones included, with the columns that tell whether
+// and when they were paid
+func (a BKKHeaderRepository) GetPostings(companyID string, branchID string) (models.BKKHeaders, error) {
+	list := make(models.BKKHeaders, 0)
+
+	result := a.db.ORM.Unscoped().Model(&models.BKKHeader{}).Select("id", "num", "status", "paid_date").
+		Where("company_id=? AND branch_id=?", companyID, branchID).Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
 func (a BKKHeaderRepository) Get(id string) (*models.BKKHeader, error) {
 	bkkheader := new(models.BKKHeader)
 
@@ -98,10 +117,23 @@ func (a BKKHeaderRepository) Get(id string) (*models.BKKHeader, error) {
 	return bkkheader, nil
 }
 
+// GetForUpdate locks the bkk row until the transaction ends so a transition can't run twice
+func (a BKKHeaderRepository) GetForUpdate(id string) (*models.BKKHeader, error) {
+	bkkheader := new(models.BKKHeader)
+
+	if ok, err := QueryOne(a.db.ORM.Model(bkkheader).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id), bkkheader); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return bkkheader, nil
+}
+
 func (a BKKHeaderRepository) Create(bkkheader *models.BKKHeader) error {
 	result := a.db.ORM.Model(bkkheader).
 		Select("ID", "Num", "CompanyID", "BranchID", "ReleaseDate",
-			"PaidDate", "TotalAmount", "KasbonID", "InvoiceID", "Status", "BKKDetails",
+			"PaidDate", "TotalAmount", "KasbonID", "InvoiceID", "Status", "VoidReason", "VoidBy", "BKKDetails",
 			"CreatedAt", "CreatedBy", "UpdateBy").Create(bkkheader)
 	if result.Error != nil {
 		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
@@ -110,10 +142,11 @@ func (a BKKHeaderRepository) Create(bkkheader *models.BKKHeader) error {
 	return nil
 }
 
+// Update leaves Status, PaidDate and InvoiceID to the state machine, the company, branch, kasbon and
+// creator of a BKK do not change
 func (a BKKHeaderRepository) Update(id string, bkkheader *models.BKKHeader) error {
-	result := a.db.ORM.Model(bkkheader).Where("id=?", id).Select("ID", "Num", "CompanyID", "BranchID", "ReleaseDate",
-		"PaidDate", "TotalAmount", "KasbonID", "InvoiceID", "Status", "BKKDetails",
-		"CreatedAt", "CreatedBy", "UpdatedAt", "UpdateBy").Updates(bkkheader)
+	result := a.db.ORM.Model(bkkheader).Where("id=?", id).Select("ID", "Num", "ReleaseDate",
+		"TotalAmount", "BKKDetails", "UpdatedAt", "UpdateBy").Updates(bkkheader)
 	if result.Error != nil {
 		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
 	}
@@ -143,6 +176,33 @@ func (a BKKHeaderRepository) UpdateStatus(id string, status string) error {
 	return nil
 }
 
+func (a BKKHeaderRepository) UpdatePaid(id string, bkkheader *models.BKKHeader) error {
+	result := a.db.ORM.Model(bkkheader).Where("id=?", id).Select("Status", "PaidDate", "PaidBy", "UpdatedAt", "UpdateBy").Updates(bkkheader)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a BKKHeaderRepository) UpdateVoid(id string, bkkheader *models.BKKHeader) error {
+	result := a.db.ORM.Model(bkkheader).Where("id=?", id).Select("Status", "VoidDate", "VoidReason", "VoidBy", "UpdatedAt", "UpdateBy").Updates(bkkheader)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a BKKHeaderRepository) UpdateInvoice(id string, bkkheader *models.BKKHeader) error {
+	result := a.db.ORM.Model(bkkheader).Where("id=?", id).Select("Status", "InvoiceID", "UpdatedAt").Updates(bkkheader)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
 func (a BKKHeaderRepository) UpdateApprove(ids []string, status int) error {
 	bkkheader := new(models.BKKHeader)
 
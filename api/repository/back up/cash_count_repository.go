@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// CashCountRepository database structure
+type CashCountRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewCashCountRepository creates a new cashcount repository
+func NewCashCountRepository(db lib.Database, logger lib.Logger) CashCountRepository {
+	return CashCountRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a CashCountRepository) WithTrx(trxHandle *gorm.DB) CashCountRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a CashCountRepository) Query(param *models.CashCountQueryParam) (*models.CashCountQueryResult, error) {
+	db := a.db.ORM.Model(&models.CashCount{}).Preload("Company").Preload("Branch").Preload("CashCountDetails")
+
+	if v := param.IDs; len(v) > 0 {
+		db = db.Where("id IN (?)", v)
+	}
+
+	if v := param.Num; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("num LIKE ?", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.Status; v != "" {
+		db = db.Where("status=?", v)
+	}
+
+	if v := param.ReasonCode; v != "" {
+		db = db.Where("reason_code=?", v)
+	}
+
+	if v := param.DateQuery; len(v) != 0 {
+		db = db.Where("count_date BETWEEN ? AND ?", param.DateQuery[0], param.DateQuery[1])
+	}
+
+	if v := param.QueryValue; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("num LIKE ? OR description LIKE ?", v, v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.CashCounts, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.CashCountQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a CashCountRepository) Get(id string) (*models.CashCount, error) {
+	cashcount := new(models.CashCount)
+
+	db := a.db.ORM.Model(cashcount).Preload("Company").Preload("Branch").
+		Preload("CashCountDetails", func(db *gorm.DB) *gorm.DB {
+			return db.Order("type DESC, denomination DESC")
+		})
+
+	if ok, err := QueryOne(db.Where("id=?", id), cashcount); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return cashcount, nil
+}
+
+// GetForUpdate is Get with the count row locked until the transaction ends, so it is approved or
+// rejected once
+func (a CashCountRepository) GetForUpdate(id string) (*models.CashCount, error) {
+	cashcount := new(models.CashCount)
+
+	db := a.db.ORM.Model(cashcount).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Company").Preload("Branch").
+		Preload("CashCountDetails", func(db *gorm.DB) *gorm.DB {
+			return db.Order("type DESC, denomination DESC")
+		})
+
+	if ok, err := QueryOne(db.Where("id=?", id), cashcount); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return cashcount, nil
+}
+
+func (a CashCountRepository) Create(cashcount *models.CashCount) error {
+	result := a.db.ORM.Model(cashcount).Omit("Company", "Branch").Create(cashcount)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a CashCountRepository) Update(id string, cashcount *models.CashCount) error {
+	result := a.db.ORM.Model(cashcount).Where("id=?", id).Select("CountDate", "SaldoSystem", "TotalCount",
+		"Variance", "ReasonCode", "Description", "CashCountDetails", "UpdatedAt", "UpdateBy").Updates(cashcount)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a CashCountRepository) UpdateApprove(id string, cashcount *models.CashCount) error {
+	result := a.db.ORM.Model(cashcount).Where("id=?", id).Select("Status", "ReasonCode", "Description",
+		"ApprovedBy", "ApprovedDate", "UpdatedAt", "UpdateBy").Updates(cashcount)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a CashCountRepository) Delete(id string) error {
+	cashcount := new(models.CashCount)
+
+	result := a.db.ORM.Model(cashcount).Where("id=?", id).Delete(cashcount)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a CashCountRepository) DeleteDetailsByHeaderID(id string) error {
+	detail := new(models.CashCountDetail)
+
+	result := a.db.ORM.Model(detail).Where("cash_count_id=?", id).Delete(detail)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
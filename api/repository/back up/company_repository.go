@@ -120,6 +120,15 @@ func (a CompanyRepository) Update(id string, company *models.Company) error {
 	return nil
 }
 
+func (a CompanyRepository) UpdateLogo(id string, key string) error {
+	result := a.db.ORM.Model(&models.Company{}).Where("id=?", id).Update("logo_key", key)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
 func (a CompanyRepository) Delete(id string) error {
 	company := new(models.Company)
 
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// CompanySignatureRepository database structure
+type CompanySignatureRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewCompanySignatureRepository creates a new companysignature repository
+func NewCompanySignatureRepository(db lib.Database, logger lib.Logger) CompanySignatureRepository {
+	return CompanySignatureRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a CompanySignatureRepository) WithTrx(trxHandle *gorm.DB) CompanySignatureRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a CompanySignatureRepository) Query(param *models.CompanySignatureQueryParam) (*models.CompanySignatureQueryResult, error) {
+	db := a.db.ORM.Model(&models.CompanySignature{})
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.RoleIDs; len(v) > 0 {
+		db = db.Where("role_id IN (?)", v)
+	}
+
+	if param.Reports {
+		db = db.Where("reports=?", true)
+	}
+
+	db = db.Order("sequence").Order(param.OrderParam.ParseOrder())
+
+	list := make(models.CompanySignatures, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.CompanySignatureQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a CompanySignatureRepository) Get(companyID string, id string) (*models.CompanySignature, error) {
+	signature := new(models.CompanySignature)
+
+	if ok, err := QueryOne(a.db.ORM.Model(signature).Where("company_id=? AND id=?", companyID, id), signature); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.CompanySignatureNotFound
+	}
+
+	return signature, nil
+}
+
+// ExistsRole tells if the role of the company already has a signature other than id
+func (a CompanySignatureRepository) ExistsRole(companyID string, roleID string, id string) (bool, error) {
+	var count int64
+	result := a.db.ORM.Model(&models.CompanySignature{}).Where("company_id=? AND role_id=? AND id<>?", companyID, roleID, id).Count(&count)
+	if result.Error != nil {
+		return false, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return count > 0, nil
+}
+
+func (a CompanySignatureRepository) Create(signature *models.CompanySignature) error {
+	result := a.db.ORM.Model(signature).Create(signature)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// Update keeps the image unless a new one was stored
+func (a CompanySignatureRepository) Update(id string, signature *models.CompanySignature) error {
+	columns := []interface{}{"Name", "Title", "Reports", "Sequence", "UpdatedAt", "UpdateBy"}
+	if signature.ImageKey != "" {
+		columns = append(columns, "ImageKey")
+	}
+
+	result := a.db.ORM.Model(signature).Where("id=?", id).Select("RoleID", columns...).Updates(signature)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a CompanySignatureRepository) Delete(id string) error {
+	signature := new(models.CompanySignature)
+
+	// unscoped so the role can be given a new signature
+	result := a.db.ORM.Model(signature).Unscoped().Where("id=?", id).Delete(signature)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
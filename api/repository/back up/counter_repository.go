@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
@@ -84,6 +85,30 @@ func (a CounterRepository) GetbyKey(key string) (*models.Counter, error) {
 	return counter, nil
 }
 
+// Increment adds one to the counter of counter.KeyCounter and returns the new value, a missing counter
+// is created first with counter.CounterMe. The row stays locked until the transaction ends so two
+// documents never get the same number and a rolled back document gives its number back.
+func (a CounterRepository) Increment(counter *models.Counter) (int, error) {
+	if result := a.db.ORM.Clauses(clause.OnConflict{DoNothing: true}).Create(counter); result.Error != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	locked := new(models.Counter)
+	db := a.db.ORM.Model(locked).Clauses(clause.Locking{Strength: "UPDATE"}).Where("key_counter=?", counter.KeyCounter)
+	if ok, err := QueryOne(db, locked); err != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return 0, errors.DatabaseRecordNotFound
+	}
+
+	result := a.db.ORM.Model(locked).Where("id=?", locked.ID).Update("counter_me", gorm.Expr("counter_me + 1"))
+	if result.Error != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return locked.CounterMe + 1, nil
+}
+
 func (a CounterRepository) Create(counter *models.Counter) error {
 	result := a.db.ORM.Model(counter).Create(counter)
 	if result.Error != nil {
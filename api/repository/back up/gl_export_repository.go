@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// GLExportRepository database structure
+type GLExportRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewGLExportRepository creates a new glexport repository
+func NewGLExportRepository(db lib.Database, logger lib.Logger) GLExportRepository {
+	return GLExportRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a GLExportRepository) WithTrx(trxHandle *gorm.DB) GLExportRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a GLExportRepository) Query(param *models.GLExportQueryParam) (*models.GLExportQueryResult, error) {
+	db := a.db.ORM.Model(&models.GLExport{})
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.GLExports, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.GLExportQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a GLExportRepository) Get(id string) (*models.GLExport, error) {
+	export := new(models.GLExport)
+
+	if ok, err := QueryOne(a.db.ORM.Model(export).Where("id=?", id), export); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.GLExportNotFound
+	}
+
+	return export, nil
+}
+
+// Create stores the export, its GroupID is the record id so every export has its own GROUP_ID
+func (a GLExportRepository) Create(export *models.GLExport) error {
+	result := a.db.ORM.Model(export).Create(export)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	export.GroupID = int64(export.RecordID)
+	result = a.db.ORM.Model(export).Where("id=?", export.ID).Update("group_id", export.GroupID)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a GLExportRepository) UpdateReset(id string, export *models.GLExport) error {
+	result := a.db.ORM.Model(export).Where("id=?", id).
+		Select("ResetAt", "ResetBy", "ResetReason", "UpdatedAt", "UpdateBy").Updates(export)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
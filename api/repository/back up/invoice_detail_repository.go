@@ -81,6 +81,18 @@ func (a InvoiceDetailRepository) Get(id string) (*models.InvoiceDetail, error) {
 	return invociedetail, nil
 }
 
+// GetByInvoiceID returns every detail of the invoice, Query is paginated and stops at a page
+func (a InvoiceDetailRepository) GetByInvoiceID(invoiceID string) (models.InvoiceDetails, error) {
+	list := make(models.InvoiceDetails, 0)
+
+	result := a.db.ORM.Model(&models.InvoiceDetail{}).Where("invoice_header_id=?", invoiceID).Order("record_id").Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
 func (a InvoiceDetailRepository) Create(invociedetail *models.InvoiceDetail) error {
 	result := a.db.ORM.Model(invociedetail).Create(invociedetail)
 	if result.Error != nil {
@@ -119,6 +131,17 @@ func (a InvoiceDetailRepository) Delete(id string) error {
 	return nil
 }
 
+func (a InvoiceDetailRepository) DeleteByInvoiceID(invoiceID string) error {
+	invociedetail := new(models.InvoiceDetail)
+
+	result := a.db.ORM.Model(invociedetail).Where("invoice_header_id=?", invoiceID).Delete(invociedetail)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
 func (a InvoiceDetailRepository) UpdateStatus(id string, status int) error {
 	invociedetail := new(models.InvoiceDetail)
 
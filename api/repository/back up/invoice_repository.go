@@ -94,6 +94,18 @@ func (a InvoiceHeaderRepository) Get(id string) (*models.InvoiceHeader, error) {
 	return invoiceheader, nil
 }
 
+// GetByIDs returns every invoice of ids, Query is paginated and stops at a page
+func (a InvoiceHeaderRepository) GetByIDs(ids []string) (models.InvoiceHeaders, error) {
+	list := make(models.InvoiceHeaders, 0)
+
+	result := a.db.ORM.Model(&models.InvoiceHeader{}).Preload("Company").Preload("Branch").Where("id IN (?)", ids).Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
 func (a InvoiceHeaderRepository) Create(invoiceheader *models.InvoiceHeader) error {
 	result := a.db.ORM.Model(invoiceheader).Omit("Branch").Omit("Company").Create(invoiceheader)
 	if result.Error != nil {
@@ -103,8 +115,9 @@ func (a InvoiceHeaderRepository) Create(invoiceheader *models.InvoiceHeader) err
 	return nil
 }
 
+// Update leaves StatusApprove to the state machine
 func (a InvoiceHeaderRepository) Update(id string, invoiceheader *models.InvoiceHeader) error {
-	result := a.db.ORM.Model(invoiceheader).Where("id=?", id).Updates(invoiceheader)
+	result := a.db.ORM.Model(invoiceheader).Where("id=?", id).Omit("StatusApprove").Updates(invoiceheader)
 	if result.Error != nil {
 		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
 	}
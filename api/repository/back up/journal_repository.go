@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+)
+
+// JournalRepository database structure
+type JournalRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewJournalRepository creates a new journal repository
+func NewJournalRepository(db lib.Database, logger lib.Logger) JournalRepository {
+	return JournalRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a JournalRepository) WithTrx(trxHandle *gorm.DB) JournalRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a JournalRepository) Query(param *models.JournalHeaderQueryParam) (*models.JournalHeaderQueryResult, error) {
+	db := a.db.ORM.Model(&models.JournalHeader{}).Preload("Company").Preload("Branch").Preload("JournalLines")
+
+	if v := param.IDs; len(v) > 0 {
+		db = db.Where("id IN (?)", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.SourceType; v != "" {
+		db = db.Where("source_type=?", v)
+	}
+
+	if v := param.SourceID; v != "" {
+		db = db.Where("source_id=?", v)
+	}
+
+	if v := param.SourceNum; v != "" {
+		db = db.Where("source_num=?", v)
+	}
+
+	if v := param.DateQuery; len(v) != 0 {
+		db = db.Where("journal_date BETWEEN ? AND ?", param.DateQuery[0], param.DateQuery[1])
+	}
+
+	if v := param.QueryValue; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("source_num LIKE ? OR description LIKE ?", v, v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.JournalHeaders, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.JournalHeaderQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a JournalRepository) Get(id string) (*models.JournalHeader, error) {
+	journal := new(models.JournalHeader)
+
+	if ok, err := QueryOne(a.db.ORM.Model(journal).Preload("JournalLines").Where("id=?", id), journal); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return journal, nil
+}
+
+// GetBySource returns the latest journal posted for the source that has not been reversed yet
+func (a JournalRepository) GetBySource(sourceType string, sourceID string) (*models.JournalHeader, error) {
+	journal := new(models.JournalHeader)
+
+	reversed := a.db.ORM.Model(journal).Select("reversal_of").Where("source_type=? AND source_id=? AND reversal_of<>''", sourceType, sourceID)
+	db := a.db.ORM.Model(journal).Preload("JournalLines").
+		Where("source_type=? AND source_id=? AND reversal_of=''", sourceType, sourceID).
+		Where("id NOT IN (?)", reversed).
+		Order("created_at DESC")
+
+	if ok, err := QueryOne(db, journal); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return journal, nil
+}
+
+func (a JournalRepository) Create(journal *models.JournalHeader) error {
+	result := a.db.ORM.Model(journal).Omit("Company", "Branch").Create(journal)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// GetUnexported returns the journals of the company, and branch when given, dated in the period that no
+// export holds, oldest first
+func (a JournalRepository) GetUnexported(companyID string, branchID string, from database.Datetime, to database.Datetime) (models.JournalHeaders, error) {
+	db := a.db.ORM.Model(&models.JournalHeader{}).Preload("Company").Preload("Branch").
+		Preload("JournalLines", func(db *gorm.DB) *gorm.DB { return db.Order("line_num") }).
+		Where("company_id=? AND gl_export_id='' AND journal_date BETWEEN ? AND ?", companyID, from, to)
+
+	if branchID != "" {
+		db = db.Where("branch_id=?", branchID)
+	}
+
+	list := make(models.JournalHeaders, 0)
+	if err := db.Order("journal_date, created_at, id").Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// GetByExport returns the journals held by the export in the order they were exported
+func (a JournalRepository) GetByExport(exportID string) (models.JournalHeaders, error) {
+	db := a.db.ORM.Model(&models.JournalHeader{}).Preload("Company").Preload("Branch").
+		Preload("JournalLines", func(db *gorm.DB) *gorm.DB { return db.Order("line_num") }).
+		Where("gl_export_id=?", exportID)
+
+	list := make(models.JournalHeaders, 0)
+	if err := db.Order("journal_date, created_at, id").Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// MarkExported sets the export of journals no export holds yet and returns how many were marked
+func (a JournalRepository) MarkExported(ids []string, exportID string) (int64, error) {
+	result := a.db.ORM.Model(&models.JournalHeader{}).Where("id IN (?) AND gl_export_id=''", ids).
+		Update("gl_export_id", exportID)
+	if result.Error != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return result.RowsAffected, nil
+}
+
+// ResetExported releases the journals of the export
+func (a JournalRepository) ResetExported(exportID string) error {
+	result := a.db.ORM.Model(&models.JournalHeader{}).Where("gl_export_id=?", exportID).Update("gl_export_id", "")
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
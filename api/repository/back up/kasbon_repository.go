@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
@@ -98,6 +99,44 @@ func (a KasbonRepository) Get(id string) (*models.Kasbon, error) {
 	return kasbon, nil
 }
 
+// GetOutstanding returns the released kasbons that are not settled yet, oldest first
+func (a KasbonRepository) GetOutstanding(param *models.KasbonAgingParam) (models.Kasbons, error) {
+	db := a.db.ORM.Model(&models.Kasbon{}).Preload("Company").Preload("Branch").Preload("Employee").
+		Where("status=?", models.KasbonStatusReleased)
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.EmployeeID; v != "" {
+		db = db.Where("employee_id=?", v)
+	}
+
+	list := make(models.Kasbons, 0)
+	if err := db.Order("release_date").Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// GetForUpdate locks the kasbon row until the transaction ends so it can't be settled twice
+func (a KasbonRepository) GetForUpdate(id string) (*models.Kasbon, error) {
+	kasbon := new(models.Kasbon)
+
+	if ok, err := QueryOne(a.db.ORM.Model(kasbon).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id), kasbon); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return kasbon, nil
+}
+
 func (a KasbonRepository) Create(kasbon *models.Kasbon) error {
 	result := a.db.ORM.Model(kasbon).Create(kasbon)
 	if result.Error != nil {
@@ -107,8 +146,10 @@ func (a KasbonRepository) Create(kasbon *models.Kasbon) error {
 	return nil
 }
 
+// Update leaves Status and the release and settle dates to the state machine
 func (a KasbonRepository) Update(id string, kasbon *models.Kasbon) error {
-	result := a.db.ORM.Model(kasbon).Where("id=?", id).Updates(kasbon)
+	result := a.db.ORM.Model(kasbon).Where("id=?", id).
+		Select("Type", "Amount", "Description", "Date", "File", "DueDate", "DeptID", "UpdatedAt", "UpdateBy").Updates(kasbon)
 	if result.Error != nil {
 		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
 	}
@@ -137,3 +178,13 @@ func (a KasbonRepository) UpdateStatus(id string, status int) error {
 
 	return nil
 }
+
+func (a KasbonRepository) UpdateRelease(id string, kasbon *models.Kasbon) error {
+	result := a.db.ORM.Model(kasbon).Where("id=?", id).
+		Select("Status", "ReleaseDate", "DueDate", "PaideDate", "LunasDate", "BKKHeaderID", "UpdatedAt", "UpdateBy").Updates(kasbon)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
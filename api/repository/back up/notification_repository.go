@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+)
+
+// NotificationRepository database structure
+type NotificationRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db lib.Database, logger lib.Logger) NotificationRepository {
+	return NotificationRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a NotificationRepository) WithTrx(trxHandle *gorm.DB) NotificationRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+// Query lists the in app notifications of param.UserID
+func (a NotificationRepository) Query(param *models.NotificationQueryParam) (*models.NotificationQueryResult, error) {
+	db := a.db.ORM.Model(&models.Notification{}).Where("user_id=? AND in_app=?", param.UserID, true)
+
+	if v := param.Event; v != "" {
+		db = db.Where("event=?", v)
+	}
+
+	if param.Unread {
+		db = db.Where("read_at IS NULL")
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.Notifications, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.NotificationQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a NotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+
+	db := a.db.ORM.Model(&models.Notification{}).Where("user_id=? AND in_app=? AND read_at IS NULL", userID, true)
+	if err := db.Count(&count).Error; err != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return count, nil
+}
+
+// Exists tells whether the event was already raised for the document
+func (a NotificationRepository) Exists(event string, docType string, docID string) (bool, error) {
+	var count int64
+
+	db := a.db.ORM.Model(&models.Notification{}).Where("event=? AND doc_type=? AND doc_id=?", event, docType, docID)
+	if err := db.Count(&count).Error; err != nil {
+		return false, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return count > 0, nil
+}
+
+// GetPending returns the notifications with an email still to send, oldest first
+func (a NotificationRepository) GetPending(limit int) (models.Notifications, error) {
+	list := make(models.Notifications, 0)
+
+	db := a.db.ORM.Model(&models.Notification{}).
+		Where("email_status=?", models.NotificationStatusPending)
+	if err := db.Order("created_at").Limit(limit).Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+func (a NotificationRepository) Create(notification *models.Notification) error {
+	result := a.db.ORM.Model(notification).Create(notification)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// MarkRead sets the read time of the unread notifications of the user, only the one of id when given
+func (a NotificationRepository) MarkRead(userID string, id string, readAt database.Datetime) (int64, error) {
+	db := a.db.ORM.Model(&models.Notification{}).Where("user_id=? AND read_at IS NULL", userID)
+	if id != "" {
+		db = db.Where("id=?", id)
+	}
+
+	result := db.Update("read_at", readAt)
+	if result.Error != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (a NotificationRepository) Get(id string, userID string) (*models.Notification, error) {
+	notification := new(models.Notification)
+
+	if ok, err := QueryOne(a.db.ORM.Model(notification).Where("id=? AND user_id=? AND in_app=?", id, userID, true), notification); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.NotificationNotFound
+	}
+
+	return notification, nil
+}
+
+// Claim moves a channel (email_status) from Pending to Sending, only the instance
+// whose update matched may send it
+func (a NotificationRepository) Claim(id string, column string) (bool, error) {
+	result := a.db.ORM.Model(&models.Notification{}).Where("id=? AND "+column+"=?", id, models.NotificationStatusPending).
+		Update(column, models.NotificationStatusSending)
+	if result.Error != nil {
+		return false, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (a NotificationRepository) UpdateStatus(id string, column string, status string, lastError string) error {
+	values := map[string]interface{}{column: status}
+	if lastError != "" {
+		values["last_error"] = lastError
+	}
+
+	result := a.db.ORM.Model(&models.Notification{}).Where("id=?", id).Updates(values)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a NotificationRepository) GetPreferences(userID string) (models.NotificationPreferences, error) {
+	list := make(models.NotificationPreferences, 0)
+
+	if err := a.db.ORM.Model(&models.NotificationPreference{}).Where("user_id=?", userID).Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// ReplacePreferences stores the preferences of the user in place of the previous ones
+func (a NotificationRepository) ReplacePreferences(userID string, preferences models.NotificationPreferences) error {
+	preference := new(models.NotificationPreference)
+
+	result := a.db.ORM.Model(preference).Unscoped().Where("user_id=?", userID).Delete(preference)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	if len(preferences) == 0 {
+		return nil
+	}
+
+	if result = a.db.ORM.Model(preference).Create(&preferences); result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// ReplenishmentRepository database structure
+type ReplenishmentRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewReplenishmentRepository creates a new replenishment repository
+func NewReplenishmentRepository(db lib.Database, logger lib.Logger) ReplenishmentRepository {
+	return ReplenishmentRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a ReplenishmentRepository) WithTrx(trxHandle *gorm.DB) ReplenishmentRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a ReplenishmentRepository) Query(param *models.ReplenishmentQueryParam) (*models.ReplenishmentQueryResult, error) {
+	db := a.db.ORM.Model(&models.Replenishment{}).Preload("Company").Preload("Branch").Preload("ReplenishmentInvoices")
+
+	if v := param.IDs; len(v) > 0 {
+		db = db.Where("id IN (?)", v)
+	}
+
+	if v := param.Num; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("num LIKE ?", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.Source; v != "" {
+		db = db.Where("source=?", v)
+	}
+
+	if v := param.Status; v != "" {
+		db = db.Where("status=?", v)
+	}
+
+	if v := param.InvoiceHeaderID; v != "" {
+		db = db.Where("id IN (?)", a.db.ORM.Model(&models.ReplenishmentInvoice{}).
+			Select("replenishment_id").Where("invoice_header_id=?", v))
+	}
+
+	if v := param.DateQuery; len(v) != 0 {
+		db = db.Where("created_at BETWEEN ? AND ?", param.DateQuery[0], param.DateQuery[1])
+	}
+
+	if v := param.QueryValue; v != "" {
+		v = "%" + v + "%"
+		db = db.Where("num LIKE ? OR description LIKE ?", v, v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.Replenishments, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.ReplenishmentQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a ReplenishmentRepository) Get(id string) (*models.Replenishment, error) {
+	replenishment := new(models.Replenishment)
+
+	db := a.db.ORM.Model(replenishment).Preload("Company").Preload("Branch").
+		Preload("ReplenishmentInvoices.InvoiceHeader").Preload("TarikDana")
+
+	if ok, err := QueryOne(db.Where("id=?", id), replenishment); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return replenishment, nil
+}
+
+// GetForUpdate locks the request row until the transaction ends so it is approved or rejected once
+func (a ReplenishmentRepository) GetForUpdate(id string) (*models.Replenishment, error) {
+	replenishment := new(models.Replenishment)
+
+	if ok, err := QueryOne(a.db.ORM.Model(replenishment).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id), replenishment); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return replenishment, nil
+}
+
+// GetPending returns the open request of a branch, if any
+func (a ReplenishmentRepository) GetPending(companyId string, branchId string) (*models.Replenishment, error) {
+	replenishment := new(models.Replenishment)
+
+	db := a.db.ORM.Model(replenishment).Where("company_id=? AND branch_id=? AND status=?",
+		companyId, branchId, models.ReplenishmentStatusPending)
+
+	if ok, err := QueryOne(db, replenishment); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return replenishment, nil
+}
+
+// CountLinkedInvoices counts the invoices of ids that are in a pending or approved request
+func (a ReplenishmentRepository) CountLinkedInvoices(invoiceIDs []string) (int64, error) {
+	requests := a.db.ORM.Model(&models.Replenishment{}).Select("id").
+		Where("status IN (?)", []string{models.ReplenishmentStatusPending, models.ReplenishmentStatusApproved})
+
+	var count int64
+	result := a.db.ORM.Model(&models.ReplenishmentInvoice{}).
+		Where("invoice_header_id IN (?) AND replenishment_id IN (?)", invoiceIDs, requests).Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return count, nil
+}
+
+func (a ReplenishmentRepository) Create(replenishment *models.Replenishment) error {
+	result := a.db.ORM.Model(replenishment).Omit("Company", "Branch", "TarikDana", "ReplenishmentInvoices.InvoiceHeader").
+		Create(replenishment)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ReplenishmentRepository) UpdateApprove(id string, replenishment *models.Replenishment) error {
+	result := a.db.ORM.Model(replenishment).Where("id=?", id).Select("Amount", "Status", "ApprovedBy",
+		"ApprovedDate", "RejectReason", "TarikDanaID", "UpdatedAt", "UpdateBy").Updates(replenishment)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// DeleteInvoices removes the invoice links of a request for good, the unique invoice index counts
+// soft deleted rows too
+func (a ReplenishmentRepository) DeleteInvoices(replenishmentID string) error {
+	invoice := new(models.ReplenishmentInvoice)
+
+	result := a.db.ORM.Model(invoice).Unscoped().Where("replenishment_id=?", replenishmentID).Delete(invoice)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
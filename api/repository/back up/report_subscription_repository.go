@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+)
+
+// ReportSubscriptionRepository database structure
+type ReportSubscriptionRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewReportSubscriptionRepository creates a new reportsubscription repository
+func NewReportSubscriptionRepository(db lib.Database, logger lib.Logger) ReportSubscriptionRepository {
+	return ReportSubscriptionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a ReportSubscriptionRepository) WithTrx(trxHandle *gorm.DB) ReportSubscriptionRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a ReportSubscriptionRepository) Query(param *models.ReportSubscriptionQueryParam) (*models.ReportSubscriptionQueryResult, error) {
+	db := a.db.ORM.Model(&models.ReportSubscription{})
+
+	if v := param.OwnerID; v != "" {
+		db = db.Where("owner_id=?", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.Report; v != "" {
+		db = db.Where("report=?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.ReportSubscriptions, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.ReportSubscriptionQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a ReportSubscriptionRepository) Get(id string) (*models.ReportSubscription, error) {
+	subscription := new(models.ReportSubscription)
+
+	if ok, err := QueryOne(a.db.ORM.Model(subscription).Where("id=?", id), subscription); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.ReportSubscriptionNotFound
+	}
+
+	return subscription, nil
+}
+
+// GetDue returns the subscriptions not paused whose next run is not after now
+func (a ReportSubscriptionRepository) GetDue(now database.Datetime) (models.ReportSubscriptions, error) {
+	list := make(models.ReportSubscriptions, 0)
+
+	db := a.db.ORM.Model(&models.ReportSubscription{}).Where("paused=? AND next_run_at<=?", false, now)
+	if err := db.Order("next_run_at").Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// Claim moves the next run of a due subscription, only the instance whose update matched the
+// run it read may send the report
+func (a ReportSubscriptionRepository) Claim(id string, run database.Datetime, next database.Datetime) (bool, error) {
+	result := a.db.ORM.Model(&models.ReportSubscription{}).Where("id=? AND next_run_at=?", id, run).
+		Update("next_run_at", next)
+	if result.Error != nil {
+		return false, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (a ReportSubscriptionRepository) UpdateRun(id string, run database.Datetime, lastError string) error {
+	result := a.db.ORM.Model(&models.ReportSubscription{}).Where("id=?", id).
+		Updates(map[string]interface{}{"last_run_at": run, "last_error": lastError})
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ReportSubscriptionRepository) Create(subscription *models.ReportSubscription) error {
+	result := a.db.ORM.Model(subscription).Create(subscription)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ReportSubscriptionRepository) Update(id string, subscription *models.ReportSubscription) error {
+	result := a.db.ORM.Model(subscription).Where("id=?", id).
+		Select("Name", "Report", "Format", "CompanyID", "BranchID", "Frequency", "Day", "Hour", "Recipients",
+			"Paused", "NextRunAt", "UpdatedAt", "UpdateBy").Updates(subscription)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a ReportSubscriptionRepository) Delete(id string) error {
+	subscription := new(models.ReportSubscription)
+
+	result := a.db.ORM.Model(subscription).Where("id=?", id).Delete(subscription)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -19,6 +19,7 @@ var Module = fx.Options(
 	fx.Provide(NewSaldoRepository),
 	fx.Provide(NewSaldoHistoryRepository),
 	fx.Provide(NewSaldoMonthRepository),
+	fx.Provide(NewSaldoMonthLogRepository),
 	fx.Provide(NewTrxRepository),
 	fx.Provide(NewKasbonRepository),
 	fx.Provide(NewCounterRepository),
@@ -28,4 +29,17 @@ var Module = fx.Options(
 	fx.Provide(NewTarikDanaRepository),
 	fx.Provide(NewInvoiceHeaderRepository),
 	fx.Provide(NewInvoiceDetailRepository),
+	fx.Provide(NewJournalRepository),
+	fx.Provide(NewCashCountRepository),
+	fx.Provide(NewReplenishmentRepository),
+	fx.Provide(NewApprovalFlowRepository),
+	fx.Provide(NewApprovalRequestRepository),
+	fx.Provide(NewAuditLogRepository),
+	fx.Provide(NewAttachmentRepository),
+	fx.Provide(NewBKKDuplicateRepository),
+	fx.Provide(NewCompanySignatureRepository),
+	fx.Provide(NewReportSubscriptionRepository),
+	fx.Provide(NewNotificationRepository),
+	fx.Provide(NewWebhookRepository),
+	fx.Provide(NewGLExportRepository),
 )
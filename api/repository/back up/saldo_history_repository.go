@@ -159,6 +159,20 @@ func (a SaldoHistoryRepository) DeleteByDesc(desc string) error {
 	return nil
 }
 
+// DeleteReserved removes the out row that BKKs created before the history moved to Pay wrote at their
+// creation, it is a soft delete so the row stays in the audit trail
+func (a SaldoHistoryRepository) DeleteReserved(companyId string, branchId string, desc string) error {
+	saldohistory := new(models.SaldoHistory)
+
+	result := a.db.ORM.Model(saldohistory).Where("company_id=? AND branch_id=? AND `desc`=? AND out_amount>0", companyId, branchId, desc).
+		Delete(saldohistory)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
 func (a SaldoHistoryRepository) UpdateStatus(id string, status int) error {
 	saldohistory := new(models.SaldoHistory)
 
@@ -169,3 +183,27 @@ func (a SaldoHistoryRepository) UpdateStatus(id string, status int) error {
 
 	return nil
 }
+
+func (a SaldoHistoryRepository) GetAllByCompanyAndBranch(companyId string, branchId string) (models.SaldoHistories, error) {
+	list := make(models.SaldoHistories, 0)
+
+	result := a.db.ORM.Model(&models.SaldoHistory{}).Where("company_id=? AND branch_id=?", companyId, branchId).
+		Order("record_id").Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
+// UpdateBalance rewrites the running balance of a history row, rows are keyed by record_id
+// because older postings were created without an id
+func (a SaldoHistoryRepository) UpdateBalance(recordID uint, saldoAwal int64, saldoAkhir int64) error {
+	result := a.db.ORM.Model(&models.SaldoHistory{}).Where("record_id=?", recordID).
+		Updates(map[string]interface{}{"saldo_awal": saldoAwal, "saldo_akhir": saldoAkhir})
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// SaldoMonthLogRepository database structure
+type SaldoMonthLogRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewSaldoMonthLogRepository creates a new saldomonthlog repository
+func NewSaldoMonthLogRepository(db lib.Database, logger lib.Logger) SaldoMonthLogRepository {
+	return SaldoMonthLogRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a SaldoMonthLogRepository) WithTrx(trxHandle *gorm.DB) SaldoMonthLogRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+func (a SaldoMonthLogRepository) Query(param *models.SaldoMonthLogQueryParam) (*models.SaldoMonthLogQueryResult, error) {
+	db := a.db.ORM.Model(&models.SaldoMonthLog{})
+
+	if v := param.SaldoMonthID; v != "" {
+		db = db.Where("saldo_month_id=?", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.BranchID; v != "" {
+		db = db.Where("branch_id=?", v)
+	}
+
+	if v := param.MonthYear; v != "" {
+		db = db.Where("month_year=?", v)
+	}
+
+	if v := param.Action; v != "" {
+		db = db.Where("action=?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.SaldoMonthLogs, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.SaldoMonthLogQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a SaldoMonthLogRepository) Create(saldomonthlog *models.SaldoMonthLog) error {
+	result := a.db.ORM.Model(saldomonthlog).Create(saldomonthlog)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
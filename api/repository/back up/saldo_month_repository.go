@@ -56,6 +56,10 @@ func (a SaldoMonthRepository) Query(param *models.SaldoMonthQueryParam) (*models
 		db = db.Where("active_flag=?", v)
 	}
 
+	if v := param.ClosedFlag; v != "" {
+		db = db.Where("closed_flag=?", v == "true")
+	}
+
 	db = db.Order(param.OrderParam.ParseOrder())
 
 	list := make(models.SaldoMonths, 0)
@@ -108,6 +112,18 @@ func (a SaldoMonthRepository) GetbyCompanyAndBranchMonth(companyId string, branc
 	return saldomonth, nil
 }
 
+func (a SaldoMonthRepository) GetLastClosed(companyId string, branchId string) (*models.SaldoMonth, error) {
+	saldomonth := new(models.SaldoMonth)
+
+	if ok, err := QueryOne(a.db.ORM.Model(saldomonth).Order("month_year desc").Where("company_id=? AND branch_id=? AND closed_flag=?", companyId, branchId, true), saldomonth); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return saldomonth, nil
+}
+
 func (a SaldoMonthRepository) Create(saldomonth *models.SaldoMonth) error {
 	result := a.db.ORM.Model(saldomonth).Create(saldomonth)
 	if result.Error != nil {
@@ -126,6 +142,16 @@ func (a SaldoMonthRepository) Update(id string, saldomonth *models.SaldoMonth) e
 	return nil
 }
 
+func (a SaldoMonthRepository) UpdateClosed(id string, saldomonth *models.SaldoMonth) error {
+	result := a.db.ORM.Model(saldomonth).Where("id=?", id).
+		Select("ClosedFlag", "ClosedDate", "ClosedBy", "UpdatedAt").Updates(saldomonth)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
 func (a SaldoMonthRepository) Delete(id string) error {
 	saldomonth := new(models.SaldoMonth)
 
@@ -147,3 +173,26 @@ func (a SaldoMonthRepository) UpdateStatus(id string, status int) error {
 
 	return nil
 }
+
+func (a SaldoMonthRepository) GetAllByCompanyAndBranch(companyId string, branchId string) (models.SaldoMonths, error) {
+	list := make(models.SaldoMonths, 0)
+
+	result := a.db.ORM.Model(&models.SaldoMonth{}).Where("company_id=? AND branch_id=?", companyId, branchId).
+		Order("month_year").Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
+// UpdateBalance writes the derived columns, zero values included
+func (a SaldoMonthRepository) UpdateBalance(id string, saldomonth *models.SaldoMonth) error {
+	result := a.db.ORM.Model(saldomonth).Where("id=?", id).
+		Select("SaldoAwal", "SaldoIn", "UsedBKK", "SaldoAkhir", "UpdatedAt").Updates(saldomonth)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
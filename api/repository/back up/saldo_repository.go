@@ -159,3 +159,44 @@ func (a SaldoRepository) UpdateStatus(id string, status int) error {
 
 	return nil
 }
+
+func (a SaldoRepository) GetAll(companyId string, branchId string) (models.Saldos, error) {
+	db := a.db.ORM.Model(&models.Saldo{})
+
+	if companyId != "" {
+		db = db.Where("company_id=?", companyId)
+	}
+
+	if branchId != "" {
+		db = db.Where("branch_id=?", branchId)
+	}
+
+	list := make(models.Saldos, 0)
+	if err := db.Order("company_id, branch_id").Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// UpdateBalance writes the derived columns, zero values included
+func (a SaldoRepository) UpdateBalance(id string, saldo *models.Saldo) error {
+	result := a.db.ORM.Model(saldo).Where("id=?", id).
+		Select("SaldoIn", "UsedBKK", "SaldoAkhir", "UpdatedAt").Updates(saldo)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a SaldoRepository) UpdateUsedKBS(id string, usedKBS int64) error {
+	saldo := new(models.Saldo)
+
+	result := a.db.ORM.Model(saldo).Where("id=?", id).Update("used_kbs", usedKBS)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
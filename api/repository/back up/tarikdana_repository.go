@@ -2,6 +2,7 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
@@ -39,6 +40,10 @@ func (a TarikDanaRepository) Query(param *models.TarikDanaQueryParam) (*models.T
 		db = db.Where("id IN (?)", v)
 	}
 
+	if v := param.Num; v != "" {
+		db = db.Where("num=?", v)
+	}
+
 	if v := param.Description; v != "" {
 		v = "%" + v + "%"
 		db = db.Where("description LIKE ?", v)
@@ -56,6 +61,10 @@ func (a TarikDanaRepository) Query(param *models.TarikDanaQueryParam) (*models.T
 		db = db.Where("date=?", v)
 	}
 
+	if v := param.ReplenishmentID; v != "" {
+		db = db.Where("replenishment_id=?", v)
+	}
+
 	if v := param.QueryValue; v != "" {
 		v = "%" + v + "%"
 		db = db.Where("num LIKE ? OR description LIKE ?", v, v)
@@ -89,6 +98,19 @@ func (a TarikDanaRepository) Get(id string) (*models.TarikDana, error) {
 	return tarikdana, nil
 }
 
+// GetForUpdate locks the tarikdana row until the transaction ends so it can't be voided twice
+func (a TarikDanaRepository) GetForUpdate(id string) (*models.TarikDana, error) {
+	tarikdana := new(models.TarikDana)
+
+	if ok, err := QueryOne(a.db.ORM.Model(tarikdana).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id), tarikdana); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return tarikdana, nil
+}
+
 func (a TarikDanaRepository) Create(tarikdana *models.TarikDana) error {
 	result := a.db.ORM.Model(tarikdana).Create(tarikdana)
 	if result.Error != nil {
@@ -98,8 +120,10 @@ func (a TarikDanaRepository) Create(tarikdana *models.TarikDana) error {
 	return nil
 }
 
+// Update saves the fields that may change before the TarikDana is posted
 func (a TarikDanaRepository) Update(id string, tarikdana *models.TarikDana) error {
-	result := a.db.ORM.Model(tarikdana).Where("id=?", id).Updates(tarikdana)
+	result := a.db.ORM.Model(tarikdana).Where("id=?", id).
+		Select("Type", "Amount", "Description", "Date", "File", "UpdatedAt", "UpdateBy").Updates(tarikdana)
 	if result.Error != nil {
 		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
 	}
@@ -118,6 +142,26 @@ func (a TarikDanaRepository) Delete(id string) error {
 	return nil
 }
 
+func (a TarikDanaRepository) UpdatePostStatus(id string, status string) error {
+	tarikdana := new(models.TarikDana)
+
+	result := a.db.ORM.Model(tarikdana).Where("id=?", id).Update("post_status", status)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a TarikDanaRepository) UpdateVoid(id string, tarikdana *models.TarikDana) error {
+	result := a.db.ORM.Model(tarikdana).Where("id=?", id).Select("PostStatus", "VoidDate", "VoidReason", "VoidBy", "UpdatedAt", "UpdateBy").Updates(tarikdana)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
 func (a TarikDanaRepository) UpdateStatus(id string, status int) error {
 	tarikdana := new(models.TarikDana)
 
@@ -97,6 +97,23 @@ func (a TrxRepository) Get(id string) (*models.Trx, error) {
 	return trx, nil
 }
 
+// GetByAccountNum returns the Trx of the branch booked on the account number, or the Trx of the company
+// when the branch has none
+func (a TrxRepository) GetByAccountNum(companyID string, branchID string, accountNum string) (*models.Trx, error) {
+	trx := new(models.Trx)
+	accounts := a.db.ORM.Model(&models.Account{}).Select("id").Where("company_id=? AND num=?", companyID, accountNum)
+
+	db := a.db.ORM.Model(trx).Where("company_id=? AND account_id IN (?) AND branch_id IN (?)", companyID, accounts, []string{branchID, ""}).
+		Order("branch_id desc")
+	if ok, err := QueryOne(db, trx); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.DatabaseRecordNotFound
+	}
+
+	return trx, nil
+}
+
 func (a TrxRepository) Create(trx *models.Trx) error {
 	result := a.db.ORM.Model(trx).Create(trx)
 	if result.Error != nil {
@@ -118,6 +118,18 @@ func (a UserRepository) GetByUsername(username string) (*models.User, error) {
 	return user, nil
 }
 
+// GetByEmails returns the users with one of the email addresses
+func (a UserRepository) GetByEmails(emails []string) (models.Users, error) {
+	list := make(models.Users, 0)
+
+	result := a.db.ORM.Model(&models.User{}).Where("email IN (?)", emails).Find(&list)
+	if result.Error != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return list, nil
+}
+
 func (a UserRepository) Create(user *models.User) error {
 	result := a.db.ORM.Model(user).Create(user)
 	if result.Error != nil {
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+)
+
+// WebhookRepository database structure of the endpoints and their delivery log
+type WebhookRepository struct {
+	db     lib.Database
+	logger lib.Logger
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db lib.Database, logger lib.Logger) WebhookRepository {
+	return WebhookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTrx enables repository with transaction
+func (a WebhookRepository) WithTrx(trxHandle *gorm.DB) WebhookRepository {
+	if trxHandle == nil {
+		a.logger.Zap.Error("Transaction Database not found in echo context. ")
+		return a
+	}
+
+	a.db.ORM = trxHandle
+	return a
+}
+
+// Query lists the company endpoints, the notification endpoints of the users are left out
+func (a WebhookRepository) Query(param *models.WebhookEndpointQueryParam) (*models.WebhookEndpointQueryResult, error) {
+	db := a.db.ORM.Model(&models.WebhookEndpoint{}).Where("user_id=?", "")
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.Event; v != "" {
+		db = db.Where("FIND_IN_SET(?, events)", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.WebhookEndpoints, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.WebhookEndpointQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a WebhookRepository) Get(id string) (*models.WebhookEndpoint, error) {
+	endpoint := new(models.WebhookEndpoint)
+
+	if ok, err := QueryOne(a.db.ORM.Model(endpoint).Where("id=?", id), endpoint); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.WebhookEndpointNotFound
+	}
+
+	return endpoint, nil
+}
+
+// GetSubscribed returns the active company endpoints of the company subscribed to the event
+func (a WebhookRepository) GetSubscribed(companyID string, event string) (models.WebhookEndpoints, error) {
+	list := make(models.WebhookEndpoints, 0)
+
+	db := a.db.ORM.Model(&models.WebhookEndpoint{}).
+		Where("company_id=? AND user_id=? AND active_flag=? AND FIND_IN_SET(?, events)", companyID, "", true, event)
+	if err := db.Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+// GetByUserURL returns the notification endpoint of the user for the url
+func (a WebhookRepository) GetByUserURL(userID string, url string) (*models.WebhookEndpoint, error) {
+	endpoint := new(models.WebhookEndpoint)
+
+	if ok, err := QueryOne(a.db.ORM.Model(endpoint).Where("user_id=? AND url=?", userID, url), endpoint); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.WebhookEndpointNotFound
+	}
+
+	return endpoint, nil
+}
+
+func (a WebhookRepository) Create(endpoint *models.WebhookEndpoint) error {
+	result := a.db.ORM.Model(endpoint).Create(endpoint)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a WebhookRepository) Update(id string, endpoint *models.WebhookEndpoint) error {
+	result := a.db.ORM.Model(endpoint).Where("id=?", id).
+		Select("Name", "URL", "Secret", "Events", "UpdatedAt", "UpdateBy").Updates(endpoint)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a WebhookRepository) UpdateActive(id string, active bool) error {
+	result := a.db.ORM.Model(&models.WebhookEndpoint{}).Where("id=?", id).Update("active_flag", active)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a WebhookRepository) Delete(id string) error {
+	endpoint := new(models.WebhookEndpoint)
+
+	result := a.db.ORM.Model(endpoint).Where("id=?", id).Delete(endpoint)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+func (a WebhookRepository) QueryDeliveries(param *models.WebhookDeliveryQueryParam) (*models.WebhookDeliveryQueryResult, error) {
+	db := a.db.ORM.Model(&models.WebhookDelivery{})
+
+	if v := param.WebhookEndpointID; v != "" {
+		db = db.Where("webhook_endpoint_id=?", v)
+	}
+
+	if v := param.CompanyID; v != "" {
+		db = db.Where("company_id=?", v)
+	}
+
+	if v := param.Event; v != "" {
+		db = db.Where("event=?", v)
+	}
+
+	if v := param.Status; v != "" {
+		db = db.Where("status=?", v)
+	}
+
+	if v := param.DocID; v != "" {
+		db = db.Where("doc_id=?", v)
+	}
+
+	db = db.Order(param.OrderParam.ParseOrder())
+
+	list := make(models.WebhookDeliveries, 0)
+	pagination, err := QueryPagination(db, param.PaginationParam, &list)
+	if err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	qr := &models.WebhookDeliveryQueryResult{
+		Pagination: pagination,
+		List:       list,
+	}
+
+	return qr, nil
+}
+
+func (a WebhookRepository) GetDelivery(id string) (*models.WebhookDelivery, error) {
+	delivery := new(models.WebhookDelivery)
+
+	if ok, err := QueryOne(a.db.ORM.Model(delivery).Where("id=?", id), delivery); err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	} else if !ok {
+		return nil, errors.WebhookDeliveryNotFound
+	}
+
+	return delivery, nil
+}
+
+// GetDue returns the pending deliveries whose next attempt is not after now, oldest first
+func (a WebhookRepository) GetDue(now database.Datetime, limit int) (models.WebhookDeliveries, error) {
+	list := make(models.WebhookDeliveries, 0)
+
+	db := a.db.ORM.Model(&models.WebhookDelivery{}).
+		Where("status=? AND next_attempt_at<=?", models.WebhookDeliveryStatusPending, now)
+	if err := db.Order("next_attempt_at").Limit(limit).Find(&list).Error; err != nil {
+		return nil, errors.Wrap(errors.DatabaseInternalError, err.Error())
+	}
+
+	return list, nil
+}
+
+func (a WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	result := a.db.ORM.Model(delivery).Create(delivery)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
+
+// ClaimDelivery counts an attempt of a due delivery and moves its next attempt to the retry time,
+// only the instance whose update matched the attempts it read may send it. An instance stopped
+// while sending leaves the delivery to be retried at next.
+func (a WebhookRepository) ClaimDelivery(id string, attempts int, next database.Datetime) (bool, error) {
+	result := a.db.ORM.Model(&models.WebhookDelivery{}).
+		Where("id=? AND status=? AND attempts=?", id, models.WebhookDeliveryStatusPending, attempts).
+		Updates(map[string]interface{}{"attempts": attempts + 1, "next_attempt_at": next})
+	if result.Error != nil {
+		return false, errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateAttempt records the result of an attempt
+func (a WebhookRepository) UpdateAttempt(id string, delivery *models.WebhookDelivery) error {
+	result := a.db.ORM.Model(&models.WebhookDelivery{}).Where("id=?", id).
+		Select("Status", "LastStatusCode", "LastError", "DeliveredAt", "UpdatedAt").Updates(delivery)
+	if result.Error != nil {
+		return errors.Wrap(errors.DatabaseInternalError, result.Error.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type ApprovalRoutes struct {
+	logger             lib.Logger
+	handler            lib.HttpHandler
+	approvalController controllers.ApprovalController
+}
+
+// NewApprovalRoutes creates new approval routes
+func NewApprovalRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	approvalController controllers.ApprovalController,
+) ApprovalRoutes {
+	return ApprovalRoutes{
+		handler:            handler,
+		logger:             logger,
+		approvalController: approvalController,
+	}
+}
+
+// Setup approval routes
+func (a ApprovalRoutes) Setup() {
+	a.logger.Zap.Info("Setting up approval routes")
+	api := a.handler.RouterV1.Group("/approvals")
+	{
+		api.GET("", a.approvalController.Query)
+		api.GET("/pending", a.approvalController.QueryPending)
+
+		api.GET("/flows", a.approvalController.QueryFlow)
+		api.POST("/flows", a.approvalController.CreateFlow)
+		api.GET("/flows/:id", a.approvalController.GetFlow)
+		api.PUT("/flows/:id", a.approvalController.UpdateFlow)
+		api.DELETE("/flows/:id", a.approvalController.DeleteFlow)
+
+		api.GET("/:doc_type/:doc_id", a.approvalController.Get)
+		api.GET("/:doc_type/:doc_id/timeline", a.approvalController.Timeline)
+	}
+}
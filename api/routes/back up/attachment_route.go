@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type AttachmentRoutes struct {
+	logger               lib.Logger
+	handler              lib.HttpHandler
+	attachmentController controllers.AttachmentController
+}
+
+// NewAttachmentRoutes creates new attachment routes
+func NewAttachmentRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	attachmentController controllers.AttachmentController,
+) AttachmentRoutes {
+	return AttachmentRoutes{
+		handler:              handler,
+		logger:               logger,
+		attachmentController: attachmentController,
+	}
+}
+
+// Setup attachment routes
+func (a AttachmentRoutes) Setup() {
+	a.logger.Zap.Info("Setting up attachment routes")
+	api := a.handler.RouterV1.Group("/attachments")
+	{
+		api.GET("", a.attachmentController.Query)
+		api.POST("", a.attachmentController.Create)
+		api.GET("/:id/download", a.attachmentController.Download)
+		api.DELETE("/:id", a.attachmentController.Delete)
+	}
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type AuditLogRoutes struct {
+	logger             lib.Logger
+	handler            lib.HttpHandler
+	auditlogController controllers.AuditLogController
+}
+
+// NewAuditLogRoutes creates new audit log routes
+func NewAuditLogRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	auditlogController controllers.AuditLogController,
+) AuditLogRoutes {
+	return AuditLogRoutes{
+		handler:            handler,
+		logger:             logger,
+		auditlogController: auditlogController,
+	}
+}
+
+// Setup audit log routes
+func (a AuditLogRoutes) Setup() {
+	a.logger.Zap.Info("Setting up audit log routes")
+	api := a.handler.RouterV1.Group("/auditlogs")
+	{
+		api.GET("", a.auditlogController.Query)
+		api.GET("/:id", a.auditlogController.Get)
+	}
+}
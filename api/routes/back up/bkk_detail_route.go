@@ -31,6 +31,10 @@ func (a BKKDetailRoutes) Setup() {
 	{
 		api.GET("", a.bkkdetailController.Query)
 		api.GET(".all", a.bkkdetailController.GetAll)
+		api.GET("/analysis", a.bkkdetailController.Analysis)
+		api.GET("/analysis/vouchers", a.bkkdetailController.AnalysisVouchers)
+		api.GET("/analysis/pdf", a.bkkdetailController.AnalysisPdf)
+		api.GET("/analysis/xlsx", a.bkkdetailController.AnalysisXlsx)
 
 		api.POST("", a.bkkdetailController.Create)
 		api.GET("/:id", a.bkkdetailController.Get)
@@ -38,8 +42,5 @@ func (a BKKDetailRoutes) Setup() {
 		api.DELETE("/:id", a.bkkdetailController.Delete)
 		api.PATCH("/:id/enable", a.bkkdetailController.Enable)
 		api.PATCH("/:id/disable", a.bkkdetailController.Disable)
-		api.GET("/upload/:id", a.bkkdetailController.GetFile)
-		api.POST("/upload", a.bkkdetailController.UploadFile)
-		api.DELETE("/upload/:id", a.bkkdetailController.RemoveFile)
 	}
 }
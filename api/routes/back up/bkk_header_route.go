@@ -31,6 +31,8 @@ func (a BKKHeaderRoutes) Setup() {
 	{
 		api.GET("", a.bkkheaderController.Query)
 		api.GET(".all", a.bkkheaderController.GetAll)
+		api.GET("/duplicates", a.bkkheaderController.QueryDuplicates)
+		api.PATCH("/duplicates/:id", a.bkkheaderController.ReviewDuplicate)
 
 		api.POST("", a.bkkheaderController.Create)
 		api.GET("/:id", a.bkkheaderController.Get)
@@ -39,5 +41,8 @@ func (a BKKHeaderRoutes) Setup() {
 		api.PATCH("/:id/statuspaid", a.bkkheaderController.StatusPaid)
 		api.POST("/approve", a.bkkheaderController.StatusApprove)
 		api.POST("/reject", a.bkkheaderController.StatusReject)
+		api.PATCH("/:id/resubmit", a.bkkheaderController.Resubmit)
+		api.PATCH("/:id/void", a.bkkheaderController.Void)
+		api.GET("/:id/print", a.bkkheaderController.Print)
 	}
 }
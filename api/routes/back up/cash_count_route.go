@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type CashCountRoutes struct {
+	logger              lib.Logger
+	handler             lib.HttpHandler
+	cashcountController controllers.CashCountController
+}
+
+// NewCashCountRoutes creates new cashcount routes
+func NewCashCountRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	cashcountController controllers.CashCountController,
+) CashCountRoutes {
+	return CashCountRoutes{
+		handler:             handler,
+		logger:              logger,
+		cashcountController: cashcountController,
+	}
+}
+
+// Setup cashcount routes
+func (a CashCountRoutes) Setup() {
+	a.logger.Zap.Info("Setting up cashcount routes")
+	api := a.handler.RouterV1.Group("/cashcounts")
+	{
+		api.GET("", a.cashcountController.Query)
+		api.GET("/denominations", a.cashcountController.Denominations)
+
+		api.POST("", a.cashcountController.Create)
+		api.GET("/:id", a.cashcountController.Get)
+		api.PUT("/:id", a.cashcountController.Update)
+		api.DELETE("/:id", a.cashcountController.Delete)
+		api.PATCH("/:id/approve", a.cashcountController.Approve)
+		api.PATCH("/:id/reject", a.cashcountController.Reject)
+		api.GET("/:id/print", a.cashcountController.Print)
+	}
+}
@@ -38,5 +38,12 @@ func (a CompanyRoutes) Setup() {
 		api.DELETE("/:id", a.companyController.Delete)
 		api.PATCH("/:id/enable", a.companyController.Enable)
 		api.PATCH("/:id/disable", a.companyController.Disable)
+		api.POST("/:id/logo", a.companyController.UploadLogo)
+		api.GET("/:id/logo", a.companyController.GetLogo)
+		api.GET("/:id/signatures", a.companyController.QuerySignatures)
+		api.POST("/:id/signatures", a.companyController.CreateSignature)
+		api.PUT("/:id/signatures/:signatureId", a.companyController.UpdateSignature)
+		api.DELETE("/:id/signatures/:signatureId", a.companyController.DeleteSignature)
+		api.GET("/:id/signatures/:signatureId/image", a.companyController.GetSignatureImage)
 	}
 }
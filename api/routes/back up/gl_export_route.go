@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type GLExportRoutes struct {
+	logger             lib.Logger
+	handler            lib.HttpHandler
+	glexportController controllers.GLExportController
+}
+
+// NewGLExportRoutes creates new glexport routes
+func NewGLExportRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	glexportController controllers.GLExportController,
+) GLExportRoutes {
+	return GLExportRoutes{
+		handler:            handler,
+		logger:             logger,
+		glexportController: glexportController,
+	}
+}
+
+// Setup glexport routes
+func (a GLExportRoutes) Setup() {
+	a.logger.Zap.Info("Setting up glexport routes")
+	api := a.handler.RouterV1.Group("/glexports")
+	{
+		api.GET("", a.glexportController.Query)
+		api.POST("", a.glexportController.Export)
+		api.GET("/:id", a.glexportController.Get)
+		api.GET("/:id/csv", a.glexportController.Csv)
+		api.POST("/:id/reset", a.glexportController.Reset)
+	}
+}
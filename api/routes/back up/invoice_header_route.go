@@ -43,5 +43,6 @@ func (a InvoiceHeaderRoutes) Setup() {
 		api.POST("/reject", a.invoiceheaderController.StatusReject)
 		api.POST("/approvefinal", a.invoiceheaderController.StatusApproveFinal)
 		api.POST("/rejectfinal", a.invoiceheaderController.StatusRejectFinal)
+		api.PATCH("/:id/resubmit", a.invoiceheaderController.Resubmit)
 	}
 }
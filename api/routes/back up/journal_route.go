@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type JournalRoutes struct {
+	logger            lib.Logger
+	handler           lib.HttpHandler
+	journalController controllers.JournalController
+}
+
+// NewJournalRoutes creates new journal routes
+func NewJournalRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	journalController controllers.JournalController,
+) JournalRoutes {
+	return JournalRoutes{
+		handler:           handler,
+		logger:            logger,
+		journalController: journalController,
+	}
+}
+
+// Setup journal routes
+func (a JournalRoutes) Setup() {
+	a.logger.Zap.Info("Setting up journal routes")
+	api := a.handler.RouterV1.Group("/journals")
+	{
+		api.GET("", a.journalController.Query)
+		api.GET("/:id", a.journalController.Get)
+	}
+}
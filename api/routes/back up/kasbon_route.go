@@ -31,13 +31,19 @@ func (a KasbonRoutes) Setup() {
 	{
 		api.GET("", a.kasbonController.Query)
 		api.GET(".all", a.kasbonController.GetAll)
+		api.GET("/aging", a.kasbonController.Aging)
+		api.GET("/aging/pdf", a.kasbonController.AgingPdf)
+		api.GET("/aging/xlsx", a.kasbonController.AgingXlsx)
 
 		api.POST("", a.kasbonController.Create)
 		api.GET("/:id", a.kasbonController.Get)
 		api.PUT("/:id", a.kasbonController.Update)
 		api.DELETE("/:id", a.kasbonController.Delete)
-		api.GET("/upload/:id", a.kasbonController.GetFile)
-		api.POST("/upload", a.kasbonController.UploadFile)
-		api.DELETE("/upload/:id", a.kasbonController.RemoveFile)
+		api.PATCH("/:id/approve", a.kasbonController.Approve)
+		api.PATCH("/:id/reject", a.kasbonController.Reject)
+		api.PATCH("/:id/resubmit", a.kasbonController.Resubmit)
+		api.PATCH("/:id/release", a.kasbonController.Release)
+		api.PATCH("/:id/settle", a.kasbonController.Settle)
+		api.POST("/:id/settlement", a.kasbonController.Settlement)
 	}
 }
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type NotificationRoutes struct {
+	logger                 lib.Logger
+	handler                lib.HttpHandler
+	notificationController controllers.NotificationController
+}
+
+// NewNotificationRoutes creates new notification routes
+func NewNotificationRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	notificationController controllers.NotificationController,
+) NotificationRoutes {
+	return NotificationRoutes{
+		handler:                handler,
+		logger:                 logger,
+		notificationController: notificationController,
+	}
+}
+
+// Setup notification routes
+func (a NotificationRoutes) Setup() {
+	a.logger.Zap.Info("Setting up notification routes")
+	api := a.handler.RouterV1.Group("/notifications")
+	{
+		api.GET("", a.notificationController.Query)
+		api.GET("/unread", a.notificationController.Unread)
+		api.PATCH("/read", a.notificationController.ReadAll)
+		api.PATCH("/:id/read", a.notificationController.Read)
+		api.GET("/preferences", a.notificationController.Preferences)
+		api.PUT("/preferences", a.notificationController.UpdatePreferences)
+	}
+}
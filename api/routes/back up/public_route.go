@@ -45,10 +45,5 @@ func (a PublicRoutes) Setup() {
 		// captcha
 		api.GET("/captcha", a.captchaController.GetCaptcha)
 		api.POST("/captcha/verify", a.captchaController.VerifyCaptcha)
-
-		// testing routes
-		api.POST("/report/lmdp", a.publicController.LmdpReport)
-		api.POST("/report/lrdp", a.publicController.LrdpReport)
-
 	}
 }
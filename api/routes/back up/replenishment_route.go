@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type ReplenishmentRoutes struct {
+	logger                  lib.Logger
+	handler                 lib.HttpHandler
+	replenishmentController controllers.ReplenishmentController
+}
+
+// NewReplenishmentRoutes creates new replenishment routes
+func NewReplenishmentRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	replenishmentController controllers.ReplenishmentController,
+) ReplenishmentRoutes {
+	return ReplenishmentRoutes{
+		handler:                 handler,
+		logger:                  logger,
+		replenishmentController: replenishmentController,
+	}
+}
+
+// Setup replenishment routes
+func (a ReplenishmentRoutes) Setup() {
+	a.logger.Zap.Info("Setting up replenishment routes")
+	api := a.handler.RouterV1.Group("/replenishments")
+	{
+		api.GET("", a.replenishmentController.Query)
+
+		api.POST("", a.replenishmentController.Create)
+		api.GET("/:id", a.replenishmentController.Get)
+		api.PATCH("/:id/approve", a.replenishmentController.Approve)
+		api.PATCH("/:id/reject", a.replenishmentController.Reject)
+	}
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type ReportJobRoutes struct {
+	logger              lib.Logger
+	handler             lib.HttpHandler
+	reportjobController controllers.ReportJobController
+}
+
+// NewReportJobRoutes creates new reportjob routes
+func NewReportJobRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	reportjobController controllers.ReportJobController,
+) ReportJobRoutes {
+	return ReportJobRoutes{
+		handler:             handler,
+		logger:              logger,
+		reportjobController: reportjobController,
+	}
+}
+
+// Setup reportjob routes
+func (a ReportJobRoutes) Setup() {
+	a.logger.Zap.Info("Setting up reportjob routes")
+	api := a.handler.RouterV1.Group("/reportjobs")
+	{
+		api.POST("", a.reportjobController.Submit)
+		api.GET("/csv", a.reportjobController.Csv)
+		api.GET("/:id", a.reportjobController.Get)
+		api.GET("/:id/download", a.reportjobController.Download)
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type ReportSubscriptionRoutes struct {
+	logger                       lib.Logger
+	handler                      lib.HttpHandler
+	reportsubscriptionController controllers.ReportSubscriptionController
+}
+
+// NewReportSubscriptionRoutes creates new reportsubscription routes
+func NewReportSubscriptionRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	reportsubscriptionController controllers.ReportSubscriptionController,
+) ReportSubscriptionRoutes {
+	return ReportSubscriptionRoutes{
+		handler:                      handler,
+		logger:                       logger,
+		reportsubscriptionController: reportsubscriptionController,
+	}
+}
+
+// Setup reportsubscription routes
+func (a ReportSubscriptionRoutes) Setup() {
+	a.logger.Zap.Info("Setting up reportsubscription routes")
+	api := a.handler.RouterV1.Group("/reportsubscriptions")
+	{
+		api.GET("", a.reportsubscriptionController.Query)
+		api.POST("", a.reportsubscriptionController.Create)
+		api.GET("/:id", a.reportsubscriptionController.Get)
+		api.PUT("/:id", a.reportsubscriptionController.Update)
+		api.DELETE("/:id", a.reportsubscriptionController.Delete)
+		api.POST("/:id/send", a.reportsubscriptionController.Send)
+	}
+}
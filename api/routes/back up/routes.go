@@ -25,6 +25,17 @@ var Module = fx.Options(
 	fx.Provide(NewTarikDanaRoutes),
 	fx.Provide(NewInvoiceHeaderRoutes),
 	fx.Provide(NewInvoiceDetailRoutes),
+	fx.Provide(NewJournalRoutes),
+	fx.Provide(NewCashCountRoutes),
+	fx.Provide(NewReplenishmentRoutes),
+	fx.Provide(NewApprovalRoutes),
+	fx.Provide(NewAuditLogRoutes),
+	fx.Provide(NewAttachmentRoutes),
+	fx.Provide(NewReportJobRoutes),
+	fx.Provide(NewReportSubscriptionRoutes),
+	fx.Provide(NewNotificationRoutes),
+	fx.Provide(NewWebhookRoutes),
+	fx.Provide(NewGLExportRoutes),
 )
 
 // Routes contains multiple routes
@@ -57,6 +68,17 @@ func NewRoutes(
 	tarikdanaRoutes TarikDanaRoutes,
 	invoiceheaderRoutes InvoiceHeaderRoutes,
 	invoicedetailRoutes InvoiceDetailRoutes,
+	journalRoutes JournalRoutes,
+	cashcountRoutes CashCountRoutes,
+	replenishmentRoutes ReplenishmentRoutes,
+	approvalRoutes ApprovalRoutes,
+	auditlogRoutes AuditLogRoutes,
+	attachmentRoutes AttachmentRoutes,
+	reportjobRoutes ReportJobRoutes,
+	reportsubscriptionRoutes ReportSubscriptionRoutes,
+	notificationRoutes NotificationRoutes,
+	webhookRoutes WebhookRoutes,
+	glexportRoutes GLExportRoutes,
 ) Routes {
 	return Routes{
 		pprofRoutes,
@@ -79,6 +101,17 @@ func NewRoutes(
 		tarikdanaRoutes,
 		invoiceheaderRoutes,
 		invoicedetailRoutes,
+		journalRoutes,
+		cashcountRoutes,
+		replenishmentRoutes,
+		approvalRoutes,
+		auditlogRoutes,
+		attachmentRoutes,
+		reportjobRoutes,
+		reportsubscriptionRoutes,
+		notificationRoutes,
+		webhookRoutes,
+		glexportRoutes,
 	}
 }
 
@@ -33,11 +33,18 @@ func (a SaldoRoutes) Setup() {
 		api.GET(".all", a.saldoController.GetAll)
 
 		api.POST("", a.saldoController.Create)
+		api.GET("/reconcile", a.saldoController.Reconcile)
+		api.POST("/reconcile", a.saldoController.ReconcileFix)
 		api.GET("/:id", a.saldoController.Get)
 		api.GET("/user", a.saldoController.GetByUser)
 		api.PUT("/:id", a.saldoController.Update)
 		api.DELETE("/:id", a.saldoController.Delete)
 		api.PATCH("/:id/enable", a.saldoController.Enable)
 		api.PATCH("/:id/disable", a.saldoController.Disable)
+
+		api.GET("/months", a.saldoController.QueryMonth)
+		api.POST("/months/close", a.saldoController.ClosePeriod)
+		api.POST("/months/:id/reopen", a.saldoController.ReopenPeriod)
+		api.GET("/months/:id/logs", a.saldoController.QueryMonthLog)
 	}
 }
@@ -38,8 +38,9 @@ func (a TarikDanaRoutes) Setup() {
 		api.DELETE("/:id", a.tarikdanaController.Delete)
 		api.PATCH("/:id/enable", a.tarikdanaController.Enable)
 		api.PATCH("/:id/disable", a.tarikdanaController.Disable)
-		api.GET("/upload/:id", a.tarikdanaController.GetFile)
-		api.POST("/upload", a.tarikdanaController.UploadFile)
-		api.DELETE("/upload/:id", a.tarikdanaController.RemoveFile)
+		api.PATCH("/:id/approve", a.tarikdanaController.Approve)
+		api.PATCH("/:id/reject", a.tarikdanaController.Reject)
+		api.PATCH("/:id/resubmit", a.tarikdanaController.Resubmit)
+		api.PATCH("/:id/void", a.tarikdanaController.Void)
 	}
 }
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/api/controllers"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+)
+
+type WebhookRoutes struct {
+	logger            lib.Logger
+	handler           lib.HttpHandler
+	webhookController controllers.WebhookController
+}
+
+// NewWebhookRoutes creates new webhook routes
+func NewWebhookRoutes(
+	logger lib.Logger,
+	handler lib.HttpHandler,
+	webhookController controllers.WebhookController,
+) WebhookRoutes {
+	return WebhookRoutes{
+		handler:           handler,
+		logger:            logger,
+		webhookController: webhookController,
+	}
+}
+
+// Setup webhook routes
+func (a WebhookRoutes) Setup() {
+	a.logger.Zap.Info("Setting up webhook routes")
+	api := a.handler.RouterV1.Group("/webhooks")
+	{
+		api.GET("", a.webhookController.Query)
+		api.POST("", a.webhookController.Create)
+		api.GET("/deliveries", a.webhookController.QueryDeliveries)
+		api.GET("/deliveries/:id", a.webhookController.GetDelivery)
+		api.POST("/deliveries/:id/replay", a.webhookController.Replay)
+		api.GET("/:id", a.webhookController.Get)
+		api.PUT("/:id", a.webhookController.Update)
+		api.DELETE("/:id", a.webhookController.Delete)
+		api.PATCH("/:id/enable", a.webhookController.Enable)
+		api.PATCH("/:id/disable", a.webhookController.Disable)
+	}
+}
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/slice"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// ApprovalService is the approval engine shared by BKK, Invoice, Kasbon and TarikDana
+type ApprovalService struct {
+	logger                    lib.Logger
+	notificationService       NotificationService
+	companyRepository         repository.CompanyRepository
+	userRepository            repository.UserRepository
+	userroleRepository        repository.UserRoleRepository
+	approvalflowRepository    repository.ApprovalFlowRepository
+	approvalrequestRepository repository.ApprovalRequestRepository
+}
+
+// NewApprovalService creates a new approvalservice
+func NewApprovalService(
+	logger lib.Logger,
+	notificationService NotificationService,
+	companyRepository repository.CompanyRepository,
+	userRepository repository.UserRepository,
+	userroleRepository repository.UserRoleRepository,
+	approvalflowRepository repository.ApprovalFlowRepository,
+	approvalrequestRepository repository.ApprovalRequestRepository,
+) ApprovalService {
+	return ApprovalService{
+		logger:                    logger,
+		notificationService:       notificationService,
+		companyRepository:         companyRepository,
+		userRepository:            userRepository,
+		userroleRepository:        userroleRepository,
+		approvalflowRepository:    approvalflowRepository,
+		approvalrequestRepository: approvalrequestRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a ApprovalService) WithTrx(trxHandle *gorm.DB) ApprovalService {
+	a.companyRepository = a.companyRepository.WithTrx(trxHandle)
+	a.userRepository = a.userRepository.WithTrx(trxHandle)
+	a.userroleRepository = a.userroleRepository.WithTrx(trxHandle)
+	a.approvalflowRepository = a.approvalflowRepository.WithTrx(trxHandle)
+	a.approvalrequestRepository = a.approvalrequestRepository.WithTrx(trxHandle)
+	a.notificationService = a.notificationService.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a ApprovalService) QueryFlow(param *models.ApprovalFlowQueryParam) (*models.ApprovalFlowQueryResult, error) {
+	return a.approvalflowRepository.Query(param)
+}
+
+func (a ApprovalService) GetFlow(id string) (*models.ApprovalFlow, error) {
+	return a.approvalflowRepository.Get(id)
+}
+
+func (a ApprovalService) CheckFlow(flow *models.ApprovalFlow) error {
+	if !slice.ContainsString(models.ApprovalDocTypes, flow.DocType) {
+		return errors.ApprovalFlowInvalidDocType
+	}
+
+	sequences := make(map[int]bool)
+	for _, step := range flow.ApprovalSteps {
+		if (step.RoleID == "") == (step.UserID == "") || step.Sequence <= 0 || sequences[step.Sequence] {
+			return errors.ApprovalFlowInvalidStep
+		}
+		sequences[step.Sequence] = true
+	}
+
+	return nil
+}
+
+func (a ApprovalService) CreateFlow(flow *models.ApprovalFlow) (id string, err error) {
+	if err = a.CheckFlow(flow); err != nil {
+		return
+	}
+
+	if _, err = a.approvalflowRepository.GetByDocType(flow.CompanyID, flow.DocType); err == nil {
+		return "", errors.ApprovalFlowAlreadyExists
+	} else if err != errors.DatabaseRecordNotFound {
+		return
+	}
+
+	flow.ID = uuid.MustString()
+	flow.ActiveFlag = true
+	for _, step := range flow.ApprovalSteps {
+		step.ApprovalFlowID = flow.ID
+	}
+
+	if err = a.approvalflowRepository.Create(flow); err != nil {
+		return
+	}
+
+	return flow.ID, nil
+}
+
+// UpdateFlow replaces the steps of a flow, it is refused while requests wait on its steps
+func (a ApprovalService) UpdateFlow(id string, flow *models.ApprovalFlow) error {
+	oFlow, err := a.approvalflowRepository.Get(id)
+	if err != nil {
+		return err
+	} else if err = a.checkFlowIdle(id); err != nil {
+		return err
+	}
+
+	flow.CompanyID = oFlow.CompanyID
+	flow.DocType = oFlow.DocType
+	if err = a.CheckFlow(flow); err != nil {
+		return err
+	}
+
+	if err = a.approvalflowRepository.Update(id, flow); err != nil {
+		return err
+	}
+
+	if err = a.approvalflowRepository.DeleteStepsByFlowID(id); err != nil {
+		return err
+	}
+
+	for _, step := range flow.ApprovalSteps {
+		step.ApprovalFlowID = id
+	}
+
+	return a.approvalflowRepository.CreateSteps(flow.ApprovalSteps)
+}
+
+func (a ApprovalService) DeleteFlow(id string) error {
+	if _, err := a.approvalflowRepository.Get(id); err != nil {
+		return err
+	} else if err = a.checkFlowIdle(id); err != nil {
+		return err
+	}
+
+	if err := a.approvalflowRepository.DeleteStepsByFlowID(id); err != nil {
+		return err
+	}
+
+	return a.approvalflowRepository.Delete(id)
+}
+
+// checkFlowIdle refuses changes to a flow that pending requests still walk through
+func (a ApprovalService) checkFlowIdle(id string) error {
+	n, err := a.approvalrequestRepository.CountPendingByFlow(id)
+	if err != nil {
+		return err
+	} else if n > 0 {
+		return errors.ApprovalFlowInUse
+	}
+
+	return nil
+}
+
+func (a ApprovalService) Query(param *models.ApprovalRequestQueryParam) (*models.ApprovalRequestQueryResult, error) {
+	return a.approvalrequestRepository.Query(param)
+}
+
+// QueryPending lists the requests waiting on the user, directly or through one of the user's roles
+func (a ApprovalService) QueryPending(userID string, param *models.ApprovalRequestQueryParam) (*models.ApprovalRequestQueryResult, error) {
+	roleIDs, err := a.roleIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	param.ApproverID = userID
+	param.ApproverRoleIDs = roleIDs
+
+	return a.approvalrequestRepository.Query(param)
+}
+
+// Get returns the approval of a document with its current step and pending approvers,
+// nil when the document never went through approval
+func (a ApprovalService) Get(docType string, docID string) (*models.ApprovalRequest, error) {
+	request, err := a.approvalrequestRepository.GetByDoc(docType, docID)
+	if err == errors.DatabaseRecordNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if request.Status != models.ApprovalStatusPending {
+		request.PendingApprovers = []string{}
+		return request, nil
+	}
+
+	// shown without a step so the document stays readable, deciding it fails
+	if request.Step, err = a.currentStep(request); err != nil && err != errors.ApprovalStepNotFound {
+		return nil, err
+	}
+
+	if request.PendingApprovers, err = a.approvers(request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// IsApproved tells whether a document may go on, documents without approval request were created
+// before the approval engine or don't need approval
+func (a ApprovalService) IsApproved(docType string, docID string) (bool, error) {
+	request, err := a.approvalrequestRepository.GetByDoc(docType, docID)
+	if err == errors.DatabaseRecordNotFound {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return request.Status == models.ApprovalStatusApproved, nil
+}
+
+// Submit starts the approval of a document. Without Company.ApprovalFlag the document is approved
+// right away and nothing is stored. Without a flow for the document type a single step is created
+// that any user allowed on the approve endpoint can decide, like before the approval engine.
+func (a ApprovalService) Submit(doc *models.ApprovalRequest, username string) (*models.ApprovalRequest, error) {
+	company, err := a.companyRepository.Get(doc.CompanyID)
+	if err != nil {
+		return nil, err
+	} else if !company.ApprovalFlag {
+		doc.Status = models.ApprovalStatusApproved
+		return doc, nil
+	}
+
+	doc.ID = uuid.MustString()
+	doc.CreatedBy = username
+	if err = a.start(doc); err != nil {
+		return nil, err
+	}
+
+	if err = a.approvalrequestRepository.Create(doc); err != nil {
+		return nil, err
+	}
+
+	if err = a.history(doc, nil, models.ApprovalActionSubmit, &models.ApprovalDecision{Username: username}); err != nil {
+		return nil, err
+	}
+
+	if err = a.notifyPending(doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Resubmit restarts the approval of a rejected document, or of a pending one whose amount was changed,
+// from the first step that applies to its corrected amount, the previous decisions stay in the history
+func (a ApprovalService) Resubmit(docType string, docID string, amount int64, decision *models.ApprovalDecision) (*models.ApprovalRequest, error) {
+	request, err := a.approvalrequestRepository.GetByDocForUpdate(docType, docID)
+	if err == errors.DatabaseRecordNotFound {
+		return nil, errors.ApprovalRequestNotFound
+	} else if err != nil {
+		return nil, err
+	} else if request.Status != models.ApprovalStatusRejected && request.Status != models.ApprovalStatusPending {
+		return nil, errors.ApprovalNotRejected
+	}
+
+	request.Amount = amount
+	request.RejectReason = ""
+	request.UpdateBy = decision.Username
+	if err = a.start(request); err != nil {
+		return nil, err
+	}
+
+	if err = a.approvalrequestRepository.UpdateStep(request.ID, request); err != nil {
+		return nil, err
+	}
+
+	if err = a.history(request, nil, models.ApprovalActionResubmit, decision); err != nil {
+		return nil, err
+	}
+
+	if err = a.notifyPending(request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Approve records the decision of the current step and moves the request to the next step
+// that applies to its amount, the request is approved after the last one
+func (a ApprovalService) Approve(docType string, docID string, decision *models.ApprovalDecision) (*models.ApprovalRequest, error) {
+	request, step, err := a.decide(docType, docID, decision.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.history(request, step, models.ApprovalActionApprove, decision); err != nil {
+		return nil, err
+	}
+
+	var next *models.ApprovalStep
+	if request.ApprovalFlowID != "" {
+		flow, err := a.approvalflowRepository.Get(request.ApprovalFlowID)
+		if err != nil {
+			return nil, err
+		}
+		next = flow.ApprovalSteps.Next(request.CurrentStep, request.Amount)
+	}
+
+	if next != nil {
+		request.CurrentStep = next.Sequence
+	} else {
+		request.Status = models.ApprovalStatusApproved
+	}
+	request.UpdateBy = decision.Username
+
+	if err = a.approvalrequestRepository.UpdateStep(request.ID, request); err != nil {
+		return nil, err
+	}
+
+	if next != nil {
+		err = a.notifyPending(request)
+	} else {
+		err = a.notifyDecided(request)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Reject stops the request at the current step, the comment is kept as the reject reason
+func (a ApprovalService) Reject(docType string, docID string, decision *models.ApprovalDecision) (*models.ApprovalRequest, error) {
+	if strings.TrimSpace(decision.Comment) == "" {
+		return nil, errors.ApprovalReasonEmpty
+	}
+
+	request, step, err := a.decide(docType, docID, decision.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.history(request, step, models.ApprovalActionReject, decision); err != nil {
+		return nil, err
+	}
+
+	request.Status = models.ApprovalStatusRejected
+	request.RejectReason = decision.Comment
+	request.UpdateBy = decision.Username
+
+	if err = a.approvalrequestRepository.UpdateStep(request.ID, request); err != nil {
+		return nil, err
+	}
+
+	if err = a.notifyDecided(request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// Cancel closes the approval of a deleted document so it is no longer waiting on the approvers nor
+// holding its flow, a document that never went through approval has nothing to cancel
+func (a ApprovalService) Cancel(docType string, docID string, decision *models.ApprovalDecision) error {
+	request, err := a.approvalrequestRepository.GetByDocForUpdate(docType, docID)
+	if err == errors.DatabaseRecordNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	} else if request.Status == models.ApprovalStatusCancelled {
+		return nil
+	}
+
+	if err = a.history(request, nil, models.ApprovalActionCancel, decision); err != nil {
+		return err
+	}
+
+	request.Status = models.ApprovalStatusCancelled
+	request.UpdateBy = decision.Username
+
+	return a.approvalrequestRepository.UpdateStep(request.ID, request)
+}
+
+// Timeline returns every decision taken on a document, across resubmissions
+func (a ApprovalService) Timeline(docType string, docID string) (*models.ApprovalTimeline, error) {
+	request, err := a.approvalrequestRepository.GetByDoc(docType, docID)
+	if err == errors.DatabaseRecordNotFound {
+		return nil, errors.ApprovalRequestNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	histories, err := a.approvalrequestRepository.GetHistoriesByDoc(docType, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ApprovalTimeline{
+		DocType:      request.DocType,
+		DocID:        request.DocID,
+		DocNum:       request.DocNum,
+		Status:       request.Status,
+		RejectReason: request.RejectReason,
+		Histories:    histories,
+	}, nil
+}
+
+// Approvals returns the approve decisions of the last submission of a document, oldest first, empty
+// when the document never went through approval
+func (a ApprovalService) Approvals(docType string, docID string) (models.ApprovalHistories, error) {
+	histories, err := a.approvalrequestRepository.GetHistoriesByDoc(docType, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals := make(models.ApprovalHistories, 0)
+	for _, history := range histories {
+		switch history.Action {
+		case models.ApprovalActionSubmit, models.ApprovalActionResubmit:
+			approvals = approvals[:0]
+		case models.ApprovalActionApprove:
+			approvals = append(approvals, history)
+		}
+	}
+
+	return approvals, nil
+}
+
+// start puts the request on the first step of the active flow that applies to its amount
+func (a ApprovalService) start(request *models.ApprovalRequest) error {
+	request.Status = models.ApprovalStatusPending
+	request.ApprovalFlowID = ""
+	request.CurrentStep = 1
+
+	flow, err := a.approvalflowRepository.GetByDocType(request.CompanyID, request.DocType)
+	if err == errors.DatabaseRecordNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	request.ApprovalFlowID = flow.ID
+	if step := flow.ApprovalSteps.Next(0, request.Amount); step != nil {
+		request.CurrentStep = step.Sequence
+	} else {
+		request.CurrentStep = 0
+		request.Status = models.ApprovalStatusApproved
+	}
+
+	return nil
+}
+
+// decide locks a pending request and checks the user may decide its current step, a request
+// without flow can be decided by anyone allowed on the approve endpoint
+func (a ApprovalService) decide(docType string, docID string, userID string) (*models.ApprovalRequest, *models.ApprovalStep, error) {
+	request, err := a.approvalrequestRepository.GetByDocForUpdate(docType, docID)
+	if err == errors.DatabaseRecordNotFound {
+		return nil, nil, errors.ApprovalRequestNotFound
+	} else if err != nil {
+		return nil, nil, err
+	} else if request.Status != models.ApprovalStatusPending {
+		return nil, nil, errors.ApprovalNotPending
+	}
+
+	if request.ApprovalFlowID == "" {
+		return request, nil, nil
+	}
+
+	step, err := a.currentStep(request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if step.UserID == userID {
+		return request, step, nil
+	}
+
+	roleIDs, err := a.roleIDs(userID)
+	if err != nil {
+		return nil, nil, err
+	} else if step.RoleID == "" || !slice.ContainsString(roleIDs, step.RoleID) {
+		return nil, nil, errors.ApprovalNotApprover
+	}
+
+	return request, step, nil
+}
+
+// currentStep is nil for a request without flow, a step missing from the flow is an error
+func (a ApprovalService) currentStep(request *models.ApprovalRequest) (*models.ApprovalStep, error) {
+	if request.ApprovalFlowID == "" {
+		return nil, nil
+	}
+
+	flow, err := a.approvalflowRepository.Get(request.ApprovalFlowID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range flow.ApprovalSteps {
+		if step.Sequence == request.CurrentStep {
+			return step, nil
+		}
+	}
+
+	return nil, errors.ApprovalStepNotFound
+}
+
+// approvers returns the usernames that may decide the current step of the request
+func (a ApprovalService) approvers(request *models.ApprovalRequest) ([]string, error) {
+	usernames := []string{}
+	step := request.Step
+	if step == nil {
+		return usernames, nil
+	}
+
+	param := &models.UserQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		CompanyID:       request.CompanyID,
+	}
+	if step.UserID != "" {
+		param.ID = step.UserID
+	} else {
+		param.RoleIDs = []string{step.RoleID}
+	}
+
+	qr, err := a.userRepository.Query(param)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range qr.List {
+		usernames = append(usernames, user.Username)
+	}
+
+	return usernames, nil
+}
+
+func (a ApprovalService) roleIDs(userID string) ([]string, error) {
+	qr, err := a.userroleRepository.Query(&models.UserRoleQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		UserID:          userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return qr.List.ToRoleIDs(), nil
+}
+
+func (a ApprovalService) history(request *models.ApprovalRequest, step *models.ApprovalStep, action string, decision *models.ApprovalDecision) error {
+	history := &models.ApprovalHistory{
+		ApprovalRequestID: request.ID,
+		Sequence:          request.CurrentStep,
+		Action:            action,
+		Username:          decision.Username,
+		IPAddress:         decision.IPAddress,
+		Comment:           decision.Comment,
+	}
+	if step != nil {
+		history.StepName = step.Name
+	}
+
+	if err := a.approvalrequestRepository.CreateHistory(history); err != nil {
+		return err
+	}
+
+	request.ApprovalHistories = append(request.ApprovalHistories, history)
+	return nil
+}
+
+// notifyPending tells the approvers of the current step that the request waits for them
+func (a ApprovalService) notifyPending(request *models.ApprovalRequest) error {
+	if request.Status != models.ApprovalStatusPending {
+		return nil
+	}
+
+	step, err := a.currentStep(request)
+	if err != nil {
+		return err
+	}
+
+	request.Step = step
+	usernames, err := a.approvers(request)
+	if err != nil {
+		return err
+	}
+
+	name := notificationDocNames[request.DocType]
+	return a.notificationService.Notify(&models.NotificationEvent{
+		Event:     models.NotificationApprovalPending,
+		Usernames: usernames,
+		Title:     name + " menunggu persetujuan",
+		Message:   fmt.Sprintf("%s %s sebesar %d menunggu persetujuan Anda", name, request.DocNum, request.Amount),
+		DocType:   request.DocType,
+		DocID:     request.DocID,
+		DocNum:    request.DocNum,
+	})
+}
+
+// notifyDecided tells the submitter of the request that it is approved or rejected
+func (a ApprovalService) notifyDecided(request *models.ApprovalRequest) error {
+	name := notificationDocNames[request.DocType]
+	event := &models.NotificationEvent{
+		Event:     models.NotificationApprovalApproved,
+		Usernames: []string{request.CreatedBy},
+		Title:     name + " disetujui",
+		Message:   fmt.Sprintf("%s %s telah disetujui", name, request.DocNum),
+		DocType:   request.DocType,
+		DocID:     request.DocID,
+		DocNum:    request.DocNum,
+	}
+
+	if request.Status == models.ApprovalStatusRejected {
+		event.Event = models.NotificationApprovalRejected
+		event.Title = name + " ditolak"
+		event.Message = fmt.Sprintf("%s %s ditolak oleh %s: %s", name, request.DocNum, request.UpdateBy, request.RejectReason)
+	}
+
+	return a.notificationService.Notify(event)
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/imagehash"
+	"github.com/Aguztinus/petty-cash-backend/pkg/slice"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// AttachmentService service layer
+type AttachmentService struct {
+	logger               lib.Logger
+	config               lib.Config
+	storage              lib.Storage
+	attachmentRepository repository.AttachmentRepository
+	userRepository       repository.UserRepository
+	bkkheaderRepository  repository.BKKHeaderRepository
+	invoiceRepository    repository.InvoiceHeaderRepository
+	kasbonRepository     repository.KasbonRepository
+	tarikdanaRepository  repository.TarikDanaRepository
+}
+
+// NewAttachmentService creates a new attachmentservice
+func NewAttachmentService(
+	logger lib.Logger,
+	config lib.Config,
+	storage lib.Storage,
+	attachmentRepository repository.AttachmentRepository,
+	userRepository repository.UserRepository,
+	bkkheaderRepository repository.BKKHeaderRepository,
+	invoiceRepository repository.InvoiceHeaderRepository,
+	kasbonRepository repository.KasbonRepository,
+	tarikdanaRepository repository.TarikDanaRepository,
+) AttachmentService {
+	return AttachmentService{
+		logger:               logger,
+		config:               config,
+		storage:              storage,
+		attachmentRepository: attachmentRepository,
+		userRepository:       userRepository,
+		bkkheaderRepository:  bkkheaderRepository,
+		invoiceRepository:    invoiceRepository,
+		kasbonRepository:     kasbonRepository,
+		tarikdanaRepository:  tarikdanaRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a AttachmentService) WithTrx(trxHandle *gorm.DB) AttachmentService {
+	a.attachmentRepository = a.attachmentRepository.WithTrx(trxHandle)
+	a.userRepository = a.userRepository.WithTrx(trxHandle)
+	a.bkkheaderRepository = a.bkkheaderRepository.WithTrx(trxHandle)
+	a.invoiceRepository = a.invoiceRepository.WithTrx(trxHandle)
+	a.kasbonRepository = a.kasbonRepository.WithTrx(trxHandle)
+	a.tarikdanaRepository = a.tarikdanaRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+// Query lists the attachments of a document
+func (a AttachmentService) Query(param *models.AttachmentQueryParam, userID string) (*models.AttachmentQueryResult, error) {
+	if err := a.authorize(param.DocType, param.DocID, userID); err != nil {
+		return nil, err
+	}
+
+	return a.attachmentRepository.Query(param)
+}
+
+// Create stores the file as an attachment of a document the user may access, see store
+func (a AttachmentService) Create(attachment *models.Attachment, data []byte, userID string) (*models.Attachment, error) {
+	if err := a.authorize(attachment.DocType, attachment.DocID, userID); err != nil {
+		return nil, err
+	}
+
+	return a.store(attachment, data)
+}
+
+// LegacyFiles returns the file names the documents kept before attachments were stored
+func (a AttachmentService) LegacyFiles() (models.AttachmentLegacyFiles, error) {
+	list := make(models.AttachmentLegacyFiles, 0)
+	for _, docType := range models.ApprovalDocTypes {
+		files, err := a.attachmentRepository.GetLegacyFiles(docType)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, files...)
+	}
+
+	return list, nil
+}
+
+// ImportLegacy stores the content of a legacy file as an attachment of its document and puts the
+// attachment id in place of the file name, name is the file name without the username
+func (a AttachmentService) ImportLegacy(file *models.AttachmentLegacyFile, name string, data []byte) (*models.Attachment, error) {
+	attachment := &models.Attachment{DocType: file.DocType, DocID: file.DocID, FileName: name}
+	attachment.CreatedBy = file.CreatedBy
+	attachment.UpdateBy = file.CreatedBy
+
+	attachment, err := a.store(attachment, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.attachmentRepository.UpdateLegacyFile(file, attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// store validates the size and the detected MIME type and stores the content under its sha256,
+// the same file attached twice to a document returns the first attachment
+func (a AttachmentService) store(attachment *models.Attachment, data []byte) (*models.Attachment, error) {
+	if len(data) == 0 {
+		return nil, errors.AttachmentEmpty
+	} else if int64(len(data)) > a.MaxSize() {
+		return nil, errors.AttachmentTooLarge
+	}
+
+	mimeType := http.DetectContentType(data)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	if !slice.ContainsString(a.config.Storage.AllowedTypes, mimeType) {
+		return nil, errors.AttachmentTypeNotAllowed
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if existing, err := a.attachmentRepository.GetByDocAndHash(attachment.DocType, attachment.DocID, hash); err == nil {
+		return existing, nil
+	} else if err != errors.DatabaseRecordNotFound {
+		return nil, err
+	}
+
+	attachment.ID = uuid.MustString()
+	attachment.Hash = hash
+	attachment.Key = hash[:2] + "/" + hash
+	attachment.FileName = filepath.Base(attachment.FileName)
+	attachment.MimeType = mimeType
+	attachment.Size = int64(len(data))
+	if strings.HasPrefix(mimeType, "image/") {
+		// an undecodable image is still stored, only its PHash stays empty
+		attachment.PHash, _ = imagehash.DifferenceOf(data)
+	}
+
+	if err := a.storage.Put(attachment.Key, data, mimeType); err != nil {
+		return nil, err
+	}
+
+	if err := a.attachmentRepository.Create(attachment); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// Open returns the attachment with its content, the caller closes the reader
+func (a AttachmentService) Open(id string, userID string) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := a.attachmentRepository.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = a.authorize(attachment.DocType, attachment.DocID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	content, err := a.storage.Get(attachment.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// Delete removes the attachment and returns it, its content is removed by RemoveContent once the
+// delete is committed
+func (a AttachmentService) Delete(id string, userID string) (*models.Attachment, error) {
+	attachment, err := a.attachmentRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.authorize(attachment.DocType, attachment.DocID, userID); err != nil {
+		return nil, err
+	}
+
+	if err = a.attachmentRepository.Delete(id); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// RemoveContent removes the stored content of a deleted attachment unless another attachment shares it
+func (a AttachmentService) RemoveContent(attachment *models.Attachment) error {
+	count, err := a.attachmentRepository.CountByHash(attachment.Hash)
+	if err != nil {
+		return err
+	} else if count > 0 {
+		return nil
+	}
+
+	return a.storage.Delete(attachment.Key)
+}
+
+// MaxSize is the largest attachment in bytes
+func (a AttachmentService) MaxSize() int64 {
+	return a.config.Storage.MaxSize << 20
+}
+
+// authorize allows the users of the company and branch that own the document, users without a
+// company or branch see every company or branch
+func (a AttachmentService) authorize(docType string, docID string, userID string) error {
+	companyID, branchID, err := a.owner(docType, docID)
+	if err != nil {
+		return err
+	}
+
+	user, err := a.userRepository.Get(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Username == a.config.SuperAdmin.Username {
+		return nil
+	} else if user.CompanyID != "" && user.CompanyID != companyID {
+		return errors.AttachmentAccessForbidden
+	} else if user.BranchID != "" && user.BranchID != branchID {
+		return errors.AttachmentAccessForbidden
+	}
+
+	return nil
+}
+
+func (a AttachmentService) owner(docType string, docID string) (companyID string, branchID string, err error) {
+	switch docType {
+	case models.ApprovalDocBKK:
+		doc, err := a.bkkheaderRepository.Get(docID)
+		if err != nil {
+			return "", "", err
+		}
+		return doc.CompanyID, doc.BranchID, nil
+	case models.ApprovalDocInvoice:
+		doc, err := a.invoiceRepository.Get(docID)
+		if err != nil {
+			return "", "", err
+		}
+		return doc.CompanyID, doc.BranchID, nil
+	case models.ApprovalDocKasbon:
+		doc, err := a.kasbonRepository.Get(docID)
+		if err != nil {
+			return "", "", err
+		}
+		return doc.CompanyID, doc.BranchID, nil
+	case models.ApprovalDocTarikDana:
+		doc, err := a.tarikdanaRepository.Get(docID)
+		if err != nil {
+			return "", "", err
+		}
+		return doc.CompanyID, doc.BranchID, nil
+	default:
+		return "", "", errors.AttachmentDocTypeInvalid
+	}
+}
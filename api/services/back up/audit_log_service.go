@@ -0,0 +1,41 @@
+package services
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+// AuditLogService service layer
+type AuditLogService struct {
+	logger             lib.Logger
+	auditlogRepository repository.AuditLogRepository
+}
+
+// NewAuditLogService creates a new auditlogservice
+func NewAuditLogService(
+	logger lib.Logger,
+	auditlogRepository repository.AuditLogRepository,
+) AuditLogService {
+	return AuditLogService{
+		logger:             logger,
+		auditlogRepository: auditlogRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a AuditLogService) WithTrx(trxHandle *gorm.DB) AuditLogService {
+	a.auditlogRepository = a.auditlogRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a AuditLogService) Query(param *models.AuditLogQueryParam) (*models.AuditLogQueryResult, error) {
+	return a.auditlogRepository.Query(param)
+}
+
+func (a AuditLogService) Get(id string) (*models.AuditLog, error) {
+	return a.auditlogRepository.Get(id)
+}
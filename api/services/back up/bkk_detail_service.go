@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 
 	"gorm.io/gorm"
@@ -18,6 +19,7 @@ type BKKDetailService struct {
 	logger              lib.Logger
 	casbinService       CasbinService
 	bkkdetailRepository repository.BKKDetailRepository
+	bkkheaderRepository repository.BKKHeaderRepository
 	redis               lib.Redis
 }
 
@@ -27,12 +29,14 @@ func NewBKKDetailService(
 	logger lib.Logger,
 	casbinService CasbinService,
 	bkkdetailRepository repository.BKKDetailRepository,
+	bkkheaderRepository repository.BKKHeaderRepository,
 
 ) BKKDetailService {
 	return BKKDetailService{
 		logger:              logger,
 		casbinService:       casbinService,
 		bkkdetailRepository: bkkdetailRepository,
+		bkkheaderRepository: bkkheaderRepository,
 		redis:               redis,
 	}
 }
@@ -44,6 +48,7 @@ func wrapperBKKDetailTemp(key string) string {
 // WithTrx delegates transaction to repository database
 func (a BKKDetailService) WithTrx(trxHandle *gorm.DB) BKKDetailService {
 	a.bkkdetailRepository = a.bkkdetailRepository.WithTrx(trxHandle)
+	a.bkkheaderRepository = a.bkkheaderRepository.WithTrx(trxHandle)
 
 	return a
 }
@@ -162,3 +167,92 @@ func (a BKKDetailService) AddTamp(bkkdetail *models.BKKDetail, username string)
 
 	return nil
 }
+
+// checkExpense validates the period of the expense analysis, month by default
+func (a BKKDetailService) checkExpense(param *models.ExpenseAnalysisParam) error {
+	if len(param.DateParams) != 2 {
+		return errors.BKKDetailAnalysisDateInvalid
+	} else if param.Period == "" {
+		param.Period = models.ExpensePeriodMonth
+	} else if param.Period != models.ExpensePeriodMonth && param.Period != models.ExpensePeriodYear {
+		return errors.BKKDetailAnalysisPeriodInvalid
+	}
+
+	return nil
+}
+
+// Analysis sums the BKK lines by period, account, cost centre and department. The periods are in
+// date order, the lines of a period by account, cost centre and department and the groups by amount
+func (a BKKDetailService) Analysis(param *models.ExpenseAnalysisParam) (*models.ExpenseAnalysisResult, error) {
+	if err := a.checkExpense(param); err != nil {
+		return nil, err
+	}
+
+	lines, err := a.bkkdetailRepository.SumExpenses(param)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ExpenseAnalysisResult{}
+	periods := make(map[string]*models.ExpenseAnalysisPeriod)
+	accounts := make(map[string]*models.ExpenseAnalysisGroup)
+	costcentres := make(map[string]*models.ExpenseAnalysisGroup)
+	departments := make(map[string]*models.ExpenseAnalysisGroup)
+
+	group := func(m map[string]*models.ExpenseAnalysisGroup, list *models.ExpenseAnalysisGroups, id string, code string, name string, line *models.ExpenseAnalysisLine) {
+		g, ok := m[id]
+		if !ok {
+			g = &models.ExpenseAnalysisGroup{ID: id, Code: code, Name: name}
+			m[id] = g
+			*list = append(*list, g)
+		}
+		g.Amount += line.Amount
+		g.Count += line.Count
+	}
+
+	for _, line := range lines {
+		p, ok := periods[line.Period]
+		if !ok {
+			p = &models.ExpenseAnalysisPeriod{Period: line.Period}
+			periods[line.Period] = p
+			result.Periods = append(result.Periods, p)
+		}
+
+		p.Lines = append(p.Lines, line)
+		p.Amount += line.Amount
+		p.Count += line.Count
+		group(accounts, &result.Accounts, line.AccountID, line.AccountNum, line.AccountName, line)
+		group(costcentres, &result.CostCentres, line.CCID, line.CCCode, line.CCName, line)
+		group(departments, &result.Departments, line.DeptID, line.DeptNum, line.DeptName, line)
+		result.Amount += line.Amount
+		result.Count += line.Count
+	}
+
+	for _, p := range result.Periods {
+		sort.SliceStable(p.Lines, func(i, j int) bool {
+			x, y := p.Lines[i], p.Lines[j]
+			if x.AccountNum != y.AccountNum {
+				return x.AccountNum < y.AccountNum
+			} else if x.CCCode != y.CCCode {
+				return x.CCCode < y.CCCode
+			}
+			return x.DeptNum < y.DeptNum
+		})
+	}
+
+	for _, list := range []models.ExpenseAnalysisGroups{result.Accounts, result.CostCentres, result.Departments} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Amount > list[j].Amount })
+	}
+
+	result.Period = param.Period
+	return result, nil
+}
+
+// Vouchers returns the BKK lines behind an amount of the analysis, the filters of param pick the cell
+func (a BKKDetailService) Vouchers(param *models.ExpenseAnalysisParam) (models.ExpenseVouchers, error) {
+	if err := a.checkExpense(param); err != nil {
+		return nil, err
+	}
+
+	return a.bkkdetailRepository.GetExpenses(param)
+}
@@ -1,14 +1,22 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
+	"strings"
+	"time"
+	"unicode"
 
 	"gorm.io/gorm"
 
 	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/constants"
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
 	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/imagehash"
 	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
 )
 
@@ -18,6 +26,9 @@ type BKKHeaderService struct {
 	casbinService          CasbinService
 	counterService         CounterService
 	saldoService           SaldoService
+	journalService         JournalService
+	approvalService        ApprovalService
+	webhookService         WebhookService
 	userRepository         repository.UserRepository
 	branchRepository       repository.BranchRepository
 	bkkheaderRepository    repository.BKKHeaderRepository
@@ -25,6 +36,8 @@ type BKKHeaderService struct {
 	counterRepository      repository.CounterRepository
 	saldoRepository        repository.SaldoRepository
 	saldohistoryRepository repository.SaldoHistoryRepository
+	attachmentRepository   repository.AttachmentRepository
+	bkkduplicateRepository repository.BKKDuplicateRepository
 }
 
 // NewBKKHeaderService creates a new bkkheaderservice
@@ -33,6 +46,9 @@ func NewBKKHeaderService(
 	casbinService CasbinService,
 	counterService CounterService,
 	saldoService SaldoService,
+	journalService JournalService,
+	approvalService ApprovalService,
+	webhookService WebhookService,
 	userRepository repository.UserRepository,
 	branchRepository repository.BranchRepository,
 	bkkheaderRepository repository.BKKHeaderRepository,
@@ -40,12 +56,17 @@ func NewBKKHeaderService(
 	counterRepository repository.CounterRepository,
 	saldoRepository repository.SaldoRepository,
 	saldohistoryRepository repository.SaldoHistoryRepository,
+	attachmentRepository repository.AttachmentRepository,
+	bkkduplicateRepository repository.BKKDuplicateRepository,
 ) BKKHeaderService {
 	return BKKHeaderService{
 		logger:                 logger,
 		casbinService:          casbinService,
 		counterService:         counterService,
 		saldoService:           saldoService,
+		journalService:         journalService,
+		approvalService:        approvalService,
+		webhookService:         webhookService,
 		userRepository:         userRepository,
 		branchRepository:       branchRepository,
 		bkkheaderRepository:    bkkheaderRepository,
@@ -53,16 +74,25 @@ func NewBKKHeaderService(
 		counterRepository:      counterRepository,
 		saldoRepository:        saldoRepository,
 		saldohistoryRepository: saldohistoryRepository,
+		attachmentRepository:   attachmentRepository,
+		bkkduplicateRepository: bkkduplicateRepository,
 	}
 }
 
 // WithTrx delegates transaction to repository database
 func (a BKKHeaderService) WithTrx(trxHandle *gorm.DB) BKKHeaderService {
 	a.bkkheaderRepository = a.bkkheaderRepository.WithTrx(trxHandle)
+	a.counterService = a.counterService.WithTrx(trxHandle)
 	a.bkkdetailRepository = a.bkkdetailRepository.WithTrx(trxHandle)
 	a.counterRepository = a.counterRepository.WithTrx(trxHandle)
 	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
 	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
+	a.saldoService = a.saldoService.WithTrx(trxHandle)
+	a.journalService = a.journalService.WithTrx(trxHandle)
+	a.approvalService = a.approvalService.WithTrx(trxHandle)
+	a.attachmentRepository = a.attachmentRepository.WithTrx(trxHandle)
+	a.bkkduplicateRepository = a.bkkduplicateRepository.WithTrx(trxHandle)
+	a.webhookService = a.webhookService.WithTrx(trxHandle)
 
 	return a
 }
@@ -86,6 +116,19 @@ func (a BKKHeaderService) Get(id string) (*models.BKKHeader, error) {
 	if err != nil {
 		return nil, err
 	}
+	if bkkheader.Approval, err = a.approvalService.Get(models.ApprovalDocBKK, id); err != nil {
+		return nil, err
+	}
+	duplicates, err := a.bkkduplicateRepository.Query(&models.BKKDuplicateQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		BKKHeaderID:     id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bkkheader.Duplicates = duplicates.List
+	bkkheader.State = bkkheader.GetState()
+	bkkheader.Actions = models.BKKStateMachine.Actions(bkkheader.State)
 	return bkkheader, nil
 }
 
@@ -102,17 +145,66 @@ func (a BKKHeaderService) Check(item *models.BKKHeader) error {
 }
 
 func (a BKKHeaderService) Create(bkkheader *models.BKKHeader) (id string, err error) {
-	cbn, err := a.branchRepository.Get(bkkheader.BranchID)
+	bkkNum, err := a.counterService.Next("BKK", bkkheader.CompanyID, bkkheader.BranchID, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if err = a.saldoService.CheckPeriodOpen(bkkheader.CompanyID, bkkheader.BranchID, postingDate(bkkheader.ReleaseDate)); err != nil {
+		return "", err
+	}
+
+	total, err := a.checkTotal(bkkheader)
 	if err != nil {
 		return "", err
 	}
-	key := "BKK" + cbn.Shorter
-	count, err := a.counterService.GetAndIncrement(key)
-	padleft := fmt.Sprintf("%04d", count)
-	bkkNum := key + padleft
+
+	bkkheader.ID = uuid.MustString()
+	bkkheader.Num = bkkNum
+	bkkheader.TotalAmount = total
+	// Paid, Invoice and Void are reached through the state machine only
+	if bkkheader.Status == models.BKKStatusPaid || bkkheader.Status == models.BKKStatusInvoice || bkkheader.Status == models.BKKStatusVoid {
+		bkkheader.Status = ""
+	}
+	bkkheader.VoidReason = ""
+	bkkheader.VoidBy = ""
+	if err = a.resolveFiles(bkkheader.BKKDetails); err != nil {
+		return "", err
+	}
+
+	if err = a.bkkheaderRepository.Create(bkkheader); err != nil {
+		return "", err
+	}
+
+	if err = a.detectDuplicates(bkkheader); err != nil {
+		return "", err
+	}
+
+	approval, err := a.approvalService.Submit(&models.ApprovalRequest{
+		CompanyID: bkkheader.CompanyID,
+		BranchID:  bkkheader.BranchID,
+		DocType:   models.ApprovalDocBKK,
+		DocID:     bkkheader.ID,
+		DocNum:    bkkheader.Num,
+		Amount:    bkkheader.TotalAmount,
+	}, bkkheader.CreatedBy)
 	if err != nil {
 		return "", err
+	} else if approval.Status == models.ApprovalStatusApproved {
+		if err = a.bkkheaderRepository.UpdateApprove([]string{bkkheader.ID}, models.BKKApproveApproved); err != nil {
+			return "", err
+		}
+	}
+
+	if err = a.webhookService.Publish(models.WebhookBKKCreated, bkkheader.CompanyID, bkkheader.ID, bkkheader); err != nil {
+		return "", err
 	}
+
+	return bkkheader.ID, nil
+}
+
+// checkTotal sums the lines of the BKK, the total may not exceed the LimitBKK nor the SaldoAkhir of
+// its branch
+func (a BKKHeaderService) checkTotal(bkkheader *models.BKKHeader) (int64, error) {
 	var total int64 = 0
 	for _, item := range bkkheader.BKKDetails {
 		total += item.LinesAmount
@@ -120,50 +212,108 @@ func (a BKKHeaderService) Create(bkkheader *models.BKKHeader) (id string, err er
 
 	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(bkkheader.CompanyID, bkkheader.BranchID)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
 	if total > saldo.LimitBKK {
 		errtotal := fmt.Errorf("not allowed more than limit: %d", saldo.LimitBKK)
-		return "", errtotal
+		return 0, errtotal
 	}
 
 	if total > saldo.SaldoAkhir {
 		errtotal := fmt.Errorf("not enough saldo: %d", saldo.SaldoAkhir)
-		return "", errtotal
+		return 0, errtotal
 	}
 
+	return total, nil
+}
+
+// CreateForKasbon stores the settlement BKK of a kasbon, its receipts were paid from the advance
+// so it is created as paid and without a saldo reservation, the kasbon settlement posts the difference
+func (a BKKHeaderService) CreateForKasbon(bkkheader *models.BKKHeader, kasbon *models.Kasbon) error {
+	bkkNum, err := a.counterService.Next("BKK", kasbon.CompanyID, kasbon.BranchID, time.Now())
+	if err != nil {
+		return err
+	}
+	if err = a.saldoService.CheckPeriodOpen(kasbon.CompanyID, kasbon.BranchID, time.Now()); err != nil {
+		return err
+	}
+
+	var total int64 = 0
+	for _, item := range bkkheader.BKKDetails {
+		total += item.LinesAmount
+	}
+
+	now := database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
 	bkkheader.ID = uuid.MustString()
 	bkkheader.Num = bkkNum
+	bkkheader.CompanyID = kasbon.CompanyID
+	bkkheader.BranchID = kasbon.BranchID
+	bkkheader.KasbonID = kasbon.ID
 	bkkheader.TotalAmount = total
+	bkkheader.Status = models.BKKStatusPaid
+	bkkheader.ReleaseDate = kasbon.ReleaseDate
+	bkkheader.PaidDate = now
+	if err = a.resolveFiles(bkkheader.BKKDetails); err != nil {
+		return err
+	}
 
 	if err = a.bkkheaderRepository.Create(bkkheader); err != nil {
-		return "", err
+		return err
 	}
 
-	saldoHisCreate := new(models.SaldoHistory)
-	saldoHisCreate.Desc = bkkNum
-	saldoHisCreate.CompanyID = bkkheader.CompanyID
-	saldoHisCreate.BranchID = bkkheader.BranchID
-	saldoHisCreate.OutAmount = total
-	saldoHisCreate.SaldoAkhir = 0
-	if err = a.saldohistoryRepository.Create(saldoHisCreate); err != nil {
-		return "", err
+	if err = a.detectDuplicates(bkkheader); err != nil {
+		return err
 	}
 
-	return bkkheader.ID, nil
+	// the kasbon went through its own approval
+	if err = a.bkkheaderRepository.UpdateApprove([]string{bkkheader.ID}, models.BKKApproveApproved); err != nil {
+		return err
+	}
+
+	if err = a.webhookService.Publish(models.WebhookBKKCreated, bkkheader.CompanyID, bkkheader.ID, bkkheader); err != nil {
+		return err
+	}
+
+	return a.webhookService.Publish(models.WebhookBKKPaid, bkkheader.CompanyID, bkkheader.ID, bkkheader)
 }
 
+// Update replaces the lines of a pending or rejected BKK, the total is checked as on Create and a
+// pending approval starts over when the total changed. The company, branch and kasbon are kept.
 func (a BKKHeaderService) Update(id string, bkkheader *models.BKKHeader) error {
-	oBKKHeader, err := a.Get(id)
+	oBKKHeader, err := a.bkkheaderRepository.GetForUpdate(id)
 	if err != nil {
 		return err
+	}
+
+	state := oBKKHeader.GetState()
+	if err = models.BKKStateMachine.Check(state, models.DocActionUpdate); err != nil {
+		return err
 	} else if bkkheader.Num != oBKKHeader.Num {
 		if err = a.Check(bkkheader); err != nil {
 			return err
 		}
 	}
 	bkkheader.ID = oBKKHeader.ID
+	bkkheader.CompanyID = oBKKHeader.CompanyID
+	bkkheader.BranchID = oBKKHeader.BranchID
+	bkkheader.KasbonID = oBKKHeader.KasbonID
+
+	if err = a.saldoService.CheckPeriodOpen(oBKKHeader.CompanyID, oBKKHeader.BranchID, postingDate(oBKKHeader.ReleaseDate)); err != nil {
+		return err
+	}
+
+	if err = a.saldoService.CheckPeriodOpen(bkkheader.CompanyID, bkkheader.BranchID, postingDate(bkkheader.ReleaseDate)); err != nil {
+		return err
+	}
+
+	if bkkheader.TotalAmount, err = a.checkTotal(bkkheader); err != nil {
+		return err
+	}
+
+	if err = a.resolveFiles(bkkheader.BKKDetails); err != nil {
+		return err
+	}
 
 	if err := a.bkkdetailRepository.DeleteByHeaderID(id); err != nil {
 		return err
@@ -173,13 +323,50 @@ func (a BKKHeaderService) Update(id string, bkkheader *models.BKKHeader) error {
 		return err
 	}
 
+	if err = a.detectDuplicates(bkkheader); err != nil {
+		return err
+	}
+
+	// a rejected BKK takes its new total through Resubmit
+	if bkkheader.TotalAmount == oBKKHeader.TotalAmount || state != models.BKKStatePending {
+		return nil
+	}
+
+	approval, err := a.approvalService.Resubmit(models.ApprovalDocBKK, id, bkkheader.TotalAmount,
+		&models.ApprovalDecision{Username: bkkheader.UpdateBy})
+	if err == errors.ApprovalRequestNotFound {
+		// created before approvals were kept
+		return nil
+	} else if err != nil {
+		return err
+	} else if approval.Status == models.ApprovalStatusApproved {
+		return a.UpdateApprove([]string{id}, models.BKKApproveApproved)
+	}
+
 	return nil
 }
 
-func (a BKKHeaderService) Delete(id string) error {
-	_, err := a.bkkheaderRepository.Get(id)
+// Delete removes a BKK that was never paid and cancels its approval
+func (a BKKHeaderService) Delete(id string, username string) error {
+	bkk, err := a.bkkheaderRepository.GetForUpdate(id)
 	if err != nil {
 		return err
+	} else if err = models.BKKStateMachine.Check(bkk.GetState(), models.DocActionDelete); err != nil {
+		return err
+	}
+
+	if bkk.GetState() != models.BKKStateCancelled {
+		if err = a.releaseReserved(bkk); err != nil {
+			return err
+		}
+	}
+
+	if err = a.bkkduplicateRepository.DeleteOpenByHeader(id); err != nil {
+		return err
+	}
+
+	if err = a.approvalService.Cancel(models.ApprovalDocBKK, id, &models.ApprovalDecision{Username: username}); err != nil {
+		return err
 	}
 
 	if err := a.bkkheaderRepository.Delete(id); err != nil {
@@ -189,46 +376,247 @@ func (a BKKHeaderService) Delete(id string) error {
 	return nil
 }
 
-func (a BKKHeaderService) UpdateStatus(id string, status string, statusReject int) error {
-	bkk, err := a.bkkheaderRepository.Get(id)
+// Void keeps the BKK and marks it voided with the reason, a paid BKK gets a reversing journal and
+// its amount back into Saldo on the void date
+func (a BKKHeaderService) Void(id string, param *models.VoidParam, username string) error {
+	if param.Reason == "" {
+		return errors.VoidReasonEmpty
+	}
+
+	bkk, err := a.bkkheaderRepository.GetForUpdate(id)
 	if err != nil {
 		return err
+	} else if bkk.KasbonID != "" {
+		return errors.BKKHeaderVoidKasbon
+	}
+
+	state := bkk.GetState()
+	if err = models.BKKStateMachine.Check(state, models.DocActionVoid); err != nil {
+		return err
 	}
 
-	if status == "Paid" && statusReject == 0 {
-		if _, err = a.saldoService.CreateNewSaldoOrUpdate(bkk.CompanyID, bkk.BranchID, bkk.TotalAmount, 0); err != nil {
+	if state == models.BKKStatePaid {
+		journal, err := a.journalService.GetBySource(models.JournalSourceBKK, bkk.ID)
+		if err == errors.DatabaseRecordNotFound {
+			// paid before journals were kept
+			journal, err = a.journalService.BuildFromBKK(bkk)
+		}
+		if err != nil {
+			return err
+		}
+
+		reversal := a.journalService.BuildReversal(journal, "Void Pengeluaran Kas: "+bkk.Num)
+		saldoNow, err := a.saldoService.Reverse(bkk.CompanyID, bkk.BranchID, bkk.TotalAmount, 0, reversal)
+		if err != nil {
 			return err
 		}
+
+		if err = a.reverseHistory(bkk, "Void "+bkk.Num, saldoNow); err != nil {
+			return err
+		}
+	} else if err = a.releaseReserved(bkk); err != nil {
+		return err
 	}
 
-	if err := a.bkkheaderRepository.UpdateStatus(id, status); err != nil {
+	// a voided BKK pays nothing twice
+	if err = a.bkkduplicateRepository.DeleteOpenByHeader(id); err != nil {
+		return err
+	}
+
+	bkk.Status = models.BKKStatusVoid
+	bkk.VoidDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	bkk.VoidReason = param.Reason
+	bkk.VoidBy = username
+	bkk.UpdateBy = username
+
+	return a.bkkheaderRepository.UpdateVoid(id, bkk)
+}
+
+// reverseHistory offsets the SaldoHistory written when the BKK was paid
+func (a BKKHeaderService) reverseHistory(bkk *models.BKKHeader, desc string, saldoNow int64) error {
+	saldoHisCreate := new(models.SaldoHistory)
+	saldoHisCreate.Desc = desc
+	saldoHisCreate.CompanyID = bkk.CompanyID
+	saldoHisCreate.BranchID = bkk.BranchID
+	saldoHisCreate.SaldoAwal = saldoNow - bkk.TotalAmount
+	saldoHisCreate.InAmount = bkk.TotalAmount
+	saldoHisCreate.SaldoAkhir = saldoNow
+
+	return a.saldohistoryRepository.Create(saldoHisCreate)
+}
+
+// releaseReserved drops the out row that BKKs created before the history moved to Pay still carry,
+// Saldo never moved for it
+func (a BKKHeaderService) releaseReserved(bkk *models.BKKHeader) error {
+	return a.saldohistoryRepository.DeleteReserved(bkk.CompanyID, bkk.BranchID, bkk.Num)
+}
+
+// Pay hands the cash out of an approved BKK and posts it to Saldo and SaldoHistory, the row lock and the
+// state machine make sure it is posted once
+func (a BKKHeaderService) Pay(id string, username string) error {
+	bkk, err := a.bkkheaderRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	}
+
+	if err = models.BKKStateMachine.Check(bkk.GetState(), models.DocActionPay); err != nil {
+		return err
+	}
+
+	journal, err := a.journalService.BuildFromBKK(bkk)
+	if err != nil {
+		return err
+	}
+
+	saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(bkk.CompanyID, bkk.BranchID, bkk.TotalAmount, 0, journal)
+	if err != nil {
+		return err
+	}
+
+	if err = a.releaseReserved(bkk); err != nil {
+		return err
+	}
+
+	saldoHisCreate := new(models.SaldoHistory)
+	saldoHisCreate.Desc = bkk.Num
+	saldoHisCreate.CompanyID = bkk.CompanyID
+	saldoHisCreate.BranchID = bkk.BranchID
+	saldoHisCreate.SaldoAwal = saldoNow + bkk.TotalAmount
+	saldoHisCreate.OutAmount = bkk.TotalAmount
+	saldoHisCreate.SaldoAkhir = saldoNow
+	if err = a.saldohistoryRepository.Create(saldoHisCreate); err != nil {
 		return err
 	}
 
+	bkk.Status = models.BKKStatusPaid
+	bkk.PaidDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	bkk.PaidBy = username
+	bkk.UpdateBy = username
+
+	if err = a.bkkheaderRepository.UpdatePaid(id, bkk); err != nil {
+		return err
+	}
+
+	return a.webhookService.Publish(models.WebhookBKKPaid, bkk.CompanyID, bkk.ID, bkk)
+}
+
+// Invoice claims a paid BKK on an invoice, listing it again on the same invoice changes nothing
+func (a BKKHeaderService) Invoice(id string, invoiceID string) error {
+	bkk, err := a.bkkheaderRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	}
+
+	state := bkk.GetState()
+	if state == models.BKKStateInvoiced && bkk.InvoiceID == invoiceID {
+		return nil
+	} else if err = models.BKKStateMachine.Check(state, models.DocActionInvoice); err != nil {
+		return err
+	}
+
+	bkk.Status = models.BKKStatusInvoice
+	bkk.InvoiceID = invoiceID
+
+	return a.bkkheaderRepository.UpdateInvoice(id, bkk)
+}
+
+// Uninvoice moves a BKK taken out of its invoice back to Paid, a BKK invoiced elsewhere meanwhile is left alone
+func (a BKKHeaderService) Uninvoice(id string, invoiceID string) error {
+	bkk, err := a.bkkheaderRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	} else if bkk.GetState() != models.BKKStateInvoiced || bkk.InvoiceID != invoiceID {
+		return nil
+	}
+
+	bkk.Status = models.BKKStatusPaid
+	bkk.InvoiceID = ""
+
+	return a.bkkheaderRepository.UpdateInvoice(id, bkk)
+}
+
+// Approve records the decision of the current approval step, status_approve becomes 1 after the last step.
+// BKKs created before the approval engine have no request and are approved directly.
+func (a BKKHeaderService) Approve(ids []string, decision *models.ApprovalDecision) error {
+	for _, id := range ids {
+		if err := a.check(id, models.DocActionApprove); err != nil {
+			return err
+		}
+
+		approval, err := a.approvalService.Approve(models.ApprovalDocBKK, id, decision)
+		if err == errors.ApprovalRequestNotFound {
+			approval = &models.ApprovalRequest{Status: models.ApprovalStatusApproved}
+		} else if err != nil {
+			return err
+		}
+
+		if approval.Status == models.ApprovalStatusApproved {
+			if err = a.UpdateApprove([]string{id}, models.BKKApproveApproved); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
+// Reject stops the approval, status_approve becomes 2
+func (a BKKHeaderService) Reject(ids []string, decision *models.ApprovalDecision) error {
+	for _, id := range ids {
+		if err := a.check(id, models.DocActionReject); err != nil {
+			return err
+		}
+
+		if _, err := a.approvalService.Reject(models.ApprovalDocBKK, id, decision); err != nil && err != errors.ApprovalRequestNotFound {
+			return err
+		}
+	}
+
+	return a.UpdateApprove(ids, models.BKKApproveRejected)
+}
+
+// Resubmit sends a rejected and corrected BKK through the approval again, status_approve goes back to 0.
+// BKKs rejected before the approval engine have no request and simply wait for approval again.
+func (a BKKHeaderService) Resubmit(id string, decision *models.ApprovalDecision) error {
+	bkk, err := a.bkkheaderRepository.Get(id)
+	if err != nil {
+		return err
+	} else if err = models.BKKStateMachine.Check(bkk.GetState(), models.DocActionResubmit); err != nil {
+		return err
+	}
+
+	status := models.BKKApprovePending
+	approval, err := a.approvalService.Resubmit(models.ApprovalDocBKK, id, bkk.TotalAmount, decision)
+	if err != nil && err != errors.ApprovalRequestNotFound {
+		return err
+	} else if err == nil && approval.Status == models.ApprovalStatusApproved {
+		status = models.BKKApproveApproved
+	}
+
+	return a.UpdateApprove([]string{id}, status)
+}
+
+func (a BKKHeaderService) check(id string, action string) error {
+	bkk, err := a.bkkheaderRepository.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return models.BKKStateMachine.Check(bkk.GetState(), action)
+}
+
 func (a BKKHeaderService) UpdateApprove(ids []string, status int) error {
-	reject := -1
-	if status == reject {
+	if status == models.BKKApproveCancelled {
 		for _, id := range ids {
 			bkk, err := a.bkkheaderRepository.Get(id)
 			if err != nil {
 				return err
+			} else if bkk.GetState() == models.BKKStateCancelled {
+				continue
 			}
 
-			qr, err := a.saldohistoryRepository.Query(&models.SaldoHistoryQueryParam{
-				Desc:      bkk.Num,
-				CompanyID: bkk.CompanyID,
-				BranchID:  bkk.BranchID,
-			})
-
-			if err != nil {
+			if err = a.releaseReserved(bkk); err != nil {
 				return err
-			} else if len(qr.List) > 0 {
-				if err := a.saldohistoryRepository.DeleteByDesc(bkk.Num); err != nil {
-					return err
-				}
 			}
 		}
 	}
@@ -239,3 +627,208 @@ func (a BKKHeaderService) UpdateApprove(ids []string, status int) error {
 
 	return nil
 }
+
+// QueryDuplicates is the review queue of the possible duplicate lines
+func (a BKKHeaderService) QueryDuplicates(param *models.BKKDuplicateQueryParam) (*models.BKKDuplicateQueryResult, error) {
+	return a.bkkduplicateRepository.Query(param)
+}
+
+// ReviewDuplicate confirms or dismisses a possible duplicate, a dismissed pair is not flagged again
+// for the same reason
+func (a BKKHeaderService) ReviewDuplicate(id string, param *models.BKKDuplicateReviewParam, username string) error {
+	if param.Status != models.BKKDuplicateConfirmed && param.Status != models.BKKDuplicateDismissed {
+		return errors.BKKDuplicateStatusInvalid
+	}
+
+	duplicate, err := a.bkkduplicateRepository.Get(id)
+	if err != nil {
+		return err
+	}
+
+	duplicate.Status = param.Status
+	duplicate.ReviewNote = param.Note
+	duplicate.ReviewedBy = username
+	duplicate.ReviewedDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	duplicate.UpdateBy = username
+
+	return a.bkkduplicateRepository.UpdateReview(id, duplicate)
+}
+
+// resolveFiles copies the hashes of the attachment in LinesFile to the lines, a file name stored
+// before attachments were kept has no hash
+func (a BKKHeaderService) resolveFiles(details models.BKKDetails) error {
+	for _, item := range details {
+		item.FileHash, item.PHash = "", ""
+		if item.LinesFile == "" {
+			continue
+		}
+
+		attachment, err := a.attachmentRepository.Get(item.LinesFile)
+		if err == errors.DatabaseRecordNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		item.FileHash = attachment.Hash
+		item.PHash = attachment.PHash
+	}
+
+	return nil
+}
+
+// detectDuplicates flags the lines of the BKK that match a line of another BKK of the company, the
+// open flags of an earlier check are replaced
+func (a BKKHeaderService) detectDuplicates(bkk *models.BKKHeader) error {
+	if err := a.bkkduplicateRepository.DeleteOpenByHeader(bkk.ID); err != nil {
+		return err
+	} else if len(bkk.BKKDetails) == 0 {
+		return nil
+	}
+
+	param := &models.BKKDuplicateCandidateParam{
+		CompanyID:   bkk.CompanyID,
+		BKKHeaderID: bkk.ID,
+		Distance:    duplicateImageDistance,
+		ImagesSince: database.Datetime(sql.NullTime{Time: time.Now().Add(-duplicateImageWindow), Valid: true}),
+	}
+	for _, item := range bkk.BKKDetails {
+		if item.FileHash != "" {
+			param.FileHashes = append(param.FileHashes, item.FileHash)
+		}
+		if item.PHash != "" {
+			param.PHashes = append(param.PHashes, item.PHash)
+		}
+		param.Amounts = append(param.Amounts, item.LinesAmount)
+	}
+
+	candidates, err := a.bkkdetailRepository.QueryCandidates(param)
+	if err != nil || len(candidates) == 0 {
+		return err
+	}
+
+	headerIDs := make([]string, 0, len(candidates))
+	for _, item := range candidates {
+		headerIDs = append(headerIDs, item.BKKHeaderID)
+	}
+	headers, err := a.bkkheaderRepository.Query(&models.BKKHeaderQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		IDs:             headerIDs,
+	})
+	if err != nil {
+		return err
+	}
+	headerMap := headers.List.ToMap()
+
+	username := bkk.UpdateBy
+	if username == "" {
+		username = bkk.CreatedBy
+	}
+
+	reviewed := make(map[string]bool)
+	duplicates := make(models.BKKDuplicates, 0)
+	for _, line := range bkk.BKKDetails {
+		for _, candidate := range candidates {
+			reason, distance := matchDuplicate(line, candidate)
+			header, ok := headerMap[candidate.BKKHeaderID]
+			if reason == "" || !ok {
+				continue
+			}
+
+			key := header.ID + "/" + reason
+			if _, ok := reviewed[key]; !ok {
+				if reviewed[key], err = a.bkkduplicateRepository.ExistsReviewed(bkk.ID, header.ID, reason); err != nil {
+					return err
+				}
+			}
+			if reviewed[key] {
+				continue
+			}
+
+			duplicates = append(duplicates, &models.BKKDuplicate{
+				ModelTrans:       database.ModelTrans{CreatedBy: username, UpdateBy: username},
+				ID:               uuid.MustString(),
+				CompanyID:        bkk.CompanyID,
+				BranchID:         bkk.BranchID,
+				BKKHeaderID:      bkk.ID,
+				BKKNum:           bkk.Num,
+				LineID:           line.RecordID,
+				LinesDesc:        line.LinesDesc,
+				LinesDate:        line.LinesDate,
+				LinesAmount:      line.LinesAmount,
+				MatchBKKHeaderID: header.ID,
+				MatchBKKNum:      header.Num,
+				MatchBranchID:    header.BranchID,
+				MatchLineID:      candidate.RecordID,
+				MatchLinesDesc:   candidate.LinesDesc,
+				Reason:           reason,
+				Distance:         distance,
+				Status:           models.BKKDuplicateOpen,
+			})
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	return a.bkkduplicateRepository.CreateBatch(duplicates)
+}
+
+// duplicateImageDistance is the largest number of differing bits between the image hashes of the
+// same receipt, e.g. scanned twice or resized. The images are only compared to the BKKs created in the
+// last duplicateImageWindow, the same file is found at any age by its content hash.
+const (
+	duplicateImageDistance = 6
+	duplicateImageWindow   = 365 * 24 * time.Hour
+)
+
+// matchDuplicate returns the strongest reason the two lines look like the same receipt, the
+// distance is the image hash distance
+func matchDuplicate(line *models.BKKDetail, other *models.BKKDetail) (string, int) {
+	if line.FileHash != "" && line.FileHash == other.FileHash {
+		return models.BKKDuplicateReasonFile, 0
+	}
+
+	if line.PHash != "" && other.PHash != "" {
+		if distance := imagehash.Distance(line.PHash, other.PHash); distance >= 0 && distance <= duplicateImageDistance {
+			return models.BKKDuplicateReasonImage, distance
+		}
+	}
+
+	if line.LinesAmount == other.LinesAmount && line.LinesDate.Valid && other.LinesDate.Valid &&
+		line.LinesDate.Time.Format(constants.DateFormat) == other.LinesDate.Time.Format(constants.DateFormat) &&
+		similarDesc(line.LinesDesc, other.LinesDesc) {
+		return models.BKKDuplicateReasonDetails, 0
+	}
+
+	return "", 0
+}
+
+// similarDesc compares the words of two descriptions, case and punctuation are ignored and 80% of
+// the words must be shared
+func similarDesc(a string, b string) bool {
+	split := func(s string) map[string]bool {
+		words := make(map[string]bool)
+		for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			words[word] = true
+		}
+		return words
+	}
+
+	wa, wb := split(a), split(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return false
+	}
+
+	shared := 0
+	for word := range wa {
+		if wb[word] {
+			shared++
+		}
+	}
+
+	return float64(shared)/float64(len(wa)+len(wb)-shared) >= 0.8
+}
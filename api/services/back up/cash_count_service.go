@@ -0,0 +1,264 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// CashCountService service layer
+type CashCountService struct {
+	logger                 lib.Logger
+	counterService         CounterService
+	saldoService           SaldoService
+	journalService         JournalService
+	cashcountRepository    repository.CashCountRepository
+	saldoRepository        repository.SaldoRepository
+	saldohistoryRepository repository.SaldoHistoryRepository
+}
+
+// NewCashCountService creates a new cashcountservice
+func NewCashCountService(
+	logger lib.Logger,
+	counterService CounterService,
+	saldoService SaldoService,
+	journalService JournalService,
+	cashcountRepository repository.CashCountRepository,
+	saldoRepository repository.SaldoRepository,
+	saldohistoryRepository repository.SaldoHistoryRepository,
+) CashCountService {
+	return CashCountService{
+		logger:                 logger,
+		counterService:         counterService,
+		saldoService:           saldoService,
+		journalService:         journalService,
+		cashcountRepository:    cashcountRepository,
+		saldoRepository:        saldoRepository,
+		saldohistoryRepository: saldohistoryRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a CashCountService) WithTrx(trxHandle *gorm.DB) CashCountService {
+	a.counterService = a.counterService.WithTrx(trxHandle)
+	a.saldoService = a.saldoService.WithTrx(trxHandle)
+	a.journalService = a.journalService.WithTrx(trxHandle)
+	a.cashcountRepository = a.cashcountRepository.WithTrx(trxHandle)
+	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
+	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a CashCountService) Query(param *models.CashCountQueryParam) (cashcountQR *models.CashCountQueryResult, err error) {
+	return a.cashcountRepository.Query(param)
+}
+
+func (a CashCountService) Get(id string) (*models.CashCount, error) {
+	cashcount, err := a.cashcountRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return cashcount, nil
+}
+
+// Count totals the denominations and takes the system balance from Saldo at count time
+func (a CashCountService) Count(cashcount *models.CashCount) error {
+	var total int64 = 0
+	for _, item := range cashcount.CashCountDetails {
+		if !validDenomination(item.Type, item.Denomination) {
+			return errors.CashCountInvalidDenomination
+		}
+
+		if item.Quantity < 0 {
+			return errors.CashCountInvalidQuantity
+		}
+
+		item.Amount = item.Denomination * item.Quantity
+		total += item.Amount
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(cashcount.CompanyID, cashcount.BranchID)
+	if err != nil {
+		return err
+	}
+
+	cashcount.SaldoSystem = saldo.SaldoAkhir
+	cashcount.TotalCount = total
+	cashcount.Variance = total - saldo.SaldoAkhir
+
+	return nil
+}
+
+func (a CashCountService) Create(cashcount *models.CashCount) (id string, err error) {
+	if err = a.Count(cashcount); err != nil {
+		return "", err
+	}
+
+	num, err := a.counterService.Next("CC", cashcount.CompanyID, cashcount.BranchID, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	cashcount.ID = uuid.MustString()
+	cashcount.Num = num
+	cashcount.Status = models.CashCountStatusDraft
+	if !cashcount.CountDate.Valid {
+		cashcount.CountDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	}
+
+	if err = a.cashcountRepository.Create(cashcount); err != nil {
+		return "", err
+	}
+
+	return cashcount.ID, nil
+}
+
+func (a CashCountService) Update(id string, cashcount *models.CashCount) error {
+	oCashCount, err := a.Get(id)
+	if err != nil {
+		return err
+	} else if oCashCount.Status != models.CashCountStatusDraft {
+		return errors.CashCountNotDraft
+	}
+
+	cashcount.ID = oCashCount.ID
+	cashcount.CompanyID = oCashCount.CompanyID
+	cashcount.BranchID = oCashCount.BranchID
+	if err = a.Count(cashcount); err != nil {
+		return err
+	}
+
+	if err = a.cashcountRepository.DeleteDetailsByHeaderID(id); err != nil {
+		return err
+	}
+
+	return a.cashcountRepository.Update(id, cashcount)
+}
+
+func (a CashCountService) Delete(id string) error {
+	cashcount, err := a.cashcountRepository.Get(id)
+	if err != nil {
+		return err
+	} else if cashcount.Status != models.CashCountStatusDraft {
+		return errors.CashCountNotDraft
+	}
+
+	if err = a.cashcountRepository.DeleteDetailsByHeaderID(id); err != nil {
+		return err
+	}
+
+	return a.cashcountRepository.Delete(id)
+}
+
+// Approve accepts the count, a variance is posted as an adjustment through SaldoService
+// with its journal and a SaldoHistory row carrying the reason code
+func (a CashCountService) Approve(id string, param *models.CashCountApproveParam, username string) error {
+	cashcount, err := a.cashcountRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	} else if cashcount.Status != models.CashCountStatusDraft {
+		return errors.CashCountNotDraft
+	}
+
+	if param.ReasonCode != "" {
+		cashcount.ReasonCode = param.ReasonCode
+	}
+
+	if param.Description != "" {
+		cashcount.Description = param.Description
+	}
+
+	if cashcount.Variance != 0 {
+		if !validReason(cashcount.ReasonCode) {
+			return errors.CashCountReasonRequired
+		}
+
+		if err = a.postVariance(cashcount); err != nil {
+			return err
+		}
+	}
+
+	cashcount.Status = models.CashCountStatusApproved
+	cashcount.ApprovedBy = username
+	cashcount.ApprovedDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	cashcount.UpdateBy = username
+
+	return a.cashcountRepository.UpdateApprove(id, cashcount)
+}
+
+func (a CashCountService) Reject(id string, param *models.CashCountApproveParam, username string) error {
+	cashcount, err := a.cashcountRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	} else if cashcount.Status != models.CashCountStatusDraft {
+		return errors.CashCountNotDraft
+	}
+
+	cashcount.Status = models.CashCountStatusRejected
+	cashcount.Description = param.Description
+	cashcount.ApprovedBy = username
+	cashcount.ApprovedDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	cashcount.UpdateBy = username
+
+	return a.cashcountRepository.UpdateApprove(id, cashcount)
+}
+
+func (a CashCountService) postVariance(cashcount *models.CashCount) error {
+	var totalOut, totalIn int64 = 0, 0
+	if cashcount.Variance < 0 {
+		totalOut = -cashcount.Variance
+	} else {
+		totalIn = cashcount.Variance
+	}
+
+	journal, err := a.journalService.BuildFromCashCount(cashcount)
+	if err != nil {
+		return err
+	}
+
+	saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(cashcount.CompanyID, cashcount.BranchID, totalOut, totalIn, journal)
+	if err != nil {
+		return err
+	}
+
+	saldoHisCreate := new(models.SaldoHistory)
+	saldoHisCreate.ID = uuid.MustString()
+	saldoHisCreate.Desc = "Selisih Kas " + cashcount.Num + " (" + cashcount.ReasonCode + ")"
+	saldoHisCreate.CompanyID = cashcount.CompanyID
+	saldoHisCreate.BranchID = cashcount.BranchID
+	saldoHisCreate.SaldoAwal = saldoNow + totalOut - totalIn
+	saldoHisCreate.InAmount = totalIn
+	saldoHisCreate.OutAmount = totalOut
+	saldoHisCreate.SaldoAkhir = saldoNow
+
+	return a.saldohistoryRepository.Create(saldoHisCreate)
+}
+
+func validDenomination(typ string, denomination int64) bool {
+	for _, item := range models.CashCountDenominations[typ] {
+		if item == denomination {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validReason(reason string) bool {
+	for _, item := range models.CashCountReasons {
+		if item == reason {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,11 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
 	"gorm.io/gorm"
 
 	"github.com/Aguztinus/petty-cash-backend/api/repository"
@@ -12,30 +17,39 @@ import (
 
 // CompanyService service layer
 type CompanyService struct {
-	logger               lib.Logger
-	casbinService        CasbinService
-	userRepository       repository.UserRepository
-	companyRepository    repository.CompanyRepository
-	menuRepository       repository.MenuRepository
-	menuActionRepository repository.MenuActionRepository
+	logger                     lib.Logger
+	config                     lib.Config
+	storage                    lib.Storage
+	casbinService              CasbinService
+	userRepository             repository.UserRepository
+	companyRepository          repository.CompanyRepository
+	menuRepository             repository.MenuRepository
+	menuActionRepository       repository.MenuActionRepository
+	companysignatureRepository repository.CompanySignatureRepository
 }
 
 // NewCompanyService creates a new companyservice
 func NewCompanyService(
 	logger lib.Logger,
+	config lib.Config,
+	storage lib.Storage,
 	casbinService CasbinService,
 	userRepository repository.UserRepository,
 	companyRepository repository.CompanyRepository,
 	menuRepository repository.MenuRepository,
 	menuActionRepository repository.MenuActionRepository,
+	companysignatureRepository repository.CompanySignatureRepository,
 ) CompanyService {
 	return CompanyService{
-		logger:               logger,
-		casbinService:        casbinService,
-		userRepository:       userRepository,
-		companyRepository:    companyRepository,
-		menuRepository:       menuRepository,
-		menuActionRepository: menuActionRepository,
+		logger:                     logger,
+		config:                     config,
+		storage:                    storage,
+		casbinService:              casbinService,
+		userRepository:             userRepository,
+		companyRepository:          companyRepository,
+		menuRepository:             menuRepository,
+		menuActionRepository:       menuActionRepository,
+		companysignatureRepository: companysignatureRepository,
 	}
 }
 
@@ -43,6 +57,7 @@ func NewCompanyService(
 func (a CompanyService) WithTrx(trxHandle *gorm.DB) CompanyService {
 	a.companyRepository = a.companyRepository.WithTrx(trxHandle)
 	a.userRepository = a.userRepository.WithTrx(trxHandle)
+	a.companysignatureRepository = a.companysignatureRepository.WithTrx(trxHandle)
 
 	return a
 }
@@ -56,6 +71,7 @@ func (a CompanyService) Get(id string) (*models.Company, error) {
 	if err != nil {
 		return nil, err
 	}
+	company.HasLogo = company.LogoKey != ""
 	return company, nil
 }
 
@@ -127,3 +143,145 @@ func (a CompanyService) UpdateStatus(id string, status int) error {
 
 	return nil
 }
+
+// UploadLogo stores the logo printed on the letterhead of the company reports
+func (a CompanyService) UploadLogo(id string, data []byte) error {
+	if _, err := a.companyRepository.Get(id); err != nil {
+		return err
+	}
+
+	key, err := a.storeImage(data)
+	if err != nil {
+		return err
+	}
+
+	return a.companyRepository.UpdateLogo(id, key)
+}
+
+// OpenLogo returns the logo of the company and its MIME type
+func (a CompanyService) OpenLogo(id string) ([]byte, string, error) {
+	company, err := a.companyRepository.Get(id)
+	if err != nil {
+		return nil, "", err
+	} else if company.LogoKey == "" {
+		return nil, "", errors.CompanyLogoNotFound
+	}
+
+	return a.openImage(company.LogoKey)
+}
+
+func (a CompanyService) QuerySignatures(param *models.CompanySignatureQueryParam) (*models.CompanySignatureQueryResult, error) {
+	return a.companysignatureRepository.Query(param)
+}
+
+// CreateSignature stores the signatory of a role, a role has one signature per company
+func (a CompanyService) CreateSignature(signature *models.CompanySignature, data []byte) (*models.CompanySignature, error) {
+	if _, err := a.companyRepository.Get(signature.CompanyID); err != nil {
+		return nil, err
+	}
+
+	if exists, err := a.companysignatureRepository.ExistsRole(signature.CompanyID, signature.RoleID, ""); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, errors.CompanySignatureAlreadyExists
+	}
+
+	key, err := a.storeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	signature.ID = uuid.MustString()
+	signature.ImageKey = key
+	if err = a.companysignatureRepository.Create(signature); err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// UpdateSignature changes the signatory of a role, the image is kept when data is empty
+func (a CompanyService) UpdateSignature(id string, signature *models.CompanySignature, data []byte) error {
+	oSignature, err := a.companysignatureRepository.Get(signature.CompanyID, id)
+	if err != nil {
+		return err
+	}
+
+	if exists, err := a.companysignatureRepository.ExistsRole(oSignature.CompanyID, signature.RoleID, id); err != nil {
+		return err
+	} else if exists {
+		return errors.CompanySignatureAlreadyExists
+	}
+
+	signature.ImageKey = ""
+	if len(data) > 0 {
+		if signature.ImageKey, err = a.storeImage(data); err != nil {
+			return err
+		}
+	}
+
+	return a.companysignatureRepository.Update(id, signature)
+}
+
+func (a CompanyService) DeleteSignature(companyID string, id string) error {
+	if _, err := a.companysignatureRepository.Get(companyID, id); err != nil {
+		return err
+	}
+
+	return a.companysignatureRepository.Delete(id)
+}
+
+// OpenSignature returns the signature image and its MIME type
+func (a CompanyService) OpenSignature(companyID string, id string) ([]byte, string, error) {
+	signature, err := a.companysignatureRepository.Get(companyID, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return a.openImage(signature.ImageKey)
+}
+
+// MaxImageSize is the largest logo or signature image in bytes
+func (a CompanyService) MaxImageSize() int64 {
+	return a.config.Storage.MaxSize << 20
+}
+
+// storeImage keeps a PNG or JPEG by its content, the same image uploaded twice is stored once and
+// is never removed as other companies or signatures may share it
+func (a CompanyService) storeImage(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", errors.CompanyImageEmpty
+	} else if int64(len(data)) > a.MaxImageSize() {
+		return "", errors.CompanyImageTooLarge
+	}
+
+	mimeType := http.DetectContentType(data)
+	if mimeType != "image/png" && mimeType != "image/jpeg" {
+		return "", errors.CompanyImageTypeNotAllowed
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := "branding/" + hash[:2] + "/" + hash
+	if err := a.storage.Put(key, data, mimeType); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// openImage reads an image, they are no larger than MaxImageSize
+func (a CompanyService) openImage(key string) ([]byte, string, error) {
+	content, err := a.storage.Get(key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer content.Close()
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, http.DetectContentType(data), nil
+}
@@ -1,30 +1,43 @@
 package services
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
 	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/docnum"
 	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
 )
 
 // CounterService service layer
 type CounterService struct {
 	logger            lib.Logger
+	config            lib.Config
 	casbinService     CasbinService
+	companyRepository repository.CompanyRepository
+	branchRepository  repository.BranchRepository
 	counterRepository repository.CounterRepository
 }
 
 // NewCounterService creates a new counterservice
 func NewCounterService(
 	logger lib.Logger,
+	config lib.Config,
 	casbinService CasbinService,
+	companyRepository repository.CompanyRepository,
+	branchRepository repository.BranchRepository,
 	counterRepository repository.CounterRepository,
 ) CounterService {
 	return CounterService{
 		logger:            logger,
+		config:            config,
 		casbinService:     casbinService,
+		companyRepository: companyRepository,
+		branchRepository:  branchRepository,
 		counterRepository: counterRepository,
 	}
 }
@@ -32,6 +45,8 @@ func NewCounterService(
 // WithTrx delegates transaction to repository database
 func (a CounterService) WithTrx(trxHandle *gorm.DB) CounterService {
 	a.counterRepository = a.counterRepository.WithTrx(trxHandle)
+	a.companyRepository = a.companyRepository.WithTrx(trxHandle)
+	a.branchRepository = a.branchRepository.WithTrx(trxHandle)
 
 	return a
 }
@@ -48,28 +63,42 @@ func (a CounterService) Get(id string) (*models.Counter, error) {
 	return counter, nil
 }
 
-func (a CounterService) GetAndIncrement(key string) (count int, err error) {
-	counterdb, err := a.counterRepository.GetbyKey(key)
-	if err != nil {
-		counter := new(models.Counter)
-		counter.ID = uuid.MustString()
-		counter.KeyCounter = key
-		counter.CounterMe = 2
-		if err = a.counterRepository.Create(counter); err != nil {
-			return 0, err
-		}
-		return 1, nil
+// Next issues the next number of a document from the format configured for its prefix, the sequence is
+// kept per rendered format and restarts every year or month when the format resets
+func (a CounterService) Next(prefix string, companyID string, branchID string, date time.Time) (string, error) {
+	format := a.config.Numbering.Of(prefix)
+	if err := docnum.Validate(format.Format, format.Reset); err != nil {
+		return "", errors.Wrap(errors.CounterFormatInvalid, err.Error())
 	}
 
+	company, err := a.companyRepository.Get(companyID)
+	if err != nil {
+		return "", err
+	}
+	branch, err := a.branchRepository.Get(branchID)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
 
-	counterdb.CounterMe = counterdb.CounterMe + 1
+	values := docnum.Values{Prefix: prefix, Company: company.Num, Branch: branch.Shorter, Date: date}
+	counter := &models.Counter{
+		ID:         uuid.MustString(),
+		KeyCounter: docnum.Key(format.Format, format.Reset, values),
+	}
 
-	if err = a.counterRepository.Update(counterdb.ID, counterdb); err != nil {
-		return 0, err
+	// the default format continues after the numbers issued by the old prefix+branch counter
+	if format.Format == docnum.DefaultFormat && format.Reset == docnum.ResetNone {
+		if legacy, err := a.counterRepository.GetbyKey(prefix + branch.Shorter); err == nil {
+			counter.CounterMe = legacy.CounterMe + 1
+		} else if err != errors.DatabaseRecordNotFound {
+			return "", err
+		}
+	}
+
+	seq, err := a.counterRepository.Increment(counter)
+	if err != nil {
+		return "", err
 	}
 
-	return counterdb.CounterMe + 1, nil
+	return docnum.Render(format.Format, values, seq), nil
 }
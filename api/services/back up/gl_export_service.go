@@ -0,0 +1,224 @@
+package services
+
+import (
+	"database/sql"
+	"io"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/pkg/glinterface"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// GLExportService turns the posted journals into Oracle GL_INTERFACE rows, a journal is exported once
+// until its export is reset
+type GLExportService struct {
+	logger             lib.Logger
+	config             lib.Config
+	journalRepository  repository.JournalRepository
+	glexportRepository repository.GLExportRepository
+}
+
+// NewGLExportService creates a new glexportservice
+func NewGLExportService(
+	logger lib.Logger,
+	config lib.Config,
+	journalRepository repository.JournalRepository,
+	glexportRepository repository.GLExportRepository,
+) GLExportService {
+	return GLExportService{
+		logger:             logger,
+		config:             config,
+		journalRepository:  journalRepository,
+		glexportRepository: glexportRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a GLExportService) WithTrx(trxHandle *gorm.DB) GLExportService {
+	a.journalRepository = a.journalRepository.WithTrx(trxHandle)
+	a.glexportRepository = a.glexportRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a GLExportService) Query(param *models.GLExportQueryParam) (*models.GLExportQueryResult, error) {
+	return a.glexportRepository.Query(param)
+}
+
+func (a GLExportService) Get(id string) (*models.GLExport, error) {
+	return a.glexportRepository.Get(id)
+}
+
+// Export marks the journals of the period that were never exported as a new export, nothing is
+// marked when a segmented value does not fit the GL segments
+func (a GLExportService) Export(param *models.GLExportParam, username string) (*models.GLExport, error) {
+	from, err := time.ParseInLocation("2006-01-02", param.DateFrom, time.Local)
+	if err != nil {
+		return nil, errors.GLExportDateInvalid
+	}
+	to, err := time.ParseInLocation("2006-01-02", param.DateTo, time.Local)
+	if err != nil || to.Before(from) {
+		return nil, errors.GLExportDateInvalid
+	}
+	to = to.AddDate(0, 0, 1).Add(-time.Second)
+
+	journals, err := a.journalRepository.GetUnexported(param.CompanyID, param.BranchID,
+		database.Datetime(sql.NullTime{Time: from, Valid: true}), database.Datetime(sql.NullTime{Time: to, Valid: true}))
+	if err != nil {
+		return nil, err
+	} else if len(journals) == 0 {
+		return nil, errors.GLExportEmpty
+	}
+
+	export := &models.GLExport{
+		ID:        uuid.MustString(),
+		CompanyID: param.CompanyID,
+		BranchID:  param.BranchID,
+		DateFrom:  database.Datetime(sql.NullTime{Time: from, Valid: true}),
+		DateTo:    database.Datetime(sql.NullTime{Time: to, Valid: true}),
+	}
+	export.CreatedBy = username
+	export.UpdateBy = username
+	export.BatchName = batchName(export, journals[0])
+
+	ids := make([]string, 0, len(journals))
+	for _, journal := range journals {
+		ids = append(ids, journal.ID)
+		export.JournalCount++
+		export.LineCount += len(journal.JournalLines)
+		export.TotalDebit += journal.TotalDebit
+		export.TotalCredit += journal.TotalCredit
+	}
+
+	if _, err = a.rows(export, journals); err != nil {
+		return nil, err
+	}
+
+	if err = a.glexportRepository.Create(export); err != nil {
+		return nil, err
+	}
+
+	if marked, err := a.journalRepository.MarkExported(ids, export.ID); err != nil {
+		return nil, err
+	} else if marked != int64(len(ids)) {
+		return nil, errors.GLExportConflict
+	}
+
+	return export, nil
+}
+
+// WriteCsv writes the GL_INTERFACE rows of the export, downloading it again gives the same rows
+func (a GLExportService) WriteCsv(w io.Writer, id string) error {
+	export, err := a.glexportRepository.Get(id)
+	if err != nil {
+		return err
+	} else if export.ResetAt.Valid {
+		return errors.GLExportAlreadyReset
+	}
+
+	journals, err := a.journalRepository.GetByExport(id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := a.rows(export, journals)
+	if err != nil {
+		return err
+	}
+
+	return rows.WriteCsv(w, a.config.GL.Segments)
+}
+
+// Reset releases the journals of the export so the next export of their period takes them again,
+// to be used when Oracle rejected or never loaded the file
+func (a GLExportService) Reset(id string, param *models.GLExportResetParam, username string) error {
+	export, err := a.glexportRepository.Get(id)
+	if err != nil {
+		return err
+	} else if export.ResetAt.Valid {
+		return errors.GLExportAlreadyReset
+	}
+
+	if err = a.journalRepository.ResetExported(id); err != nil {
+		return err
+	}
+
+	export.ResetAt = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	export.ResetBy = username
+	export.ResetReason = param.Reason
+	export.UpdateBy = username
+
+	return a.glexportRepository.UpdateReset(id, export)
+}
+
+// rows builds a row per journal line, the journal name is made unique in the batch as Journal Import
+// groups the lines of a journal by it
+func (a GLExportService) rows(export *models.GLExport, journals models.JournalHeaders) (glinterface.Rows, error) {
+	gl := a.config.GL
+	created := export.CreatedAt.Time
+	if !export.CreatedAt.Valid {
+		created = time.Now()
+	}
+
+	rows := make(glinterface.Rows, 0, export.LineCount)
+	names := make(map[string]int)
+	for _, journal := range journals {
+		name := journal.SourceType + " " + journal.SourceNum
+		if journal.ReversalOf != "" {
+			name += " Reversal"
+		}
+		if names[name]++; names[name] > 1 {
+			name += " #" + strconv.Itoa(names[name])
+		}
+
+		for _, line := range journal.JournalLines {
+			segments, err := glinterface.SplitSegments(line.SegmentedValue, gl.SegmentSeparator, gl.Segments)
+			if err != nil {
+				return nil, errors.Wrapf(errors.GLExportSegmentInvalid, "%s line %d: %v", journal.SourceNum, line.LineNum, err)
+			}
+
+			rows = append(rows, &glinterface.Row{
+				LedgerID:           gl.LedgerOf(journal.Company.Num),
+				AccountingDate:     journal.JournalDate.Time,
+				CurrencyCode:       gl.CurrencyCode,
+				DateCreated:        created,
+				CreatedBy:          gl.CreatedBy,
+				UserJeCategoryName: gl.UserJeCategoryName,
+				UserJeSourceName:   gl.UserJeSourceName,
+				Segments:           segments,
+				EnteredDr:          line.Debit,
+				EnteredCr:          line.Credit,
+				BatchName:          export.BatchName,
+				BatchDescription:   "Petty Cash " + journal.Company.Name,
+				JournalName:        name,
+				JournalDescription: journal.Description,
+				LineDescription:    line.Description,
+				VoucherNum:         journal.SourceNum,
+				SourceType:         journal.SourceType,
+				SourceID:           journal.SourceID,
+				GroupID:            export.GroupID,
+				Attribute1:         strconv.Itoa(journal.Branch.RegOrgID),
+			})
+		}
+	}
+
+	return rows, nil
+}
+
+// batchName is "PC <company> <branch> <from>-<to>", the branch is left out when the whole company is exported
+func batchName(export *models.GLExport, journal *models.JournalHeader) string {
+	name := "PC " + journal.Company.Num
+	if export.BranchID != "" {
+		name += " " + journal.Branch.Code
+	}
+
+	return name + " " + export.DateFrom.Time.Format("20060102") + "-" + export.DateTo.Time.Format("20060102")
+}
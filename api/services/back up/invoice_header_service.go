@@ -1,9 +1,7 @@
 package services
 
 import (
-	"fmt"
-	"strconv"
-	"strings"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -20,6 +18,10 @@ type InvoiceHeaderService struct {
 	casbinService           CasbinService
 	counterService          CounterService
 	saldoService            SaldoService
+	journalService          JournalService
+	approvalService         ApprovalService
+	replenishmentService    ReplenishmentService
+	webhookService          WebhookService
 	userService             UserService
 	bkkheaderService        BKKHeaderService
 	userRepository          repository.UserRepository
@@ -39,6 +41,10 @@ func NewInvoiceHeaderService(
 	casbinService CasbinService,
 	counterService CounterService,
 	saldoService SaldoService,
+	journalService JournalService,
+	approvalService ApprovalService,
+	replenishmentService ReplenishmentService,
+	webhookService WebhookService,
 	userService UserService,
 	bkkheaderService BKKHeaderService,
 	userRepository repository.UserRepository,
@@ -56,6 +62,10 @@ func NewInvoiceHeaderService(
 		casbinService:           casbinService,
 		counterService:          counterService,
 		saldoService:            saldoService,
+		journalService:          journalService,
+		approvalService:         approvalService,
+		replenishmentService:    replenishmentService,
+		webhookService:          webhookService,
 		userService:             userService,
 		bkkheaderService:        bkkheaderService,
 		userRepository:          userRepository,
@@ -73,11 +83,19 @@ func NewInvoiceHeaderService(
 // WithTrx delegates transaction to repository database
 func (a InvoiceHeaderService) WithTrx(trxHandle *gorm.DB) InvoiceHeaderService {
 	a.invoiceheaderRepository = a.invoiceheaderRepository.WithTrx(trxHandle)
+	a.invociedetailRepository = a.invociedetailRepository.WithTrx(trxHandle)
+	a.counterService = a.counterService.WithTrx(trxHandle)
 	a.bkkheaderRepository = a.bkkheaderRepository.WithTrx(trxHandle)
 	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
 	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
 	a.saldomonthRepository = a.saldomonthRepository.WithTrx(trxHandle)
 	a.counterRepository = a.counterRepository.WithTrx(trxHandle)
+	a.saldoService = a.saldoService.WithTrx(trxHandle)
+	a.journalService = a.journalService.WithTrx(trxHandle)
+	a.replenishmentService = a.replenishmentService.WithTrx(trxHandle)
+	a.bkkheaderService = a.bkkheaderService.WithTrx(trxHandle)
+	a.approvalService = a.approvalService.WithTrx(trxHandle)
+	a.webhookService = a.webhookService.WithTrx(trxHandle)
 
 	return a
 }
@@ -91,6 +109,11 @@ func (a InvoiceHeaderService) Get(id string) (*models.InvoiceHeader, error) {
 	if err != nil {
 		return nil, err
 	}
+	if invoiceheader.Approval, err = a.approvalService.Get(models.ApprovalDocInvoice, id); err != nil {
+		return nil, err
+	}
+	invoiceheader.State = invoiceheader.GetState()
+	invoiceheader.Actions = models.InvoiceStateMachine.Actions(invoiceheader.State)
 	return invoiceheader, nil
 }
 
@@ -107,27 +130,37 @@ func (a InvoiceHeaderService) Check(item *models.InvoiceHeader) error {
 }
 
 func (a InvoiceHeaderService) Create(invoiceheader *models.InvoiceHeader) (id string, err error) {
-	cbn, err := a.branchRepository.Get(invoiceheader.BranchID)
-	if err != nil {
-		return "", err
-	}
-
-	key := "INV" + cbn.Shorter
-	count, err := a.counterService.GetAndIncrement(key)
-	padleft := fmt.Sprintf("%04d", count)
+	num, err := a.counterService.Next("INV", invoiceheader.CompanyID, invoiceheader.BranchID, time.Now())
 	if err != nil {
 		return "", err
 	}
 
 	invoiceheader.ID = uuid.MustString()
-	invoiceheader.Num = key + padleft
+	invoiceheader.Num = num
+	invoiceheader.StatusApprove = models.InvoiceApprovePending
 
 	if err = a.invoiceheaderRepository.Create(invoiceheader); err != nil {
 		return "", err
 	}
 	// update bkk status to invoice
 	for _, item := range invoiceheader.InvoiceDetails {
-		if err = a.bkkheaderService.UpdateStatus(item.BKKHeaderID, "Invoice", 0); err != nil {
+		if err = a.bkkheaderService.Invoice(item.BKKHeaderID, invoiceheader.ID); err != nil {
+			return "", err
+		}
+	}
+
+	approval, err := a.approvalService.Submit(&models.ApprovalRequest{
+		CompanyID: invoiceheader.CompanyID,
+		BranchID:  invoiceheader.BranchID,
+		DocType:   models.ApprovalDocInvoice,
+		DocID:     invoiceheader.ID,
+		DocNum:    invoiceheader.Num,
+		Amount:    invoiceheader.Amount,
+	}, invoiceheader.CreatedBy)
+	if err != nil {
+		return "", err
+	} else if approval.Status == models.ApprovalStatusApproved {
+		if err = a.UpdateApprove([]string{invoiceheader.ID}, models.InvoiceApproveFinal); err != nil {
 			return "", err
 		}
 	}
@@ -139,6 +172,8 @@ func (a InvoiceHeaderService) Update(id string, invoiceheader *models.InvoiceHea
 	oInvoiceHeader, err := a.Get(id)
 	if err != nil {
 		return err
+	} else if err = models.InvoiceStateMachine.Check(oInvoiceHeader.State, models.DocActionUpdate); err != nil {
+		return err
 	} else if invoiceheader.Num != oInvoiceHeader.Num {
 		if err = a.Check(invoiceheader); err != nil {
 			return err
@@ -146,17 +181,18 @@ func (a InvoiceHeaderService) Update(id string, invoiceheader *models.InvoiceHea
 	}
 	invoiceheader.ID = oInvoiceHeader.ID
 
-	if err := a.invoiceheaderRepository.Update(id, invoiceheader); err != nil {
+	// the details are saved again with the invoice
+	if err = a.releaseBKKs(id, invoiceheader.InvoiceDetails); err != nil {
 		return err
 	}
 
-	if err := a.invoiceheaderRepository.UpdateApprove(id, 0); err != nil {
+	if err := a.invoiceheaderRepository.Update(id, invoiceheader); err != nil {
 		return err
 	}
 
 	// update bkk status to invoice
 	for _, item := range invoiceheader.InvoiceDetails {
-		if err = a.bkkheaderService.UpdateStatus(item.BKKHeaderID, "Invoice", 0); err != nil {
+		if err = a.bkkheaderService.Invoice(item.BKKHeaderID, id); err != nil {
 			return err
 		}
 	}
@@ -164,10 +200,20 @@ func (a InvoiceHeaderService) Update(id string, invoiceheader *models.InvoiceHea
 	return nil
 }
 
-func (a InvoiceHeaderService) Delete(id string) error {
-	_, err := a.invoiceheaderRepository.Get(id)
+func (a InvoiceHeaderService) Delete(id string, username string) error {
+	inv, err := a.invoiceheaderRepository.Get(id)
 	if err != nil {
 		return err
+	} else if err = models.InvoiceStateMachine.Check(inv.GetState(), models.DocActionDelete); err != nil {
+		return err
+	}
+
+	if err = a.releaseBKKs(id, nil); err != nil {
+		return err
+	}
+
+	if err = a.approvalService.Cancel(models.ApprovalDocInvoice, id, &models.ApprovalDecision{Username: username}); err != nil {
+		return err
 	}
 
 	if err := a.invoiceheaderRepository.Delete(id); err != nil {
@@ -177,6 +223,30 @@ func (a InvoiceHeaderService) Delete(id string) error {
 	return nil
 }
 
+// releaseBKKs removes the details of the invoice, the BKKs not kept go back to Paid
+func (a InvoiceHeaderService) releaseBKKs(id string, keep models.InvoiceDetails) error {
+	details, err := a.invociedetailRepository.GetByInvoiceID(id)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool)
+	for _, item := range keep {
+		kept[item.BKKHeaderID] = true
+	}
+
+	for _, detail := range details {
+		if kept[detail.BKKHeaderID] {
+			continue
+		}
+		if err = a.bkkheaderService.Uninvoice(detail.BKKHeaderID, id); err != nil {
+			return err
+		}
+	}
+
+	return a.invociedetailRepository.DeleteByInvoiceID(id)
+}
+
 func (a InvoiceHeaderService) UpdateStatus(id string, status int) error {
 	_, err := a.invoiceheaderRepository.Get(id)
 	if err != nil {
@@ -190,30 +260,106 @@ func (a InvoiceHeaderService) UpdateStatus(id string, status int) error {
 	return nil
 }
 
-func (a InvoiceHeaderService) UpdateApprove(ids []string, status int) error {
-	reject := "2,4"
-	approveFinal := 3
-	if strings.Contains(reject, strconv.Itoa(status)) {
-		for _, id := range ids {
-			_, err := a.invociedetailRepository.Query(&models.InvoiceDetailQueryParam{BKKHeaderID: id})
-			if err != nil {
-				return err
+// Approve records the decision of the current approval step, the invoice is final approved after the last step.
+// Invoices created before the approval engine keep the two levels: submitted to 1, then 1 to final.
+func (a InvoiceHeaderService) Approve(ids []string, decision *models.ApprovalDecision) error {
+	for _, id := range ids {
+		inv, err := a.invoiceheaderRepository.Get(id)
+		if err != nil {
+			return err
+		} else if err = models.InvoiceStateMachine.Check(inv.GetState(), models.DocActionApprove); err != nil {
+			return err
+		}
+
+		status := models.InvoiceApproveFinal
+		approval, err := a.approvalService.Approve(models.ApprovalDocInvoice, id, decision)
+		if err == errors.ApprovalRequestNotFound {
+			if inv.StatusApprove == models.InvoiceApprovePending {
+				status = models.InvoiceApproveReviewed
 			}
-			// for _, item := range dtl.List {
-			// 	if err := a.bkkheaderService.UpdateStatus(item.BKKHeaderID, "Paid", 1); err != nil {
-			// 		return err
-			// 	}
-			// }
-		}
-	} else if status == approveFinal {
-		hdrs, err := a.invoiceheaderRepository.Query(&models.InvoiceHeaderQueryParam{IDs: ids})
+		} else if err != nil {
+			return err
+		} else if approval.Status == models.ApprovalStatusPending {
+			status = models.InvoiceApproveReviewed
+		}
+
+		if err = a.UpdateApprove([]string{id}, status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reject stops the approval, status_approve becomes 2 at the first step and 4 after it
+func (a InvoiceHeaderService) Reject(ids []string, decision *models.ApprovalDecision) error {
+	for _, id := range ids {
+		inv, err := a.invoiceheaderRepository.Get(id)
 		if err != nil {
 			return err
+		} else if err = models.InvoiceStateMachine.Check(inv.GetState(), models.DocActionReject); err != nil {
+			return err
+		}
+
+		if _, err = a.approvalService.Reject(models.ApprovalDocInvoice, id, decision); err != nil && err != errors.ApprovalRequestNotFound {
+			return err
+		}
+
+		status := models.InvoiceRejectFinal
+		if inv.StatusApprove == models.InvoiceApprovePending {
+			status = models.InvoiceApproveRejected
+		}
+
+		if err = a.UpdateApprove([]string{id}, status); err != nil {
+			return err
 		}
-		for _, hdr := range hdrs.List {
+	}
+
+	return nil
+}
+
+// Resubmit sends a rejected and corrected invoice through the approval again, status_approve goes back to 0.
+// Invoices rejected before the approval engine have no request and go back to the two levels.
+func (a InvoiceHeaderService) Resubmit(id string, decision *models.ApprovalDecision) error {
+	inv, err := a.invoiceheaderRepository.Get(id)
+	if err != nil {
+		return err
+	} else if err = models.InvoiceStateMachine.Check(inv.GetState(), models.DocActionResubmit); err != nil {
+		return err
+	}
+
+	status := models.InvoiceApprovePending
+	approval, err := a.approvalService.Resubmit(models.ApprovalDocInvoice, id, inv.Amount, decision)
+	if err != nil && err != errors.ApprovalRequestNotFound {
+		return err
+	} else if err == nil && approval.Status == models.ApprovalStatusApproved {
+		status = models.InvoiceApproveFinal
+	}
+
+	return a.UpdateApprove([]string{id}, status)
+}
+
+func (a InvoiceHeaderService) UpdateApprove(ids []string, status int) error {
+	invs, err := a.invoiceheaderRepository.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	for _, inv := range invs {
+		if err := a.saldoService.CheckPeriodOpen(inv.CompanyID, inv.BranchID, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	switch status {
+	case models.InvoiceApproveFinal:
+		for _, hdr := range invs {
 			if hdr.SisaAmount > 0 {
-				saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(hdr.CompanyID, hdr.BranchID, 0, hdr.SisaAmount)
+				journal, err := a.journalService.BuildFromInvoice(hdr, hdr.SisaAmount)
+				if err != nil {
+					return err
+				}
 
+				saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(hdr.CompanyID, hdr.BranchID, 0, hdr.SisaAmount, journal)
 				if err != nil {
 					return err
 				}
@@ -228,12 +374,31 @@ func (a InvoiceHeaderService) UpdateApprove(ids []string, status int) error {
 					return err
 				}
 			}
+
+			if !hdr.IsReimbursement() {
+				continue
+			}
+
+			if err = a.replenishmentService.CreateFromInvoice(hdr); err != nil {
+				return err
+			}
 		}
+	case models.InvoiceApproveRejected, models.InvoiceRejectFinal:
+		// a rejected invoice keeps its BKKs until it is corrected and resubmitted, or deleted
 	}
 
 	if err := a.invoiceheaderRepository.UpdateApproves(ids, status); err != nil {
 		return err
 	}
 
+	if status == models.InvoiceApproveFinal {
+		for _, inv := range invs {
+			inv.StatusApprove = int8(status)
+			if err := a.webhookService.Publish(models.WebhookInvoiceFinalApproved, inv.CompanyID, inv.ID, inv); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
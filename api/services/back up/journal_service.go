@@ -0,0 +1,389 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// JournalService service layer
+type JournalService struct {
+	logger              lib.Logger
+	config              lib.Config
+	journalRepository   repository.JournalRepository
+	saldoRepository     repository.SaldoRepository
+	trxRepository       repository.TrxRepository
+	bkkdetailRepository repository.BKKDetailRepository
+}
+
+// NewJournalService creates a new journalservice
+func NewJournalService(
+	logger lib.Logger,
+	config lib.Config,
+	journalRepository repository.JournalRepository,
+	saldoRepository repository.SaldoRepository,
+	trxRepository repository.TrxRepository,
+	bkkdetailRepository repository.BKKDetailRepository,
+) JournalService {
+	return JournalService{
+		logger:              logger,
+		config:              config,
+		journalRepository:   journalRepository,
+		saldoRepository:     saldoRepository,
+		trxRepository:       trxRepository,
+		bkkdetailRepository: bkkdetailRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a JournalService) WithTrx(trxHandle *gorm.DB) JournalService {
+	a.journalRepository = a.journalRepository.WithTrx(trxHandle)
+	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
+	a.trxRepository = a.trxRepository.WithTrx(trxHandle)
+	a.bkkdetailRepository = a.bkkdetailRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a JournalService) Query(param *models.JournalHeaderQueryParam) (journalQR *models.JournalHeaderQueryResult, err error) {
+	return a.journalRepository.Query(param)
+}
+
+func (a JournalService) Get(id string) (*models.JournalHeader, error) {
+	journal, err := a.journalRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+// Check makes sure the journal is balanced and its cash lines match the saldo movement
+func (a JournalService) Check(journal *models.JournalHeader, totalOut int64, totalIn int64) error {
+	if journal == nil || len(journal.JournalLines) == 0 {
+		return errors.JournalRequired
+	}
+
+	var debit, credit int64 = 0, 0
+	for _, line := range journal.JournalLines {
+		debit += line.Debit
+		credit += line.Credit
+	}
+
+	if debit != credit {
+		return errors.JournalNotBalanced
+	}
+
+	in, out := journal.JournalLines.CashTotals()
+	if in != totalIn || out != totalOut {
+		return errors.JournalCashMismatch
+	}
+
+	journal.TotalDebit = debit
+	journal.TotalCredit = credit
+
+	return nil
+}
+
+// Post validates and stores the journal, called by SaldoService for every saldo posting
+func (a JournalService) Post(journal *models.JournalHeader, totalOut int64, totalIn int64) error {
+	if err := a.Check(journal, totalOut, totalIn); err != nil {
+		return err
+	}
+
+	journal.ID = uuid.MustString()
+	if !journal.JournalDate.Valid {
+		journal.JournalDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	}
+
+	for i, line := range journal.JournalLines {
+		line.JournalHeaderID = journal.ID
+		line.LineNum = i + 1
+	}
+
+	return a.journalRepository.Create(journal)
+}
+
+// GetBySource returns the journal of a posted document that can still be reversed
+func (a JournalService) GetBySource(sourceType string, sourceID string) (*models.JournalHeader, error) {
+	return a.journalRepository.GetBySource(sourceType, sourceID)
+}
+
+// BuildReversal swaps the debit and credit of every line, the reversing journal is dated when it is posted
+// and keeps the source of the original one
+func (a JournalService) BuildReversal(original *models.JournalHeader, desc string) *models.JournalHeader {
+	journal := newJournal(original.CompanyID, original.BranchID, original.SourceType, original.SourceID, original.SourceNum, desc)
+	journal.ReversalOf = original.ID
+
+	for _, line := range original.JournalLines {
+		journal.JournalLines = append(journal.JournalLines, &models.JournalLine{
+			TrxID:          line.TrxID,
+			AccID:          line.AccID,
+			CCID:           line.CCID,
+			DeptID:         line.DeptID,
+			SegmentedValue: line.SegmentedValue,
+			Description:    desc,
+			Debit:          line.Credit,
+			Credit:         line.Debit,
+			CashFlag:       line.CashFlag,
+		})
+	}
+
+	return journal
+}
+
+// BuildFromBKK debits the expense of every BKK line and credits petty cash for the total
+func (a JournalService) BuildFromBKK(bkk *models.BKKHeader) (*models.JournalHeader, error) {
+	cash, _, err := a.getCashAndFund(bkk.CompanyID, bkk.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := newJournal(bkk.CompanyID, bkk.BranchID, models.JournalSourceBKK, bkk.ID, bkk.Num, "Pengeluaran Kas: "+bkk.Num)
+
+	lines, total, err := a.expenseLines(bkk)
+	if err != nil {
+		return nil, err
+	}
+
+	journal.JournalLines = append(lines, newJournalLine(cash, journal.Description, 0, total, true))
+
+	return journal, nil
+}
+
+// BuildFromKasbonSettlement books the receipts of the settlement BKK against the employee advance,
+// only the difference between the receipts and the kasbon goes through petty cash
+func (a JournalService) BuildFromKasbonSettlement(kasbon *models.Kasbon, bkk *models.BKKHeader) (*models.JournalHeader, error) {
+	cash, _, err := a.getCashAndFund(kasbon.CompanyID, kasbon.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(kasbon.CompanyID, kasbon.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	advance, err := a.account(saldo, saldo.KasbonTrxID, a.config.Journal.KasbonAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := newJournal(kasbon.CompanyID, kasbon.BranchID, models.JournalSourceKasbon,
+		kasbon.ID, kasbon.Num, "Realisasi Kasbon: "+kasbon.Num+" / "+bkk.Num)
+
+	lines, total, err := a.expenseLines(bkk)
+	if err != nil {
+		return nil, err
+	}
+
+	journal.JournalLines = append(lines, newJournalLine(advance, journal.Description, 0, kasbon.Amount, false))
+	if diff := kasbon.Amount - total; diff > 0 {
+		journal.JournalLines = append(journal.JournalLines, newJournalLine(cash, journal.Description, diff, 0, true))
+	} else if diff < 0 {
+		journal.JournalLines = append(journal.JournalLines, newJournalLine(cash, journal.Description, 0, -diff, true))
+	}
+
+	return journal, nil
+}
+
+// expenseLines debits the Trx of every BKK detail
+func (a JournalService) expenseLines(bkk *models.BKKHeader) (lines models.JournalLines, total int64, err error) {
+	details := bkk.BKKDetails
+	if len(details) == 0 {
+		if details, err = a.bkkdetailRepository.GetByHeaderID(bkk.ID); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	for _, item := range details {
+		trx := &item.Trx
+		if trx.ID == "" {
+			if trx, err = a.trxRepository.Get(item.TrxID); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		lines = append(lines, newJournalLine(trx, item.LinesDesc, item.LinesAmount, 0, false))
+		total += item.LinesAmount
+	}
+
+	return lines, total, nil
+}
+
+// BuildFromTarikDana debits petty cash and credits the fund account for a top-up
+func (a JournalService) BuildFromTarikDana(tarikdana *models.TarikDana) (*models.JournalHeader, error) {
+	// TarikDanas from before they were numbered are referenced by their id
+	num := tarikdana.Num
+	if num == "" {
+		num = tarikdana.ID
+	}
+
+	journal := newJournal(tarikdana.CompanyID, tarikdana.BranchID, models.JournalSourceTarikDana,
+		tarikdana.ID, num, "Penerimaan Dana: "+tarikdana.Description)
+
+	return a.buildTopUp(journal, tarikdana.Amount)
+}
+
+// BuildFromInvoice debits petty cash and credits the fund account for the returned invoice remainder
+func (a JournalService) BuildFromInvoice(invoice *models.InvoiceHeader, amount int64) (*models.JournalHeader, error) {
+	journal := newJournal(invoice.CompanyID, invoice.BranchID, models.JournalSourceInvoice,
+		invoice.ID, invoice.Num, "Pengembalian Dana Invoice: "+invoice.Num)
+
+	return a.buildTopUp(journal, amount)
+}
+
+// BuildFromCashCount posts the count variance against the cash over/short account,
+// a shortage credits petty cash and an overage debits it
+func (a JournalService) BuildFromCashCount(cashcount *models.CashCount) (*models.JournalHeader, error) {
+	cash, _, err := a.getCashAndFund(cashcount.CompanyID, cashcount.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(cashcount.CompanyID, cashcount.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	variance, err := a.account(saldo, saldo.VarianceTrxID, a.config.Journal.VarianceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := newJournal(cashcount.CompanyID, cashcount.BranchID, models.JournalSourceCashCount,
+		cashcount.ID, cashcount.Num, "Selisih Kas: "+cashcount.Num+" ("+cashcount.ReasonCode+")")
+
+	if cashcount.Variance < 0 {
+		journal.JournalLines = models.JournalLines{
+			newJournalLine(variance, journal.Description, -cashcount.Variance, 0, false),
+			newJournalLine(cash, journal.Description, 0, -cashcount.Variance, true),
+		}
+	} else {
+		journal.JournalLines = models.JournalLines{
+			newJournalLine(cash, journal.Description, cashcount.Variance, 0, true),
+			newJournalLine(variance, journal.Description, 0, cashcount.Variance, false),
+		}
+	}
+
+	return journal, nil
+}
+
+// BuildFromKasbon moves a cash advance between petty cash and the employee advance account,
+// a release credits petty cash and a refund debits it
+func (a JournalService) BuildFromKasbon(kasbon *models.Kasbon, amount int64, refund bool) (*models.JournalHeader, error) {
+	cash, _, err := a.getCashAndFund(kasbon.CompanyID, kasbon.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(kasbon.CompanyID, kasbon.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	advance, err := a.account(saldo, saldo.KasbonTrxID, a.config.Journal.KasbonAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	if refund {
+		journal := newJournal(kasbon.CompanyID, kasbon.BranchID, models.JournalSourceKasbon,
+			kasbon.ID, kasbon.Num, "Pengembalian Kasbon: "+kasbon.Num)
+		journal.JournalLines = models.JournalLines{
+			newJournalLine(cash, journal.Description, amount, 0, true),
+			newJournalLine(advance, journal.Description, 0, amount, false),
+		}
+		return journal, nil
+	}
+
+	journal := newJournal(kasbon.CompanyID, kasbon.BranchID, models.JournalSourceKasbon,
+		kasbon.ID, kasbon.Num, "Kasbon: "+kasbon.Num)
+	journal.JournalLines = models.JournalLines{
+		newJournalLine(advance, journal.Description, amount, 0, false),
+		newJournalLine(cash, journal.Description, 0, amount, true),
+	}
+
+	return journal, nil
+}
+
+func (a JournalService) buildTopUp(journal *models.JournalHeader, amount int64) (*models.JournalHeader, error) {
+	cash, fund, err := a.getCashAndFund(journal.CompanyID, journal.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	journal.JournalLines = models.JournalLines{
+		newJournalLine(cash, journal.Description, amount, 0, true),
+		newJournalLine(fund, journal.Description, 0, amount, false),
+	}
+
+	return journal, nil
+}
+
+func (a JournalService) getCashAndFund(companyID string, branchID string) (cash *models.Trx, fund *models.Trx, err error) {
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(companyID, branchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cash, err = a.account(saldo, saldo.CashTrxID, a.config.Journal.CashAccount); err != nil {
+		return nil, nil, err
+	}
+
+	if fund, err = a.account(saldo, saldo.FundTrxID, a.config.Journal.FundAccount); err != nil {
+		return nil, nil, err
+	}
+
+	return cash, fund, nil
+}
+
+// account returns the Trx set on the saldo, a saldo without one falls back to the Trx booked on the
+// account number of config Journal, so existing branches keep posting until their Trx are set
+func (a JournalService) account(saldo *models.Saldo, trxID string, accountNum string) (*models.Trx, error) {
+	if trxID != "" {
+		return a.trxRepository.Get(trxID)
+	} else if accountNum == "" {
+		return nil, errors.JournalAccountNotSet
+	}
+
+	trx, err := a.trxRepository.GetByAccountNum(saldo.CompanyID, saldo.BranchID, accountNum)
+	if err == errors.DatabaseRecordNotFound {
+		return nil, errors.JournalAccountNotSet
+	}
+
+	return trx, err
+}
+
+func newJournal(companyID, branchID, sourceType, sourceID, sourceNum, desc string) *models.JournalHeader {
+	journal := new(models.JournalHeader)
+	journal.CompanyID = companyID
+	journal.BranchID = branchID
+	journal.SourceType = sourceType
+	journal.SourceID = sourceID
+	journal.SourceNum = sourceNum
+	journal.Description = desc
+
+	return journal
+}
+
+func newJournalLine(trx *models.Trx, desc string, debit int64, credit int64, cash bool) *models.JournalLine {
+	return &models.JournalLine{
+		TrxID:          trx.ID,
+		AccID:          trx.AccID,
+		CCID:           trx.CCID,
+		DeptID:         trx.DeptID,
+		SegmentedValue: trx.SegmentedValue,
+		Description:    desc,
+		Debit:          debit,
+		Credit:         credit,
+		CashFlag:       cash,
+	}
+}
@@ -1,7 +1,10 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -9,38 +12,66 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
 	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
 	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
 )
 
 // KasbonService service layer
 type KasbonService struct {
-	logger             lib.Logger
-	casbinService      CasbinService
-	counterService     CounterService
-	employeeRepository repository.EmployeeRepository
-	branchRepository   repository.BranchRepository
-	kasbonRepository   repository.KasbonRepository
-	counterRepository  repository.CounterRepository
+	logger                 lib.Logger
+	config                 lib.Config
+	casbinService          CasbinService
+	counterService         CounterService
+	saldoService           SaldoService
+	journalService         JournalService
+	approvalService        ApprovalService
+	bkkheaderService       BKKHeaderService
+	notificationService    NotificationService
+	employeeRepository     repository.EmployeeRepository
+	branchRepository       repository.BranchRepository
+	kasbonRepository       repository.KasbonRepository
+	counterRepository      repository.CounterRepository
+	saldoRepository        repository.SaldoRepository
+	saldohistoryRepository repository.SaldoHistoryRepository
+	bkkheaderRepository    repository.BKKHeaderRepository
 }
 
 // NewKasbonService creates a new kasbonservice
 func NewKasbonService(
 	logger lib.Logger,
+	config lib.Config,
 	casbinService CasbinService,
 	counterService CounterService,
+	saldoService SaldoService,
+	journalService JournalService,
+	approvalService ApprovalService,
+	bkkheaderService BKKHeaderService,
+	notificationService NotificationService,
 	employeeRepository repository.EmployeeRepository,
 	branchRepository repository.BranchRepository,
 	kasbonRepository repository.KasbonRepository,
 	counterRepository repository.CounterRepository,
+	saldoRepository repository.SaldoRepository,
+	saldohistoryRepository repository.SaldoHistoryRepository,
+	bkkheaderRepository repository.BKKHeaderRepository,
 ) KasbonService {
 	return KasbonService{
-		logger:             logger,
-		casbinService:      casbinService,
-		counterService:     counterService,
-		employeeRepository: employeeRepository,
-		branchRepository:   branchRepository,
-		kasbonRepository:   kasbonRepository,
-		counterRepository:  counterRepository,
+		logger:                 logger,
+		config:                 config,
+		casbinService:          casbinService,
+		counterService:         counterService,
+		saldoService:           saldoService,
+		journalService:         journalService,
+		approvalService:        approvalService,
+		bkkheaderService:       bkkheaderService,
+		notificationService:    notificationService,
+		employeeRepository:     employeeRepository,
+		branchRepository:       branchRepository,
+		kasbonRepository:       kasbonRepository,
+		counterRepository:      counterRepository,
+		saldoRepository:        saldoRepository,
+		saldohistoryRepository: saldohistoryRepository,
+		bkkheaderRepository:    bkkheaderRepository,
 	}
 }
 
@@ -50,6 +81,15 @@ func (a KasbonService) WithTrx(trxHandle *gorm.DB) KasbonService {
 	a.employeeRepository = a.employeeRepository.WithTrx(trxHandle)
 	a.branchRepository = a.branchRepository.WithTrx(trxHandle)
 	a.counterRepository = a.counterRepository.WithTrx(trxHandle)
+	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
+	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
+	a.bkkheaderRepository = a.bkkheaderRepository.WithTrx(trxHandle)
+	a.counterService = a.counterService.WithTrx(trxHandle)
+	a.saldoService = a.saldoService.WithTrx(trxHandle)
+	a.journalService = a.journalService.WithTrx(trxHandle)
+	a.bkkheaderService = a.bkkheaderService.WithTrx(trxHandle)
+	a.approvalService = a.approvalService.WithTrx(trxHandle)
+	a.notificationService = a.notificationService.WithTrx(trxHandle)
 
 	return a
 }
@@ -63,9 +103,40 @@ func (a KasbonService) Get(id string) (*models.Kasbon, error) {
 	if err != nil {
 		return nil, err
 	}
+	if kasbon.Approval, err = a.approvalService.Get(models.ApprovalDocKasbon, id); err != nil {
+		return nil, err
+	}
+	kasbon.Actions = a.actions(kasbon)
 	return kasbon, nil
 }
 
+// actions lists the state machine actions the approval of the kasbon allows: approve and reject while
+// it is pending, release once it is approved
+func (a KasbonService) actions(kasbon *models.Kasbon) []string {
+	pending := kasbon.Approval != nil && kasbon.Approval.Status == models.ApprovalStatusPending
+
+	actions := []string{}
+	for _, action := range models.KasbonStateMachine.Actions(kasbon.Status) {
+		switch action {
+		case models.DocActionApprove, models.DocActionReject:
+			if !pending {
+				continue
+			}
+		case models.DocActionRelease:
+			if pending {
+				continue
+			}
+		case models.DocActionUpdate:
+			if kasbon.IsApproved() {
+				continue
+			}
+		}
+		actions = append(actions, action)
+	}
+
+	return actions
+}
+
 func (a KasbonService) Check(item *models.Kasbon) error {
 	qr, err := a.kasbonRepository.Query(&models.KasbonQueryParam{Num: item.Num})
 
@@ -79,53 +150,90 @@ func (a KasbonService) Check(item *models.Kasbon) error {
 }
 
 func (a KasbonService) Create(kasbon *models.Kasbon) (id string, err error) {
-	usr, err := a.employeeRepository.Get(kasbon.EmployeeID)
-	if err != nil {
-		return "", err
+	if kasbon.Amount <= 0 {
+		return "", errors.KasbonAmountInvalid
 	}
-	cbn, err := a.branchRepository.Get(usr.BranchID)
+
+	usr, err := a.employeeRepository.Get(kasbon.EmployeeID)
 	if err != nil {
 		return "", err
 	}
-	key := "KBS" + cbn.Shorter
-	count, err := a.counterService.GetAndIncrement(key)
+	num, err := a.counterService.Next("KBS", usr.CompanyID, usr.BranchID, time.Now())
 	if err != nil {
 		return "", err
 	}
-	padleft := fmt.Sprintf("%04d", count)
 	kasbon.ID = uuid.MustString()
 	kasbon.CompanyID = usr.CompanyID
 	kasbon.BranchID = usr.BranchID
-	kasbon.Num = key + padleft
+	kasbon.Num = num
+	kasbon.Status = models.KasbonStatusOpen
 
 	if err = a.kasbonRepository.Create(kasbon); err != nil {
 		return
 	}
+
+	if _, err = a.approvalService.Submit(&models.ApprovalRequest{
+		CompanyID: kasbon.CompanyID,
+		BranchID:  kasbon.BranchID,
+		DocType:   models.ApprovalDocKasbon,
+		DocID:     kasbon.ID,
+		DocNum:    kasbon.Num,
+		Amount:    kasbon.Amount,
+	}, kasbon.CreatedBy); err != nil {
+		return
+	}
+
 	return kasbon.ID, nil
 }
 
+// Update changes a kasbon until its approval is decided, a pending approval starts over when the
+// amount changed
 func (a KasbonService) Update(id string, kasbon *models.Kasbon) error {
 	oKasbon, err := a.Get(id)
 	if err != nil {
 		return err
-	} else if kasbon.Description != oKasbon.Description {
-		if err = a.Check(kasbon); err != nil {
-			return err
-		}
+	} else if err = models.KasbonStateMachine.Check(oKasbon.Status, models.DocActionUpdate); err != nil {
+		return err
+	} else if oKasbon.IsApproved() {
+		return errors.KasbonApproved
+	} else if kasbon.Amount <= 0 {
+		return errors.KasbonAmountInvalid
+	}
+
+	amountChanged := kasbon.Amount != oKasbon.Amount
+	oKasbon.Type = kasbon.Type
+	oKasbon.Amount = kasbon.Amount
+	oKasbon.Description = kasbon.Description
+	oKasbon.Date = kasbon.Date
+	oKasbon.File = kasbon.File
+	oKasbon.DueDate = kasbon.DueDate
+	oKasbon.DeptID = kasbon.DeptID
+	oKasbon.UpdateBy = kasbon.UpdateBy
+
+	if err := a.kasbonRepository.Update(id, oKasbon); err != nil {
+		return err
 	}
-	kasbon.ID = oKasbon.ID
 
-	if err := a.kasbonRepository.Update(id, kasbon); err != nil {
+	// a rejected kasbon takes its new amount through Resubmit
+	if amountChanged && oKasbon.Approval != nil && oKasbon.Approval.Status == models.ApprovalStatusPending {
+		_, err = a.approvalService.Resubmit(models.ApprovalDocKasbon, id, oKasbon.Amount,
+			&models.ApprovalDecision{Username: kasbon.UpdateBy})
 		return err
 	}
 
 	return nil
 }
 
-func (a KasbonService) Delete(id string) error {
-	_, err := a.kasbonRepository.Get(id)
+func (a KasbonService) Delete(id string, username string) error {
+	kasbon, err := a.kasbonRepository.Get(id)
 	if err != nil {
 		return err
+	} else if err = models.KasbonStateMachine.Check(kasbon.Status, models.DocActionDelete); err != nil {
+		return err
+	}
+
+	if err = a.approvalService.Cancel(models.ApprovalDocKasbon, id, &models.ApprovalDecision{Username: username}); err != nil {
+		return err
 	}
 
 	if err := a.kasbonRepository.Delete(id); err != nil {
@@ -135,6 +243,389 @@ func (a KasbonService) Delete(id string) error {
 	return nil
 }
 
+// Approve records the decision of the current approval step, the kasbon can be released once every step approved
+func (a KasbonService) Approve(id string, decision *models.ApprovalDecision) error {
+	kasbon, err := a.kasbonRepository.Get(id)
+	if err != nil {
+		return err
+	} else if err = models.KasbonStateMachine.Check(kasbon.Status, models.DocActionApprove); err != nil {
+		return err
+	}
+
+	_, err = a.approvalService.Approve(models.ApprovalDocKasbon, id, decision)
+	return err
+}
+
+// Reject stops the approval and closes the kasbon
+func (a KasbonService) Reject(id string, decision *models.ApprovalDecision) error {
+	kasbon, err := a.kasbonRepository.Get(id)
+	if err != nil {
+		return err
+	} else if err = models.KasbonStateMachine.Check(kasbon.Status, models.DocActionReject); err != nil {
+		return err
+	}
+
+	if _, err = a.approvalService.Reject(models.ApprovalDocKasbon, id, decision); err != nil {
+		return err
+	}
+
+	kasbon.Status = models.KasbonStatusRejected
+	kasbon.UpdateBy = decision.Username
+
+	return a.kasbonRepository.UpdateRelease(id, kasbon)
+}
+
+// Resubmit reopens a rejected kasbon, corrected through Update, and sends it through the approval again
+func (a KasbonService) Resubmit(id string, decision *models.ApprovalDecision) error {
+	kasbon, err := a.kasbonRepository.Get(id)
+	if err != nil {
+		return err
+	} else if err = models.KasbonStateMachine.Check(kasbon.Status, models.DocActionResubmit); err != nil {
+		return err
+	}
+
+	if _, err = a.approvalService.Resubmit(models.ApprovalDocKasbon, id, kasbon.Amount, decision); err != nil {
+		return err
+	}
+
+	kasbon.Status = models.KasbonStatusOpen
+	kasbon.UpdateBy = decision.Username
+
+	return a.kasbonRepository.UpdateRelease(id, kasbon)
+}
+
+// Release hands the cash out, the advance must fit in Saldo.LimitKBS and the available balance,
+// it is posted as an outflow and counted in Saldo.UsedKBS until the kasbon is settled
+func (a KasbonService) Release(id string, username string) error {
+	kasbon, err := a.kasbonRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	} else if err = models.KasbonStateMachine.Check(kasbon.Status, models.DocActionRelease); err != nil {
+		return err
+	}
+
+	if approved, err := a.approvalService.IsApproved(models.ApprovalDocKasbon, id); err != nil {
+		return err
+	} else if !approved {
+		return errors.ApprovalNotApproved
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(kasbon.CompanyID, kasbon.BranchID)
+	if err != nil {
+		return err
+	} else if kasbon.Amount > saldo.SaldoAkhir {
+		return errors.KasbonSaldoNotEnough
+	}
+
+	if err = a.saldoService.UpdateUsedKBS(kasbon.CompanyID, kasbon.BranchID, kasbon.Amount); err != nil {
+		return err
+	}
+
+	if err = a.post(kasbon, kasbon.Amount, false); err != nil {
+		return err
+	}
+
+	kasbon.Status = models.KasbonStatusReleased
+	kasbon.ReleaseDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	kasbon.DueDate = database.Datetime(sql.NullTime{Time: a.dueDate(kasbon), Valid: true})
+	kasbon.UpdateBy = username
+
+	return a.kasbonRepository.UpdateRelease(id, kasbon)
+}
+
+// Settle closes a released kasbon whose cash is returned in full to the box
+func (a KasbonService) Settle(id string, username string) error {
+	kasbon, err := a.getReleased(id)
+	if err != nil {
+		return err
+	}
+
+	if err = a.post(kasbon, kasbon.Amount, true); err != nil {
+		return err
+	}
+
+	if err = a.saldoService.UpdateUsedKBS(kasbon.CompanyID, kasbon.BranchID, -kasbon.Amount); err != nil {
+		return err
+	}
+
+	now := database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	kasbon.Status = models.KasbonStatusSettled
+	kasbon.PaideDate = now
+	kasbon.LunasDate = now
+	kasbon.UpdateBy = username
+
+	return a.kasbonRepository.UpdateRelease(id, kasbon)
+}
+
+// SettleWithBKK settles a released kasbon against the BKK holding the real receipts, the receipts are
+// booked against the advance and only the difference moves the cash box: a refund when the receipts
+// are lower than the kasbon, an additional payout when they are higher
+func (a KasbonService) SettleWithBKK(id string, bkk *models.BKKHeader, username string) (*models.KasbonSettlement, error) {
+	if len(bkk.BKKDetails) == 0 {
+		return nil, errors.KasbonNoReceipts
+	}
+
+	kasbon, err := a.getReleased(id)
+	if err != nil {
+		return nil, err
+	}
+
+	bkk.CreatedBy = username
+	if err = a.bkkheaderService.CreateForKasbon(bkk, kasbon); err != nil {
+		return nil, err
+	}
+
+	settlement := &models.KasbonSettlement{
+		KasbonID:    kasbon.ID,
+		BKKHeaderID: bkk.ID,
+		BKKNum:      bkk.Num,
+		Amount:      kasbon.Amount,
+		Expense:     bkk.TotalAmount,
+	}
+	if diff := kasbon.Amount - bkk.TotalAmount; diff > 0 {
+		settlement.Refund = diff
+	} else {
+		settlement.Payout = -diff
+	}
+
+	journal, err := a.journalService.BuildFromKasbonSettlement(kasbon, bkk)
+	if err != nil {
+		return nil, err
+	}
+
+	saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(kasbon.CompanyID, kasbon.BranchID, settlement.Payout, settlement.Refund, journal)
+	if err != nil {
+		return nil, err
+	}
+
+	if settlement.Refund > 0 || settlement.Payout > 0 {
+		saldoHisCreate := new(models.SaldoHistory)
+		saldoHisCreate.ID = uuid.MustString()
+		saldoHisCreate.CompanyID = kasbon.CompanyID
+		saldoHisCreate.BranchID = kasbon.BranchID
+		saldoHisCreate.SaldoAkhir = saldoNow
+		if settlement.Refund > 0 {
+			saldoHisCreate.Desc = "Pengembalian Kasbon: " + kasbon.Num
+			saldoHisCreate.InAmount = settlement.Refund
+			saldoHisCreate.SaldoAwal = saldoNow - settlement.Refund
+		} else {
+			saldoHisCreate.Desc = bkk.Num
+			saldoHisCreate.OutAmount = settlement.Payout
+			saldoHisCreate.SaldoAwal = saldoNow + settlement.Payout
+		}
+		if err = a.saldohistoryRepository.Create(saldoHisCreate); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = a.saldoService.UpdateUsedKBS(kasbon.CompanyID, kasbon.BranchID, -kasbon.Amount); err != nil {
+		return nil, err
+	}
+
+	now := database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	kasbon.Status = models.KasbonStatusSettled
+	kasbon.PaideDate = now
+	kasbon.LunasDate = now
+	kasbon.BKKHeaderID = bkk.ID
+	kasbon.UpdateBy = username
+
+	if err = a.kasbonRepository.UpdateRelease(id, kasbon); err != nil {
+		return nil, err
+	}
+
+	return settlement, nil
+}
+
+// getReleased locks the kasbon for the settlement, a kasbon that already has a settlement BKK is refused
+func (a KasbonService) getReleased(id string) (*models.Kasbon, error) {
+	kasbon, err := a.kasbonRepository.GetForUpdate(id)
+	if err != nil {
+		return nil, err
+	} else if err = models.KasbonStateMachine.Check(kasbon.Status, models.DocActionSettle); err != nil {
+		return nil, err
+	}
+
+	qr, err := a.bkkheaderRepository.Query(&models.BKKHeaderQueryParam{KasbonID: kasbon.ID})
+	if err != nil {
+		return nil, err
+	} else if len(qr.List) > 0 {
+		return nil, errors.KasbonAlreadySettled
+	}
+
+	return kasbon, nil
+}
+
+// Aging buckets the outstanding kasbons by days since release, per employee, branch and company
+func (a KasbonService) Aging(param *models.KasbonAgingParam) (*models.KasbonAgingResult, error) {
+	list, err := a.kasbonRepository.GetOutstanding(param)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := param.AsOf()
+	result := &models.KasbonAgingResult{Date: asOf.Format("2006-01-02")}
+	employees := make(map[string]*models.KasbonAgingGroup)
+	branches := make(map[string]*models.KasbonAgingGroup)
+	companies := make(map[string]*models.KasbonAgingGroup)
+
+	group := func(m map[string]*models.KasbonAgingGroup, list *models.KasbonAgingGroups, id string, name string) *models.KasbonAgingGroup {
+		g, ok := m[id]
+		if !ok {
+			g = &models.KasbonAgingGroup{ID: id, Name: name}
+			m[id] = g
+			*list = append(*list, g)
+		}
+		return g
+	}
+
+	for _, kasbon := range list {
+		released := kasbon.ReleaseDate.Time
+		if !kasbon.ReleaseDate.Valid {
+			released = kasbon.Date.Time
+		}
+		dueDate := a.dueDate(kasbon)
+
+		line := &models.KasbonAgingLine{
+			KasbonID:     kasbon.ID,
+			Num:          kasbon.Num,
+			Type:         kasbon.Type,
+			Description:  kasbon.Description,
+			EmployeeID:   kasbon.EmployeeID,
+			EmployeeName: kasbon.Employee.Name,
+			CompanyID:    kasbon.CompanyID,
+			CompanyName:  kasbon.Company.Name,
+			BranchID:     kasbon.BranchID,
+			BranchName:   kasbon.Branch.Name,
+			Amount:       kasbon.Amount,
+			ReleaseDate:  database.Datetime(sql.NullTime{Time: released, Valid: true}),
+			DueDate:      database.Datetime(sql.NullTime{Time: dueDate, Valid: true}),
+			Age:          daysBetween(released, asOf),
+			OverdueDays:  daysBetween(dueDate, asOf),
+		}
+		if line.Age < 0 {
+			continue
+		}
+		if line.OverdueDays < 0 {
+			line.OverdueDays = 0
+		}
+		line.Bucket = models.KasbonAgingBucketOf(line.Age)
+
+		result.Lines = append(result.Lines, line)
+		group(employees, &result.Employees, line.EmployeeID, line.EmployeeName).Add(line)
+		group(branches, &result.Branches, line.BranchID, line.BranchName).Add(line)
+		group(companies, &result.Companies, line.CompanyID, line.CompanyName).Add(line)
+		result.Total.Add(line)
+	}
+
+	return result, nil
+}
+
+// NotifyOverdue tells the requester of every outstanding kasbon past its due date, once per kasbon
+func (a KasbonService) NotifyOverdue(now time.Time) error {
+	list, err := a.kasbonRepository.GetOutstanding(&models.KasbonAgingParam{})
+	if err != nil {
+		return err
+	}
+
+	for _, kasbon := range list {
+		dueDate := a.dueDate(kasbon)
+		if daysBetween(dueDate, now) <= 0 {
+			continue
+		}
+
+		err = a.notificationService.NotifyOnce(&models.NotificationEvent{
+			Event:     models.NotificationKasbonOverdue,
+			Usernames: []string{kasbon.CreatedBy},
+			Title:     "Kasbon jatuh tempo",
+			Message: fmt.Sprintf("Kasbon %s %s sebesar %d jatuh tempo %s dan belum diselesaikan",
+				kasbon.Num, kasbon.Employee.Name, kasbon.Amount, dueDate.In(time.Local).Format("02-01-2006")),
+			DocType: models.ApprovalDocKasbon,
+			DocID:   kasbon.ID,
+			DocNum:  kasbon.Num,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Start checks the overdue kasbons every hour until ctx is done
+func (a KasbonService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if err := a.NotifyOverdue(now); err != nil {
+					a.logger.Zap.Errorf("Error to notify overdue kasbons: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// dueDate is the stored due date, or the release date (kasbon date before release) plus the due days of its type
+func (a KasbonService) dueDate(kasbon *models.Kasbon) time.Time {
+	if kasbon.DueDate.Valid {
+		return kasbon.DueDate.Time
+	}
+
+	base := kasbon.ReleaseDate.Time
+	if !kasbon.ReleaseDate.Valid {
+		base = kasbon.Date.Time
+	}
+
+	return base.AddDate(0, 0, a.config.Kasbon.DueDaysOf(kasbon.Type))
+}
+
+// daysBetween counts calendar days from from to to
+func daysBetween(from time.Time, to time.Time) int {
+	y1, m1, d1 := from.In(time.Local).Date()
+	y2, m2, d2 := to.In(time.Local).Date()
+	start := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+
+	return int(end.Sub(start).Hours() / 24)
+}
+
+// post writes the saldo movement of a kasbon with its journal and SaldoHistory row
+func (a KasbonService) post(kasbon *models.Kasbon, amount int64, refund bool) error {
+	journal, err := a.journalService.BuildFromKasbon(kasbon, amount, refund)
+	if err != nil {
+		return err
+	}
+
+	saldoHisCreate := new(models.SaldoHistory)
+	saldoHisCreate.ID = uuid.MustString()
+	saldoHisCreate.CompanyID = kasbon.CompanyID
+	saldoHisCreate.BranchID = kasbon.BranchID
+
+	var saldoNow int64
+	if refund {
+		saldoNow, err = a.saldoService.CreateNewSaldoOrUpdate(kasbon.CompanyID, kasbon.BranchID, 0, amount, journal)
+		saldoHisCreate.Desc = "Pengembalian Kasbon: " + kasbon.Num
+		saldoHisCreate.InAmount = amount
+		saldoHisCreate.SaldoAwal = saldoNow - amount
+	} else {
+		saldoNow, err = a.saldoService.CreateNewSaldoOrUpdate(kasbon.CompanyID, kasbon.BranchID, amount, 0, journal)
+		saldoHisCreate.Desc = kasbon.Num
+		saldoHisCreate.OutAmount = amount
+		saldoHisCreate.SaldoAwal = saldoNow + amount
+	}
+	if err != nil {
+		return err
+	}
+	saldoHisCreate.SaldoAkhir = saldoNow
+
+	return a.saldohistoryRepository.Create(saldoHisCreate)
+}
+
 func (a KasbonService) UpdateStatus(id string, status int) error {
 	_, err := a.kasbonRepository.Get(id)
 	if err != nil {
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/pkg/mailer"
+	"github.com/Aguztinus/petty-cash-backend/pkg/slice"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// notificationDocNames are the names of the approval document types used in the messages
+var notificationDocNames = map[string]string{
+	models.ApprovalDocBKK:       "BKK",
+	models.ApprovalDocInvoice:   "Invoice",
+	models.ApprovalDocKasbon:    "Kasbon",
+	models.ApprovalDocTarikDana: "Tarik Dana",
+}
+
+// NotificationService stores the notifications of the workflow events with the document change,
+// the emails are sent by Start after the commit and the webhooks are queued to WebhookService
+type NotificationService struct {
+	logger                 lib.Logger
+	mailer                 lib.Mailer
+	userRepository         repository.UserRepository
+	notificationRepository repository.NotificationRepository
+	webhookService         WebhookService
+}
+
+// NewNotificationService creates a new notificationservice
+func NewNotificationService(
+	logger lib.Logger,
+	mailer lib.Mailer,
+	userRepository repository.UserRepository,
+	notificationRepository repository.NotificationRepository,
+	webhookService WebhookService,
+) NotificationService {
+	return NotificationService{
+		logger:                 logger,
+		mailer:                 mailer,
+		userRepository:         userRepository,
+		notificationRepository: notificationRepository,
+		webhookService:         webhookService,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a NotificationService) WithTrx(trxHandle *gorm.DB) NotificationService {
+	a.userRepository = a.userRepository.WithTrx(trxHandle)
+	a.notificationRepository = a.notificationRepository.WithTrx(trxHandle)
+	a.webhookService = a.webhookService.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a NotificationService) Query(param *models.NotificationQueryParam, userID string) (*models.NotificationQueryResult, error) {
+	param.UserID = userID
+	return a.notificationRepository.Query(param)
+}
+
+func (a NotificationService) Unread(userID string) (*models.NotificationUnread, error) {
+	count, err := a.notificationRepository.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationUnread{Count: count}, nil
+}
+
+// Read marks a notification of the user as read, reading it again keeps the first read time
+func (a NotificationService) Read(id string, userID string) error {
+	if _, err := a.notificationRepository.Get(id, userID); err != nil {
+		return err
+	}
+
+	_, err := a.notificationRepository.MarkRead(userID, id, database.Datetime(sql.NullTime{Time: time.Now(), Valid: true}))
+	return err
+}
+
+// ReadAll marks every notification of the user as read and returns how many were unread
+func (a NotificationService) ReadAll(userID string) (*models.NotificationUnread, error) {
+	count, err := a.notificationRepository.MarkRead(userID, "", database.Datetime(sql.NullTime{Time: time.Now(), Valid: true}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.NotificationUnread{Count: count}, nil
+}
+
+// Preferences returns the channels of every event, in app only for the events the user never set
+func (a NotificationService) Preferences(userID string) (models.NotificationPreferences, error) {
+	stored, err := a.notificationRepository.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := stored.ToMap()
+	preferences := make(models.NotificationPreferences, 0, len(models.NotificationEvents))
+	for _, event := range models.NotificationEvents {
+		if preference, ok := m[event]; ok {
+			preferences = append(preferences, preference)
+		} else {
+			preferences = append(preferences, &models.NotificationPreference{UserID: userID, Event: event, InApp: true})
+		}
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences replaces the channels of the events given, the other events keep theirs
+func (a NotificationService) UpdatePreferences(userID string, preferences models.NotificationPreferences) (models.NotificationPreferences, error) {
+	user, err := a.userRepository.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := a.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := current.ToMap()
+	secrets := make(map[string]string)
+	for _, preference := range preferences {
+		if !slice.ContainsString(models.NotificationEvents, preference.Event) {
+			return nil, errors.NotificationEventInvalid
+		}
+
+		if preference.Email && user.Email == "" {
+			return nil, errors.NotificationEmailAddressNotExists
+		}
+
+		preference.WebhookEndpointID = ""
+		if preference.Webhook {
+			if u, err := url.Parse(preference.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, errors.NotificationWebhookURLInvalid
+			}
+
+			endpoint, secret, err := a.webhookService.NotificationEndpoint(user, preference.WebhookURL)
+			if err != nil {
+				return nil, err
+			}
+
+			if secret != "" {
+				secrets[preference.WebhookURL] = secret
+			}
+			preference.WebhookEndpointID = endpoint.ID
+			preference.WebhookSecret = secrets[preference.WebhookURL]
+		}
+
+		preference.UserID = userID
+		m[preference.Event] = preference
+	}
+
+	list := make(models.NotificationPreferences, 0, len(models.NotificationEvents))
+	for _, event := range models.NotificationEvents {
+		preference := *m[event]
+		preference.Model = database.Model{}
+		list = append(list, &preference)
+	}
+
+	if err = a.notificationRepository.ReplacePreferences(userID, list); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Notify stores a notification for every user of the event through the channels of its preference,
+// unknown usernames such as "system" are skipped
+func (a NotificationService) Notify(event *models.NotificationEvent) error {
+	notified := make(map[string]bool)
+	for _, username := range event.Usernames {
+		if username == "" || notified[username] {
+			continue
+		}
+		notified[username] = true
+
+		user, err := a.userRepository.GetByUsername(username)
+		if err == errors.DatabaseRecordNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if err = a.notify(user, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotifyOnce is Notify for an event raised at most once per document, such as an overdue kasbon
+func (a NotificationService) NotifyOnce(event *models.NotificationEvent) error {
+	exists, err := a.notificationRepository.Exists(event.Event, event.DocType, event.DocID)
+	if err != nil || exists {
+		return err
+	}
+
+	return a.Notify(event)
+}
+
+func (a NotificationService) notify(user *models.User, event *models.NotificationEvent) error {
+	preferences, err := a.Preferences(user.ID)
+	if err != nil {
+		return err
+	}
+
+	preference := preferences.ToMap()[event.Event]
+	notification := &models.Notification{
+		ID:      uuid.MustString(),
+		UserID:  user.ID,
+		Event:   event.Event,
+		Title:   event.Title,
+		Message: event.Message,
+		DocType: event.DocType,
+		DocID:   event.DocID,
+		DocNum:  event.DocNum,
+		InApp:   preference.InApp,
+	}
+
+	if preference.Email && user.Email != "" {
+		notification.Email = user.Email
+		notification.EmailStatus = models.NotificationStatusPending
+	}
+
+	if preference.Webhook && preference.WebhookEndpointID != "" {
+		delivery, err := a.webhookService.Notify(preference.WebhookEndpointID, user.CompanyID, event.Event, event.DocID, map[string]interface{}{
+			"id":       notification.ID,
+			"event":    notification.Event,
+			"title":    notification.Title,
+			"message":  notification.Message,
+			"doc_type": notification.DocType,
+			"doc_id":   notification.DocID,
+			"doc_num":  notification.DocNum,
+		})
+		if err != nil {
+			return err
+		}
+		notification.WebhookDeliveryID = delivery.ID
+	}
+
+	if !notification.InApp && notification.EmailStatus == "" && notification.WebhookDeliveryID == "" {
+		return nil
+	}
+
+	return a.notificationRepository.Create(notification)
+}
+
+// Start sends the pending emails every minute until ctx is done
+func (a NotificationService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.dispatch()
+			}
+		}
+	}()
+}
+
+// dispatch sends each pending email once, a failed email is not retried
+func (a NotificationService) dispatch() {
+	list, err := a.notificationRepository.GetPending(100)
+	if err != nil {
+		a.logger.Zap.Errorf("Error to list pending notifications: %v", err)
+		return
+	}
+
+	for _, notification := range list {
+		a.send(notification, "email_status", a.sendEmail)
+	}
+}
+
+func (a NotificationService) send(notification *models.Notification, column string, fn func(*models.Notification) error) {
+	claimed, err := a.notificationRepository.Claim(notification.ID, column)
+	if err != nil {
+		a.logger.Zap.Errorf("Error to claim notification %s: %v", notification.ID, err)
+		return
+	} else if !claimed {
+		return
+	}
+
+	status, lastError := models.NotificationStatusSent, ""
+	if err = fn(notification); err != nil {
+		a.logger.Zap.Errorf("Error to send notification %s: %v", notification.ID, err)
+		status, lastError = models.NotificationStatusFailed, err.Error()
+		if len(lastError) > 500 {
+			lastError = lastError[:500]
+		}
+	}
+
+	if err = a.notificationRepository.UpdateStatus(notification.ID, column, status, lastError); err != nil {
+		a.logger.Zap.Errorf("Error to save notification %s status: %v", notification.ID, err)
+	}
+}
+
+func (a NotificationService) sendEmail(notification *models.Notification) error {
+	return a.mailer.Send(&mailer.Message{
+		To:      []string{notification.Email},
+		Subject: notification.Title,
+		Body:    notification.Message + "\r\n",
+	})
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// ReplenishmentService service layer, approval is done by TarikDanaService.Replenish
+// because it posts the TarikDana
+type ReplenishmentService struct {
+	logger                  lib.Logger
+	counterService          CounterService
+	saldoRepository         repository.SaldoRepository
+	invoiceheaderRepository repository.InvoiceHeaderRepository
+	replenishmentRepository repository.ReplenishmentRepository
+}
+
+// NewReplenishmentService creates a new replenishmentservice
+func NewReplenishmentService(
+	logger lib.Logger,
+	counterService CounterService,
+	saldoRepository repository.SaldoRepository,
+	invoiceheaderRepository repository.InvoiceHeaderRepository,
+	replenishmentRepository repository.ReplenishmentRepository,
+) ReplenishmentService {
+	return ReplenishmentService{
+		logger:                  logger,
+		counterService:          counterService,
+		saldoRepository:         saldoRepository,
+		invoiceheaderRepository: invoiceheaderRepository,
+		replenishmentRepository: replenishmentRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a ReplenishmentService) WithTrx(trxHandle *gorm.DB) ReplenishmentService {
+	a.counterService = a.counterService.WithTrx(trxHandle)
+	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
+	a.invoiceheaderRepository = a.invoiceheaderRepository.WithTrx(trxHandle)
+	a.replenishmentRepository = a.replenishmentRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a ReplenishmentService) Query(param *models.ReplenishmentQueryParam) (*models.ReplenishmentQueryResult, error) {
+	return a.replenishmentRepository.Query(param)
+}
+
+func (a ReplenishmentService) Get(id string) (*models.Replenishment, error) {
+	replenishment, err := a.replenishmentRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return replenishment, nil
+}
+
+// Create raises a manual request, only one can be pending per branch and an invoice is only
+// reimbursed by one request
+func (a ReplenishmentService) Create(param *models.ReplenishmentParam, username string) (id string, err error) {
+	if _, err = a.replenishmentRepository.GetPending(param.CompanyID, param.BranchID); err == nil {
+		return "", errors.ReplenishmentAlreadyPending
+	} else if err != errors.DatabaseRecordNotFound {
+		return "", err
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(param.CompanyID, param.BranchID)
+	if err != nil {
+		return "", err
+	}
+
+	replenishment := newReplenishment(saldo, models.ReplenishmentSourceManual, param.Amount, param.Description, username)
+	if replenishment.Amount == 0 {
+		replenishment.Amount = saldo.ImprestAmount - saldo.SaldoAkhir
+	}
+
+	if len(param.InvoiceHeaderIDs) > 0 {
+		invoices, err := a.invoiceheaderRepository.GetByIDs(param.InvoiceHeaderIDs)
+		if err != nil {
+			return "", err
+		} else if len(invoices) != len(param.InvoiceHeaderIDs) {
+			return "", errors.ReplenishmentInvoiceInvalid
+		}
+
+		if linked, err := a.replenishmentRepository.CountLinkedInvoices(param.InvoiceHeaderIDs); err != nil {
+			return "", err
+		} else if linked > 0 {
+			return "", errors.ReplenishmentInvoiceLinked
+		}
+
+		for _, inv := range invoices {
+			if inv.CompanyID != param.CompanyID || inv.BranchID != param.BranchID {
+				return "", errors.ReplenishmentInvoiceInvalid
+			}
+
+			replenishment.ReplenishmentInvoices = append(replenishment.ReplenishmentInvoices,
+				&models.ReplenishmentInvoice{InvoiceHeaderID: inv.ID, Amount: inv.Amount})
+		}
+	}
+
+	if err = a.create(replenishment); err != nil {
+		return "", err
+	}
+
+	return replenishment.ID, nil
+}
+
+// CheckThreshold raises a request when the balance falls below Saldo.MinSaldo,
+// nothing is raised while another request of the branch is still pending
+func (a ReplenishmentService) CheckThreshold(saldo *models.Saldo) error {
+	if saldo.MinSaldo <= 0 || saldo.ImprestAmount <= 0 || saldo.SaldoAkhir >= saldo.MinSaldo {
+		return nil
+	}
+
+	if _, err := a.replenishmentRepository.GetPending(saldo.CompanyID, saldo.BranchID); err == nil {
+		return nil
+	} else if err != errors.DatabaseRecordNotFound {
+		return err
+	}
+
+	desc := fmt.Sprintf("Saldo %d di bawah batas minimum %d", saldo.SaldoAkhir, saldo.MinSaldo)
+	replenishment := newReplenishment(saldo, models.ReplenishmentSourceThreshold,
+		saldo.ImprestAmount-saldo.SaldoAkhir, desc, "system")
+
+	return a.create(replenishment)
+}
+
+// CreateFromInvoice raises a request covering the reimbursement of a final approved invoice, nothing
+// is raised while another request of the branch is still pending as it tops the float up already
+func (a ReplenishmentService) CreateFromInvoice(invoice *models.InvoiceHeader) error {
+	if _, err := a.replenishmentRepository.GetPending(invoice.CompanyID, invoice.BranchID); err == nil {
+		return nil
+	} else if err != errors.DatabaseRecordNotFound {
+		return err
+	}
+
+	if linked, err := a.replenishmentRepository.CountLinkedInvoices([]string{invoice.ID}); err != nil {
+		return err
+	} else if linked > 0 {
+		return nil
+	}
+
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(invoice.CompanyID, invoice.BranchID)
+	if err != nil {
+		return err
+	}
+
+	replenishment := newReplenishment(saldo, models.ReplenishmentSourceInvoice, invoice.Amount,
+		"Reimbursement Invoice: "+invoice.Num, "system")
+	replenishment.ReplenishmentInvoices = models.ReplenishmentInvoices{
+		{InvoiceHeaderID: invoice.ID, Amount: invoice.Amount},
+	}
+
+	return a.create(replenishment)
+}
+
+// GetForUpdate returns the request locked until the transaction ends
+func (a ReplenishmentService) GetForUpdate(id string) (*models.Replenishment, error) {
+	return a.replenishmentRepository.GetForUpdate(id)
+}
+
+// MarkApproved links the posted TarikDana to the request
+func (a ReplenishmentService) MarkApproved(replenishment *models.Replenishment, tarikdanaID string, username string) error {
+	replenishment.Status = models.ReplenishmentStatusApproved
+	replenishment.TarikDanaID = tarikdanaID
+	replenishment.ApprovedBy = username
+	replenishment.ApprovedDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	replenishment.UpdateBy = username
+
+	return a.replenishmentRepository.UpdateApprove(replenishment.ID, replenishment)
+}
+
+// Reject closes the request, its invoices can be requested again
+func (a ReplenishmentService) Reject(id string, param *models.ReplenishmentApproveParam, username string) error {
+	if param.Reason == "" {
+		return errors.ReplenishmentReasonEmpty
+	}
+
+	replenishment, err := a.replenishmentRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	} else if replenishment.Status != models.ReplenishmentStatusPending {
+		return errors.ReplenishmentNotPending
+	}
+
+	if err = a.replenishmentRepository.DeleteInvoices(id); err != nil {
+		return err
+	}
+
+	replenishment.Status = models.ReplenishmentStatusRejected
+	replenishment.RejectReason = param.Reason
+	replenishment.ApprovedBy = username
+	replenishment.ApprovedDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	replenishment.UpdateBy = username
+
+	return a.replenishmentRepository.UpdateApprove(id, replenishment)
+}
+
+func (a ReplenishmentService) create(replenishment *models.Replenishment) error {
+	if replenishment.Amount <= 0 {
+		return errors.ReplenishmentAmountInvalid
+	}
+
+	num, err := a.counterService.Next("RPL", replenishment.CompanyID, replenishment.BranchID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	replenishment.ID = uuid.MustString()
+	replenishment.Num = num
+	for _, item := range replenishment.ReplenishmentInvoices {
+		item.ReplenishmentID = replenishment.ID
+	}
+
+	return a.replenishmentRepository.Create(replenishment)
+}
+
+func newReplenishment(saldo *models.Saldo, source string, amount int64, desc string, username string) *models.Replenishment {
+	replenishment := new(models.Replenishment)
+	replenishment.CompanyID = saldo.CompanyID
+	replenishment.BranchID = saldo.BranchID
+	replenishment.Source = source
+	replenishment.Amount = amount
+	replenishment.SaldoAkhir = saldo.SaldoAkhir
+	replenishment.Description = desc
+	replenishment.Status = models.ReplenishmentStatusPending
+	replenishment.CreatedBy = username
+
+	return replenishment
+}
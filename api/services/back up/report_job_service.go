@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/jobqueue"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// ReportJobService generates the reports in the background, every job writes its own file
+type ReportJobService struct {
+	logger         lib.Logger
+	config         lib.Config
+	queue          lib.TaskQueue
+	reportService  ReportService
+	userRepository repository.UserRepository
+}
+
+// NewReportJobService creates a new reportjobservice
+func NewReportJobService(
+	logger lib.Logger,
+	config lib.Config,
+	queue lib.TaskQueue,
+	reportService ReportService,
+	userRepository repository.UserRepository,
+) ReportJobService {
+	return ReportJobService{
+		logger:         logger,
+		config:         config,
+		queue:          queue,
+		reportService:  reportService,
+		userRepository: userRepository,
+	}
+}
+
+// Check validates the report parameters and that the user may see the branch
+func (a ReportJobService) Check(param *models.ReportJobParam, userID string) error {
+	if param.Type != models.ReportJobLmdp && param.Type != models.ReportJobLrdp {
+		return errors.ReportJobTypeInvalid
+	} else if len(param.DateParams) != 2 {
+		return errors.ReportJobDateInvalid
+	}
+
+	user, err := a.userRepository.Get(userID)
+	if err != nil {
+		return err
+	} else if user.Username != a.config.SuperAdmin.Username {
+		if (user.CompanyID != "" && user.CompanyID != param.CompanyID) || (user.BranchID != "" && user.BranchID != param.BranchID) {
+			return errors.ReportJobAccessForbidden
+		}
+	}
+
+	return nil
+}
+
+// Submit queues a report of a branch the user may see
+func (a ReportJobService) Submit(param *models.ReportJobParam, userID string, username string) (*jobqueue.Job, error) {
+	if param.Format == "" {
+		param.Format = models.ReportFormatPdf
+	} else if param.Format != models.ReportFormatPdf && param.Format != models.ReportFormatXlsx {
+		return nil, errors.ReportJobFormatInvalid
+	}
+
+	if err := a.Check(param, userID); err != nil {
+		return nil, err
+	}
+
+	param.UserId = username
+	payload, err := json.Marshal(param)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &jobqueue.Job{
+		ID:      uuid.MustString(),
+		Type:    param.Type,
+		Owner:   userID,
+		Payload: payload,
+	}
+	if err = a.queue.Enqueue(context.TODO(), job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// WriteCsv streams a report checked by Check as CSV to w
+func (a ReportJobService) WriteCsv(w io.Writer, param *models.ReportJobParam) error {
+	switch param.Type {
+	case models.ReportJobLmdp:
+		return a.reportService.WriteLmdpCsv(w, &param.ReportMonitoring)
+	case models.ReportJobLrdp:
+		return a.reportService.WriteLrdpCsv(w, &param.ReportMonitoring)
+	default:
+		return errors.ReportJobTypeInvalid
+	}
+}
+
+// Get returns the job to the user who submitted it
+func (a ReportJobService) Get(id string, userID string) (*jobqueue.Job, error) {
+	job, err := a.queue.Get(context.TODO(), id)
+	if err == jobqueue.ErrNotFound {
+		return nil, errors.ReportJobNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	if job.Owner != userID {
+		user, err := a.userRepository.Get(userID)
+		if err != nil {
+			return nil, err
+		} else if user.Username != a.config.SuperAdmin.Username {
+			return nil, errors.ReportJobAccessForbidden
+		}
+	}
+
+	return job, nil
+}
+
+// Open returns the path of the output of a done job and the file name to download it as
+func (a ReportJobService) Open(id string, userID string) (path string, name string, err error) {
+	job, err := a.Get(id, userID)
+	if err != nil {
+		return "", "", err
+	} else if job.Status != jobqueue.StatusDone {
+		return "", "", errors.ReportJobNotReady
+	}
+
+	return filepath.Join(a.config.Report.Directory, job.File), job.Type + "-" + job.CreatedAt.Format("20060102150405") + filepath.Ext(job.File), nil
+}
+
+// Start runs the workers and the removal of expired output until ctx is done
+func (a ReportJobService) Start(ctx context.Context) {
+	if err := os.MkdirAll(a.config.Report.Directory, 0755); err != nil {
+		a.logger.Zap.Errorf("Error to create report directory: %v", err)
+	}
+
+	for i := 0; i < a.config.Report.Workers; i++ {
+		go a.work(ctx)
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.cleanup(ctx, now)
+			}
+		}
+	}()
+}
+
+func (a ReportJobService) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := a.queue.Next(ctx, 5*time.Second)
+		if err != nil {
+			if ctx.Err() == nil {
+				a.logger.Zap.Errorf("Error to take report job: %v", err)
+				time.Sleep(5 * time.Second)
+			}
+			continue
+		} else if job == nil {
+			continue
+		}
+
+		job.Finish(a.run(job), time.Now(), a.queue.TTL())
+		if err = a.queue.Save(context.Background(), job); err != nil {
+			a.logger.Zap.Errorf("Error to save report job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// run generates the report of the job, a panic fails the job instead of the server
+func (a ReportJobService) run(job *jobqueue.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("report job panic: %v", r)
+		}
+	}()
+
+	param := new(models.ReportJobParam)
+	if err = json.Unmarshal(job.Payload, param); err != nil {
+		return err
+	}
+
+	// jobs queued before the format existed are pdf
+	if param.Format == "" {
+		param.Format = models.ReportFormatPdf
+	}
+
+	job.File = job.ID + "." + param.Format
+	path := filepath.Join(a.config.Report.Directory, job.File)
+
+	switch job.Type + "." + param.Format {
+	case models.ReportJobLmdp + "." + models.ReportFormatPdf:
+		return a.reportService.GenerateLmdp(&param.ReportMonitoring, path)
+	case models.ReportJobLmdp + "." + models.ReportFormatXlsx:
+		return a.reportService.GenerateLmdpXlsx(&param.ReportMonitoring, path)
+	case models.ReportJobLrdp + "." + models.ReportFormatPdf:
+		return a.reportService.GenerateLrdp(&param.ReportMonitoring, path)
+	case models.ReportJobLrdp + "." + models.ReportFormatXlsx:
+		return a.reportService.GenerateLrdpXlsx(&param.ReportMonitoring, path)
+	default:
+		return errors.ReportJobFormatInvalid
+	}
+}
+
+func (a ReportJobService) cleanup(ctx context.Context, now time.Time) {
+	ids, err := a.queue.Expired(ctx, now)
+	if err != nil {
+		a.logger.Zap.Errorf("Error to list expired report jobs: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		matches, _ := filepath.Glob(filepath.Join(a.config.Report.Directory, id+".*"))
+		for _, name := range matches {
+			if err := os.Remove(name); err != nil {
+				a.logger.Zap.Errorf("Error to remove report file %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -1,9 +1,15 @@
 package services
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
 	"fmt"
-	"os"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/johnfercher/maroto/pkg/color"
@@ -13,43 +19,76 @@ import (
 	"github.com/leekchan/accounting"
 
 	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
 	"github.com/Aguztinus/petty-cash-backend/models"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/terbilang"
+	"github.com/Aguztinus/petty-cash-backend/pkg/xlsx"
 )
 
 // ReportService service layer
 type ReportService struct {
-	logger                 lib.Logger
-	casbinService          CasbinService
-	saldoService           SaldoService
-	userRepository         repository.UserRepository
-	bkkheaderRepository    repository.BKKHeaderRepository
-	saldohistoryRepository repository.SaldoHistoryRepository
-	saldoRepository        repository.SaldoRepository
-	branchRepository       repository.BranchRepository
+	logger                     lib.Logger
+	storage                    lib.Storage
+	config                     lib.Config
+	casbinService              CasbinService
+	saldoService               SaldoService
+	kasbonService              KasbonService
+	bkkdetailService           BKKDetailService
+	approvalService            ApprovalService
+	userRepository             repository.UserRepository
+	userroleRepository         repository.UserRoleRepository
+	bkkheaderRepository        repository.BKKHeaderRepository
+	bkkdetailRepository        repository.BKKDetailRepository
+	companyRepository          repository.CompanyRepository
+	companysignatureRepository repository.CompanySignatureRepository
+	saldohistoryRepository     repository.SaldoHistoryRepository
+	saldoRepository            repository.SaldoRepository
+	branchRepository           repository.BranchRepository
+	cashcountRepository        repository.CashCountRepository
 }
 
 // NewReportService creates a new reportservice
 func NewReportService(
 	logger lib.Logger,
+	storage lib.Storage,
+	config lib.Config,
 	casbinService CasbinService,
 	saldoService SaldoService,
+	kasbonService KasbonService,
+	bkkdetailService BKKDetailService,
+	approvalService ApprovalService,
 	userRepository repository.UserRepository,
+	userroleRepository repository.UserRoleRepository,
 	bkkheaderRepository repository.BKKHeaderRepository,
+	bkkdetailRepository repository.BKKDetailRepository,
+	companyRepository repository.CompanyRepository,
+	companysignatureRepository repository.CompanySignatureRepository,
 	saldohistoryRepository repository.SaldoHistoryRepository,
 	saldoRepository repository.SaldoRepository,
 	branchRepository repository.BranchRepository,
+	cashcountRepository repository.CashCountRepository,
 ) ReportService {
 	return ReportService{
-		logger:                 logger,
-		casbinService:          casbinService,
-		saldoService:           saldoService,
-		userRepository:         userRepository,
-		bkkheaderRepository:    bkkheaderRepository,
-		saldohistoryRepository: saldohistoryRepository,
-		saldoRepository:        saldoRepository,
-		branchRepository:       branchRepository,
+		logger:                     logger,
+		storage:                    storage,
+		config:                     config,
+		casbinService:              casbinService,
+		saldoService:               saldoService,
+		kasbonService:              kasbonService,
+		bkkdetailService:           bkkdetailService,
+		approvalService:            approvalService,
+		userRepository:             userRepository,
+		userroleRepository:         userroleRepository,
+		bkkheaderRepository:        bkkheaderRepository,
+		bkkdetailRepository:        bkkdetailRepository,
+		companyRepository:          companyRepository,
+		companysignatureRepository: companysignatureRepository,
+		saldohistoryRepository:     saldohistoryRepository,
+		saldoRepository:            saldoRepository,
+		branchRepository:           branchRepository,
+		cashcountRepository:        cashcountRepository,
 	}
 }
 
@@ -58,12 +97,23 @@ const (
 	layoutDetail = "02-01-2006"
 )
 
-func (a ReportService) GenerateLmdp(param *dto.ReportMonitoring) error {
+// GenerateLmdp writes the report to path, each report job has its own file
+func (a ReportService) GenerateLmdp(param *dto.ReportMonitoring, path string) error {
 	cbn, err := a.branchRepository.Get(param.BranchID)
 	if err != nil {
 		return err
 	}
 
+	brand, err := a.brand(param.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	signatures, err := a.reportSignatures(brand)
+	if err != nil {
+		return err
+	}
+
 	begin := time.Now()
 
 	period1, _ := time.Parse(time.RFC3339, param.DateParams[0])
@@ -74,54 +124,20 @@ func (a ReportService) GenerateLmdp(param *dto.ReportMonitoring) error {
 	var kodeNama string = "Kode - Nama: " + cbn.Code + " - " + cbn.Name
 	var periodeParam string = "Periode: " + period1.Format(layoutID) + " - " + period2.Format(layoutID)
 
-	darkGrayColor := getDarkGrayColor()
 	grayColor := getGrayColor()
 	whiteColor := color.NewWhite()
 	header := getHeaderLmdp()
-	contents, totalA, totalI, totalO, totalAh := getDataSaldo(a, param.CompanyID, param.BranchID, param.DateParams)
+	contents, totalA, totalI, totalO, totalAh, err := getDataSaldo(a, param)
+	if err != nil {
+		return err
+	}
 
 	m := pdf.NewMaroto(consts.Portrait, consts.A4)
 	m.SetPageMargins(10, 15, 10)
 	m.SetAliasNbPages("{nb}")
 	m.SetFirstPageNb(1)
 
-	m.RegisterHeader(func() {
-		m.Row(20, func() {
-			m.Col(3, func() {
-				m.Text("Leader Barista Point Coffe. SA", props.Text{
-					Size:        8,
-					Align:       consts.Left,
-					Extrapolate: false,
-					Color:       darkGrayColor,
-				})
-			})
-
-			m.ColSpace(6)
-
-			m.Col(3, func() {
-				m.Text(tglCetak, props.Text{
-					Style: consts.BoldItalic,
-					Size:  8,
-					Align: consts.Right,
-					Color: darkGrayColor,
-				})
-				m.Text(pukulCetak, props.Text{
-					Top:   3,
-					Style: consts.BoldItalic,
-					Size:  8,
-					Align: consts.Right,
-					Color: darkGrayColor,
-				})
-				m.Text(userIdCetak, props.Text{
-					Top:   6,
-					Style: consts.BoldItalic,
-					Size:  8,
-					Align: consts.Right,
-					Color: darkGrayColor,
-				})
-			})
-		})
-	})
+	registerHeader(m, brand, tglCetak, pukulCetak, userIdCetak)
 
 	m.Row(10, func() {
 		m.Col(12, func() {
@@ -262,24 +278,13 @@ func (a ReportService) GenerateLmdp(param *dto.ReportMonitoring) error {
 
 	m.Line(10)
 
-	m.Row(35, func() {
-		m.ColSpace(5)
-		m.Col(14, func() {
-			_ = m.FileImage("assets/images/ttd.png", props.Rect{
-				Percent: 100,
-			})
-		})
-	})
+	signatureBoxes(m, signatures)
 
-	errFile := m.OutputFileAndClose("pdfs/lmdp.pdf")
-	if errFile != nil {
-		fmt.Println("Could not save PDF:", errFile)
-		os.Exit(1)
+	if err = m.OutputFileAndClose(path); err != nil {
+		return errors.Wrap(err, "could not save PDF")
 	}
 
-	end := time.Now()
-	fmt.Println(end.Sub(begin))
-
+	a.logger.Zap.Debugf("lmdp report generated in %s", time.Since(begin))
 	return nil
 }
 
@@ -287,73 +292,222 @@ func getHeaderLmdp() []string {
 	return []string{"No", "Tanggal", "Deskripsi", "Saldo Awal (Rp.)", "Uang Masuk (Rp.)", "Uang Keluar (Rp.)", "Saldo Akhir (Rp.)"}
 }
 
-func getDataSaldo(a ReportService, companyId string, branchId string, dateQuery []string) (data [][]string, totalA string, totalI string, totalO string, totalAh string) {
-	var result [][]string
-	var totalSaldoAwal int64 = 0
-	var totalInAmount int64 = 0
-	var totalOutAmount int64 = 0
-	var totalSaldoAkhir int64 = 0
+// lmdpLine is a line of the LMDP, the amounts are in rupiah
+type lmdpLine struct {
+	Date       string
+	Desc       string
+	SaldoAwal  int64
+	InAmount   int64
+	OutAmount  int64
+	SaldoAkhir int64
+}
+
+// lmdpTotal - SaldoAkhir is the balance after the last line
+type lmdpTotal struct {
+	SaldoAwal  int64
+	InAmount   int64
+	OutAmount  int64
+	SaldoAkhir int64
+}
+
+// reportPageSize is the number of rows read from the database at a time by the report exports
+const reportPageSize = 500
+
+// reportPageOrder - the id keeps the pages stable when rows share a timestamp
+var reportPageOrder = dto.OrderParam{Key: "created_at, id", Direction: dto.OrderByASC}
+
+// eachLmdpLine calls fn for every line of the LMDP, the saldo history is read a page at a time.
+// The PDF, XLSX and CSV exports all go through it so they show the same numbers
+func (a ReportService) eachLmdpLine(param *dto.ReportMonitoring, fn func(no int, line *lmdpLine) error) (*lmdpTotal, error) {
+	if len(param.DateParams) != 2 {
+		return nil, errors.ReportJobDateInvalid
+	}
+
+	total := new(lmdpTotal)
+	no := 0
 	t := time.Now()
 	firstday := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
-	dateStart, _ := time.Parse(time.RFC3339, dateQuery[0])
-
-	acc := accounting.Accounting{Precision: 2, Thousand: ".", Decimal: ","}
-	qr, err := a.saldohistoryRepository.Query(&models.SaldoHistoryQueryParam{
-		CompanyID:  companyId,
-		BranchID:   branchId,
-		DateQuery:  dateQuery,
-		OrderParam: dto.OrderParam{Key: "created_at", Direction: dto.OrderByASC},
-	})
+	dateStart, _ := time.Parse(time.RFC3339, param.DateParams[0])
 
-	saldoAwal, _ := a.saldoService.GetSaldoAwal(companyId, branchId, dateQuery[0], firstday.String())
+	saldoAwal, _ := a.saldoService.GetSaldoAwal(param.CompanyID, param.BranchID, param.DateParams[0], firstday.String())
 	if saldoAwal != 0 {
-		awl := []string{strconv.Itoa(1),
-			dateStart.Format(layoutDetail),
-			"Saldo Awal",
-			acc.FormatMoney(saldoAwal),
-			acc.FormatMoney(0),
-			acc.FormatMoney(0),
-			acc.FormatMoney(saldoAwal),
+		total.SaldoAwal = saldoAwal
+		total.SaldoAkhir = saldoAwal
+		no++
+		if err := fn(no, &lmdpLine{
+			Date:       dateStart.Format(layoutDetail),
+			Desc:       "Saldo Awal",
+			SaldoAwal:  saldoAwal,
+			SaldoAkhir: saldoAwal,
+		}); err != nil {
+			return nil, err
 		}
-		result = append(result, awl)
-		totalSaldoAwal = saldoAwal
-		totalSaldoAkhir = saldoAwal
 	}
 
-	if err == nil {
+	for page := 1; ; page++ {
+		qr, err := a.saldohistoryRepository.Query(&models.SaldoHistoryQueryParam{
+			CompanyID:       param.CompanyID,
+			BranchID:        param.BranchID,
+			DateQuery:       param.DateParams,
+			PaginationParam: dto.PaginationParam{PageSize: reportPageSize, Current: page},
+			OrderParam:      reportPageOrder,
+		})
+		if err != nil {
+			return nil, err
+		}
 
-		for i, e := range qr.List {
-			// i is the index, e the element
+		for _, e := range qr.List {
 			tgl, _ := e.CreatedAt.ValueDate()
 
-			totalSaldoAkhir = totalSaldoAkhir + e.InAmount - e.OutAmount
-
-			x := []string{strconv.Itoa(i + 1),
-				tgl.(string),
-				e.Desc,
-				acc.FormatMoney(e.SaldoAwal),
-				acc.FormatMoney(e.InAmount),
-				acc.FormatMoney(e.OutAmount),
-				acc.FormatMoney(totalSaldoAkhir),
+			total.SaldoAwal += e.SaldoAwal
+			total.InAmount += e.InAmount
+			total.OutAmount += e.OutAmount
+			total.SaldoAkhir += e.InAmount - e.OutAmount
+
+			no++
+			if err = fn(no, &lmdpLine{
+				Date:       tgl.(string),
+				Desc:       e.Desc,
+				SaldoAwal:  e.SaldoAwal,
+				InAmount:   e.InAmount,
+				OutAmount:  e.OutAmount,
+				SaldoAkhir: total.SaldoAkhir,
+			}); err != nil {
+				return nil, err
 			}
-			result = append(result, x)
-			totalSaldoAwal = totalSaldoAwal + e.SaldoAwal
-			totalInAmount = totalInAmount + e.InAmount
-			totalOutAmount = totalOutAmount + e.OutAmount
+		}
+
+		if len(qr.List) < reportPageSize {
+			return total, nil
+		}
+	}
+}
+
+func getDataSaldo(a ReportService, param *dto.ReportMonitoring) (data [][]string, totalA string, totalI string, totalO string, totalAh string, err error) {
+	var result [][]string
+	acc := accounting.Accounting{Precision: 2, Thousand: ".", Decimal: ","}
+
+	total, err := a.eachLmdpLine(param, func(no int, line *lmdpLine) error {
+		result = append(result, []string{strconv.Itoa(no),
+			line.Date,
+			line.Desc,
+			acc.FormatMoney(line.SaldoAwal),
+			acc.FormatMoney(line.InAmount),
+			acc.FormatMoney(line.OutAmount),
+			acc.FormatMoney(line.SaldoAkhir),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, "", "", "", "", err
+	}
+
+	return result, acc.FormatMoney(total.SaldoAwal), acc.FormatMoney(total.InAmount), acc.FormatMoney(total.OutAmount), acc.FormatMoney(total.SaldoAkhir), nil
+}
+
+// GenerateLmdpXlsx writes the LMDP as a spreadsheet, amounts are numeric cells and the totals are formulas
+func (a ReportService) GenerateLmdpXlsx(param *dto.ReportMonitoring, path string) error {
+	begin := time.Now()
+
+	f := xlsx.New()
+	sheet := f.AddSheet("LMDP")
+	sheet.Widths = []float64{8, 12, 40, 18, 18, 18, 18}
+
+	var cells []xlsx.Cell
+	for _, h := range getHeaderLmdp() {
+		cells = append(cells, xlsx.Str(h).Bold())
+	}
+	sheet.AddRow(cells...)
 
+	last := 1
+	_, err := a.eachLmdpLine(param, func(no int, line *lmdpLine) error {
+		last = sheet.AddRow(xlsx.Int(int64(no)), xlsx.Str(line.Date), xlsx.Str(line.Desc),
+			xlsx.Int(line.SaldoAwal), xlsx.Int(line.InAmount), xlsx.Int(line.OutAmount), xlsx.Int(line.SaldoAkhir))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the saldo akhir of the period is the one of the last line, not a sum
+	saldoAkhir := "0"
+	if last > 1 {
+		saldoAkhir = xlsx.CellName(6, last)
+	}
+	sheet.AddRow(xlsx.Str("Total").Bold(), xlsx.Str(""), xlsx.Str(""),
+		xlsx.Formula(sumFormula(3, 2, last)).Bold(), xlsx.Formula(sumFormula(4, 2, last)).Bold(),
+		xlsx.Formula(sumFormula(5, 2, last)).Bold(), xlsx.Formula(saldoAkhir).Bold())
+
+	if err = f.Save(path); err != nil {
+		return errors.Wrap(err, "could not save XLSX")
+	}
+
+	a.logger.Zap.Debugf("lmdp spreadsheet generated in %s", time.Since(begin))
+	return nil
+}
+
+// WriteLmdpCsv streams the LMDP as CSV, the rows are flushed to w after every page read from the database
+func (a ReportService) WriteLmdpCsv(w io.Writer, param *dto.ReportMonitoring) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(getHeaderLmdp()); err != nil {
+		return err
+	}
+
+	total, err := a.eachLmdpLine(param, func(no int, line *lmdpLine) error {
+		err := cw.Write([]string{strconv.Itoa(no), line.Date, line.Desc,
+			strconv.FormatInt(line.SaldoAwal, 10),
+			strconv.FormatInt(line.InAmount, 10),
+			strconv.FormatInt(line.OutAmount, 10),
+			strconv.FormatInt(line.SaldoAkhir, 10),
+		})
+		if err == nil && no%reportPageSize == 0 {
+			err = flushCsv(cw, w)
 		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = cw.Write([]string{"Total", "", "",
+		strconv.FormatInt(total.SaldoAwal, 10),
+		strconv.FormatInt(total.InAmount, 10),
+		strconv.FormatInt(total.OutAmount, 10),
+		strconv.FormatInt(total.SaldoAkhir, 10),
+	}); err != nil {
+		return err
+	}
 
+	return flushCsv(cw, w)
+}
+
+// flushCsv pushes the buffered rows to the client when w is an http response
+func flushCsv(cw *csv.Writer, w io.Writer) error {
+	cw.Flush()
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
 	}
 
-	return result, acc.FormatMoney(totalSaldoAwal), acc.FormatMoney(totalInAmount), acc.FormatMoney(totalOutAmount), acc.FormatMoney(totalSaldoAkhir)
+	return cw.Error()
 }
 
-func (a ReportService) GenerateLrdp(param *dto.ReportMonitoring) error {
+// GenerateLrdp writes the report to path, each report job has its own file
+func (a ReportService) GenerateLrdp(param *dto.ReportMonitoring, path string) error {
 	cbn, err := a.branchRepository.Get(param.BranchID)
 	if err != nil {
 		return err
 	}
 
+	brand, err := a.brand(param.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	signatures, err := a.reportSignatures(brand)
+	if err != nil {
+		return err
+	}
+
 	begin := time.Now()
 
 	period1, _ := time.Parse(time.RFC3339, param.DateParams[0])
@@ -364,54 +518,20 @@ func (a ReportService) GenerateLrdp(param *dto.ReportMonitoring) error {
 	var kodeNama string = "Kode - Nama: " + cbn.Code + " - " + cbn.Name
 	var periodeParam string = "Periode: " + period1.Format(layoutID) + " - " + period2.Format(layoutID)
 
-	darkGrayColor := getDarkGrayColor()
 	grayColor := getGrayColor()
 	whiteColor := color.NewWhite()
 	header := getHeaderLrdp()
-	contents, totalAll := getDataBkk(a, param.CompanyID, param.BranchID, param.DateParams)
+	contents, totalAll, err := getDataBkk(a, param)
+	if err != nil {
+		return err
+	}
 
 	m := pdf.NewMaroto(consts.Portrait, consts.A4)
 	m.SetPageMargins(10, 15, 10)
 	m.SetAliasNbPages("{nb}")
 	m.SetFirstPageNb(1)
 
-	m.RegisterHeader(func() {
-		m.Row(20, func() {
-			m.Col(3, func() {
-				m.Text("Leader Barista Point Coffe. SA", props.Text{
-					Size:        8,
-					Align:       consts.Left,
-					Extrapolate: false,
-					Color:       darkGrayColor,
-				})
-			})
-
-			m.ColSpace(6)
-
-			m.Col(3, func() {
-				m.Text(tglCetak, props.Text{
-					Style: consts.BoldItalic,
-					Size:  8,
-					Align: consts.Right,
-					Color: darkGrayColor,
-				})
-				m.Text(pukulCetak, props.Text{
-					Top:   3,
-					Style: consts.BoldItalic,
-					Size:  8,
-					Align: consts.Right,
-					Color: darkGrayColor,
-				})
-				m.Text(userIdCetak, props.Text{
-					Top:   6,
-					Style: consts.BoldItalic,
-					Size:  8,
-					Align: consts.Right,
-					Color: darkGrayColor,
-				})
-			})
-		})
-	})
+	registerHeader(m, brand, tglCetak, pukulCetak, userIdCetak)
 
 	m.Row(10, func() {
 		m.Col(12, func() {
@@ -492,24 +612,13 @@ func (a ReportService) GenerateLrdp(param *dto.ReportMonitoring) error {
 
 	m.Line(10)
 
-	m.Row(35, func() {
-		m.ColSpace(5)
-		m.Col(14, func() {
-			_ = m.FileImage("assets/images/ttd.png", props.Rect{
-				Percent: 100,
-			})
-		})
-	})
+	signatureBoxes(m, signatures)
 
-	errFile := m.OutputFileAndClose("pdfs/lrdp.pdf")
-	if errFile != nil {
-		fmt.Println("Could not save PDF:", errFile)
-		os.Exit(1)
+	if err = m.OutputFileAndClose(path); err != nil {
+		return errors.Wrap(err, "could not save PDF")
 	}
 
-	end := time.Now()
-	fmt.Println(end.Sub(begin))
-
+	a.logger.Zap.Debugf("lrdp report generated in %s", time.Since(begin))
 	return nil
 }
 
@@ -517,37 +626,1227 @@ func getHeaderLrdp() []string {
 	return []string{"No", "Tanggal", "Deskripsi", "Nilai (Rp.)"}
 }
 
-func getDataBkk(a ReportService, companyId string, branchId string, dateQuery []string) (data [][]string, totalAll string) {
-	var result [][]string
-	var total int64 = 0
+// lrdpLine is a BKK detail line of the LRDP, the amount is in rupiah
+type lrdpLine struct {
+	Date   string
+	Desc   string
+	Amount int64
+}
 
-	acc := accounting.Accounting{Precision: 2, Thousand: ".", Decimal: ","}
-	qr, err := a.bkkheaderRepository.Query(&models.BKKHeaderQueryParam{
-		CompanyID:  companyId,
-		BranchID:   branchId,
-		DateQuery:  dateQuery,
-		OrderParam: dto.OrderParam{Key: "created_at", Direction: dto.OrderByASC},
-	})
+// eachLrdpLine calls fn for every BKK detail line of the period and returns the total, the BKKs
+// are read a page at a time. The PDF, XLSX and CSV exports all go through it
+func (a ReportService) eachLrdpLine(param *dto.ReportMonitoring, fn func(no int, line *lrdpLine) error) (int64, error) {
+	if len(param.DateParams) != 2 {
+		return 0, errors.ReportJobDateInvalid
+	}
+
+	var total int64
+	no := 0
+	for page := 1; ; page++ {
+		qr, err := a.bkkheaderRepository.Query(&models.BKKHeaderQueryParam{
+			CompanyID:       param.CompanyID,
+			BranchID:        param.BranchID,
+			DateQuery:       param.DateParams,
+			PaginationParam: dto.PaginationParam{PageSize: reportPageSize, Current: page},
+			OrderParam:      reportPageOrder,
+		})
+		if err != nil {
+			return 0, err
+		}
 
-	if err == nil {
-		count := 0
 		for _, e := range qr.List {
 			tgl, _ := e.CreatedAt.ValueDate()
 			for _, d := range e.BKKDetails {
-				count++
-				x := []string{strconv.Itoa(count),
-					tgl.(string),
-					d.LinesDesc,
-					acc.FormatMoney(d.LinesAmount),
+				total += d.LinesAmount
+				no++
+				if err = fn(no, &lrdpLine{Date: tgl.(string), Desc: d.LinesDesc, Amount: d.LinesAmount}); err != nil {
+					return 0, err
 				}
-				result = append(result, x)
-				total = total + d.LinesAmount
 			}
+		}
+
+		if len(qr.List) < reportPageSize {
+			return total, nil
+		}
+	}
+}
+
+func getDataBkk(a ReportService, param *dto.ReportMonitoring) (data [][]string, totalAll string, err error) {
+	var result [][]string
+	acc := accounting.Accounting{Precision: 2, Thousand: ".", Decimal: ","}
+
+	total, err := a.eachLrdpLine(param, func(no int, line *lrdpLine) error {
+		result = append(result, []string{strconv.Itoa(no),
+			line.Date,
+			line.Desc,
+			acc.FormatMoney(line.Amount),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return result, acc.FormatMoney(total), nil
+}
+
+// GenerateLrdpXlsx writes the LRDP as a spreadsheet, amounts are numeric cells and the total is a formula
+func (a ReportService) GenerateLrdpXlsx(param *dto.ReportMonitoring, path string) error {
+	begin := time.Now()
+
+	f := xlsx.New()
+	sheet := f.AddSheet("LRDP")
+	sheet.Widths = []float64{8, 12, 48, 18}
+
+	var cells []xlsx.Cell
+	for _, h := range getHeaderLrdp() {
+		cells = append(cells, xlsx.Str(h).Bold())
+	}
+	sheet.AddRow(cells...)
+
+	last := 1
+	_, err := a.eachLrdpLine(param, func(no int, line *lrdpLine) error {
+		last = sheet.AddRow(xlsx.Int(int64(no)), xlsx.Str(line.Date), xlsx.Str(line.Desc), xlsx.Int(line.Amount))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sheet.AddRow(xlsx.Str("Total").Bold(), xlsx.Str(""), xlsx.Str(""), xlsx.Formula(sumFormula(3, 2, last)).Bold())
+
+	if err = f.Save(path); err != nil {
+		return errors.Wrap(err, "could not save XLSX")
+	}
+
+	a.logger.Zap.Debugf("lrdp spreadsheet generated in %s", time.Since(begin))
+	return nil
+}
+
+// WriteLrdpCsv streams the LRDP as CSV, the rows are flushed to w after every page read from the database
+func (a ReportService) WriteLrdpCsv(w io.Writer, param *dto.ReportMonitoring) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(getHeaderLrdp()); err != nil {
+		return err
+	}
+
+	total, err := a.eachLrdpLine(param, func(no int, line *lrdpLine) error {
+		err := cw.Write([]string{strconv.Itoa(no), line.Date, line.Desc, strconv.FormatInt(line.Amount, 10)})
+		if err == nil && no%reportPageSize == 0 {
+			err = flushCsv(cw, w)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = cw.Write([]string{"Total", "", "", strconv.FormatInt(total, 10)}); err != nil {
+		return err
+	}
+
+	return flushCsv(cw, w)
+}
+
+// GenerateCashCount prints the berita acara of a cash count of a company and branch the user may see
+// and returns the PDF
+func (a ReportService) GenerateCashCount(id string, userId string) ([]byte, error) {
+	cashcount, err := a.cashcountRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.authorize(cashcount.CompanyID, cashcount.BranchID, userId); err != nil {
+		return nil, err
+	}
+
+	brand, err := a.brand(cashcount.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	counter, err := a.signatureOf(brand, cashcount.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	approver, err := a.signatureOf(brand, cashcount.ApprovedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	begin := time.Now()
+	acc := accounting.Accounting{Precision: 2, Thousand: ".", Decimal: ","}
+
+	var tglCetak string = "Tgl Cetak: " + begin.Format(layoutID)
+	var pukulCetak string = "Pkl Cetak: " + begin.Format("15:04:05")
+	var userIdCetak string = "User Cetak: " + userId
+	var kodeNama string = "Kode - Nama: " + cashcount.Branch.Code + " - " + cashcount.Branch.Name
+	var tglHitung string = "No: " + cashcount.Num + "   Tgl Hitung: " + cashcount.CountDate.Time.Format(layoutID)
+
+	grayColor := getGrayColor()
+	header := []string{"Jenis", "Pecahan (Rp.)", "Jumlah", "Nilai (Rp.)"}
 
+	var contents [][]string
+	for _, d := range cashcount.CashCountDetails {
+		jenis := "Kertas"
+		if d.Type == models.CashCountTypeCoin {
+			jenis = "Logam"
 		}
+		contents = append(contents, []string{jenis,
+			acc.FormatMoney(d.Denomination),
+			strconv.FormatInt(d.Quantity, 10),
+			acc.FormatMoney(d.Amount),
+		})
+	}
+
+	summary := [][]string{
+		{"Total Hasil Hitung:", acc.FormatMoney(cashcount.TotalCount)},
+		{"Saldo Sistem:", acc.FormatMoney(cashcount.SaldoSystem)},
+		{"Selisih:", acc.FormatMoney(cashcount.Variance)},
+		{"Alasan:", cashcount.ReasonCode},
+		{"Status:", cashcount.Status},
 	}
 
-	return result, acc.FormatMoney(total)
+	m := pdf.NewMaroto(consts.Portrait, consts.A4)
+	m.SetPageMargins(10, 15, 10)
+
+	registerHeader(m, brand, tglCetak, pukulCetak, userIdCetak)
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text("Berita Acara Perhitungan Kas (Cash Opname)", props.Text{
+				Size:  14,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text(kodeNama, props.Text{
+				Top:   1,
+				Size:  10,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+			m.Text(tglHitung, props.Text{
+				Top:   6,
+				Size:  10,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	m.Line(10)
+
+	m.TableList(header, contents, props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{3, 3, 3, 3},
+		},
+		ContentProp: props.TableListContent{
+			Size:      8,
+			GridSizes: []uint{3, 3, 3, 3},
+		},
+		Align:                consts.Center,
+		AlternatedBackground: &grayColor,
+		HeaderContentSpace:   2,
+		Line:                 false,
+	})
+
+	m.Line(5)
+
+	for _, s := range summary {
+		label, value := s[0], s[1]
+		m.Row(4, func() {
+			m.ColSpace(6)
+			m.Col(3, func() {
+				m.Text(label, props.Text{
+					Top:   5,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+				})
+			})
+			m.Col(3, func() {
+				m.Text(value, props.Text{
+					Top:   5,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+				})
+			})
+		})
+	}
+
+	m.Line(10)
+
+	signatureBoxes(m, []signatureBox{
+		{title: "Dihitung Oleh", name: cashcount.CreatedBy, image: counter},
+		{title: "Disaksikan Oleh"},
+		{title: "Disetujui Oleh", name: cashcount.ApprovedBy, image: approver},
+	})
+
+	buf, err := m.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func getHeaderBKKVoucher() []string {
+	return []string{"No", "Tanggal", "Akun", "Keterangan", "Jumlah (Rp.)"}
+}
+
+// GenerateBKKVoucher prints the Bukti Kas Keluar of a BKK and returns the PDF, the approvers
+// of its last submission fill the signature boxes after the requester and the cashier
+func (a ReportService) GenerateBKKVoucher(id string, userId string) ([]byte, error) {
+	qr, err := a.bkkheaderRepository.Query(&models.BKKHeaderQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 1, Current: 1},
+		IDs:             []string{id},
+	})
+	if err != nil {
+		return nil, err
+	} else if len(qr.List) == 0 {
+		return nil, errors.DatabaseRecordNotFound
+	}
+	bkk := qr.List[0]
+
+	if err = a.authorize(bkk.CompanyID, bkk.BranchID, userId); err != nil {
+		return nil, err
+	}
+
+	details, err := a.bkkdetailRepository.GetByHeaderID(bkk.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	approvals, err := a.approvalService.Approvals(models.ApprovalDocBKK, bkk.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	brand, err := a.brand(bkk.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	begin := time.Now()
+	acc := accounting.Accounting{Precision: 2, Thousand: ".", Decimal: ","}
+
+	var tglCetak string = "Tgl Cetak: " + begin.Format(layoutID)
+	var pukulCetak string = "Pkl Cetak: " + begin.Format("15:04:05")
+	var userIdCetak string = "User Cetak: " + userId
+	var kodeNama string = "Kode - Nama: " + bkk.Branch.Code + " - " + bkk.Branch.Name
+	var noTanggal string = "No: " + bkk.Num + "   Tanggal: " + bkk.ReleaseDate.Time.Format(layoutID)
+
+	grayColor := getGrayColor()
+
+	var contents [][]string
+	var total int64 = 0
+	for i, d := range details {
+		contents = append(contents, []string{strconv.Itoa(i + 1),
+			d.LinesDate.Time.Format(layoutDetail),
+			d.Trx.SegmentedValue,
+			d.LinesDesc,
+			acc.FormatMoney(d.LinesAmount),
+		})
+		total += d.LinesAmount
+	}
+
+	requester, err := a.signatureOf(brand, bkk.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	cashier, err := a.signatureOf(brand, bkk.PaidBy)
+	if err != nil {
+		return nil, err
+	}
+
+	signatures := []signatureBox{
+		{title: "Diminta Oleh", name: bkk.CreatedBy, image: requester},
+		{title: "Kasir", name: bkk.PaidBy, image: cashier},
+	}
+	for _, approval := range approvals {
+		box := signatureBox{title: approval.StepName, name: approval.Username}
+		if box.title == "" {
+			box.title = "Disetujui Oleh"
+		}
+		if box.image, err = a.signatureOf(brand, approval.Username); err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, box)
+	}
+	if len(approvals) == 0 {
+		signatures = append(signatures, signatureBox{title: "Disetujui Oleh"})
+	}
+
+	m := pdf.NewMaroto(consts.Portrait, consts.A4)
+	m.SetPageMargins(10, 15, 10)
+
+	registerHeader(m, brand, tglCetak, pukulCetak, userIdCetak)
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text("Bukti Kas Keluar", props.Text{
+				Size:  14,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text(kodeNama, props.Text{
+				Top:   1,
+				Size:  10,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+			m.Text(noTanggal, props.Text{
+				Top:   6,
+				Size:  10,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	if bkk.Status == models.BKKStatusVoid {
+		m.Row(8, func() {
+			m.Col(12, func() {
+				m.Text("DIBATALKAN (VOID): "+bkk.VoidReason, props.Text{
+					Top:   2,
+					Size:  10,
+					Style: consts.Bold,
+					Align: consts.Center,
+					Color: color.Color{Red: 200},
+				})
+			})
+		})
+	}
+
+	m.Line(10)
+
+	m.TableList(getHeaderBKKVoucher(), contents, props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      10,
+			GridSizes: []uint{1, 2, 3, 4, 2},
+		},
+		ContentProp: props.TableListContent{
+			Size:      8,
+			GridSizes: []uint{1, 2, 3, 4, 2},
+		},
+		Align:                consts.Center,
+		AlternatedBackground: &grayColor,
+		HeaderContentSpace:   2,
+		Line:                 false,
+	})
+
+	m.Line(5)
+
+	m.Row(4, func() {
+		m.ColSpace(8)
+		m.Col(2, func() {
+			m.Text("Total:", props.Text{
+				Top:   5,
+				Style: consts.Bold,
+				Size:  8,
+				Align: consts.Right,
+			})
+		})
+		m.Col(2, func() {
+			m.Text(acc.FormatMoney(total), props.Text{
+				Top:   5,
+				Style: consts.Bold,
+				Size:  8,
+				Align: consts.Right,
+			})
+		})
+	})
+
+	m.Row(12, func() {
+		m.Col(12, func() {
+			m.Text("Terbilang: # "+terbilang.Rupiah(total)+" #", props.Text{
+				Top:   8,
+				Style: consts.Italic,
+				Size:  9,
+				Align: consts.Left,
+			})
+		})
+	})
+
+	m.Line(10)
+
+	signatureBoxes(m, signatures)
+
+	buf, err := m.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func getHeaderKasbonAging() []string {
+	return []string{"No Kasbon", "Karyawan", "Cabang", "Tgl Release", "Jatuh Tempo", "Umur (Hari)", "0-7", "8-14", "15-30", ">30"}
+}
+
+func getHeaderKasbonAgingGroup(title string) []string {
+	return []string{title, "Jumlah Kasbon", "0-7", "8-14", "15-30", ">30", "Total"}
+}
+
+func (a ReportService) GenerateKasbonAging(param *models.KasbonAgingParam, userId string) ([]byte, error) {
+	aging, err := a.kasbonService.Aging(param)
+	if err != nil {
+		return nil, err
+	}
+
+	begin := time.Now()
+	acc := accounting.Accounting{Precision: 0, Thousand: ".", Decimal: ","}
+
+	var tglCetak string = "Tgl Cetak: " + begin.Format(layoutID)
+	var pukulCetak string = "Pkl Cetak: " + begin.Format("15:04:05")
+	var userIdCetak string = "User Cetak: " + userId
+	var perTanggal string = "Per Tanggal: " + param.AsOf().Format(layoutID)
+
+	grayColor := getGrayColor()
+
+	// the letterhead of a single company, a report across companies has none
+	var companyID string
+	if len(aging.Companies) == 1 {
+		companyID = aging.Companies[0].ID
+	}
+	brand, err := a.brand(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var contents [][]string
+	for _, line := range aging.Lines {
+		row := []string{line.Num, line.EmployeeName, line.BranchName,
+			line.ReleaseDate.Time.Format(layoutDetail), line.DueDate.Time.Format(layoutDetail), strconv.Itoa(line.Age)}
+		for _, bucket := range models.KasbonAgingBuckets {
+			if bucket == line.Bucket {
+				row = append(row, acc.FormatMoney(line.Amount))
+			} else {
+				row = append(row, "")
+			}
+		}
+		contents = append(contents, row)
+	}
+
+	groupContents := func(groups models.KasbonAgingGroups) (rows [][]string) {
+		for _, g := range groups {
+			row := []string{g.Name, strconv.Itoa(g.Count)}
+			for _, v := range g.Values() {
+				row = append(row, acc.FormatMoney(v))
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+
+	m := pdf.NewMaroto(consts.Landscape, consts.A4)
+	m.SetPageMargins(10, 15, 10)
+
+	registerHeader(m, brand, tglCetak, pukulCetak, userIdCetak)
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text("Laporan Umur Kasbon", props.Text{
+				Size:  14,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text(perTanggal, props.Text{
+				Top:   1,
+				Size:  10,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	m.Line(10)
+
+	m.TableList(getHeaderKasbonAging(), contents, props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      9,
+			GridSizes: []uint{1, 2, 1, 1, 1, 1, 1, 1, 1, 2},
+		},
+		ContentProp: props.TableListContent{
+			Size:      8,
+			GridSizes: []uint{1, 2, 1, 1, 1, 1, 1, 1, 1, 2},
+		},
+		Align:                consts.Center,
+		AlternatedBackground: &grayColor,
+		HeaderContentSpace:   2,
+		Line:                 false,
+	})
+
+	for _, section := range []struct {
+		title  string
+		column string
+		groups models.KasbonAgingGroups
+	}{
+		{"Ringkasan Per Karyawan", "Karyawan", aging.Employees},
+		{"Ringkasan Per Cabang", "Cabang", aging.Branches},
+		{"Ringkasan Per Perusahaan", "Perusahaan", aging.Companies},
+	} {
+		title := section.title
+		m.Line(10)
+		m.Row(8, func() {
+			m.Col(12, func() {
+				m.Text(title, props.Text{
+					Size:  10,
+					Style: consts.Bold,
+					Align: consts.Left,
+				})
+			})
+		})
+
+		m.TableList(getHeaderKasbonAgingGroup(section.column), groupContents(section.groups), props.TableList{
+			HeaderProp: props.TableListContent{
+				Size:      9,
+				GridSizes: []uint{3, 1, 1, 1, 2, 2, 2},
+			},
+			ContentProp: props.TableListContent{
+				Size:      8,
+				GridSizes: []uint{3, 1, 1, 1, 2, 2, 2},
+			},
+			Align:                consts.Center,
+			AlternatedBackground: &grayColor,
+			HeaderContentSpace:   2,
+			Line:                 false,
+		})
+	}
+
+	m.Line(5)
+
+	summary := [][]string{
+		{"Total Kasbon Outstanding:", acc.FormatMoney(aging.Total.Total)},
+		{"Total Lewat Jatuh Tempo:", acc.FormatMoney(aging.Total.Overdue)},
+	}
+	for _, s := range summary {
+		label, value := s[0], s[1]
+		m.Row(4, func() {
+			m.ColSpace(6)
+			m.Col(3, func() {
+				m.Text(label, props.Text{
+					Top:   5,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+				})
+			})
+			m.Col(3, func() {
+				m.Text(value, props.Text{
+					Top:   5,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+				})
+			})
+		})
+	}
+
+	buf, err := m.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GenerateKasbonAgingXlsx writes the aging detail and the per employee, branch and company summaries
+// as separate sheets, amounts are numeric cells and the totals are formulas
+func (a ReportService) GenerateKasbonAgingXlsx(param *models.KasbonAgingParam) ([]byte, error) {
+	aging, err := a.kasbonService.Aging(param)
+	if err != nil {
+		return nil, err
+	}
+
+	f := xlsx.New()
+
+	detail := f.AddSheet("Detail")
+	detail.Widths = []float64{14, 28, 24, 12, 12, 12, 14, 14, 14, 14}
+	var cells []xlsx.Cell
+	for _, h := range getHeaderKasbonAging() {
+		cells = append(cells, xlsx.Str(h).Bold())
+	}
+	detail.AddRow(cells...)
+	last := 1
+	for _, line := range aging.Lines {
+		cells = []xlsx.Cell{xlsx.Str(line.Num), xlsx.Str(line.EmployeeName), xlsx.Str(line.BranchName),
+			xlsx.Str(line.ReleaseDate.Time.Format(layoutDetail)), xlsx.Str(line.DueDate.Time.Format(layoutDetail)),
+			xlsx.Int(int64(line.Age))}
+		for _, bucket := range models.KasbonAgingBuckets {
+			if bucket == line.Bucket {
+				cells = append(cells, xlsx.Int(line.Amount))
+			} else {
+				cells = append(cells, xlsx.Str(""))
+			}
+		}
+		last = detail.AddRow(cells...)
+	}
+	cells = []xlsx.Cell{xlsx.Str("Total").Bold(), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""), xlsx.Str("")}
+	for col := 6; col < 10; col++ {
+		cells = append(cells, xlsx.Formula(sumFormula(col, 2, last)).Bold())
+	}
+	detail.AddRow(cells...)
+
+	for _, section := range []struct {
+		name   string
+		groups models.KasbonAgingGroups
+	}{
+		{"Karyawan", aging.Employees},
+		{"Cabang", aging.Branches},
+		{"Perusahaan", aging.Companies},
+	} {
+		sheet := f.AddSheet(section.name)
+		sheet.Widths = []float64{28, 14, 14, 14, 14, 14, 16}
+		cells = nil
+		for _, h := range getHeaderKasbonAgingGroup(section.name) {
+			cells = append(cells, xlsx.Str(h).Bold())
+		}
+		sheet.AddRow(cells...)
+		last = 1
+		for _, g := range section.groups {
+			cells = []xlsx.Cell{xlsx.Str(g.Name), xlsx.Int(int64(g.Count))}
+			for _, v := range g.Values()[:len(models.KasbonAgingBuckets)] {
+				cells = append(cells, xlsx.Int(v))
+			}
+			row := last + 1
+			cells = append(cells, xlsx.Formula(fmt.Sprintf("SUM(%s:%s)", xlsx.CellName(2, row), xlsx.CellName(5, row))))
+			last = sheet.AddRow(cells...)
+		}
+		cells = []xlsx.Cell{xlsx.Str("Total").Bold()}
+		for col := 1; col < 7; col++ {
+			cells = append(cells, xlsx.Formula(sumFormula(col, 2, last)).Bold())
+		}
+		sheet.AddRow(cells...)
+	}
+
+	buf := new(bytes.Buffer)
+	if err = f.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func getHeaderExpenseAnalysis() []string {
+	return []string{"Periode", "Akun", "Nama Akun", "Cost Centre", "Nama Cost Centre", "Departemen", "Nama Departemen", "Jumlah", "Nilai (Rp.)"}
+}
+
+func getHeaderExpenseGroup(title string) []string {
+	return []string{"Kode", title, "Jumlah", "Nilai (Rp.)"}
+}
+
+func getHeaderExpenseVoucher() []string {
+	return []string{"No BKK", "Tanggal", "Periode", "Transaksi", "Akun", "Cost Centre", "Departemen", "Deskripsi", "Nilai (Rp.)"}
+}
+
+// GenerateExpenseAnalysis prints the expenses per period with their subtotals followed by the
+// summaries per account, cost centre and department
+func (a ReportService) GenerateExpenseAnalysis(param *models.ExpenseAnalysisParam, userId string) ([]byte, error) {
+	analysis, err := a.bkkdetailService.Analysis(param)
+	if err != nil {
+		return nil, err
+	}
+
+	brand, err := a.brand(param.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	begin := time.Now()
+	acc := accounting.Accounting{Precision: 0, Thousand: ".", Decimal: ","}
+
+	period1, _ := time.Parse(time.RFC3339, param.DateParams[0])
+	period2, _ := time.Parse(time.RFC3339, param.DateParams[1])
+	var tglCetak string = "Tgl Cetak: " + begin.Format(layoutID)
+	var pukulCetak string = "Pkl Cetak: " + begin.Format("15:04:05")
+	var userIdCetak string = "User Cetak: " + userId
+	var periodeParam string = "Periode: " + period1.Format(layoutID) + " - " + period2.Format(layoutID)
+
+	grayColor := getGrayColor()
+
+	m := pdf.NewMaroto(consts.Landscape, consts.A4)
+	m.SetPageMargins(10, 15, 10)
+
+	registerHeader(m, brand, tglCetak, pukulCetak, userIdCetak)
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text("Laporan Analisa Biaya Petty Cash", props.Text{
+				Size:  14,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text(periodeParam, props.Text{
+				Top:   1,
+				Size:  10,
+				Style: consts.Bold,
+				Align: consts.Center,
+			})
+		})
+	})
+
+	totalRow := func(label string, value string) {
+		m.Row(4, func() {
+			m.ColSpace(6)
+			m.Col(3, func() {
+				m.Text(label, props.Text{
+					Top:   5,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+				})
+			})
+			m.Col(3, func() {
+				m.Text(value, props.Text{
+					Top:   5,
+					Style: consts.Bold,
+					Size:  8,
+					Align: consts.Right,
+				})
+			})
+		})
+	}
+
+	for _, p := range analysis.Periods {
+		var contents [][]string
+		for _, line := range p.Lines {
+			contents = append(contents, []string{line.Period, line.AccountNum, line.AccountName, line.CCCode,
+				line.DeptNum, strconv.Itoa(line.Count), acc.FormatMoney(line.Amount)})
+		}
+
+		m.Line(10)
+		m.TableList([]string{"Periode", "Akun", "Nama Akun", "Cost Centre", "Departemen", "Jumlah", "Nilai (Rp.)"}, contents, props.TableList{
+			HeaderProp: props.TableListContent{
+				Size:      9,
+				GridSizes: []uint{1, 1, 4, 2, 1, 1, 2},
+			},
+			ContentProp: props.TableListContent{
+				Size:      8,
+				GridSizes: []uint{1, 1, 4, 2, 1, 1, 2},
+			},
+			Align:                consts.Center,
+			AlternatedBackground: &grayColor,
+			HeaderContentSpace:   2,
+			Line:                 false,
+		})
+		totalRow("Subtotal "+p.Period+":", acc.FormatMoney(p.Amount))
+	}
+
+	for _, section := range []struct {
+		title  string
+		column string
+		groups models.ExpenseAnalysisGroups
+	}{
+		{"Ringkasan Per Akun", "Akun", analysis.Accounts},
+		{"Ringkasan Per Cost Centre", "Cost Centre", analysis.CostCentres},
+		{"Ringkasan Per Departemen", "Departemen", analysis.Departments},
+	} {
+		title := section.title
+		m.Line(10)
+		m.Row(8, func() {
+			m.Col(12, func() {
+				m.Text(title, props.Text{
+					Size:  10,
+					Style: consts.Bold,
+					Align: consts.Left,
+				})
+			})
+		})
+
+		var contents [][]string
+		for _, g := range section.groups {
+			contents = append(contents, []string{g.Code, g.Name, strconv.Itoa(g.Count), acc.FormatMoney(g.Amount)})
+		}
+
+		m.TableList(getHeaderExpenseGroup(section.column), contents, props.TableList{
+			HeaderProp: props.TableListContent{
+				Size:      9,
+				GridSizes: []uint{2, 6, 1, 3},
+			},
+			ContentProp: props.TableListContent{
+				Size:      8,
+				GridSizes: []uint{2, 6, 1, 3},
+			},
+			Align:                consts.Center,
+			AlternatedBackground: &grayColor,
+			HeaderContentSpace:   2,
+			Line:                 false,
+		})
+	}
+
+	m.Line(5)
+	totalRow("Total Biaya:", acc.FormatMoney(analysis.Amount))
+
+	buf, err := m.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GenerateExpenseAnalysisXlsx writes the expenses per period with subtotals, the summaries per
+// account, cost centre and department and the vouchers behind them as separate sheets, amounts are
+// numeric cells and the subtotals and totals are formulas
+func (a ReportService) GenerateExpenseAnalysisXlsx(param *models.ExpenseAnalysisParam) ([]byte, error) {
+	analysis, err := a.bkkdetailService.Analysis(param)
+	if err != nil {
+		return nil, err
+	}
+
+	vouchers, err := a.bkkdetailService.Vouchers(param)
+	if err != nil {
+		return nil, err
+	}
+
+	f := xlsx.New()
+
+	header := func(sheet *xlsx.Sheet, titles []string) {
+		var cells []xlsx.Cell
+		for _, h := range titles {
+			cells = append(cells, xlsx.Str(h).Bold())
+		}
+		sheet.AddRow(cells...)
+	}
+
+	detail := f.AddSheet("Periode")
+	detail.Widths = []float64{10, 12, 28, 12, 24, 12, 24, 10, 16}
+	header(detail, getHeaderExpenseAnalysis())
+	var subtotals []int
+	last := 1
+	for _, p := range analysis.Periods {
+		first := last + 1
+		for _, line := range p.Lines {
+			last = detail.AddRow(xlsx.Str(line.Period), xlsx.Str(line.AccountNum), xlsx.Str(line.AccountName),
+				xlsx.Str(line.CCCode), xlsx.Str(line.CCName), xlsx.Str(line.DeptNum), xlsx.Str(line.DeptName),
+				xlsx.Int(int64(line.Count)), xlsx.Int(line.Amount))
+		}
+		last = detail.AddRow(xlsx.Str("Subtotal "+p.Period).Bold(), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""),
+			xlsx.Str(""), xlsx.Str(""), xlsx.Str(""),
+			xlsx.Formula(sumFormula(7, first, last)).Bold(), xlsx.Formula(sumFormula(8, first, last)).Bold())
+		subtotals = append(subtotals, last)
+	}
+	// the total adds the subtotal rows up, summing the column would count every line twice
+	totalOf := func(col int) string {
+		if len(subtotals) == 0 {
+			return "0"
+		}
+		cells := make([]string, len(subtotals))
+		for i, row := range subtotals {
+			cells[i] = xlsx.CellName(col, row)
+		}
+		return strings.Join(cells, "+")
+	}
+	detail.AddRow(xlsx.Str("Total").Bold(), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""),
+		xlsx.Str(""), xlsx.Formula(totalOf(7)).Bold(), xlsx.Formula(totalOf(8)).Bold())
+
+	for _, section := range []struct {
+		name   string
+		column string
+		groups models.ExpenseAnalysisGroups
+	}{
+		{"Akun", "Nama Akun", analysis.Accounts},
+		{"Cost Centre", "Nama Cost Centre", analysis.CostCentres},
+		{"Departemen", "Nama Departemen", analysis.Departments},
+	} {
+		sheet := f.AddSheet(section.name)
+		sheet.Widths = []float64{12, 32, 10, 16}
+		header(sheet, getHeaderExpenseGroup(section.column))
+		last = 1
+		for _, g := range section.groups {
+			last = sheet.AddRow(xlsx.Str(g.Code), xlsx.Str(g.Name), xlsx.Int(int64(g.Count)), xlsx.Int(g.Amount))
+		}
+		sheet.AddRow(xlsx.Str("Total").Bold(), xlsx.Str(""),
+			xlsx.Formula(sumFormula(2, 2, last)).Bold(), xlsx.Formula(sumFormula(3, 2, last)).Bold())
+	}
+
+	sheet := f.AddSheet("Voucher")
+	sheet.Widths = []float64{18, 12, 10, 24, 12, 12, 12, 40, 16}
+	header(sheet, getHeaderExpenseVoucher())
+	last = 1
+	for _, v := range vouchers {
+		last = sheet.AddRow(xlsx.Str(v.Num), xlsx.Str(v.Date.Time.Format(layoutDetail)), xlsx.Str(v.Period),
+			xlsx.Str(v.TrxName), xlsx.Str(v.AccountNum), xlsx.Str(v.CCCode), xlsx.Str(v.DeptNum),
+			xlsx.Str(v.LinesDesc), xlsx.Int(v.Amount))
+	}
+	sheet.AddRow(xlsx.Str("Total").Bold(), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""), xlsx.Str(""),
+		xlsx.Str(""), xlsx.Str(""), xlsx.Formula(sumFormula(8, 2, last)).Bold())
+
+	buf := new(bytes.Buffer)
+	if err = f.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// sumFormula sums a column between two 1-based rows, an empty range (to < from) sums nothing
+func sumFormula(col int, from int, to int) string {
+	if to < from {
+		return "0"
+	}
+
+	return fmt.Sprintf("SUM(%s:%s)", xlsx.CellName(col, from), xlsx.CellName(col, to))
+}
+
+// reportBrand is the letterhead and the authorised signatories of a company on the reports
+type reportBrand struct {
+	name       string
+	address    string
+	logo       *reportImage
+	signatures models.CompanySignatures
+}
+
+type reportImage struct {
+	base64    string
+	extension consts.Extension
+}
+
+type signatureBox struct {
+	title string
+	name  string
+	image *reportImage
+}
+
+// authorize refuses a document of another company or branch than the one of the user, like the attachments
+func (a ReportService) authorize(companyID string, branchID string, username string) error {
+	user, err := a.userRepository.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if user.Username == a.config.SuperAdmin.Username {
+		return nil
+	} else if user.CompanyID != "" && user.CompanyID != companyID {
+		return errors.ReportAccessForbidden
+	} else if user.BranchID != "" && user.BranchID != branchID {
+		return errors.ReportAccessForbidden
+	}
+
+	return nil
+}
+
+// brand loads the letterhead of a company, no company gives an empty letterhead
+func (a ReportService) brand(companyID string) (*reportBrand, error) {
+	brand := new(reportBrand)
+	if companyID == "" {
+		return brand, nil
+	}
+
+	company, err := a.companyRepository.Get(companyID)
+	if err != nil {
+		return nil, err
+	}
+	brand.name = company.Letterhead()
+	brand.address = company.Address
+
+	if company.LogoKey != "" {
+		if brand.logo, err = a.image(company.LogoKey); err != nil {
+			return nil, err
+		}
+	}
+
+	signatures, err := a.companysignatureRepository.Query(&models.CompanySignatureQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		CompanyID:       companyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	brand.signatures = signatures.List
+
+	return brand, nil
+}
+
+// reportSignatures are the boxes of the signatories printed under the monitoring reports
+func (a ReportService) reportSignatures(brand *reportBrand) ([]signatureBox, error) {
+	var boxes []signatureBox
+	for _, signature := range brand.signatures {
+		if !signature.Reports {
+			continue
+		}
+
+		image, err := a.image(signature.ImageKey)
+		if err != nil {
+			return nil, err
+		}
+		boxes = append(boxes, signatureBox{title: signature.Title, name: signature.Name, image: image})
+	}
+
+	return boxes, nil
+}
+
+// signatureOf returns the signature image of the role of a user, nil when none of the roles has one
+func (a ReportService) signatureOf(brand *reportBrand, username string) (*reportImage, error) {
+	if username == "" || len(brand.signatures) == 0 {
+		return nil, nil
+	}
+
+	user, err := a.userRepository.GetByUsername(username)
+	if err == errors.DatabaseRecordNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	roles, err := a.userroleRepository.Query(&models.UserRoleQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		UserID:          user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signature := brand.signatures.ByRole(roles.List.ToRoleIDs())
+	if signature == nil {
+		return nil, nil
+	}
+
+	return a.image(signature.ImageKey)
+}
+
+func (a ReportService) image(key string) (*reportImage, error) {
+	content, err := a.storage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &reportImage{base64: base64.StdEncoding.EncodeToString(data), extension: consts.Jpg}
+	if http.DetectContentType(data) == "image/png" {
+		image.extension = consts.Png
+	}
+
+	return image, nil
+}
+
+// registerHeader prints the letterhead on the left and the print lines on the right of every page
+func registerHeader(m pdf.Maroto, brand *reportBrand, lines ...string) {
+	darkGrayColor := getDarkGrayColor()
+
+	m.RegisterHeader(func() {
+		m.Row(20, func() {
+			// the logo takes two columns and the name one more
+			width, space := uint(3), uint(6)
+			if brand.logo != nil {
+				m.Col(2, func() {
+					_ = m.Base64Image(brand.logo.base64, brand.logo.extension, props.Rect{
+						Percent: 90,
+					})
+				})
+				width, space = 4, 3
+			}
+
+			m.Col(width, func() {
+				m.Text(brand.name, props.Text{
+					Size:        8,
+					Style:       consts.Bold,
+					Align:       consts.Left,
+					Extrapolate: false,
+					Color:       darkGrayColor,
+				})
+				m.Text(brand.address, props.Text{
+					Top:   4,
+					Size:  7,
+					Align: consts.Left,
+					Color: darkGrayColor,
+				})
+			})
+
+			m.ColSpace(space)
+
+			m.Col(3, func() {
+				for i, line := range lines {
+					m.Text(line, props.Text{
+						Top:   float64(i * 3),
+						Style: consts.BoldItalic,
+						Size:  8,
+						Align: consts.Right,
+						Color: darkGrayColor,
+					})
+				}
+			})
+		})
+	})
+}
+
+// signatureBoxes prints four boxes a row, the signature image sits between the title and the name
+func signatureBoxes(m pdf.Maroto, boxes []signatureBox) {
+	for i := 0; i < len(boxes); i += 4 {
+		end := i + 4
+		if end > len(boxes) {
+			end = len(boxes)
+		}
+		row := boxes[i:end]
+
+		m.Row(8, func() {
+			for _, box := range row {
+				title := box.title
+				m.Col(3, func() {
+					m.Text(title, props.Text{
+						Size:  8,
+						Align: consts.Center,
+					})
+				})
+			}
+		})
+
+		m.Row(20, func() {
+			for _, box := range row {
+				image := box.image
+				m.Col(3, func() {
+					if image != nil {
+						_ = m.Base64Image(image.base64, image.extension, props.Rect{
+							Center:  true,
+							Percent: 90,
+						})
+					}
+				})
+			}
+		})
+
+		m.Row(8, func() {
+			for _, box := range row {
+				name := box.name
+				m.Col(3, func() {
+					m.Text("( "+name+" )", props.Text{
+						Size:  8,
+						Align: consts.Center,
+					})
+				})
+			}
+		})
+	}
 }
 
 func getDarkGrayColor() color.Color {
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/mailer"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+	"github.com/Aguztinus/petty-cash-backend/pkg/xlsx"
+)
+
+// reportTitles are the titles of the reports that can be subscribed to, as printed on them
+var reportTitles = map[string]string{
+	models.ReportJobLmdp:         "Laporan Monitoring Dana Petty Cash",
+	models.ReportJobLrdp:         "Laporan Reimbursement Dana Petty Cash",
+	models.ReportExpenseAnalysis: "Laporan Analisa Biaya Petty Cash",
+	models.ReportKasbonAging:     "Laporan Umur Kasbon",
+}
+
+// ReportSubscriptionService emails the subscribed reports on their schedule
+type ReportSubscriptionService struct {
+	logger                       lib.Logger
+	config                       lib.Config
+	mailer                       lib.Mailer
+	reportService                ReportService
+	userRepository               repository.UserRepository
+	reportsubscriptionRepository repository.ReportSubscriptionRepository
+}
+
+// NewReportSubscriptionService creates a new reportsubscriptionservice
+func NewReportSubscriptionService(
+	logger lib.Logger,
+	config lib.Config,
+	mailer lib.Mailer,
+	reportService ReportService,
+	userRepository repository.UserRepository,
+	reportsubscriptionRepository repository.ReportSubscriptionRepository,
+) ReportSubscriptionService {
+	return ReportSubscriptionService{
+		logger:                       logger,
+		config:                       config,
+		mailer:                       mailer,
+		reportService:                reportService,
+		userRepository:               userRepository,
+		reportsubscriptionRepository: reportsubscriptionRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a ReportSubscriptionService) WithTrx(trxHandle *gorm.DB) ReportSubscriptionService {
+	a.reportsubscriptionRepository = a.reportsubscriptionRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+// authorize tells if the user is the superadmin and rejects a company or branch the user may not see
+func (a ReportSubscriptionService) authorize(userID string, companyID string, branchID string) (bool, error) {
+	user, err := a.userRepository.Get(userID)
+	if err != nil {
+		return false, err
+	} else if user.Username == a.config.SuperAdmin.Username {
+		return true, nil
+	}
+
+	if (user.CompanyID != "" && user.CompanyID != companyID) || (user.BranchID != "" && user.BranchID != branchID) {
+		return false, errors.ReportSubscriptionAccessForbidden
+	}
+
+	return false, nil
+}
+
+// check validates the subscription and normalizes its format and recipients
+func (a ReportSubscriptionService) check(subscription *models.ReportSubscription, userID string) error {
+	if _, ok := reportTitles[subscription.Report]; !ok {
+		return errors.ReportSubscriptionReportInvalid
+	}
+
+	if subscription.Format == "" {
+		subscription.Format = models.ReportFormatPdf
+	} else if subscription.Format != models.ReportFormatPdf && subscription.Format != models.ReportFormatXlsx {
+		return errors.ReportSubscriptionFormatInvalid
+	}
+
+	if (subscription.Report == models.ReportJobLmdp || subscription.Report == models.ReportJobLrdp) &&
+		(subscription.CompanyID == "" || subscription.BranchID == "") {
+		return errors.ReportSubscriptionBranchRequired
+	}
+
+	if err := subscription.Schedule().Validate(); err != nil {
+		return errors.ReportSubscriptionScheduleInvalid
+	}
+
+	recipients := subscription.RecipientList()
+	if len(recipients) == 0 {
+		return errors.ReportSubscriptionRecipientInvalid
+	}
+	for i, recipient := range recipients {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return errors.ReportSubscriptionRecipientInvalid
+		}
+		recipients[i] = address.Address
+	}
+	subscription.Recipients = strings.Join(recipients, ", ")
+
+	if err := a.checkRecipients(subscription); err != nil {
+		return err
+	}
+
+	_, err := a.authorize(userID, subscription.CompanyID, subscription.BranchID)
+	return err
+}
+
+// checkRecipients allows the enabled users of the company of the subscription, of any company for a
+// subscription over all companies, and the addresses in Report.RecipientDomains
+func (a ReportSubscriptionService) checkRecipients(subscription *models.ReportSubscription) error {
+	recipients := subscription.RecipientList()
+	users, err := a.userRepository.GetByEmails(recipients)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool)
+	for _, user := range users {
+		if user.Status == 1 && (subscription.CompanyID == "" || user.CompanyID == "" || user.CompanyID == subscription.CompanyID) {
+			allowed[strings.ToLower(user.Email)] = true
+		}
+	}
+
+	for _, recipient := range recipients {
+		recipient = strings.ToLower(recipient)
+		if allowed[recipient] {
+			continue
+		}
+
+		domain := recipient[strings.LastIndex(recipient, "@")+1:]
+		found := false
+		for _, v := range a.config.Report.RecipientDomains {
+			if strings.ToLower(v) == domain {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Wrapf(errors.ReportSubscriptionRecipientDenied, "%s", recipient)
+		}
+	}
+
+	return nil
+}
+
+// checkOwner refuses to run the subscription of a disabled owner or of an owner that lost access to
+// its company or branch
+func (a ReportSubscriptionService) checkOwner(subscription *models.ReportSubscription) error {
+	owner, err := a.userRepository.Get(subscription.OwnerID)
+	if err != nil {
+		return err
+	} else if owner.Status != 1 && owner.Username != a.config.SuperAdmin.Username {
+		return errors.ReportSubscriptionOwnerDisabled
+	}
+
+	_, err = a.authorize(subscription.OwnerID, subscription.CompanyID, subscription.BranchID)
+	return err
+}
+
+// Query lists the subscriptions of the user, the superadmin sees all
+func (a ReportSubscriptionService) Query(param *models.ReportSubscriptionQueryParam, userID string) (*models.ReportSubscriptionQueryResult, error) {
+	user, err := a.userRepository.Get(userID)
+	if err != nil {
+		return nil, err
+	} else if user.Username != a.config.SuperAdmin.Username {
+		param.OwnerID = userID
+	}
+
+	return a.reportsubscriptionRepository.Query(param)
+}
+
+// Get returns the subscription to its owner or the superadmin
+func (a ReportSubscriptionService) Get(id string, userID string) (*models.ReportSubscription, error) {
+	subscription, err := a.reportsubscriptionRepository.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.OwnerID != userID {
+		user, err := a.userRepository.Get(userID)
+		if err != nil {
+			return nil, err
+		} else if user.Username != a.config.SuperAdmin.Username {
+			return nil, errors.ReportSubscriptionAccessForbidden
+		}
+	}
+
+	return subscription, nil
+}
+
+func (a ReportSubscriptionService) Create(subscription *models.ReportSubscription, userID string) (*models.ReportSubscription, error) {
+	if err := a.check(subscription, userID); err != nil {
+		return nil, err
+	}
+
+	subscription.ID = uuid.MustString()
+	subscription.OwnerID = userID
+	subscription.NextRunAt = database.Datetime(sql.NullTime{Time: subscription.Schedule().Next(time.Now()), Valid: true})
+	if err := a.reportsubscriptionRepository.Create(subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// Update changes the subscription, the next run follows the new schedule
+func (a ReportSubscriptionService) Update(id string, subscription *models.ReportSubscription, userID string) error {
+	if _, err := a.Get(id, userID); err != nil {
+		return err
+	}
+
+	if err := a.check(subscription, userID); err != nil {
+		return err
+	}
+
+	subscription.NextRunAt = database.Datetime(sql.NullTime{Time: subscription.Schedule().Next(time.Now()), Valid: true})
+	return a.reportsubscriptionRepository.Update(id, subscription)
+}
+
+func (a ReportSubscriptionService) Delete(id string, userID string) error {
+	if _, err := a.Get(id, userID); err != nil {
+		return err
+	}
+
+	return a.reportsubscriptionRepository.Delete(id)
+}
+
+// Send emails the report now, covering the days since the run before now, to check a subscription
+func (a ReportSubscriptionService) Send(id string, userID string) error {
+	subscription, err := a.Get(id, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = a.deliver(subscription, now)
+	a.recordRun(subscription, now, err)
+
+	return err
+}
+
+// Start sends the due subscriptions every minute until ctx is done
+func (a ReportSubscriptionService) Start(ctx context.Context) {
+	if err := os.MkdirAll(a.config.Report.Directory, 0755); err != nil {
+		a.logger.Zap.Errorf("Error to create report directory: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.runDue(now)
+			}
+		}
+	}()
+}
+
+// runDue sends every due subscription once, runs missed while the server was down are not repeated
+func (a ReportSubscriptionService) runDue(now time.Time) {
+	list, err := a.reportsubscriptionRepository.GetDue(database.Datetime(sql.NullTime{Time: now, Valid: true}))
+	if err != nil {
+		a.logger.Zap.Errorf("Error to list due report subscriptions: %v", err)
+		return
+	}
+
+	for _, subscription := range list {
+		next := database.Datetime(sql.NullTime{Time: subscription.Schedule().Next(now), Valid: true})
+		claimed, err := a.reportsubscriptionRepository.Claim(subscription.ID, subscription.NextRunAt, next)
+		if err != nil {
+			a.logger.Zap.Errorf("Error to claim report subscription %s: %v", subscription.ID, err)
+			continue
+		} else if !claimed {
+			continue
+		}
+
+		err = a.deliver(subscription, subscription.NextRunAt.Time)
+		a.recordRun(subscription, now, err)
+	}
+}
+
+func (a ReportSubscriptionService) recordRun(subscription *models.ReportSubscription, now time.Time, err error) {
+	lastError := ""
+	if err != nil {
+		a.logger.Zap.Errorf("Error to send report subscription %s: %v", subscription.ID, err)
+		lastError = err.Error()
+		if len(lastError) > 500 {
+			lastError = lastError[:500]
+		}
+	}
+
+	if err = a.reportsubscriptionRepository.UpdateRun(subscription.ID, database.Datetime(sql.NullTime{Time: now, Valid: true}), lastError); err != nil {
+		a.logger.Zap.Errorf("Error to save report subscription %s run: %v", subscription.ID, err)
+	}
+}
+
+// deliver generates the report of the whole days from the run before run up to run and emails it,
+// a panic fails the run instead of the server
+func (a ReportSubscriptionService) deliver(subscription *models.ReportSubscription, run time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("report subscription panic: %v", r)
+		}
+	}()
+
+	// the owner or the recipients may have lost access since the subscription was saved
+	if err = a.checkOwner(subscription); err != nil {
+		return err
+	} else if err = a.checkRecipients(subscription); err != nil {
+		return err
+	}
+
+	run = run.In(time.Local)
+	y, m, d := subscription.Schedule().Previous(run).Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	y, m, d = run.Date()
+	to := time.Date(y, m, d, 0, 0, 0, 0, time.Local).Add(-time.Second)
+
+	data, err := a.generate(subscription, from, to)
+	if err != nil {
+		return err
+	}
+
+	contentType := "application/pdf"
+	if subscription.Format == models.ReportFormatXlsx {
+		contentType = xlsx.ContentType
+	}
+
+	title := reportTitles[subscription.Report]
+	period := from.Format(layoutDetail) + " s/d " + to.Format(layoutDetail)
+
+	return a.mailer.Send(&mailer.Message{
+		To:      subscription.RecipientList(),
+		Subject: subscription.Name + " - " + title + " " + period,
+		Body: title + " periode " + period + " terlampir.\r\n\r\n" +
+			"Email ini dikirim otomatis oleh jadwal laporan \"" + subscription.Name + "\".\r\n",
+		Attachments: []mailer.Attachment{{
+			Name:        subscription.Report + "-" + from.Format("20060102") + "-" + to.Format("20060102") + "." + subscription.Format,
+			ContentType: contentType,
+			Data:        data,
+		}},
+	})
+}
+
+// generate returns the report, the monitoring reports are written to a file of their own in the
+// report directory that is removed once read
+func (a ReportSubscriptionService) generate(subscription *models.ReportSubscription, from time.Time, to time.Time) ([]byte, error) {
+	spreadsheet := subscription.Format == models.ReportFormatXlsx
+	monitoring := dto.ReportMonitoring{
+		DateParams: []string{from.Format(time.RFC3339), to.Format(time.RFC3339)},
+		CompanyID:  subscription.CompanyID,
+		BranchID:   subscription.BranchID,
+		UserId:     subscription.CreatedBy,
+	}
+
+	switch subscription.Report {
+	case models.ReportJobLmdp, models.ReportJobLrdp:
+		file, err := ioutil.TempFile(a.config.Report.Directory, "subscription-"+subscription.ID+"-*."+subscription.Format)
+		if err != nil {
+			return nil, err
+		}
+		file.Close()
+		defer os.Remove(file.Name())
+
+		switch {
+		case subscription.Report == models.ReportJobLmdp && spreadsheet:
+			err = a.reportService.GenerateLmdpXlsx(&monitoring, file.Name())
+		case subscription.Report == models.ReportJobLmdp:
+			err = a.reportService.GenerateLmdp(&monitoring, file.Name())
+		case spreadsheet:
+			err = a.reportService.GenerateLrdpXlsx(&monitoring, file.Name())
+		default:
+			err = a.reportService.GenerateLrdp(&monitoring, file.Name())
+		}
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadFile(file.Name())
+	case models.ReportExpenseAnalysis:
+		param := &models.ExpenseAnalysisParam{ReportMonitoring: monitoring}
+		if spreadsheet {
+			return a.reportService.GenerateExpenseAnalysisXlsx(param)
+		}
+		return a.reportService.GenerateExpenseAnalysis(param, subscription.CreatedBy)
+	case models.ReportKasbonAging:
+		param := &models.KasbonAgingParam{
+			CompanyID: subscription.CompanyID,
+			BranchID:  subscription.BranchID,
+			Date:      to.Format("2006-01-02"),
+		}
+		if spreadsheet {
+			return a.reportService.GenerateKasbonAgingXlsx(param)
+		}
+		return a.reportService.GenerateKasbonAging(param, subscription.CreatedBy)
+	default:
+		return nil, errors.ReportSubscriptionReportInvalid
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+)
+
+// SaldoReconService recomputes Saldo and SaldoMonth from SaldoHistory
+type SaldoReconService struct {
+	logger                 lib.Logger
+	saldoRepository        repository.SaldoRepository
+	saldomonthRepository   repository.SaldoMonthRepository
+	saldohistoryRepository repository.SaldoHistoryRepository
+	bkkheaderRepository    repository.BKKHeaderRepository
+}
+
+// NewSaldoReconService creates a new saldoreconservice
+func NewSaldoReconService(
+	logger lib.Logger,
+	saldoRepository repository.SaldoRepository,
+	saldomonthRepository repository.SaldoMonthRepository,
+	saldohistoryRepository repository.SaldoHistoryRepository,
+	bkkheaderRepository repository.BKKHeaderRepository,
+) SaldoReconService {
+	return SaldoReconService{
+		logger:                 logger,
+		saldoRepository:        saldoRepository,
+		saldomonthRepository:   saldomonthRepository,
+		saldohistoryRepository: saldohistoryRepository,
+		bkkheaderRepository:    bkkheaderRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a SaldoReconService) WithTrx(trxHandle *gorm.DB) SaldoReconService {
+	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
+	a.saldomonthRepository = a.saldomonthRepository.WithTrx(trxHandle)
+	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
+	a.bkkheaderRepository = a.bkkheaderRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+// Reconcile reports the drift of every company/branch, with param.Fix the derived rows are
+// rewritten as well, so callers must run it inside a single transaction
+func (a SaldoReconService) Reconcile(param *models.SaldoReconParam) (*models.SaldoReconResult, error) {
+	saldos, err := a.saldoRepository.GetAll(param.CompanyID, param.BranchID)
+	if err != nil {
+		return nil, err
+	} else if len(saldos) == 0 {
+		return nil, errors.SaldoRecordNotFound
+	}
+
+	result := &models.SaldoReconResult{
+		Branches: len(saldos),
+		Fixed:    param.Fix,
+		Lines:    make(models.SaldoReconLines, 0),
+	}
+
+	for _, saldo := range saldos {
+		lines, err := a.reconcileBranch(saldo, param.Fix)
+		if err != nil {
+			return nil, err
+		}
+		result.Lines = append(result.Lines, lines...)
+	}
+
+	return result, nil
+}
+
+type reconPeriod struct {
+	in  int64
+	out int64
+}
+
+// reconcileBranch replays SaldoHistory from Saldo.SaldoAwal, months that are closed are
+// reported but never rewritten, they have to be reopened first
+func (a SaldoReconService) reconcileBranch(saldo *models.Saldo, fix bool) (models.SaldoReconLines, error) {
+	histories, err := a.saldohistoryRepository.GetAllByCompanyAndBranch(saldo.CompanyID, saldo.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	bkks, err := a.bkkheaderRepository.GetPostings(saldo.CompanyID, saldo.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	bkkMap := make(map[string]*models.BKKHeader)
+	for _, item := range bkks {
+		bkkMap[item.Num] = item
+	}
+
+	months, err := a.saldomonthRepository.GetAllByCompanyAndBranch(saldo.CompanyID, saldo.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	monthMap := make(map[string]*models.SaldoMonth)
+	periods := make(map[string]*reconPeriod)
+	for _, item := range months {
+		monthMap[item.MonthYear] = item
+		periods[item.MonthYear] = new(reconPeriod)
+	}
+
+	closed := func(monthYear string) bool {
+		month, ok := monthMap[monthYear]
+		return ok && month.ClosedFlag
+	}
+
+	lines := make(models.SaldoReconLines, 0)
+	running := saldo.SaldoAwal
+	var totalIn, totalOut int64 = 0, 0
+
+	for _, his := range histories {
+		monthYear, ok := historyMonth(his, bkkMap)
+		if !ok {
+			continue
+		}
+		if _, ok := periods[monthYear]; !ok {
+			periods[monthYear] = new(reconPeriod)
+		}
+		periods[monthYear].in += his.InAmount
+		periods[monthYear].out += his.OutAmount
+		totalIn += his.InAmount
+		totalOut += his.OutAmount
+
+		saldoAwal := running
+		running = running + his.InAmount - his.OutAmount
+
+		base := newReconLine(saldo, models.SaldoReconTableHistory, his.RecordID, monthYear, closed(monthYear))
+		drift := lines.Append(base, "saldo_awal", his.SaldoAwal, saldoAwal)
+		drift = lines.Append(base, "saldo_akhir", his.SaldoAkhir, running) || drift
+
+		if fix && drift && !base.Skipped {
+			if err = a.saldohistoryRepository.UpdateBalance(his.RecordID, saldoAwal, running); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	monthYears := make([]string, 0, len(periods))
+	for monthYear := range periods {
+		monthYears = append(monthYears, monthYear)
+	}
+	sort.Strings(monthYears)
+
+	opening := saldo.SaldoAwal
+	for _, monthYear := range monthYears {
+		period := periods[monthYear]
+		computed := &models.SaldoMonth{
+			SaldoAwal:  opening,
+			SaldoIn:    period.in,
+			UsedBKK:    period.out,
+			SaldoAkhir: opening + period.in - period.out,
+		}
+		opening = computed.SaldoAkhir
+
+		stored, ok := monthMap[monthYear]
+		if !ok {
+			stored = new(models.SaldoMonth)
+		}
+
+		base := newReconLine(saldo, models.SaldoReconTableMonth, stored.ID, monthYear, stored.ClosedFlag)
+		drift := lines.Append(base, "saldo_awal", stored.SaldoAwal, computed.SaldoAwal)
+		drift = lines.Append(base, "saldo_in", stored.SaldoIn, computed.SaldoIn) || drift
+		drift = lines.Append(base, "used_bkk", stored.UsedBKK, computed.UsedBKK) || drift
+		drift = lines.Append(base, "saldo_akhir", stored.SaldoAkhir, computed.SaldoAkhir) || drift
+
+		if !fix || !drift || base.Skipped {
+			continue
+		}
+
+		if !ok {
+			if err = a.createMonth(saldo, monthYear, computed); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err = a.saldomonthRepository.UpdateBalance(stored.ID, computed); err != nil {
+			return nil, err
+		}
+	}
+
+	computed := &models.Saldo{
+		SaldoIn:    totalIn,
+		UsedBKK:    totalOut,
+		SaldoAkhir: saldo.SaldoAwal + totalIn - totalOut,
+	}
+
+	base := newReconLine(saldo, models.SaldoReconTableSaldo, saldo.ID, saldo.MonthYear, false)
+	drift := lines.Append(base, "saldo_in", saldo.SaldoIn, computed.SaldoIn)
+	drift = lines.Append(base, "used_bkk", saldo.UsedBKK, computed.UsedBKK) || drift
+	drift = lines.Append(base, "saldo_akhir", saldo.SaldoAkhir, computed.SaldoAkhir) || drift
+
+	if fix && drift {
+		if err = a.saldoRepository.UpdateBalance(saldo.ID, computed); err != nil {
+			return nil, err
+		}
+	}
+
+	return lines, nil
+}
+
+// historyMonth is the month a history row moved Saldo in. BKKs created before the history moved to Pay
+// wrote their out row at creation, those rows count in the month of the payment, and not at all, together
+// with their "Batal"/"Void" offsets, when the BKK was never paid
+func historyMonth(his *models.SaldoHistory, bkks map[string]*models.BKKHeader) (string, bool) {
+	num := his.Desc
+	for _, prefix := range []string{"Batal ", "Void "} {
+		if strings.HasPrefix(num, prefix) {
+			num = strings.TrimPrefix(num, prefix)
+			break
+		}
+	}
+
+	bkk, ok := bkks[num]
+	if !ok {
+		return his.CreatedAt.Time.Format("2006-01"), true
+	} else if !bkk.IsPaid() {
+		return "", false
+	} else if num == his.Desc && bkk.PaidDate.Valid {
+		return bkk.PaidDate.Time.Format("2006-01"), true
+	}
+
+	return his.CreatedAt.Time.Format("2006-01"), true
+}
+
+func (a SaldoReconService) createMonth(saldo *models.Saldo, monthYear string, computed *models.SaldoMonth) error {
+	var year, month int
+	if _, err := fmt.Sscanf(monthYear, "%d-%d", &year, &month); err != nil {
+		return err
+	}
+
+	computed.ID = uuid.MustString()
+	computed.CompanyID = saldo.CompanyID
+	computed.BranchID = saldo.BranchID
+	computed.MonthYear = monthYear
+	computed.Month = month
+	computed.Year = year
+	computed.CreatedBy = saldo.CreatedBy
+
+	return a.saldomonthRepository.Create(computed)
+}
+
+func newReconLine(saldo *models.Saldo, table string, refID interface{}, monthYear string, skipped bool) models.SaldoReconLine {
+	return models.SaldoReconLine{
+		CompanyID: saldo.CompanyID,
+		BranchID:  saldo.BranchID,
+		Table:     table,
+		RefID:     fmt.Sprint(refID),
+		MonthYear: monthYear,
+		Skipped:   skipped,
+	}
+}
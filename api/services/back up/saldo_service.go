@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -10,42 +11,58 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
 	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
 	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
 )
 
 // SaldoService service layer
 type SaldoService struct {
-	logger                 lib.Logger
-	casbinService          CasbinService
-	userRepository         repository.UserRepository
-	saldoRepository        repository.SaldoRepository
-	saldomonthRepository   repository.SaldoMonthRepository
-	saldohistoryRepository repository.SaldoHistoryRepository
-	menuRepository         repository.MenuRepository
-	menuActionRepository   repository.MenuActionRepository
+	logger                  lib.Logger
+	casbinService           CasbinService
+	journalService          JournalService
+	replenishmentService    ReplenishmentService
+	notificationService     NotificationService
+	webhookService          WebhookService
+	userRepository          repository.UserRepository
+	saldoRepository         repository.SaldoRepository
+	saldomonthRepository    repository.SaldoMonthRepository
+	saldohistoryRepository  repository.SaldoHistoryRepository
+	saldomonthlogRepository repository.SaldoMonthLogRepository
+	menuRepository          repository.MenuRepository
+	menuActionRepository    repository.MenuActionRepository
 }
 
 // NewSaldoService creates a new saldoservice
 func NewSaldoService(
 	logger lib.Logger,
 	casbinService CasbinService,
+	journalService JournalService,
+	replenishmentService ReplenishmentService,
+	notificationService NotificationService,
+	webhookService WebhookService,
 	userRepository repository.UserRepository,
 	saldoRepository repository.SaldoRepository,
 	saldomonthRepository repository.SaldoMonthRepository,
 	saldohistoryRepository repository.SaldoHistoryRepository,
+	saldomonthlogRepository repository.SaldoMonthLogRepository,
 	menuRepository repository.MenuRepository,
 	menuActionRepository repository.MenuActionRepository,
 ) SaldoService {
 	return SaldoService{
-		logger:                 logger,
-		casbinService:          casbinService,
-		userRepository:         userRepository,
-		saldoRepository:        saldoRepository,
-		saldomonthRepository:   saldomonthRepository,
-		saldohistoryRepository: saldohistoryRepository,
-		menuRepository:         menuRepository,
-		menuActionRepository:   menuActionRepository,
+		logger:                  logger,
+		casbinService:           casbinService,
+		journalService:          journalService,
+		replenishmentService:    replenishmentService,
+		notificationService:     notificationService,
+		webhookService:          webhookService,
+		userRepository:          userRepository,
+		saldoRepository:         saldoRepository,
+		saldomonthRepository:    saldomonthRepository,
+		saldohistoryRepository:  saldohistoryRepository,
+		saldomonthlogRepository: saldomonthlogRepository,
+		menuRepository:          menuRepository,
+		menuActionRepository:    menuActionRepository,
 	}
 }
 
@@ -53,7 +70,13 @@ func NewSaldoService(
 func (a SaldoService) WithTrx(trxHandle *gorm.DB) SaldoService {
 	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
 	a.saldomonthRepository = a.saldomonthRepository.WithTrx(trxHandle)
+	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
+	a.saldomonthlogRepository = a.saldomonthlogRepository.WithTrx(trxHandle)
 	a.userRepository = a.userRepository.WithTrx(trxHandle)
+	a.journalService = a.journalService.WithTrx(trxHandle)
+	a.replenishmentService = a.replenishmentService.WithTrx(trxHandle)
+	a.notificationService = a.notificationService.WithTrx(trxHandle)
+	a.webhookService = a.webhookService.WithTrx(trxHandle)
 
 	return a
 }
@@ -220,8 +243,38 @@ func (a SaldoService) CreateAndMonth(saldo *models.Saldo) (id string, err error)
 	return saldo.ID, nil
 }
 
-func (a SaldoService) CreateNewSaldoOrUpdate(companyID string, branchID string, totalOut int64, totalIn int64) (saldoNow int64, err error) {
+// CreateNewSaldoOrUpdate posts a cash movement, the journal must be balanced and its cash lines
+// must equal totalOut/totalIn, so no saldo posting can exist without its journal
+func (a SaldoService) CreateNewSaldoOrUpdate(companyID string, branchID string, totalOut int64, totalIn int64, journal *models.JournalHeader) (saldoNow int64, err error) {
 	now := time.Now()
+	if err = a.CheckPeriodOpen(companyID, branchID, now); err != nil {
+		return 0, err
+	}
+
+	if err = a.journalService.Post(journal, totalOut, totalIn); err != nil {
+		return 0, err
+	}
+
+	return a.apply(companyID, branchID, now, totalOut, totalIn)
+}
+
+// Reverse takes back a cash movement posted by CreateNewSaldoOrUpdate on the current date, totalOut/totalIn
+// are the amounts of the original posting and the journal is its reversal, so its cash lines are swapped
+func (a SaldoService) Reverse(companyID string, branchID string, totalOut int64, totalIn int64, journal *models.JournalHeader) (saldoNow int64, err error) {
+	now := time.Now()
+	if err = a.CheckPeriodOpen(companyID, branchID, now); err != nil {
+		return 0, err
+	}
+
+	if err = a.journalService.Post(journal, totalIn, totalOut); err != nil {
+		return 0, err
+	}
+
+	return a.apply(companyID, branchID, now, -totalOut, -totalIn)
+}
+
+// apply moves Saldo and the SaldoMonth of now, negative amounts take back an earlier movement
+func (a SaldoService) apply(companyID string, branchID string, now time.Time, totalOut int64, totalIn int64) (saldoNow int64, err error) {
 	prev := now.AddDate(0, -1, 0)
 	prevMonthYear := prev.Format("2006-01")
 	nowMonthYear := now.Format("2006-01")
@@ -244,6 +297,16 @@ func (a SaldoService) CreateNewSaldoOrUpdate(companyID string, branchID string,
 		return 0, err
 	}
 
+	if totalOut > 0 {
+		if err = a.replenishmentService.CheckThreshold(saldo); err != nil {
+			return 0, err
+		}
+
+		if err = a.notifyLow(saldo, saldoAkhir+totalOut-totalIn); err != nil {
+			return 0, err
+		}
+	}
+
 	saldomonthnow, err := a.saldomonthRepository.GetbyCompanyAndBranchMonth(companyID, branchID, nowMonthYear)
 	if err == errors.DatabaseRecordNotFound {
 		saldoprev, errs := a.saldomonthRepository.GetbyCompanyAndBranchMonth(companyID, branchID, prevMonthYear)
@@ -281,6 +344,172 @@ func (a SaldoService) CreateNewSaldoOrUpdate(companyID string, branchID string,
 	return saldoAkhir, nil
 }
 
+// UpdateUsedKBS moves the outstanding kasbon of a branch, a release must stay within Saldo.LimitKBS
+func (a SaldoService) UpdateUsedKBS(companyID string, branchID string, amount int64) error {
+	saldo, err := a.saldoRepository.GetbyCompanyAndBranch(companyID, branchID)
+	if err != nil {
+		return err
+	}
+
+	usedKBS := saldo.UsedKBS + amount
+	if amount > 0 && usedKBS > saldo.LimitKBS {
+		return errors.KasbonLimitExceeded
+	} else if usedKBS < 0 {
+		usedKBS = 0
+	}
+
+	return a.saldoRepository.UpdateUsedKBS(saldo.ID, usedKBS)
+}
+
+func (a SaldoService) QueryMonth(param *models.SaldoMonthQueryParam) (*models.SaldoMonthQueryResult, error) {
+	return a.saldomonthRepository.Query(param)
+}
+
+func (a SaldoService) QueryMonthLog(param *models.SaldoMonthLogQueryParam) (*models.SaldoMonthLogQueryResult, error) {
+	return a.saldomonthlogRepository.Query(param)
+}
+
+// CheckPeriodOpen refuses postings dated in, or before, the last closed month
+func (a SaldoService) CheckPeriodOpen(companyID string, branchID string, date time.Time) error {
+	last, err := a.saldomonthRepository.GetLastClosed(companyID, branchID)
+	if err == errors.DatabaseRecordNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if date.Format("2006-01") <= last.MonthYear {
+		return errors.SaldoPeriodClosed
+	}
+
+	return nil
+}
+
+// postingDate is the document date, or today when the document has none
+func postingDate(date database.Datetime) time.Time {
+	if date.Valid {
+		return date.Time
+	}
+
+	return time.Now()
+}
+
+// ClosePeriod freezes the month and carries its SaldoAkhir into the opening of the next month, months are
+// closed in order so a closed month never has its opening rewritten
+func (a SaldoService) ClosePeriod(param *models.SaldoMonthCloseParam, username string, ip string) error {
+	saldomonth, err := a.saldomonthRepository.GetbyCompanyAndBranchMonth(param.CompanyID, param.BranchID, param.MonthYear)
+	if err != nil {
+		return err
+	} else if saldomonth.ClosedFlag {
+		return errors.SaldoPeriodAlreadyClosed
+	}
+
+	period := time.Date(saldomonth.Year, time.Month(saldomonth.Month), 1, 0, 0, 0, 0, time.Local)
+	prevMonthYear := period.AddDate(0, -1, 0).Format("2006-01")
+
+	// the first month of a branch has no previous month to close
+	saldoprev, err := a.saldomonthRepository.GetbyCompanyAndBranchMonth(param.CompanyID, param.BranchID, prevMonthYear)
+	if err == nil && !saldoprev.ClosedFlag {
+		return errors.SaldoPeriodPrevOpen
+	} else if err != nil && err != errors.DatabaseRecordNotFound {
+		return err
+	}
+
+	next := period.AddDate(0, 1, 0)
+	nextMonthYear := next.Format("2006-01")
+
+	saldonext, err := a.saldomonthRepository.GetbyCompanyAndBranchMonth(param.CompanyID, param.BranchID, nextMonthYear)
+	if err == nil && saldonext.ClosedFlag {
+		return errors.SaldoPeriodNextClosed
+	} else if err != nil && err != errors.DatabaseRecordNotFound {
+		return err
+	}
+
+	now := time.Now()
+	saldomonth.ClosedFlag = true
+	saldomonth.ClosedDate = database.Datetime(sql.NullTime{Time: now, Valid: true})
+	saldomonth.ClosedBy = username
+
+	if err = a.saldomonthRepository.UpdateClosed(saldomonth.ID, saldomonth); err != nil {
+		return err
+	}
+
+	if saldonext == nil {
+		saldonext = new(models.SaldoMonth)
+		saldonext.ID = uuid.MustString()
+		saldonext.CompanyID = saldomonth.CompanyID
+		saldonext.BranchID = saldomonth.BranchID
+		saldonext.MonthYear = nextMonthYear
+		saldonext.Month = int(next.Month())
+		saldonext.Year = next.Year()
+		saldonext.SaldoAwal = saldomonth.SaldoAkhir
+		saldonext.SaldoAkhir = saldomonth.SaldoAkhir
+		saldonext.CreatedBy = username
+
+		if err = a.saldomonthRepository.Create(saldonext); err != nil {
+			return err
+		}
+	} else {
+		saldonext.SaldoAwal = saldomonth.SaldoAkhir
+		saldonext.SaldoAkhir = saldomonth.SaldoAkhir + saldonext.SaldoIn - saldonext.UsedBKK
+		saldonext.UpdateBy = username
+
+		if err = a.saldomonthRepository.Update(saldonext.ID, saldonext); err != nil {
+			return err
+		}
+	}
+
+	return a.createMonthLog(saldomonth, models.SaldoMonthActionClose, param.Reason, username, ip)
+}
+
+// ReopenPeriod is the privileged counterpart of ClosePeriod, a reason is always recorded
+func (a SaldoService) ReopenPeriod(id string, reason string, username string, ip string) error {
+	if reason == "" {
+		return errors.SaldoPeriodReasonEmpty
+	}
+
+	saldomonth, err := a.saldomonthRepository.Get(id)
+	if err != nil {
+		return err
+	} else if !saldomonth.ClosedFlag {
+		return errors.SaldoPeriodNotClosed
+	}
+
+	last, err := a.saldomonthRepository.GetLastClosed(saldomonth.CompanyID, saldomonth.BranchID)
+	if err != nil {
+		return err
+	} else if last.MonthYear > saldomonth.MonthYear {
+		return errors.SaldoPeriodNextClosed
+	}
+
+	saldomonth.ClosedFlag = false
+	saldomonth.ClosedDate = database.Datetime{}
+	saldomonth.ClosedBy = ""
+
+	if err = a.saldomonthRepository.UpdateClosed(saldomonth.ID, saldomonth); err != nil {
+		return err
+	}
+
+	return a.createMonthLog(saldomonth, models.SaldoMonthActionReopen, reason, username, ip)
+}
+
+func (a SaldoService) createMonthLog(saldomonth *models.SaldoMonth, action string, reason string, username string, ip string) error {
+	log := new(models.SaldoMonthLog)
+	log.ID = uuid.MustString()
+	log.SaldoMonthID = saldomonth.ID
+	log.CompanyID = saldomonth.CompanyID
+	log.BranchID = saldomonth.BranchID
+	log.MonthYear = saldomonth.MonthYear
+	log.Action = action
+	log.Reason = reason
+	log.SaldoAwal = saldomonth.SaldoAwal
+	log.SaldoAkhir = saldomonth.SaldoAkhir
+	log.ActionBy = username
+	log.IP = ip
+
+	return a.saldomonthlogRepository.Create(log)
+}
+
 func (a SaldoService) Update(id string, saldo *models.Saldo) error {
 	oSaldo, err := a.Get(id)
 	if err != nil {
@@ -334,3 +563,38 @@ func (a SaldoService) UpdateStatus(id string, status int) error {
 
 	return nil
 }
+
+// notifyLow tells the users of the branch and the webhooks of the company when the balance crosses
+// below Saldo.MinSaldo, further movements below the limit don't notify again
+func (a SaldoService) notifyLow(saldo *models.Saldo, before int64) error {
+	if saldo.MinSaldo <= 0 || before < saldo.MinSaldo || saldo.SaldoAkhir >= saldo.MinSaldo {
+		return nil
+	}
+
+	if err := a.webhookService.Publish(models.WebhookSaldoBelowLimit, saldo.CompanyID, saldo.ID, saldo); err != nil {
+		return err
+	}
+
+	qr, err := a.userRepository.Query(&models.UserQueryParam{
+		PaginationParam: dto.PaginationParam{PageSize: 999, Current: 1},
+		CompanyID:       saldo.CompanyID,
+		BranchID:        saldo.BranchID,
+		Status:          1,
+	})
+	if err != nil {
+		return err
+	}
+
+	usernames := make([]string, 0, len(qr.List))
+	for _, user := range qr.List {
+		usernames = append(usernames, user.Username)
+	}
+
+	return a.notificationService.Notify(&models.NotificationEvent{
+		Event:     models.NotificationSaldoLow,
+		Usernames: usernames,
+		Title:     "Saldo di bawah batas minimum",
+		Message:   fmt.Sprintf("Saldo %d di bawah batas minimum %d", saldo.SaldoAkhir, saldo.MinSaldo),
+		DocID:     saldo.ID,
+	})
+}
@@ -25,4 +25,16 @@ var Module = fx.Options(
 	fx.Provide(NewTarikDanaService),
 	fx.Provide(NewInvoiceHeaderService),
 	fx.Provide(NewInvoiceDetailService),
+	fx.Provide(NewJournalService),
+	fx.Provide(NewSaldoReconService),
+	fx.Provide(NewCashCountService),
+	fx.Provide(NewReplenishmentService),
+	fx.Provide(NewApprovalService),
+	fx.Provide(NewAuditLogService),
+	fx.Provide(NewAttachmentService),
+	fx.Provide(NewReportJobService),
+	fx.Provide(NewReportSubscriptionService),
+	fx.Provide(NewNotificationService),
+	fx.Provide(NewWebhookService),
+	fx.Provide(NewGLExportService),
 )
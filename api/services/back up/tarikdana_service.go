@@ -1,12 +1,16 @@
 package services
 
 import (
+	"database/sql"
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/Aguztinus/petty-cash-backend/api/repository"
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
 	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
 	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
 )
 
@@ -16,6 +20,10 @@ type TarikDanaService struct {
 	casbinService          CasbinService
 	counterService         CounterService
 	saldoService           SaldoService
+	journalService         JournalService
+	approvalService        ApprovalService
+	replenishmentService   ReplenishmentService
+	webhookService         WebhookService
 	branchRepository       repository.BranchRepository
 	tarikdanaRepository    repository.TarikDanaRepository
 	counterRepository      repository.CounterRepository
@@ -30,6 +38,10 @@ func NewTarikDanaService(
 	casbinService CasbinService,
 	counterService CounterService,
 	saldoService SaldoService,
+	journalService JournalService,
+	approvalService ApprovalService,
+	replenishmentService ReplenishmentService,
+	webhookService WebhookService,
 	branchRepository repository.BranchRepository,
 	tarikdanaRepository repository.TarikDanaRepository,
 	counterRepository repository.CounterRepository,
@@ -42,6 +54,10 @@ func NewTarikDanaService(
 		casbinService:          casbinService,
 		counterService:         counterService,
 		saldoService:           saldoService,
+		journalService:         journalService,
+		approvalService:        approvalService,
+		replenishmentService:   replenishmentService,
+		webhookService:         webhookService,
 		branchRepository:       branchRepository,
 		tarikdanaRepository:    tarikdanaRepository,
 		counterRepository:      counterRepository,
@@ -59,6 +75,11 @@ func (a TarikDanaService) WithTrx(trxHandle *gorm.DB) TarikDanaService {
 	a.saldohistoryRepository = a.saldohistoryRepository.WithTrx(trxHandle)
 	a.saldomonthRepository = a.saldomonthRepository.WithTrx(trxHandle)
 	a.saldoRepository = a.saldoRepository.WithTrx(trxHandle)
+	a.saldoService = a.saldoService.WithTrx(trxHandle)
+	a.journalService = a.journalService.WithTrx(trxHandle)
+	a.replenishmentService = a.replenishmentService.WithTrx(trxHandle)
+	a.approvalService = a.approvalService.WithTrx(trxHandle)
+	a.webhookService = a.webhookService.WithTrx(trxHandle)
 
 	return a
 }
@@ -72,6 +93,9 @@ func (a TarikDanaService) Get(id string) (*models.TarikDana, error) {
 	if err != nil {
 		return nil, err
 	}
+	if tarikdana.Approval, err = a.approvalService.Get(models.ApprovalDocTarikDana, id); err != nil {
+		return nil, err
+	}
 	return tarikdana, nil
 }
 
@@ -91,16 +115,71 @@ func (a TarikDanaService) Check(item *models.TarikDana) error {
 	return nil
 }
 
+// Create submits the TarikDana for approval, it is posted right away when no approval is needed
 func (a TarikDanaService) Create(tarikdana *models.TarikDana) (id string, err error) {
+	return a.create(tarikdana, true)
+}
+
+func (a TarikDanaService) create(tarikdana *models.TarikDana, submit bool) (id string, err error) {
 	if err = a.Check(tarikdana); err != nil {
 		return
 	}
 
-	saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(tarikdana.CompanyID, tarikdana.BranchID, 0, tarikdana.Amount)
-	if err != nil {
+	if err = a.saldoService.CheckPeriodOpen(tarikdana.CompanyID, tarikdana.BranchID, postingDate(tarikdana.Date)); err != nil {
+		return
+	}
+
+	if tarikdana.Num, err = a.counterService.Next("TD", tarikdana.CompanyID, tarikdana.BranchID, time.Now()); err != nil {
+		return
+	}
+
+	tarikdana.ID = uuid.MustString()
+	tarikdana.PostStatus = models.TarikDanaPostPosted
+
+	if submit {
+		approval, err := a.approvalService.Submit(&models.ApprovalRequest{
+			CompanyID: tarikdana.CompanyID,
+			BranchID:  tarikdana.BranchID,
+			DocType:   models.ApprovalDocTarikDana,
+			DocID:     tarikdana.ID,
+			DocNum:    tarikdana.Num,
+			Amount:    tarikdana.Amount,
+		}, tarikdana.CreatedBy)
+		if err != nil {
+			return "", err
+		} else if approval.Status != models.ApprovalStatusApproved {
+			tarikdana.PostStatus = models.TarikDanaPostPending
+		}
+	}
+
+	if tarikdana.PostStatus == models.TarikDanaPostPosted {
+		if err = a.post(tarikdana); err != nil {
+			return
+		}
+	}
+
+	if err = a.tarikdanaRepository.Create(tarikdana); err != nil {
 		return
 	}
-	// saldoHis, _ := a.saldohistoryRepository.GetbyCompanyAndBranch(tarikdana.CompanyID, tarikdana.BranchID)
+
+	if err = a.webhookService.Publish(models.WebhookTarikDanaCreated, tarikdana.CompanyID, tarikdana.ID, tarikdana); err != nil {
+		return
+	}
+
+	return tarikdana.ID, nil
+}
+
+// post books the TarikDana into Saldo with its "Penerimaan Dana" SaldoHistory
+func (a TarikDanaService) post(tarikdana *models.TarikDana) error {
+	journal, err := a.journalService.BuildFromTarikDana(tarikdana)
+	if err != nil {
+		return err
+	}
+
+	saldoNow, err := a.saldoService.CreateNewSaldoOrUpdate(tarikdana.CompanyID, tarikdana.BranchID, 0, tarikdana.Amount, journal)
+	if err != nil {
+		return err
+	}
 
 	saldoHisCreate := new(models.SaldoHistory)
 	saldoHisCreate.Desc = "Penerimaan Dana"
@@ -108,41 +187,185 @@ func (a TarikDanaService) Create(tarikdana *models.TarikDana) (id string, err er
 	saldoHisCreate.BranchID = tarikdana.BranchID
 	saldoHisCreate.InAmount = tarikdana.Amount
 	saldoHisCreate.SaldoAkhir = saldoNow
-	if err = a.saldohistoryRepository.Create(saldoHisCreate); err != nil {
+
+	return a.saldohistoryRepository.Create(saldoHisCreate)
+}
+
+// Approve records the decision of the current approval step, the TarikDana is posted after the last step
+func (a TarikDanaService) Approve(id string, decision *models.ApprovalDecision) error {
+	tarikdana, err := a.tarikdanaRepository.Get(id)
+	if err != nil {
+		return err
+	} else if tarikdana.PostStatus != models.TarikDanaPostPending {
+		return errors.ApprovalNotPending
+	}
+
+	approval, err := a.approvalService.Approve(models.ApprovalDocTarikDana, id, decision)
+	if err != nil {
+		return err
+	} else if approval.Status != models.ApprovalStatusApproved {
+		return nil
+	}
+
+	if err = a.saldoService.CheckPeriodOpen(tarikdana.CompanyID, tarikdana.BranchID, postingDate(tarikdana.Date)); err != nil {
+		return err
+	}
+
+	if err = a.post(tarikdana); err != nil {
+		return err
+	}
+
+	return a.tarikdanaRepository.UpdatePostStatus(id, models.TarikDanaPostPosted)
+}
+
+// Reject stops the approval, the TarikDana is never posted
+func (a TarikDanaService) Reject(id string, decision *models.ApprovalDecision) error {
+	tarikdana, err := a.tarikdanaRepository.Get(id)
+	if err != nil {
+		return err
+	} else if tarikdana.PostStatus != models.TarikDanaPostPending {
+		return errors.ApprovalNotPending
+	}
+
+	if _, err = a.approvalService.Reject(models.ApprovalDocTarikDana, id, decision); err != nil {
+		return err
+	}
+
+	return a.tarikdanaRepository.UpdatePostStatus(id, models.TarikDanaPostRejected)
+}
+
+// Resubmit sends a rejected and corrected TarikDana through the approval again, it is posted right away
+// when no step applies anymore
+func (a TarikDanaService) Resubmit(id string, decision *models.ApprovalDecision) error {
+	tarikdana, err := a.tarikdanaRepository.Get(id)
+	if err != nil {
+		return err
+	} else if tarikdana.PostStatus != models.TarikDanaPostRejected {
+		return errors.ApprovalNotRejected
+	}
+
+	approval, err := a.approvalService.Resubmit(models.ApprovalDocTarikDana, id, tarikdana.Amount, decision)
+	if err != nil {
+		return err
+	} else if approval.Status != models.ApprovalStatusApproved {
+		return a.tarikdanaRepository.UpdatePostStatus(id, models.TarikDanaPostPending)
+	}
+
+	if err = a.saldoService.CheckPeriodOpen(tarikdana.CompanyID, tarikdana.BranchID, postingDate(tarikdana.Date)); err != nil {
+		return err
+	}
+
+	if err = a.post(tarikdana); err != nil {
+		return err
+	}
+
+	return a.tarikdanaRepository.UpdatePostStatus(id, models.TarikDanaPostPosted)
+}
+
+// Replenish is the HQ approval of a replenishment request, it posts the TarikDana together
+// with its "Penerimaan Dana" SaldoHistory and links it back to the request
+func (a TarikDanaService) Replenish(id string, param *models.ReplenishmentApproveParam, username string) (string, error) {
+	replenishment, err := a.replenishmentService.GetForUpdate(id)
+	if err != nil {
 		return "", err
+	} else if replenishment.Status != models.ReplenishmentStatusPending {
+		return "", errors.ReplenishmentNotPending
 	}
 
-	tarikdana.ID = uuid.MustString()
+	if param.Amount < 0 {
+		return "", errors.ReplenishmentAmountInvalid
+	} else if param.Amount > 0 {
+		replenishment.Amount = param.Amount
+	}
 
-	if err = a.tarikdanaRepository.Create(tarikdana); err != nil {
-		return
+	tarikdana := new(models.TarikDana)
+	tarikdana.Type = models.TarikDanaTypeReplenishment
+	tarikdana.Amount = replenishment.Amount
+	tarikdana.Description = "Replenishment " + replenishment.Num
+	tarikdana.Date = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	tarikdana.CompanyID = replenishment.CompanyID
+	tarikdana.BranchID = replenishment.BranchID
+	tarikdana.ReplenishmentID = replenishment.ID
+	tarikdana.CreatedBy = username
+
+	// approved through the replenishment request
+	tarikdanaID, err := a.create(tarikdana, false)
+	if err != nil {
+		return "", err
 	}
 
-	return tarikdana.ID, nil
+	if err = a.replenishmentService.MarkApproved(replenishment, tarikdanaID, username); err != nil {
+		return "", err
+	}
+
+	return tarikdanaID, nil
 }
 
+// Update changes a TarikDana that is not posted yet, a pending one goes through the approval again
+// when its amount changed
 func (a TarikDanaService) Update(id string, tarikdana *models.TarikDana) error {
-	oTarikDana, err := a.Get(id)
+	oTarikDana, err := a.tarikdanaRepository.Get(id)
 	if err != nil {
 		return err
-	} else if tarikdana.Description != oTarikDana.Description {
+	} else if oTarikDana.PostStatus != models.TarikDanaPostPending && oTarikDana.PostStatus != models.TarikDanaPostRejected {
+		return errors.TarikDanaPosted
+	}
+
+	tarikdana.CompanyID = oTarikDana.CompanyID
+	tarikdana.BranchID = oTarikDana.BranchID
+	if tarikdana.Description != oTarikDana.Description {
 		if err = a.Check(tarikdana); err != nil {
 			return err
 		}
 	}
-	tarikdana.ID = oTarikDana.ID
 
-	if err := a.tarikdanaRepository.Update(id, tarikdana); err != nil {
+	if err = a.saldoService.CheckPeriodOpen(oTarikDana.CompanyID, oTarikDana.BranchID, postingDate(tarikdana.Date)); err != nil {
 		return err
 	}
 
-	return nil
+	amountChanged := tarikdana.Amount != oTarikDana.Amount
+	oTarikDana.Type = tarikdana.Type
+	oTarikDana.Amount = tarikdana.Amount
+	oTarikDana.Description = tarikdana.Description
+	oTarikDana.Date = tarikdana.Date
+	oTarikDana.File = tarikdana.File
+	oTarikDana.UpdateBy = tarikdana.UpdateBy
+
+	if err = a.tarikdanaRepository.Update(id, oTarikDana); err != nil {
+		return err
+	}
+
+	// a rejected TarikDana takes its new amount through Resubmit
+	if !amountChanged || oTarikDana.PostStatus != models.TarikDanaPostPending {
+		return nil
+	}
+
+	approval, err := a.approvalService.Resubmit(models.ApprovalDocTarikDana, id, oTarikDana.Amount,
+		&models.ApprovalDecision{Username: tarikdana.UpdateBy})
+	if err != nil {
+		return err
+	} else if approval.Status != models.ApprovalStatusApproved {
+		return nil
+	}
+
+	if err = a.post(oTarikDana); err != nil {
+		return err
+	}
+
+	return a.tarikdanaRepository.UpdatePostStatus(id, models.TarikDanaPostPosted)
 }
 
-func (a TarikDanaService) Delete(id string) error {
-	_, err := a.tarikdanaRepository.Get(id)
+// Delete removes a TarikDana that was never posted and cancels its approval, a posted one is voided instead
+func (a TarikDanaService) Delete(id string, username string) error {
+	tarikdana, err := a.tarikdanaRepository.Get(id)
 	if err != nil {
 		return err
+	} else if tarikdana.IsPosted() || tarikdana.PostStatus == models.TarikDanaPostVoided {
+		return errors.TarikDanaPosted
+	}
+
+	if err = a.approvalService.Cancel(models.ApprovalDocTarikDana, id, &models.ApprovalDecision{Username: username}); err != nil {
+		return err
 	}
 
 	if err := a.tarikdanaRepository.Delete(id); err != nil {
@@ -152,6 +375,54 @@ func (a TarikDanaService) Delete(id string) error {
 	return nil
 }
 
+// Void keeps the TarikDana and marks it voided with the reason, a reversing journal takes the amount
+// back out of Saldo on the void date
+func (a TarikDanaService) Void(id string, param *models.VoidParam, username string) error {
+	if param.Reason == "" {
+		return errors.VoidReasonEmpty
+	}
+
+	tarikdana, err := a.tarikdanaRepository.GetForUpdate(id)
+	if err != nil {
+		return err
+	} else if !tarikdana.IsPosted() {
+		return errors.TarikDanaNotPosted
+	}
+
+	journal, err := a.journalService.GetBySource(models.JournalSourceTarikDana, tarikdana.ID)
+	if err == errors.DatabaseRecordNotFound {
+		// posted before journals were kept
+		journal, err = a.journalService.BuildFromTarikDana(tarikdana)
+	}
+	if err != nil {
+		return err
+	}
+
+	reversal := a.journalService.BuildReversal(journal, "Void Penerimaan Dana: "+tarikdana.Description)
+	saldoNow, err := a.saldoService.Reverse(tarikdana.CompanyID, tarikdana.BranchID, 0, tarikdana.Amount, reversal)
+	if err != nil {
+		return err
+	}
+
+	saldoHisCreate := new(models.SaldoHistory)
+	saldoHisCreate.Desc = "Void Penerimaan Dana"
+	saldoHisCreate.CompanyID = tarikdana.CompanyID
+	saldoHisCreate.BranchID = tarikdana.BranchID
+	saldoHisCreate.OutAmount = tarikdana.Amount
+	saldoHisCreate.SaldoAkhir = saldoNow
+	if err = a.saldohistoryRepository.Create(saldoHisCreate); err != nil {
+		return err
+	}
+
+	tarikdana.PostStatus = models.TarikDanaPostVoided
+	tarikdana.VoidDate = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	tarikdana.VoidReason = param.Reason
+	tarikdana.VoidBy = username
+	tarikdana.UpdateBy = username
+
+	return a.tarikdanaRepository.UpdateVoid(id, tarikdana)
+}
+
 func (a TarikDanaService) UpdateStatus(id string, status int) error {
 	_, err := a.tarikdanaRepository.Get(id)
 	if err != nil {
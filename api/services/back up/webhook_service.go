@@ -0,0 +1,432 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/pkg/slice"
+	"github.com/Aguztinus/petty-cash-backend/pkg/uuid"
+	"github.com/Aguztinus/petty-cash-backend/pkg/webhook"
+)
+
+// a delivery is attempted webhookMaxAttempts times, the wait doubles from webhookRetryBase up to
+// webhookRetryMax. webhookWorkers deliveries are sent at the same time.
+const (
+	webhookMaxAttempts = 8
+	webhookRetryBase   = time.Minute
+	webhookRetryMax    = 6 * time.Hour
+	webhookWorkers     = 8
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookService pushes the domain events to the endpoints registered per company, and the notifications
+// of a user to the endpoints of that user. The deliveries are stored with the document change by Publish
+// and Notify and sent by Start after the commit.
+type WebhookService struct {
+	logger            lib.Logger
+	config            lib.Config
+	webhookRepository repository.WebhookRepository
+}
+
+// NewWebhookService creates a new webhookservice
+func NewWebhookService(
+	logger lib.Logger,
+	config lib.Config,
+	webhookRepository repository.WebhookRepository,
+) WebhookService {
+	return WebhookService{
+		logger:            logger,
+		config:            config,
+		webhookRepository: webhookRepository,
+	}
+}
+
+// WithTrx delegates transaction to repository database
+func (a WebhookService) WithTrx(trxHandle *gorm.DB) WebhookService {
+	a.webhookRepository = a.webhookRepository.WithTrx(trxHandle)
+
+	return a
+}
+
+func (a WebhookService) Query(param *models.WebhookEndpointQueryParam) (*models.WebhookEndpointQueryResult, error) {
+	return a.webhookRepository.Query(param)
+}
+
+func (a WebhookService) Get(id string) (*models.WebhookEndpoint, error) {
+	return a.webhookRepository.Get(id)
+}
+
+// check validates the endpoint and normalizes its events
+func (a WebhookService) check(endpoint *models.WebhookEndpoint) error {
+	if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.WebhookEndpointURLInvalid
+	}
+
+	events := endpoint.EventList()
+	if len(events) == 0 {
+		return errors.WebhookEventInvalid
+	}
+	for _, event := range events {
+		if !slice.ContainsString(models.WebhookEvents, event) {
+			return errors.WebhookEventInvalid
+		}
+	}
+	endpoint.Events = strings.Join(events, ",")
+
+	return nil
+}
+
+// Create registers the endpoint with a new secret, the answer is the only place the secret is shown
+func (a WebhookService) Create(endpoint *models.WebhookEndpoint) (*models.WebhookEndpointCreated, error) {
+	if err := a.check(endpoint); err != nil {
+		return nil, err
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint.ID = uuid.MustString()
+	endpoint.Secret = secret
+	endpoint.ActiveFlag = true
+	if err := a.webhookRepository.Create(endpoint); err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookEndpointCreated{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+// Update changes the endpoint and keeps its secret, UpdateActive enables it
+func (a WebhookService) Update(id string, endpoint *models.WebhookEndpoint) error {
+	oEndpoint, err := a.webhookRepository.Get(id)
+	if err != nil {
+		return err
+	}
+	endpoint.Secret = oEndpoint.Secret
+
+	if err = a.check(endpoint); err != nil {
+		return err
+	}
+
+	return a.webhookRepository.Update(id, endpoint)
+}
+
+// UpdateActive enables or disables the endpoint, the pending deliveries of a disabled endpoint fail
+func (a WebhookService) UpdateActive(id string, active bool) error {
+	if _, err := a.webhookRepository.Get(id); err != nil {
+		return err
+	}
+
+	return a.webhookRepository.UpdateActive(id, active)
+}
+
+func (a WebhookService) Delete(id string) error {
+	if _, err := a.webhookRepository.Get(id); err != nil {
+		return err
+	}
+
+	return a.webhookRepository.Delete(id)
+}
+
+func (a WebhookService) QueryDeliveries(param *models.WebhookDeliveryQueryParam) (*models.WebhookDeliveryQueryResult, error) {
+	return a.webhookRepository.QueryDeliveries(param)
+}
+
+func (a WebhookService) GetDelivery(id string) (*models.WebhookDelivery, error) {
+	return a.webhookRepository.GetDelivery(id)
+}
+
+// Replay sends the payload of a delivery again as a new delivery, the payload id is kept so the
+// receiver can tell it already handled the event
+func (a WebhookService) Replay(id string) (*models.WebhookDelivery, error) {
+	delivery, err := a.webhookRepository.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = a.webhookRepository.Get(delivery.WebhookEndpointID); err != nil {
+		return nil, err
+	}
+
+	replay := &models.WebhookDelivery{
+		ID:                uuid.MustString(),
+		WebhookEndpointID: delivery.WebhookEndpointID,
+		CompanyID:         delivery.CompanyID,
+		Event:             delivery.Event,
+		DocID:             delivery.DocID,
+		Payload:           delivery.Payload,
+		Status:            models.WebhookDeliveryStatusPending,
+		NextAttemptAt:     database.Datetime(sql.NullTime{Time: time.Now(), Valid: true}),
+		ReplayOf:          delivery.ID,
+	}
+	if err = a.webhookRepository.CreateDelivery(replay); err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+// Publish queues the event for every active endpoint of the company subscribed to it, data is the
+// document of the event
+func (a WebhookService) Publish(event string, companyID string, docID string, data interface{}) error {
+	endpoints, err := a.webhookRepository.GetSubscribed(companyID, event)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	payload, err := a.payload(event, companyID, data)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if _, err = a.queue(endpoint.ID, companyID, event, docID, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NotificationEndpoint returns the endpoint of the user for a notification webhook url, it is
+// registered with a new secret the first time and the secret is returned only then
+func (a WebhookService) NotificationEndpoint(user *models.User, rawURL string) (*models.WebhookEndpoint, string, error) {
+	if !a.allowed(rawURL) {
+		return nil, "", errors.WebhookEndpointHostDenied
+	}
+
+	endpoint, err := a.webhookRepository.GetByUserURL(user.ID, rawURL)
+	if err == nil {
+		return endpoint, "", nil
+	} else if err != errors.WebhookEndpointNotFound {
+		return nil, "", err
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	endpoint = &models.WebhookEndpoint{
+		ID:        uuid.MustString(),
+		CompanyID: user.CompanyID,
+		UserID:    user.ID,
+		Name:      "Notification " + user.Username,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    strings.Join(models.NotificationEvents, ","),
+	}
+	endpoint.ActiveFlag = true
+	endpoint.CreatedBy = user.Username
+	endpoint.UpdateBy = user.Username
+	if err = a.webhookRepository.Create(endpoint); err != nil {
+		return nil, "", err
+	}
+
+	return endpoint, secret, nil
+}
+
+// Notify queues a notification of the user for its endpoint, it is sent and retried like the events
+func (a WebhookService) Notify(endpointID string, companyID string, event string, docID string, data interface{}) (*models.WebhookDelivery, error) {
+	payload, err := a.payload(event, companyID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.queue(endpointID, companyID, event, docID, payload)
+}
+
+func (a WebhookService) payload(event string, companyID string, data interface{}) (string, error) {
+	payload, err := json.Marshal(&models.WebhookPayload{
+		ID:        uuid.MustString(),
+		Event:     event,
+		CompanyID: companyID,
+		CreatedAt: database.Datetime(sql.NullTime{Time: time.Now(), Valid: true}),
+		Data:      data,
+	})
+
+	return string(payload), err
+}
+
+func (a WebhookService) queue(endpointID string, companyID string, event string, docID string, payload string) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		ID:                uuid.MustString(),
+		WebhookEndpointID: endpointID,
+		CompanyID:         companyID,
+		Event:             event,
+		DocID:             docID,
+		Payload:           payload,
+		Status:            models.WebhookDeliveryStatusPending,
+		NextAttemptAt:     database.Datetime(sql.NullTime{Time: time.Now(), Valid: true}),
+	}
+	if err := a.webhookRepository.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// allowed tells whether a notification webhook may be sent to the host of the url
+func (a WebhookService) allowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	for _, host := range a.config.Webhook.NotificationHosts {
+		if strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Start sends the due deliveries every 30 seconds until ctx is done
+func (a WebhookService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				a.dispatch(now)
+			}
+		}
+	}()
+}
+
+// dispatch attempts every due delivery once, webhookWorkers at a time, a failed attempt is retried
+// after the backoff
+func (a WebhookService) dispatch(now time.Time) {
+	list, err := a.webhookRepository.GetDue(database.Datetime(sql.NullTime{Time: now, Valid: true}), 100)
+	if err != nil {
+		a.logger.Zap.Errorf("Error to list due webhook deliveries: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, webhookWorkers)
+	endpoints := make(map[string]*models.WebhookEndpoint)
+	for _, delivery := range list {
+		next := database.Datetime(sql.NullTime{Time: now.Add(webhook.Backoff(delivery.Attempts+1, webhookRetryBase, webhookRetryMax)), Valid: true})
+		claimed, err := a.webhookRepository.ClaimDelivery(delivery.ID, delivery.Attempts, next)
+		if err != nil {
+			a.logger.Zap.Errorf("Error to claim webhook delivery %s: %v", delivery.ID, err)
+			continue
+		} else if !claimed {
+			continue
+		}
+		delivery.Attempts++
+
+		endpoint, ok := endpoints[delivery.WebhookEndpointID]
+		if !ok {
+			if endpoint, err = a.webhookRepository.Get(delivery.WebhookEndpointID); err != nil && err != errors.WebhookEndpointNotFound {
+				a.logger.Zap.Errorf("Error to get webhook endpoint %s: %v", delivery.WebhookEndpointID, err)
+				continue
+			}
+			endpoints[delivery.WebhookEndpointID] = endpoint
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+
+			a.attempt(endpoint, delivery)
+			if err := a.webhookRepository.UpdateAttempt(delivery.ID, delivery); err != nil {
+				a.logger.Zap.Errorf("Error to save webhook delivery %s: %v", delivery.ID, err)
+			}
+		}(endpoint, delivery)
+	}
+
+	wg.Wait()
+}
+
+// attempt posts the delivery and sets its status, a deleted or inactive endpoint, or a notification
+// endpoint whose host is no longer allowed, fails it right away
+func (a WebhookService) attempt(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) {
+	delivery.LastStatusCode = 0
+	delivery.LastError = ""
+
+	var err error
+	if endpoint == nil || !endpoint.ActiveFlag {
+		delivery.Attempts = webhookMaxAttempts
+		err = fmt.Errorf("webhook endpoint is deleted or inactive")
+	} else if endpoint.UserID != "" && !a.allowed(endpoint.URL) {
+		delivery.Attempts = webhookMaxAttempts
+		err = errors.WebhookEndpointHostDenied
+	} else {
+		delivery.LastStatusCode, err = a.post(endpoint, delivery)
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryStatusSuccess
+		delivery.DeliveredAt = database.Datetime(sql.NullTime{Time: time.Now(), Valid: true})
+	case delivery.Attempts >= webhookMaxAttempts:
+		delivery.Status = models.WebhookDeliveryStatusFailed
+	default:
+		delivery.Status = models.WebhookDeliveryStatusPending
+	}
+
+	if err != nil {
+		delivery.LastError = err.Error()
+		if len(delivery.LastError) > 500 {
+			delivery.LastError = delivery.LastError[:500]
+		}
+	}
+}
+
+// post sends the payload signed with the endpoint secret at the time of sending, any status other
+// than 2xx fails the attempt
+func (a WebhookService) post(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.EventHeader, delivery.Event)
+	req.Header.Set(webhook.DeliveryHeader, delivery.ID)
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(endpoint.Secret, time.Now(), body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/api/services"
 	"github.com/Aguztinus/petty-cash-backend/errors"
 	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/pkg/audit"
 
 	"go.uber.org/fx"
 )
@@ -35,12 +36,24 @@ func bootstrap(
 	config lib.Config,
 	middlewares middlewares.Middlewares,
 	database lib.Database,
+	auditlogRepository repository.AuditLogRepository,
+	reportjobService services.ReportJobService,
+	reportsubscriptionService services.ReportSubscriptionService,
+	notificationService services.NotificationService,
+	kasbonService services.KasbonService,
+	webhookService services.WebhookService,
 ) {
 	db, err := database.ORM.DB()
 	if err != nil {
 		logger.Zap.Fatalf("Error to get database connection: %v", err)
 	}
 
+	if err := database.ORM.Use(audit.NewPlugin(auditlogRepository.Record)); err != nil {
+		logger.Zap.Fatalf("Error to register audit plugin: %v", err)
+	}
+
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+
 	lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
 			logger.Zap.Info("Starting Application")
@@ -54,6 +67,12 @@ func bootstrap(
 			db.SetMaxIdleConns(config.Database.MaxIdleConns)
 			db.SetConnMaxLifetime(time.Duration(config.Database.MaxLifetime) * time.Second)
 
+			reportjobService.Start(jobCtx)
+			reportsubscriptionService.Start(jobCtx)
+			notificationService.Start(jobCtx)
+			kasbonService.Start(jobCtx)
+			webhookService.Start(jobCtx)
+
 			go func() {
 				middlewares.Setup()
 				routes.Setup()
@@ -71,6 +90,7 @@ func bootstrap(
 		},
 		OnStop: func(context.Context) error {
 			logger.Zap.Info("Stopping Application")
+			stopJobs()
 
 			handler.Engine.Close()
 			db.Close()
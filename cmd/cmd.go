@@ -5,7 +5,10 @@ import (
 	"os"
 
 	"github.com/Aguztinus/petty-cash-backend/cmd/delete"
+	"github.com/Aguztinus/petty-cash-backend/cmd/glexport"
+	"github.com/Aguztinus/petty-cash-backend/cmd/importfiles"
 	"github.com/Aguztinus/petty-cash-backend/cmd/migrate"
+	"github.com/Aguztinus/petty-cash-backend/cmd/reconcile"
 	"github.com/Aguztinus/petty-cash-backend/cmd/runserver"
 	"github.com/Aguztinus/petty-cash-backend/cmd/setup"
 	"github.com/spf13/cobra"
@@ -16,6 +19,9 @@ func init() {
 	rootCmd.AddCommand(migrate.StartCmd)
 	rootCmd.AddCommand(setup.StartCmd)
 	rootCmd.AddCommand(delete.StartCmd)
+	rootCmd.AddCommand(reconcile.StartCmd)
+	rootCmd.AddCommand(glexport.StartCmd)
+	rootCmd.AddCommand(importfiles.StartCmd)
 }
 
 var rootCmd = &cobra.Command{
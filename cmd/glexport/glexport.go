@@ -0,0 +1,108 @@
+package glexport
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+var configFile string
+var companyID string
+var branchID string
+var dateFrom string
+var dateTo string
+var output string
+var resetID string
+var reason string
+
+func init() {
+	pf := StartCmd.PersistentFlags()
+	pf.StringVarP(&configFile, "config", "c",
+		"config/config.yaml", "this parameter is used to start the service application")
+	pf.StringVar(&companyID, "company", "", "company id to export")
+	pf.StringVar(&branchID, "branch", "", "only export this branch id, every branch when empty")
+	pf.StringVar(&dateFrom, "from", "", "first journal date, yyyy-mm-dd")
+	pf.StringVar(&dateTo, "to", "", "last journal date, yyyy-mm-dd")
+	pf.StringVarP(&output, "output", "o", "", "csv file to write, stdout when empty")
+	pf.StringVar(&resetID, "reset", "", "reset this export id instead of exporting, its journals are exported again next time")
+	pf.StringVar(&reason, "reason", "", "why the export is reset, required with --reset")
+
+	cobra.MarkFlagRequired(pf, "config")
+}
+
+var StartCmd = &cobra.Command{
+	Use:          "glexport",
+	Short:        "Export posted journals as Oracle GL_INTERFACE CSV, or reset an export",
+	Example:      "{execfile} glexport -c config/config.yaml --company <id> --from 2021-05-01 --to 2021-05-31 -o gl.csv",
+	SilenceUsage: true,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		lib.SetConfigPath(configFile)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		config := lib.NewConfig()
+		logger := lib.NewLogger(config)
+		db := lib.NewDatabase(config, logger)
+
+		glexportService := services.NewGLExportService(
+			logger,
+			config,
+			repository.NewJournalRepository(db, logger),
+			repository.NewGLExportRepository(db, logger),
+		)
+
+		if resetID != "" {
+			if reason == "" {
+				logger.Zap.Fatal("glexport error: --reason is required with --reset")
+			}
+
+			err := db.ORM.Transaction(func(tx *gorm.DB) error {
+				return glexportService.WithTrx(tx).Reset(resetID, &models.GLExportResetParam{Reason: reason}, "system")
+			})
+			if err != nil {
+				logger.Zap.Fatalf("glexport reset error: %v", err)
+			}
+
+			logger.Zap.Infof("reset export %s, its journals are exported again by the next export of their period", resetID)
+			return
+		}
+
+		param := &models.GLExportParam{CompanyID: companyID, BranchID: branchID, DateFrom: dateFrom, DateTo: dateTo}
+		if param.CompanyID == "" || param.DateFrom == "" || param.DateTo == "" {
+			logger.Zap.Fatal("glexport error: --company, --from and --to are required")
+		}
+
+		var export *models.GLExport
+		err := db.ORM.Transaction(func(tx *gorm.DB) (err error) {
+			export, err = glexportService.WithTrx(tx).Export(param, "system")
+			return err
+		})
+		if err != nil {
+			logger.Zap.Fatalf("glexport error: %v", err)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				logger.Zap.Fatalf("glexport error: %v", err)
+			}
+			defer file.Close()
+			w = file
+		}
+
+		// the journals are marked already, the file can be written again from the API or this export id
+		if err := glexportService.WriteCsv(w, export.ID); err != nil {
+			logger.Zap.Fatalf("glexport %s csv error: %v", export.ID, err)
+		}
+
+		logger.Zap.Infof("exported %d journals, %d lines as %s, export id %s group id %d",
+			export.JournalCount, export.LineCount, export.BatchName, export.ID, export.GroupID)
+	},
+}
@@ -0,0 +1,116 @@
+package importfiles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+var configFile string
+var dir string
+
+func init() {
+	pf := StartCmd.PersistentFlags()
+	pf.StringVarP(&configFile, "config", "c",
+		"config/config.yaml", "this parameter is used to start the service application")
+	pf.StringVar(&dir, "dir", "./upload", "directory of the legacy uploads, one folder per username")
+
+	cobra.MarkFlagRequired(pf, "config")
+}
+
+var StartCmd = &cobra.Command{
+	Use:          "importfiles",
+	Short:        "Import the files uploaded before attachments were stored as attachments of their documents",
+	Example:      "{execfile} importfiles -c config/config.yaml --dir ./upload",
+	SilenceUsage: true,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		lib.SetConfigPath(configFile)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		config := lib.NewConfig()
+		logger := lib.NewLogger(config)
+		db := lib.NewDatabase(config, logger)
+
+		attachmentService := services.NewAttachmentService(
+			logger,
+			config,
+			lib.NewStorage(config, logger),
+			repository.NewAttachmentRepository(db, logger),
+			repository.NewUserRepository(db, logger),
+			repository.NewBKKHeaderRepository(db, logger),
+			repository.NewInvoiceHeaderRepository(db, logger),
+			repository.NewKasbonRepository(db, logger),
+			repository.NewTarikDanaRepository(db, logger),
+		)
+
+		files, err := attachmentService.LegacyFiles()
+		if err != nil {
+			logger.Zap.Fatalf("importfiles error: %v", err)
+		}
+
+		imported, missing, failed := 0, 0, 0
+		for _, file := range files {
+			path, name, ok := legacyPath(file)
+			if !ok {
+				logger.Zap.Warnf("%s %s: %s is not in %s", file.DocType, file.DocID, file.File, dir)
+				missing++
+				continue
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err == nil {
+				// a file is imported in its own transaction, a failed one is tried again by the next run
+				err = db.ORM.Transaction(func(tx *gorm.DB) error {
+					_, err := attachmentService.WithTrx(tx).ImportLegacy(file, name, data)
+					return err
+				})
+			}
+			if err != nil {
+				logger.Zap.Errorf("%s %s: import %s error: %v", file.DocType, file.DocID, path, err)
+				failed++
+				continue
+			}
+			imported++
+		}
+
+		logger.Zap.Infof("imported %d legacy files, %d not found, %d failed, the files in %s are left in place",
+			imported, missing, failed, dir)
+	},
+}
+
+// legacyPath finds the file "<dir>/<username>/<username>-<name>", the creator of the document is tried
+// first as a username may contain "-"
+func legacyPath(file *models.AttachmentLegacyFile) (path string, name string, ok bool) {
+	if file.File != filepath.Base(file.File) || strings.HasPrefix(file.File, ".") {
+		return "", "", false
+	}
+
+	usernames := []string{file.CreatedBy}
+	for i, c := range file.File {
+		if c == '-' {
+			usernames = append(usernames, file.File[:i])
+		}
+	}
+
+	for _, username := range usernames {
+		if username == "" || !strings.HasPrefix(file.File, username+"-") {
+			continue
+		}
+
+		path = filepath.Join(dir, username, file.File)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, strings.TrimPrefix(file.File, username+"-"), true
+		}
+	}
+
+	return "", "", false
+}
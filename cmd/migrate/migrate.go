@@ -53,7 +53,26 @@ var StartCmd = &cobra.Command{
 			&models.InvoiceDetail{},
 			&models.SaldoHistory{},
 			&models.SaldoMonth{},
+			&models.SaldoMonthLog{},
 			&models.TarikDana{},
+			&models.JournalHeader{},
+			&models.JournalLine{},
+			&models.CashCount{},
+			&models.CashCountDetail{},
+			&models.Replenishment{},
+			&models.ReplenishmentInvoice{},
+			&models.ApprovalFlow{},
+			&models.ApprovalStep{},
+			&models.ApprovalRequest{},
+			&models.ApprovalHistory{},
+			&models.AuditLog{},
+			&models.Attachment{}, &models.BKKDuplicate{}, &models.CompanySignature{},
+			&models.ReportSubscription{},
+			&models.Notification{},
+			&models.NotificationPreference{},
+			&models.WebhookEndpoint{},
+			&models.WebhookDelivery{},
+			&models.GLExport{},
 		); err != nil {
 			logger.Zap.Fatalf("Error to migrate database: %v", err)
 		}
@@ -0,0 +1,79 @@
+package reconcile
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/Aguztinus/petty-cash-backend/api/repository"
+	"github.com/Aguztinus/petty-cash-backend/api/services"
+	"github.com/Aguztinus/petty-cash-backend/lib"
+	"github.com/Aguztinus/petty-cash-backend/models"
+)
+
+var configFile string
+var companyID string
+var branchID string
+var fix bool
+
+func init() {
+	pf := StartCmd.PersistentFlags()
+	pf.StringVarP(&configFile, "config", "c",
+		"config/config.yaml", "this parameter is used to start the service application")
+	pf.StringVar(&companyID, "company", "", "only reconcile this company id")
+	pf.StringVar(&branchID, "branch", "", "only reconcile this branch id")
+	pf.BoolVar(&fix, "fix", false, "rewrite saldo, saldo month and saldo history in one transaction")
+
+	cobra.MarkFlagRequired(pf, "config")
+}
+
+var StartCmd = &cobra.Command{
+	Use:          "reconcile",
+	Short:        "Recompute saldo from saldo history and report the drift",
+	Example:      "{execfile} reconcile -c config/config.yaml --fix",
+	SilenceUsage: true,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		lib.SetConfigPath(configFile)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		config := lib.NewConfig()
+		logger := lib.NewLogger(config)
+		db := lib.NewDatabase(config, logger)
+
+		reconService := services.NewSaldoReconService(
+			logger,
+			repository.NewSaldoRepository(db, logger),
+			repository.NewSaldoMonthRepository(db, logger),
+			repository.NewSaldoHistoryRepository(db, logger),
+			repository.NewBKKHeaderRepository(db, logger),
+		)
+
+		param := &models.SaldoReconParam{CompanyID: companyID, BranchID: branchID, Fix: fix}
+
+		var result *models.SaldoReconResult
+		err := db.ORM.Transaction(func(tx *gorm.DB) (err error) {
+			result, err = reconService.WithTrx(tx).Reconcile(param)
+			return err
+		})
+		if err != nil {
+			logger.Zap.Fatalf("reconcile error: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight|tabwriter.Debug)
+		fmt.Fprintln(w, "company\tbranch\ttable\tref\tmonth\tfield\tstored\tcomputed\tdrift\tskipped\t")
+		for _, line := range result.Lines {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%t\t\n", line.CompanyID, line.BranchID,
+				line.Table, line.RefID, line.MonthYear, line.Field, line.Stored, line.Computed, line.Drift, line.Skipped)
+		}
+		w.Flush()
+
+		if fix {
+			logger.Zap.Infof("reconciled %d branches, %d drifting values, closed months are skipped", result.Branches, len(result.Lines))
+			return
+		}
+		logger.Zap.Infof("checked %d branches, %d drifting values, run with --fix to rewrite", result.Branches, len(result.Lines))
+	},
+}
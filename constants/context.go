@@ -3,4 +3,6 @@ package constants
 const (
 	// DBTransaction is database transaction handle set at router context
 	DBTransaction = "db_trx"
+	// AfterCommit is the list of funcs run once the transaction is committed, see echox.AfterCommit
+	AfterCommit = "after_commit"
 )
@@ -0,0 +1,16 @@
+package errors
+
+var (
+	ApprovalFlowRecordNotFound = New("ApprovalFlow record not found")
+	ApprovalFlowAlreadyExists  = New("ApprovalFlow already exists for this company and document type")
+	ApprovalFlowInvalidDocType = New("ApprovalFlow document type is not valid")
+	ApprovalFlowInvalidStep    = New("ApprovalFlow step needs a role or a user and a unique sequence")
+	ApprovalFlowInUse          = New("ApprovalFlow has pending requests, decide them before changing the flow")
+	ApprovalStepNotFound       = New("Approval step of the request is no longer in its flow")
+	ApprovalRequestNotFound    = New("Approval request not found")
+	ApprovalNotPending         = New("Approval is not pending")
+	ApprovalNotApprover        = New("User is not an approver of the current step")
+	ApprovalNotApproved        = New("Document is not approved yet")
+	ApprovalNotRejected        = New("Only a rejected document can be resubmitted")
+	ApprovalReasonEmpty        = New("A reason is required to reject a document")
+)
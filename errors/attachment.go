@@ -0,0 +1,9 @@
+package errors
+
+var (
+	AttachmentDocTypeInvalid  = New("Attachment document type is invalid")
+	AttachmentEmpty           = New("Attachment file is empty")
+	AttachmentTooLarge        = New("Attachment file is too large")
+	AttachmentTypeNotAllowed  = New("Attachment file type is not allowed")
+	AttachmentAccessForbidden = New("Attachment document belongs to another company or branch")
+)
@@ -5,3 +5,8 @@ var (
 	BKKDetailIsDisable      = New("BKKDetail is disabled")
 	BKKDetailAlreadyExists  = New("BKKDetail already exists")
 )
+
+var (
+	BKKDetailAnalysisDateInvalid   = New("BKKDetail expense analysis needs a start and an end date")
+	BKKDetailAnalysisPeriodInvalid = New("BKKDetail expense analysis period must be month or year")
+)
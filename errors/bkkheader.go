@@ -1,7 +1,9 @@
 package errors
 
 var (
-	BKKHeaderRecordNotFound = New("BKKHeader record not found")
-	BKKHeaderIsDisable      = New("BKKHeader is disabled")
-	BKKHeaderAlreadyExists  = New("BKKHeader already exists")
+	BKKHeaderRecordNotFound   = New("BKKHeader record not found")
+	BKKHeaderIsDisable        = New("BKKHeader is disabled")
+	BKKHeaderAlreadyExists    = New("BKKHeader already exists")
+	BKKHeaderVoidKasbon       = New("BKKHeader of a kasbon settlement cannot be voided")
+	BKKDuplicateStatusInvalid = New("BKKDuplicate review status must be Confirmed or Dismissed")
 )
@@ -0,0 +1,10 @@
+package errors
+
+var (
+	CashCountRecordNotFound      = New("CashCount record not found")
+	CashCountAlreadyExists       = New("CashCount already exists")
+	CashCountNotDraft            = New("CashCount is not a draft")
+	CashCountInvalidDenomination = New("CashCount denomination is not valid")
+	CashCountInvalidQuantity     = New("CashCount quantity is not valid")
+	CashCountReasonRequired      = New("CashCount variance needs a valid reason code")
+)
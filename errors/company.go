@@ -5,3 +5,12 @@ var (
 	CompanyIsDisable      = New("company is disabled")
 	CompanyAlreadyExists  = New("company already exists")
 )
+
+var (
+	CompanyImageEmpty             = New("company image is empty")
+	CompanyImageTooLarge          = New("company image is too large")
+	CompanyImageTypeNotAllowed    = New("company image must be a PNG or JPEG")
+	CompanyLogoNotFound           = New("company has no logo")
+	CompanySignatureNotFound      = New("company signature record not found")
+	CompanySignatureAlreadyExists = New("company signature of the role already exists")
+)
@@ -4,4 +4,5 @@ var (
 	CounterRecordNotFound = New("Counter record not found")
 	CounterIsDisable      = New("Counter is disabled")
 	CounterAlreadyExists  = New("Counter already exists")
+	CounterFormatInvalid  = New("Counter number format is not valid")
 )
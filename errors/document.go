@@ -0,0 +1,5 @@
+package errors
+
+var (
+	VoidReasonEmpty = New("A reason is required to void a document")
+)
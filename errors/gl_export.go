@@ -0,0 +1,10 @@
+package errors
+
+var (
+	GLExportNotFound       = New("GLExport not found")
+	GLExportDateInvalid    = New("GLExport needs a date from and a date to as yyyy-mm-dd")
+	GLExportEmpty          = New("GLExport found no journal left to export in the period")
+	GLExportSegmentInvalid = New("GLExport segmented value does not fit the GL segments")
+	GLExportConflict       = New("GLExport journals were exported by another export, try again")
+	GLExportAlreadyReset   = New("GLExport was reset, its journals can be exported again")
+)
@@ -0,0 +1,10 @@
+package errors
+
+var (
+	JournalRecordNotFound = New("Journal record not found")
+	JournalAlreadyExists  = New("Journal already exists")
+	JournalNotBalanced    = New("Journal debit and credit are not balanced")
+	JournalCashMismatch   = New("Journal cash lines do not match the saldo posting")
+	JournalAccountNotSet  = New("Journal account is not set on saldo nor in config Journal")
+	JournalRequired       = New("Journal is required for saldo posting")
+)
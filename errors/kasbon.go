@@ -4,4 +4,13 @@ var (
 	KasbonRecordNotFound = New("Kasbon record not found")
 	KasbonIsDisable      = New("Kasbon is disabled")
 	KasbonAlreadyExists  = New("Kasbon already exists")
+	KasbonApproved       = New("Kasbon is approved, it can't be changed anymore")
+)
+
+var (
+	KasbonAmountInvalid  = New("Kasbon amount is not valid")
+	KasbonLimitExceeded  = New("Kasbon limit exceeded")
+	KasbonSaldoNotEnough = New("Kasbon exceeds the available saldo")
+	KasbonAlreadySettled = New("Kasbon is already settled")
+	KasbonNoReceipts     = New("Kasbon settlement has no receipts")
 )
@@ -0,0 +1,8 @@
+package errors
+
+var (
+	NotificationNotFound              = New("Notification not found")
+	NotificationEventInvalid          = New("Notification event is invalid")
+	NotificationWebhookURLInvalid     = New("Notification webhook needs an http or https url")
+	NotificationEmailAddressNotExists = New("Notification by email needs an email address on the user")
+)
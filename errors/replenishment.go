@@ -0,0 +1,11 @@
+package errors
+
+var (
+	ReplenishmentRecordNotFound = New("Replenishment record not found")
+	ReplenishmentAlreadyPending = New("Replenishment already pending for this branch")
+	ReplenishmentNotPending     = New("Replenishment is not pending")
+	ReplenishmentAmountInvalid  = New("Replenishment amount is not valid")
+	ReplenishmentReasonEmpty    = New("Replenishment reject reason is required")
+	ReplenishmentInvoiceInvalid = New("Replenishment invoice does not belong to the branch")
+	ReplenishmentInvoiceLinked  = New("Replenishment invoice is already in a pending or approved replenishment")
+)
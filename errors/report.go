@@ -0,0 +1,26 @@
+package errors
+
+var (
+	ReportJobTypeInvalid     = New("ReportJob type is invalid")
+	ReportJobFormatInvalid   = New("ReportJob format is invalid")
+	ReportJobDateInvalid     = New("ReportJob needs a start and an end date")
+	ReportJobNotFound        = New("ReportJob not found or expired")
+	ReportJobNotReady        = New("ReportJob is not done yet")
+	ReportJobAccessForbidden = New("ReportJob belongs to another user")
+)
+
+var (
+	ReportAccessForbidden = New("Report document belongs to another company or branch")
+)
+
+var (
+	ReportSubscriptionNotFound         = New("ReportSubscription not found")
+	ReportSubscriptionReportInvalid    = New("ReportSubscription report must be lmdp, lrdp, expense_analysis or kasbon_aging")
+	ReportSubscriptionFormatInvalid    = New("ReportSubscription format must be pdf or xlsx")
+	ReportSubscriptionScheduleInvalid  = New("ReportSubscription schedule is invalid")
+	ReportSubscriptionRecipientInvalid = New("ReportSubscription recipients must be email addresses")
+	ReportSubscriptionBranchRequired   = New("ReportSubscription of a monitoring report needs a company and a branch")
+	ReportSubscriptionAccessForbidden  = New("ReportSubscription belongs to another user")
+	ReportSubscriptionRecipientDenied  = New("ReportSubscription recipient is not a user of the company nor in an allowed domain")
+	ReportSubscriptionOwnerDisabled    = New("ReportSubscription owner is disabled")
+)
@@ -6,3 +6,12 @@ var (
 	SaldoAlreadyExists  = New("Saldo already exists")
 	SaldoNeedsNew       = New("Saldo needs news")
 )
+
+var (
+	SaldoPeriodClosed        = New("Saldo period is closed")
+	SaldoPeriodAlreadyClosed = New("Saldo period already closed")
+	SaldoPeriodNotClosed     = New("Saldo period is not closed")
+	SaldoPeriodNextClosed    = New("Saldo next period is already closed")
+	SaldoPeriodPrevOpen      = New("Saldo previous period is not closed yet")
+	SaldoPeriodReasonEmpty   = New("Saldo period reopen reason is required")
+)
@@ -4,4 +4,6 @@ var (
 	TarikDanaRecordNotFound = New("TarikDana record not found")
 	TarikDanaIsDisable      = New("TarikDana is disabled")
 	TarikDanaAlreadyExists  = New("TarikDana already exists")
+	TarikDanaPosted         = New("TarikDana is posted, void it instead")
+	TarikDanaNotPosted      = New("TarikDana is not posted")
 )
@@ -0,0 +1,9 @@
+package errors
+
+var (
+	WebhookEndpointNotFound   = New("WebhookEndpoint not found")
+	WebhookEndpointURLInvalid = New("WebhookEndpoint url must be http or https")
+	WebhookEventInvalid       = New("WebhookEndpoint events must be bkk.created, bkk.paid, invoice.final_approved, tarikdana.created or saldo.below_limit")
+	WebhookEndpointHostDenied = New("WebhookEndpoint host is not allowed for notifications")
+	WebhookDeliveryNotFound   = New("WebhookDelivery not found")
+)
@@ -2,8 +2,10 @@ package lib
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/pkg/docnum"
 	"github.com/Aguztinus/petty-cash-backend/pkg/file"
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/viper"
@@ -27,6 +29,26 @@ var defaultConfig = Config{
 	Auth:       &AuthConfig{},
 	Casbin:     &CasbinConfig{Enable: false},
 	Redis:      &RedisConfig{Host: "127.0.0.1", Port: 6379},
+	Kasbon:     &KasbonConfig{DefaultDueDays: 14},
+	Storage: &StorageConfig{
+		Driver:       "local",
+		MaxSize:      5,
+		AllowedTypes: []string{"image/jpeg", "image/png", "application/pdf"},
+		Local:        &LocalStorageConfig{Root: "./upload"},
+		S3:           &S3StorageConfig{Region: "us-east-1"},
+	},
+	Report:  &ReportConfig{Directory: "./pdfs/jobs", Expiry: 60, Workers: 2},
+	Webhook: &WebhookConfig{},
+	SMTP:    &SMTPConfig{Host: "127.0.0.1", Port: 1025, From: "pettycash@localhost"},
+	GL: &GLConfig{
+		CurrencyCode:       "IDR",
+		UserJeSourceName:   "Petty Cash",
+		UserJeCategoryName: "Petty Cash",
+		SegmentSeparator:   ".",
+		Segments:           5,
+		CreatedBy:          -1,
+	},
+	Journal: &JournalConfig{},
 	Database: &DatabaseConfig{
 		Parameters:   "charset=utf8mb4&parseTime=True&loc=Local&allowNativePasswords=true&timeout=5s",
 		MaxLifetime:  7200,
@@ -77,6 +99,14 @@ type Config struct {
 	Casbin     *CasbinConfig     `mapstructure:"Casbin"`
 	Redis      *RedisConfig      `mapstructure:"Redis"`
 	Database   *DatabaseConfig   `mapstructure:"Database"`
+	Kasbon     *KasbonConfig     `mapstructure:"Kasbon"`
+	Numbering  NumberingConfig   `mapstructure:"Numbering"`
+	Storage    *StorageConfig    `mapstructure:"Storage"`
+	Report     *ReportConfig     `mapstructure:"Report"`
+	Webhook    *WebhookConfig    `mapstructure:"Webhook"`
+	SMTP       *SMTPConfig       `mapstructure:"SMTP"`
+	GL         *GLConfig         `mapstructure:"GL"`
+	Journal    *JournalConfig    `mapstructure:"Journal"`
 }
 
 type HttpConfig struct {
@@ -140,6 +170,105 @@ type RedisConfig struct {
 	KeyPrefix string `mapstructure:"KeyPrefix"`
 }
 
+// DueDays      : days a released kasbon may stay unsettled, per kasbon Type
+// DefaultDueDays : used for types not listed in DueDays
+type KasbonConfig struct {
+	DefaultDueDays int            `mapstructure:"DefaultDueDays"`
+	DueDays        map[string]int `mapstructure:"DueDays"`
+}
+
+// NumberingConfig maps a document prefix (BKK, INV, KBS, CC, RPL, TD) to its number format
+type NumberingConfig map[string]*NumberFormatConfig
+
+// Format : template with the tokens {prefix} {company} {branch} {yyyy} {yy} {mm} and one {seq},
+//          {seq:N} pads the sequence to N digits
+//          default {prefix}{branch}{seq:4}
+// Reset  : yearly, monthly or empty for a sequence that never resets
+type NumberFormatConfig struct {
+	Format string `mapstructure:"Format"`
+	Reset  string `mapstructure:"Reset"`
+}
+
+// Driver       : local or s3, s3 works with any S3 compatible service such as MinIO
+// MaxSize      : largest attachment in MB
+// AllowedTypes : MIME types detected from the content of an attachment
+type StorageConfig struct {
+	Driver       string              `mapstructure:"Driver"`
+	MaxSize      int64               `mapstructure:"MaxSize"`
+	AllowedTypes []string            `mapstructure:"AllowedTypes"`
+	Local        *LocalStorageConfig `mapstructure:"Local"`
+	S3           *S3StorageConfig    `mapstructure:"S3"`
+}
+
+type LocalStorageConfig struct {
+	Root string `mapstructure:"Root"`
+}
+
+// Endpoint : host[:port] without scheme, e.g. localhost:9000
+type S3StorageConfig struct {
+	Endpoint  string `mapstructure:"Endpoint"`
+	Region    string `mapstructure:"Region"`
+	Bucket    string `mapstructure:"Bucket"`
+	AccessKey string `mapstructure:"AccessKey"`
+	SecretKey string `mapstructure:"SecretKey"`
+	UseSSL    bool   `mapstructure:"UseSSL"`
+}
+
+// Directory : where the report jobs write their output
+// Expiry    : minutes a finished report job and its output are kept
+// Workers   : report jobs generated at the same time
+// RecipientDomains : email domains a scheduled report may go to besides the users of its company
+type ReportConfig struct {
+	Directory        string   `mapstructure:"Directory"`
+	Expiry           int      `mapstructure:"Expiry"`
+	Workers          int      `mapstructure:"Workers"`
+	RecipientDomains []string `mapstructure:"RecipientDomains"`
+}
+
+// NotificationHosts : hosts the users may send their notification webhooks to, none when empty
+type WebhookConfig struct {
+	NotificationHosts []string `mapstructure:"NotificationHosts"`
+}
+
+// Host, Port : the SMTP server, default a local catcher such as MailHog on 127.0.0.1:1025
+// Username   : empty sends without authentication
+// From       : sender address of the scheduled reports
+type SMTPConfig struct {
+	Host     string `mapstructure:"Host"`
+	Port     int    `mapstructure:"Port"`
+	Username string `mapstructure:"Username"`
+	Password string `mapstructure:"Password"`
+	From     string `mapstructure:"From"`
+}
+
+// LedgerIDs          : Oracle ledger of a company by Company.Num, DefaultLedgerID for the others
+// UserJeSourceName, UserJeCategoryName : journal source and category defined in Oracle GL
+// SegmentSeparator   : splits Trx.SegmentedValue into SEGMENT1 up to SEGMENT<Segments>
+// CreatedBy          : the Oracle user id written in CREATED_BY
+type GLConfig struct {
+	DefaultLedgerID    int64            `mapstructure:"DefaultLedgerID"`
+	LedgerIDs          map[string]int64 `mapstructure:"LedgerIDs"`
+	CurrencyCode       string           `mapstructure:"CurrencyCode"`
+	UserJeSourceName   string           `mapstructure:"UserJeSourceName"`
+	UserJeCategoryName string           `mapstructure:"UserJeCategoryName"`
+	SegmentSeparator   string           `mapstructure:"SegmentSeparator"`
+	Segments           int              `mapstructure:"Segments"`
+	CreatedBy          int64            `mapstructure:"CreatedBy"`
+}
+
+// The accounts posted for a saldo without its own Trx, by Account.Num. The Trx of the branch on that
+// account is used, or the one of the company when the branch has none
+// CashAccount     : petty cash
+// FundAccount     : where the top-ups come from
+// KasbonAccount   : employee advances
+// VarianceAccount : cash over/short of a cash count
+type JournalConfig struct {
+	CashAccount     string `mapstructure:"CashAccount"`
+	FundAccount     string `mapstructure:"FundAccount"`
+	KasbonAccount   string `mapstructure:"KasbonAccount"`
+	VarianceAccount string `mapstructure:"VarianceAccount"`
+}
+
 func (a *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s", a.Username, a.Password, a.Host, a.Port, a.Name, a.Parameters)
 }
@@ -152,6 +281,39 @@ func (a *HttpConfig) ListenAddr() string {
 	return fmt.Sprintf("%s:%d", a.Host, a.Port)
 }
 
+// DueDaysOf returns the due days of a kasbon type, viper lower-cases map keys so the lookup ignores case
+func (a *KasbonConfig) DueDaysOf(kasbonType string) int {
+	for k, v := range a.DueDays {
+		if strings.EqualFold(k, kasbonType) {
+			return v
+		}
+	}
+
+	return a.DefaultDueDays
+}
+
+// Of returns the number format of a document prefix, viper lower-cases map keys so the lookup ignores case
+func (a NumberingConfig) Of(prefix string) NumberFormatConfig {
+	for k, v := range a {
+		if strings.EqualFold(k, prefix) && v != nil && v.Format != "" {
+			return *v
+		}
+	}
+
+	return NumberFormatConfig{Format: docnum.DefaultFormat}
+}
+
+// LedgerOf returns the ledger id of a company number, viper lower-cases map keys so the lookup ignores case
+func (a *GLConfig) LedgerOf(companyNum string) int64 {
+	for k, v := range a.LedgerIDs {
+		if strings.EqualFold(k, companyNum) {
+			return v
+		}
+	}
+
+	return a.DefaultLedgerID
+}
+
 func (a *RedisConfig) Addr() string {
 	return fmt.Sprintf("%s:%d", a.Host, a.Port)
 }
@@ -10,4 +10,7 @@ var Module = fx.Options(
 	fx.Provide(NewDatabase),
 	fx.Provide(NewRedis),
 	fx.Provide(NewCaptcha),
+	fx.Provide(NewStorage),
+	fx.Provide(NewTaskQueue),
+	fx.Provide(NewMailer),
 )
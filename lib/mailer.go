@@ -0,0 +1,24 @@
+package lib
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/pkg/mailer"
+)
+
+// Mailer sends email through the SMTP server of the config
+type Mailer struct {
+	*mailer.Mailer
+}
+
+// NewMailer creates the mailer, the server is only contacted when a message is sent
+func NewMailer(config Config, logger Logger) Mailer {
+	smtp := config.SMTP
+	logger.Zap.Infof("Mailer: smtp %s:%d", smtp.Host, smtp.Port)
+
+	return Mailer{mailer.New(mailer.Config{
+		Host:     smtp.Host,
+		Port:     smtp.Port,
+		Username: smtp.Username,
+		Password: smtp.Password,
+		From:     smtp.From,
+	})}
+}
@@ -0,0 +1,29 @@
+package lib
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/pkg/storage"
+)
+
+// Storage keeps the attachments, the driver is chosen by Storage.Driver
+type Storage struct {
+	storage.Storage
+}
+
+// NewStorage creates the storage of the configured driver
+func NewStorage(config Config, logger Logger) Storage {
+	if config.Storage.Driver == "s3" {
+		s3 := config.Storage.S3
+		logger.Zap.Infof("Attachment storage: s3 bucket %s at %s", s3.Bucket, s3.Endpoint)
+		return Storage{storage.NewS3(storage.S3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			UseSSL:    s3.UseSSL,
+		})}
+	}
+
+	logger.Zap.Infof("Attachment storage: local %s", config.Storage.Local.Root)
+	return Storage{storage.NewLocal(config.Storage.Local.Root)}
+}
@@ -0,0 +1,37 @@
+package lib
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/Aguztinus/petty-cash-backend/constants"
+	"github.com/Aguztinus/petty-cash-backend/pkg/jobqueue"
+)
+
+// TaskQueue is the background job queue kept in the redis task database
+type TaskQueue struct {
+	*jobqueue.Queue
+}
+
+// NewTaskQueue creates the job queue, finished jobs are kept Report.Expiry minutes
+func NewTaskQueue(config Config, logger Logger) TaskQueue {
+	addr := config.Redis.Addr()
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		DB:       constants.RedisTaskDB,
+		Password: config.Redis.Password,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err := client.Ping(ctx).Result(); err != nil {
+		logger.Zap.Fatalf("Error to open redis[%s] task connection: %v", addr, err)
+	}
+
+	ttl := time.Duration(config.Report.Expiry) * time.Minute
+	return TaskQueue{jobqueue.New(client, config.Redis.KeyPrefix, ttl)}
+}
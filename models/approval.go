@@ -0,0 +1,186 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// DocType - BKK: BKKHeader, INV: InvoiceHeader, KBS: Kasbon, TRD: TarikDana
+const (
+	ApprovalDocBKK       = "BKK"
+	ApprovalDocInvoice   = "INV"
+	ApprovalDocKasbon    = "KBS"
+	ApprovalDocTarikDana = "TRD"
+)
+
+var ApprovalDocTypes = []string{ApprovalDocBKK, ApprovalDocInvoice, ApprovalDocKasbon, ApprovalDocTarikDana}
+
+// Status - Pending: waiting on CurrentStep, Approved: every step approved or no approval needed, Rejected,
+// Cancelled: the document was deleted
+const (
+	ApprovalStatusPending   = "Pending"
+	ApprovalStatusApproved  = "Approved"
+	ApprovalStatusRejected  = "Rejected"
+	ApprovalStatusCancelled = "Cancelled"
+)
+
+// Action - recorded in ApprovalHistory
+const (
+	ApprovalActionSubmit   = "Submit"
+	ApprovalActionApprove  = "Approve"
+	ApprovalActionReject   = "Reject"
+	ApprovalActionResubmit = "Resubmit"
+	ApprovalActionCancel   = "Cancel"
+)
+
+// ApprovalFlow is the approval chain of one document type of a company, it only applies when
+// Company.ApprovalFlag is set
+type ApprovalFlow struct {
+	database.Model
+	database.ModelMaster
+	ID            string        `gorm:"column:id;size:36;not null;index:idx_id_approval_flow,unique;" json:"id"`
+	CompanyID     string        `gorm:"column:company_id;size:36;index:idx_approval_flow_doc;not null;" json:"company_id" validate:"required"`
+	DocType       string        `gorm:"column:doc_type;size:5;index:idx_approval_flow_doc;not null;" json:"doc_type" validate:"required"`
+	Name          string        `gorm:"column:name;not null;" json:"name" validate:"required"`
+	ApprovalSteps ApprovalSteps `gorm:"foreignKey:ApprovalFlowID;references:ID" json:"approval_steps" yaml:"approval_steps"`
+	Company       Company       `gorm:"references:ID" json:"company" yaml:"company"`
+}
+
+// ApprovalStep is approved by a user (UserID) or by any user holding a role (RoleID),
+// the step is skipped for documents below MinAmount
+type ApprovalStep struct {
+	database.Model
+	ApprovalFlowID string `gorm:"column:approval_flow_id;size:36;index;not null;" json:"approval_flow_id"`
+	Sequence       int    `gorm:"column:sequence;not null;" json:"sequence" validate:"required"`
+	Name           string `gorm:"column:name;not null;" json:"name" validate:"required"`
+	RoleID         string `gorm:"column:role_id;size:36;index;" json:"role_id"`
+	UserID         string `gorm:"column:user_id;size:36;index;" json:"user_id"`
+	MinAmount      int64  `gorm:"column:min_amount;default:0;" json:"min_amount"`
+}
+
+// ApprovalRequest tracks the approval of one document, CurrentStep is the Sequence of the step
+// waiting for a decision
+type ApprovalRequest struct {
+	database.Model
+	database.ModelTrans
+	ID                string            `gorm:"column:id;size:36;not null;index:idx_id_approval_request,unique;" json:"id"`
+	CompanyID         string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID          string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	DocType           string            `gorm:"column:doc_type;size:5;index:idx_approval_request_doc;not null;" json:"doc_type"`
+	DocID             string            `gorm:"column:doc_id;size:36;index:idx_approval_request_doc;not null;" json:"doc_id"`
+	DocNum            string            `gorm:"column:doc_num;size:100;index;not null;" json:"doc_num"`
+	Amount            int64             `gorm:"column:amount;default:0;" json:"amount"`
+	ApprovalFlowID    string            `gorm:"column:approval_flow_id;size:36;index;" json:"approval_flow_id"`
+	CurrentStep       int               `gorm:"column:current_step;default:0;" json:"current_step"`
+	Status            string            `gorm:"column:status;size:15;index;not null;" json:"status"`
+	RejectReason      string            `gorm:"column:reject_reason;size:500;" json:"reject_reason"`
+	ApprovalHistories ApprovalHistories `gorm:"foreignKey:ApprovalRequestID;references:ID" json:"approval_histories" yaml:"approval_histories"`
+
+	Step             *ApprovalStep `gorm:"-" json:"step,omitempty"`
+	PendingApprovers []string      `gorm:"-" json:"pending_approvers"`
+}
+
+// ApprovalHistory is one entry of the decision trail, CreatedAt is the time of the decision
+type ApprovalHistory struct {
+	database.Model
+	ApprovalRequestID string `gorm:"column:approval_request_id;size:36;index;not null;" json:"approval_request_id"`
+	Sequence          int    `gorm:"column:sequence;default:0;" json:"sequence"`
+	StepName          string `gorm:"column:step_name;" json:"step_name"`
+	Action            string `gorm:"column:action;size:15;not null;" json:"action"`
+	Username          string `gorm:"column:username;size:64;index;not null;" json:"username"`
+	IPAddress         string `gorm:"column:ip_address;size:45;" json:"ip_address"`
+	Comment           string `gorm:"column:comment;size:500;" json:"comment"`
+}
+
+type ApprovalFlows []*ApprovalFlow
+
+type ApprovalSteps []*ApprovalStep
+
+type ApprovalRequests []*ApprovalRequest
+
+type ApprovalHistories []*ApprovalHistory
+
+// ApprovalDecision is the body of the approve, reject and resubmit endpoints, Comment is required
+// for a rejection and becomes the reject reason shown to the requester
+type ApprovalDecision struct {
+	Comment   string `json:"comment"`
+	UserID    string `json:"-"`
+	Username  string `json:"-"`
+	IPAddress string `json:"-"`
+}
+
+// ApprovalTimeline is the full decision trail of a document, resubmissions included
+type ApprovalTimeline struct {
+	DocType      string            `json:"doc_type"`
+	DocID        string            `json:"doc_id"`
+	DocNum       string            `json:"doc_num"`
+	Status       string            `json:"status"`
+	RejectReason string            `json:"reject_reason"`
+	Histories    ApprovalHistories `json:"histories"`
+}
+
+type ApprovalFlowQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	IDs        []string `query:"ids"`
+	CompanyID  string   `query:"company_id"`
+	DocType    string   `query:"doc_type"`
+	QueryValue string   `query:"query_value"`
+}
+
+type ApprovalFlowQueryResult struct {
+	List       ApprovalFlows   `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
+
+// ApproverID and ApproverRoleIDs select the requests waiting on that user
+type ApprovalRequestQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	IDs             []string `query:"ids"`
+	CompanyID       string   `query:"company_id"`
+	BranchID        string   `query:"branch_id"`
+	DocType         string   `query:"doc_type"`
+	DocID           string   `query:"doc_id"`
+	Status          string   `query:"status"`
+	ApproverID      string   `query:"-"`
+	ApproverRoleIDs []string `query:"-"`
+	QueryValue      string   `query:"query_value"`
+}
+
+type ApprovalRequestQueryResult struct {
+	List       ApprovalRequests `json:"list"`
+	Pagination *dto.Pagination  `json:"pagination"`
+}
+
+// Next returns the first step after sequence that applies to amount, nil when there is none left
+func (a ApprovalSteps) Next(sequence int, amount int64) *ApprovalStep {
+	var next *ApprovalStep
+	for _, item := range a {
+		if item.Sequence > sequence && amount >= item.MinAmount && (next == nil || item.Sequence < next.Sequence) {
+			next = item
+		}
+	}
+
+	return next
+}
+
+func (a ApprovalFlows) ToMap() map[string]*ApprovalFlow {
+	m := make(map[string]*ApprovalFlow)
+	for _, item := range a {
+		m[item.ID] = item
+	}
+
+	return m
+}
+
+func (a ApprovalRequests) ToMap() map[string]*ApprovalRequest {
+	m := make(map[string]*ApprovalRequest)
+	for _, item := range a {
+		m[item.ID] = item
+	}
+
+	return m
+}
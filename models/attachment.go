@@ -0,0 +1,51 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Attachment is a file of a document, DocType uses the approval document codes. The content is stored
+// once per Hash (sha256) under Key and shared by every document it is attached to. PHash is the
+// perceptual hash of an image, see pkg/imagehash.
+type Attachment struct {
+	database.Model
+	database.ModelTrans
+	ID       string `gorm:"column:id;size:36;not null;index:idx_id_attachment,unique;" json:"id"`
+	DocType  string `gorm:"column:doc_type;size:5;index:idx_attachment_doc;not null;" json:"doc_type"`
+	DocID    string `gorm:"column:doc_id;size:36;index:idx_attachment_doc;not null;" json:"doc_id"`
+	Hash     string `gorm:"column:hash;size:64;index;not null;" json:"hash"`
+	Key      string `gorm:"column:storage_key;size:100;not null;" json:"-"`
+	FileName string `gorm:"column:file_name;size:255;not null;" json:"file_name"`
+	MimeType string `gorm:"column:mime_type;size:100;not null;" json:"mime_type"`
+	Size     int64  `gorm:"column:size;default:0;" json:"size"`
+	PHash    string `gorm:"column:p_hash;size:16;not null;" json:"p_hash"`
+}
+
+type Attachments []*Attachment
+
+// AttachmentLegacyFile is a file name kept by a document before attachments were stored, the file is
+// "<username>-<name>" in the upload directory of the username. RecordID is the row holding the name
+type AttachmentLegacyFile struct {
+	RecordID  uint   `json:"-"`
+	DocType   string `json:"doc_type"`
+	DocID     string `json:"doc_id"`
+	File      string `json:"file"`
+	CreatedBy string `json:"created_by"`
+}
+
+type AttachmentLegacyFiles []*AttachmentLegacyFile
+
+type AttachmentQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	DocType string `query:"doc_type"`
+	DocID   string `query:"doc_id"`
+	Hash    string `query:"hash"`
+}
+
+type AttachmentQueryResult struct {
+	List       Attachments     `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// AuditLog is written by the audit plugin for every create, update and delete of a model with
+// database.ModelTrans or database.ModelMaster. Changes is the JSON of the changed columns, {"column": {"from", "to"}}.
+type AuditLog struct {
+	database.Model
+	ID        string `gorm:"column:id;size:36;not null;index:idx_id_audit_log,unique;" json:"id"`
+	Entity    string `gorm:"column:entity;size:50;index:idx_audit_entity;not null;" json:"entity"`
+	EntityID  string `gorm:"column:entity_id;size:36;index:idx_audit_entity;not null;" json:"entity_id"`
+	Action    string `gorm:"column:action;size:10;index;not null;" json:"action"`
+	UserID    string `gorm:"column:user_id;size:36;index;not null;" json:"user_id"`
+	Username  string `gorm:"column:username;size:50;index;not null;" json:"username"`
+	RequestID string `gorm:"column:request_id;size:36;index;not null;" json:"request_id"`
+	IPAddress string `gorm:"column:ip_address;size:45;not null;" json:"ip_address"`
+	Changes   string `gorm:"column:changes;type:text;" json:"changes"`
+}
+
+type AuditLogs []*AuditLog
+
+type AuditLogQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	Entity    string   `query:"entity"`
+	EntityID  string   `query:"entity_id"`
+	Action    string   `query:"action"`
+	UserID    string   `query:"user_id"`
+	Username  string   `query:"username"`
+	RequestID string   `query:"request_id"`
+	DateQuery []string `query:"date_query"`
+}
+
+type AuditLogQueryResult struct {
+	List       AuditLogs       `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
@@ -6,6 +6,8 @@ import (
 )
 
 // Status - 1: Enable -1: Disable
+// LinesFile - the id of an attachment of the BKK, FileHash and PHash are copied from it for the
+// duplicate receipt check
 type BKKDetail struct {
 	database.Model
 	database.ModelTrans
@@ -15,6 +17,8 @@ type BKKDetail struct {
 	LinesDate   database.Datetime `gorm:"column:lines_date;" json:"lines_date"`
 	LinesAmount int64             `gorm:"column:lines_amount;default:0;" json:"lines_amount"`
 	LinesFile   string            `gorm:"column:lines_file;not null;" json:"lines_file" validate:"required"`
+	FileHash    string            `gorm:"column:file_hash;size:64;index;not null;" json:"file_hash"`
+	PHash       string            `gorm:"column:p_hash;size:16;not null;" json:"p_hash"`
 	Status      string            `gorm:"column:status;size:1;index;not null;" json:"status"`
 	BKKHeader   BKKHeader         `gorm:"-" json:"bkk_header" yaml:"bkk_header"`
 	Trx         Trx               `gorm:"foreignKey:TrxID;references:ID" json:"trx" yaml:"trx"`
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Reason - File: same content hash, Image: close perceptual hash, Details: same amount and date with
+// a similar description
+const (
+	BKKDuplicateReasonFile    = "File"
+	BKKDuplicateReasonImage   = "Image"
+	BKKDuplicateReasonDetails = "Details"
+)
+
+// Status - Open: waiting for review, Confirmed: a duplicate claim, Dismissed: not a duplicate
+const (
+	BKKDuplicateOpen      = "Open"
+	BKKDuplicateConfirmed = "Confirmed"
+	BKKDuplicateDismissed = "Dismissed"
+)
+
+// BKKDuplicate flags a BKK line as a possible duplicate of a line of another BKK of the company,
+// LineID and MatchLineID are the record ids of the BKKDetails
+type BKKDuplicate struct {
+	database.Model
+	database.ModelTrans
+	ID               string            `gorm:"column:id;size:36;not null;index:idx_id_bkk_duplicate,unique;" json:"id"`
+	CompanyID        string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID         string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	BKKHeaderID      string            `gorm:"column:bkk_header_id;size:36;index;not null;" json:"bkk_header_id"`
+	BKKNum           string            `gorm:"column:bkk_num;size:50;not null;" json:"bkk_num"`
+	LineID           uint              `gorm:"column:line_id;not null;" json:"line_id"`
+	LinesDesc        string            `gorm:"column:lines_desc;not null;" json:"lines_desc"`
+	LinesDate        database.Datetime `gorm:"column:lines_date;" json:"lines_date"`
+	LinesAmount      int64             `gorm:"column:lines_amount;default:0;" json:"lines_amount"`
+	MatchBKKHeaderID string            `gorm:"column:match_bkk_header_id;size:36;index;not null;" json:"match_bkk_header_id"`
+	MatchBKKNum      string            `gorm:"column:match_bkk_num;size:50;not null;" json:"match_bkk_num"`
+	MatchBranchID    string            `gorm:"column:match_branch_id;size:36;not null;" json:"match_branch_id"`
+	MatchLineID      uint              `gorm:"column:match_line_id;not null;" json:"match_line_id"`
+	MatchLinesDesc   string            `gorm:"column:match_lines_desc;not null;" json:"match_lines_desc"`
+	Reason           string            `gorm:"column:reason;size:10;not null;" json:"reason"`
+	Distance         int               `gorm:"column:distance;default:0;" json:"distance"`
+	Status           string            `gorm:"column:status;size:10;index;not null;" json:"status"`
+	ReviewNote       string            `gorm:"column:review_note;size:500;not null;" json:"review_note"`
+	ReviewedBy       string            `gorm:"column:reviewed_by;size:50;not null;" json:"reviewed_by"`
+	ReviewedDate     database.Datetime `gorm:"column:reviewed_date;" json:"reviewed_date"`
+}
+
+type BKKDuplicates []*BKKDuplicate
+
+// BKKHeaderID matches both sides of the flag
+type BKKDuplicateQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	CompanyID   string `query:"company_id"`
+	BranchID    string `query:"branch_id"`
+	BKKHeaderID string `query:"bkk_header_id"`
+	Reason      string `query:"reason"`
+	Status      string `query:"status"`
+}
+
+type BKKDuplicateQueryResult struct {
+	List       BKKDuplicates   `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
+
+// BKKDuplicateCandidateParam - the lines of the other BKKs of the company that may duplicate the lines
+// of BKKHeaderID. A line with an image hash is compared to the BKKs created since ImagesSince whose
+// image hash is at most Distance bits away
+type BKKDuplicateCandidateParam struct {
+	CompanyID   string
+	BKKHeaderID string
+	FileHashes  []string
+	Amounts     []int64
+	PHashes     []string
+	Distance    int
+	ImagesSince database.Datetime
+}
+
+// BKKDuplicateReviewParam - Status is Confirmed or Dismissed
+type BKKDuplicateReviewParam struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
@@ -3,6 +3,46 @@ package models
 import (
 	"github.com/Aguztinus/petty-cash-backend/models/database"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/fsm"
+)
+
+// Status - Paid: cash handed out and posted to Saldo, Invoice: claimed on an invoice, Void: reversed
+const (
+	BKKStatusPaid    = "Paid"
+	BKKStatusInvoice = "Invoice"
+	BKKStatusVoid    = "Void"
+)
+
+// StatusApprove - 0: waiting for approval, 1: approved, 2: rejected, -1: cancelled
+const (
+	BKKApprovePending   = 0
+	BKKApproveApproved  = 1
+	BKKApproveRejected  = 2
+	BKKApproveCancelled = -1
+)
+
+// State - the life cycle of a BKK, derived from Status and StatusApprove
+const (
+	BKKStatePending   = "Pending"
+	BKKStateApproved  = "Approved"
+	BKKStateRejected  = "Rejected"
+	BKKStateCancelled = "Cancelled"
+	BKKStatePaid      = "Paid"
+	BKKStateInvoiced  = "Invoiced"
+	BKKStateVoided    = "Voided"
+)
+
+// BKKStateMachine - a BKK is paid once, after its approval, and invoiced once paid. Only drafts that
+// were never paid are deleted, an approved or paid BKK is voided and its posting reversed.
+var BKKStateMachine = fsm.New("BKK",
+	fsm.Transition{Action: DocActionUpdate, From: []string{BKKStatePending, BKKStateRejected}},
+	fsm.Transition{Action: DocActionDelete, From: []string{BKKStatePending, BKKStateRejected, BKKStateCancelled}},
+	fsm.Transition{Action: DocActionApprove, From: []string{BKKStatePending}},
+	fsm.Transition{Action: DocActionReject, From: []string{BKKStatePending}, To: BKKStateRejected},
+	fsm.Transition{Action: DocActionResubmit, From: []string{BKKStateRejected}, To: BKKStatePending},
+	fsm.Transition{Action: DocActionPay, From: []string{BKKStateApproved}, To: BKKStatePaid},
+	fsm.Transition{Action: DocActionInvoice, From: []string{BKKStatePaid}, To: BKKStateInvoiced},
+	fsm.Transition{Action: DocActionVoid, From: []string{BKKStateApproved, BKKStatePaid}, To: BKKStateVoided},
 )
 
 // Status - 1: Enable -1: Disable
@@ -10,20 +50,29 @@ type BKKHeader struct {
 	database.Model
 	database.ModelTrans
 	ID            string            `gorm:"column:id;size:36;not null;index;" json:"id"`
-	Num           string            `gorm:"column:num;size:10;not null;index:idx_num,unique;" json:"num"`
+	Num           string            `gorm:"column:num;size:50;not null;index:idx_num,unique;" json:"num"`
 	NumberSeq     int64             `gorm:"column:number_seq;default:0;" json:"number_seq"`
 	CompanyID     string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
 	BranchID      string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
 	ReleaseDate   database.Datetime `gorm:"column:release_date;" json:"release_date"`
 	PaidDate      database.Datetime `gorm:"column:paid_date;" json:"paid_date"`
+	PaidBy        string            `gorm:"column:paid_by;size:50;not null;" json:"paid_by"`
 	TotalAmount   int64             `gorm:"column:total_amount;default:0;" json:"total_amount"`
 	KasbonID      string            `gorm:"column:kasbon_id;size:36;index;not null;" json:"kasbon_id"`
 	InvoiceID     string            `gorm:"column:invoice_id;size:36;index;not null;" json:"invoice_id"`
 	Status        string            `gorm:"column:status;size:15;index;not null;" json:"status"`
 	StatusApprove int8              `gorm:"column:status_approve;default:0;" json:"status_approve"`
+	VoidDate      database.Datetime `gorm:"column:void_date;" json:"void_date"`
+	VoidReason    string            `gorm:"column:void_reason;size:500;not null;" json:"void_reason"`
+	VoidBy        string            `gorm:"column:void_by;size:50;not null;" json:"void_by"`
 	BKKDetails    BKKDetails        `gorm:"foreignKey:BKKHeaderID;references:ID" json:"bkk_detail" yaml:"bkk_detail"`
 	Company       Company           `gorm:"references:ID" json:"company" yaml:"company"`
 	Branch        Branch            `gorm:"references:ID" json:"branch" yaml:"branch"`
+
+	Approval   *ApprovalRequest `gorm:"-" json:"approval,omitempty"`
+	State      string           `gorm:"-" json:"state"`
+	Actions    []string         `gorm:"-" json:"actions"`
+	Duplicates BKKDuplicates    `gorm:"-" json:"duplicates"`
 }
 
 type BKKHeaders []*BKKHeader
@@ -52,6 +101,7 @@ type BKKHeaderQueryParam struct {
 type BKKHeaderApproveQueryParam struct {
 	IDs        []string `json:"ids"`
 	QueryValue string   `json:"query_value"`
+	Comment    string   `json:"comment"`
 }
 
 type BKKHeaderQueryResult struct {
@@ -67,3 +117,28 @@ func (a BKKHeaders) ToMap() map[string]*BKKHeader {
 
 	return m
 }
+
+// GetState returns the state of the BKK in BKKStateMachine
+func (a *BKKHeader) GetState() string {
+	switch {
+	case a.Status == BKKStatusVoid:
+		return BKKStateVoided
+	case a.Status == BKKStatusInvoice:
+		return BKKStateInvoiced
+	case a.Status == BKKStatusPaid:
+		return BKKStatePaid
+	case a.StatusApprove == BKKApproveApproved:
+		return BKKStateApproved
+	case a.StatusApprove == BKKApproveRejected:
+		return BKKStateRejected
+	case a.StatusApprove == BKKApproveCancelled:
+		return BKKStateCancelled
+	default:
+		return BKKStatePending
+	}
+}
+
+// IsPaid tells whether the cash of the BKK was handed out, a voided BKK keeps the PaidDate of its payment
+func (a *BKKHeader) IsPaid() bool {
+	return a.Status == BKKStatusPaid || a.Status == BKKStatusInvoice || a.PaidDate.Valid
+}
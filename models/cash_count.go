@@ -0,0 +1,110 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Status - Draft, Approved, Rejected
+const (
+	CashCountStatusDraft    = "Draft"
+	CashCountStatusApproved = "Approved"
+	CashCountStatusRejected = "Rejected"
+)
+
+// ReasonCode explains a variance, required when the count does not match the system balance
+const (
+	CashCountReasonRounding    = "ROUNDING"
+	CashCountReasonUnrecorded  = "UNRECORDED"
+	CashCountReasonMissing     = "MISSING"
+	CashCountReasonCounterfeit = "COUNTERFEIT"
+	CashCountReasonOther       = "OTHER"
+)
+
+// Type - NOTE: banknote, COIN: coin
+const (
+	CashCountTypeNote = "NOTE"
+	CashCountTypeCoin = "COIN"
+)
+
+// CashCountDenominations are the rupiah notes and coins accepted in a count
+var CashCountDenominations = map[string][]int64{
+	CashCountTypeNote: {100000, 50000, 20000, 10000, 5000, 2000, 1000},
+	CashCountTypeCoin: {1000, 500, 200, 100},
+}
+
+var CashCountReasons = []string{
+	CashCountReasonRounding,
+	CashCountReasonUnrecorded,
+	CashCountReasonMissing,
+	CashCountReasonCounterfeit,
+	CashCountReasonOther,
+}
+
+// Variance is TotalCount - SaldoSystem, negative means cash is missing from the box
+type CashCount struct {
+	database.Model
+	database.ModelTrans
+	ID               string            `gorm:"column:id;size:36;not null;index;" json:"id"`
+	Num              string            `gorm:"column:num;size:50;not null;index:idx_cash_count_num,unique;" json:"num"`
+	CompanyID        string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id" validate:"required"`
+	BranchID         string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id" validate:"required"`
+	CountDate        database.Datetime `gorm:"column:count_date;index;" json:"count_date"`
+	SaldoSystem      int64             `gorm:"column:saldo_system;default:0;" json:"saldo_system"`
+	TotalCount       int64             `gorm:"column:total_count;default:0;" json:"total_count"`
+	Variance         int64             `gorm:"column:variance;default:0;" json:"variance"`
+	ReasonCode       string            `gorm:"column:reason_code;size:15;" json:"reason_code"`
+	Description      string            `gorm:"column:description;" json:"description"`
+	Status           string            `gorm:"column:status;size:15;index;not null;" json:"status"`
+	ApprovedBy       string            `gorm:"column:approved_by;size:64;" json:"approved_by"`
+	ApprovedDate     database.Datetime `gorm:"column:approved_date;" json:"approved_date"`
+	CashCountDetails CashCountDetails  `gorm:"foreignKey:CashCountID;references:ID" json:"cash_count_details" yaml:"cash_count_details"`
+	Company          Company           `gorm:"references:ID" json:"company" yaml:"company"`
+	Branch           Branch            `gorm:"references:ID" json:"branch" yaml:"branch"`
+}
+
+type CashCountDetail struct {
+	database.Model
+	CashCountID  string `gorm:"column:cash_count_id;size:36;index;not null;" json:"cash_count_id"`
+	Type         string `gorm:"column:type;size:5;not null;" json:"type" validate:"required"`
+	Denomination int64  `gorm:"column:denomination;not null;" json:"denomination" validate:"required"`
+	Quantity     int64  `gorm:"column:quantity;default:0;" json:"quantity"`
+	Amount       int64  `gorm:"column:amount;default:0;" json:"amount"`
+}
+
+type CashCounts []*CashCount
+
+type CashCountDetails []*CashCountDetail
+
+type CashCountQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	IDs        []string `query:"ids"`
+	Num        string   `query:"num"`
+	CompanyID  string   `query:"company_id"`
+	BranchID   string   `query:"branch_id"`
+	Status     string   `query:"status"`
+	ReasonCode string   `query:"reason_code"`
+	DateQuery  []string `query:"date_query"`
+	QueryValue string   `query:"query_value"`
+}
+
+type CashCountApproveParam struct {
+	ReasonCode  string `json:"reason_code"`
+	Description string `json:"description"`
+}
+
+type CashCountQueryResult struct {
+	List       CashCounts      `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
+
+func (a CashCounts) ToMap() map[string]*CashCount {
+	m := make(map[string]*CashCount)
+	for _, item := range a {
+		m[item.ID] = item
+	}
+
+	return m
+}
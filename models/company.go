@@ -6,6 +6,7 @@ import (
 )
 
 // Status - 1: Enable -1: Disable
+// LetterheadName - the name printed on the reports, Name when empty. LogoKey is the storage key of the logo.
 type Company struct {
 	database.Model
 	database.ModelMaster
@@ -16,6 +17,10 @@ type Company struct {
 	PaymentFlag  bool   `gorm:"column:payment_flag;" json:"payment_flag"`
 	BDCFlag      bool   `gorm:"column:bdc_flag;" json:"bdc_flag"`
 	ApprovalFlag bool   `gorm:"column:approval_flag;" json:"approval_flag"`
+
+	LetterheadName string `gorm:"column:letterhead_name;not null;" json:"letterhead_name"`
+	LogoKey        string `gorm:"column:logo_key;size:100;not null;" json:"-"`
+	HasLogo        bool   `gorm:"-" json:"has_logo"`
 }
 
 type Companies []*Company
@@ -40,6 +45,15 @@ type CompanyQueryResult struct {
 	Pagination *dto.Pagination `json:"pagination"`
 }
 
+// Letterhead returns the name printed on the reports
+func (a *Company) Letterhead() string {
+	if a.LetterheadName != "" {
+		return a.LetterheadName
+	}
+
+	return a.Name
+}
+
 func (a Companies) ToNames() []string {
 	names := make([]string, len(a))
 	for i, item := range a {
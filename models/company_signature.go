@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// CompanySignature is the authorised signatory of a role in a company, a report signed by a user
+// holding the role prints the signature image. Reports - printed under the monitoring reports.
+type CompanySignature struct {
+	database.Model
+	database.ModelTrans
+	ID        string `gorm:"column:id;size:36;not null;index:idx_id_company_signature,unique;" json:"id"`
+	CompanyID string `gorm:"column:company_id;size:36;index:idx_company_signature_role,unique;not null;" json:"company_id"`
+	RoleID    string `gorm:"column:role_id;size:36;index:idx_company_signature_role,unique;not null;" json:"role_id" validate:"required"`
+	Name      string `gorm:"column:name;not null;" json:"name" validate:"required"`
+	Title     string `gorm:"column:title;not null;" json:"title"`
+	ImageKey  string `gorm:"column:image_key;size:100;not null;" json:"-"`
+	Reports   bool   `gorm:"column:reports;" json:"reports"`
+	Sequence  int    `gorm:"column:sequence;default:0;" json:"sequence"`
+}
+
+type CompanySignatures []*CompanySignature
+
+type CompanySignatureQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	CompanyID string   `query:"company_id"`
+	RoleIDs   []string `query:"role_ids"`
+	Reports   bool     `query:"reports"`
+}
+
+type CompanySignatureQueryResult struct {
+	List       CompanySignatures `json:"list"`
+	Pagination *dto.Pagination   `json:"pagination"`
+}
+
+// ByRole returns the signature of the first of the roles that has one
+func (a CompanySignatures) ByRole(roleIDs []string) *CompanySignature {
+	for _, roleID := range roleIDs {
+		for _, item := range a {
+			if item.RoleID == roleID {
+				return item
+			}
+		}
+	}
+
+	return nil
+}
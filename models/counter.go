@@ -5,12 +5,13 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
 )
 
-// Status - 1: Enable -1: Disable
+// Counter is a number sequence, KeyCounter comes from docnum.Key and CounterMe is the last number issued.
+// Counters from before the number formats are keyed prefix+branch and hold the next number instead.
 type Counter struct {
 	database.Model
 	database.ModelMaster
 	ID         string `gorm:"column:id;size:36;not null;index;" json:"id"`
-	KeyCounter string `gorm:"column:key_counter;size:10;not null;index:idx_key_count,unique;" json:"key_counter"`
+	KeyCounter string `gorm:"column:key_counter;size:64;not null;index:idx_key_count,unique;" json:"key_counter"`
 	CounterMe  int    `gorm:"column:counter_me;not null;" json:"counter_me" validate:"required"`
 }
 
@@ -0,0 +1,20 @@
+package models
+
+// Action - the actions of the document state machines, listed in the actions field of a GET
+const (
+	DocActionUpdate   = "update"
+	DocActionDelete   = "delete"
+	DocActionApprove  = "approve"
+	DocActionReject   = "reject"
+	DocActionResubmit = "resubmit"
+	DocActionPay      = "pay"
+	DocActionInvoice  = "invoice"
+	DocActionRelease  = "release"
+	DocActionSettle   = "settle"
+	DocActionVoid     = "void"
+)
+
+// VoidParam - the reason is kept on the voided document
+type VoidParam struct {
+	Reason string `json:"reason"`
+}
@@ -1,8 +1,8 @@
 package dto
 
 type ReportMonitoring struct {
-	DateParams []string `json:"dateparam"`
-	CompanyID  string   `json:"company_id"`
-	BranchID   string   `json:"branch_id"`
+	DateParams []string `json:"dateparam" query:"dateparam"`
+	CompanyID  string   `json:"company_id" query:"company_id"`
+	BranchID   string   `json:"branch_id" query:"branch_id"`
 	UserId     string
 }
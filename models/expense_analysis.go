@@ -0,0 +1,98 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Period - the expense analysis sums the lines of a BKK into the month or the year it was created
+const (
+	ExpensePeriodMonth = "month"
+	ExpensePeriodYear  = "year"
+)
+
+// ExpenseAnalysisParam - DateParams, CompanyID and BranchID filter the BKKs like the LMDP and LRDP,
+// Period is month (default) or year. AccountID, CCID, DeptID and PeriodKey (e.g. 2021-05) narrow
+// the drill-down to the vouchers of one cell of the analysis
+type ExpenseAnalysisParam struct {
+	dto.ReportMonitoring
+	Period    string `query:"period" json:"period"`
+	AccountID string `query:"account_id" json:"account_id"`
+	CCID      string `query:"cc_id" json:"cc_id"`
+	DeptID    string `query:"department_id" json:"department_id"`
+	PeriodKey string `query:"period_key" json:"period_key"`
+}
+
+// PeriodFormat returns the DATE_FORMAT of the period key, 2006-01 by month or 2006 by year
+func (a ExpenseAnalysisParam) PeriodFormat() string {
+	if a.Period == ExpensePeriodYear {
+		return "%Y"
+	}
+
+	return "%Y-%m"
+}
+
+// ExpenseAnalysisLine is the sum of the BKK lines of one account, cost centre and department in a period
+type ExpenseAnalysisLine struct {
+	Period      string `json:"period"`
+	AccountID   string `json:"account_id"`
+	AccountNum  string `json:"account_num"`
+	AccountName string `json:"account_name"`
+	CCID        string `json:"cc_id"`
+	CCCode      string `json:"cc_code"`
+	CCName      string `json:"cc_name"`
+	DeptID      string `json:"department_id"`
+	DeptNum     string `json:"department_num"`
+	DeptName    string `json:"department_name"`
+	Amount      int64  `json:"amount"`
+	Count       int    `json:"count"`
+}
+
+type ExpenseAnalysisLines []*ExpenseAnalysisLine
+
+// ExpenseAnalysisPeriod holds the lines of a period and their subtotal
+type ExpenseAnalysisPeriod struct {
+	Period string               `json:"period"`
+	Amount int64                `json:"amount"`
+	Count  int                  `json:"count"`
+	Lines  ExpenseAnalysisLines `json:"lines"`
+}
+
+type ExpenseAnalysisPeriods []*ExpenseAnalysisPeriod
+
+// ExpenseAnalysisGroup is the total of an account, a cost centre or a department over all periods
+type ExpenseAnalysisGroup struct {
+	ID     string `json:"id"`
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Amount int64  `json:"amount"`
+	Count  int    `json:"count"`
+}
+
+type ExpenseAnalysisGroups []*ExpenseAnalysisGroup
+
+type ExpenseAnalysisResult struct {
+	Period      string                 `json:"period"`
+	Periods     ExpenseAnalysisPeriods `json:"periods"`
+	Accounts    ExpenseAnalysisGroups  `json:"accounts"`
+	CostCentres ExpenseAnalysisGroups  `json:"cost_centres"`
+	Departments ExpenseAnalysisGroups  `json:"departments"`
+	Amount      int64                  `json:"amount"`
+	Count       int                    `json:"count"`
+}
+
+// ExpenseVoucher is a BKK line behind an amount of the expense analysis
+type ExpenseVoucher struct {
+	BKKHeaderID string            `json:"bkk_header_id"`
+	Num         string            `json:"num"`
+	Date        database.Datetime `json:"date"`
+	Period      string            `json:"period"`
+	TrxName     string            `json:"trx_name"`
+	AccountNum  string            `json:"account_num"`
+	CCCode      string            `json:"cc_code"`
+	DeptNum     string            `json:"department_num"`
+	LinesDesc   string            `json:"lines_desc"`
+	Amount      int64             `json:"amount"`
+}
+
+type ExpenseVouchers []*ExpenseVoucher
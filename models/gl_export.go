@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// GLExport is one batch of journals sent to the Oracle GL_INTERFACE, GroupID is the GROUP_ID of its rows.
+// A journal belongs to one export only, Reset releases the journals of an export so they can be exported
+// again, the export is kept with ResetAt set
+type GLExport struct {
+	database.Model
+	database.ModelTrans
+	ID           string            `gorm:"column:id;size:36;not null;index:idx_id_gl_export,unique;" json:"id"`
+	GroupID      int64             `gorm:"column:group_id;index;not null;" json:"group_id"`
+	BatchName    string            `gorm:"column:batch_name;size:100;not null;" json:"batch_name"`
+	CompanyID    string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID     string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	DateFrom     database.Datetime `gorm:"column:date_from;" json:"date_from"`
+	DateTo       database.Datetime `gorm:"column:date_to;" json:"date_to"`
+	JournalCount int               `gorm:"column:journal_count;default:0;" json:"journal_count"`
+	LineCount    int               `gorm:"column:line_count;default:0;" json:"line_count"`
+	TotalDebit   int64             `gorm:"column:total_debit;default:0;" json:"total_debit"`
+	TotalCredit  int64             `gorm:"column:total_credit;default:0;" json:"total_credit"`
+	ResetAt      database.Datetime `gorm:"column:reset_at;" json:"reset_at"`
+	ResetBy      string            `gorm:"column:reset_by;size:64;not null;" json:"reset_by"`
+	ResetReason  string            `gorm:"column:reset_reason;size:500;not null;" json:"reset_reason"`
+}
+
+type GLExports []*GLExport
+
+// GLExportParam - DateFrom and DateTo are yyyy-mm-dd, both included. BranchID empty exports every branch of
+// the company
+type GLExportParam struct {
+	CompanyID string `json:"company_id" query:"company_id" validate:"required"`
+	BranchID  string `json:"branch_id" query:"branch_id"`
+	DateFrom  string `json:"date_from" query:"date_from" validate:"required"`
+	DateTo    string `json:"date_to" query:"date_to" validate:"required"`
+}
+
+// GLExportResetParam is the body of the reset endpoint, the reason is required
+type GLExportResetParam struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type GLExportQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	CompanyID string `query:"company_id"`
+	BranchID  string `query:"branch_id"`
+}
+
+type GLExportQueryResult struct {
+	List       GLExports       `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
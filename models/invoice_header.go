@@ -1,8 +1,39 @@
 package models
 
 import (
+	"strings"
+
 	"github.com/Aguztinus/petty-cash-backend/models/database"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/fsm"
+)
+
+// StatusApprove - 0: submitted, 1: approved by a first step, 2: rejected at the first step,
+// 3: final approved, 4: rejected at a later step
+const (
+	InvoiceApprovePending  = 0
+	InvoiceApproveReviewed = 1
+	InvoiceApproveRejected = 2
+	InvoiceApproveFinal    = 3
+	InvoiceRejectFinal     = 4
+)
+
+// State - the life cycle of an invoice, derived from StatusApprove
+const (
+	InvoiceStatePending  = "Pending"
+	InvoiceStateReviewed = "Reviewed"
+	InvoiceStateApproved = "Approved"
+	InvoiceStateRejected = "Rejected"
+)
+
+// InvoiceStateMachine - an invoice is only edited before its approval, the final approval posts the
+// remaining amount back to Saldo once
+var InvoiceStateMachine = fsm.New("Invoice",
+	fsm.Transition{Action: DocActionUpdate, From: []string{InvoiceStatePending, InvoiceStateRejected}},
+	fsm.Transition{Action: DocActionDelete, From: []string{InvoiceStatePending, InvoiceStateRejected}},
+	fsm.Transition{Action: DocActionApprove, From: []string{InvoiceStatePending, InvoiceStateReviewed}},
+	fsm.Transition{Action: DocActionReject, From: []string{InvoiceStatePending, InvoiceStateReviewed}, To: InvoiceStateRejected},
+	fsm.Transition{Action: DocActionResubmit, From: []string{InvoiceStateRejected}, To: InvoiceStatePending},
 )
 
 // Status - 1: Enable -1: Disable
@@ -10,7 +41,7 @@ type InvoiceHeader struct {
 	database.Model
 	database.ModelTrans
 	ID             string            `gorm:"column:id;size:36;not null;index;" json:"id"`
-	Num            string            `gorm:"column:num;size:10;not null;index:idx_num,unique;" json:"num"`
+	Num            string            `gorm:"column:num;size:50;not null;index:idx_num,unique;" json:"num"`
 	Type           string            `gorm:"column:type;size:15;index;not null;" json:"type"`
 	Amount         int64             `gorm:"column:amount;default:0;" json:"amount"`
 	Description    string            `gorm:"column:description;not null;" json:"description"`
@@ -24,6 +55,10 @@ type InvoiceHeader struct {
 	InvoiceDetails InvoiceDetails    `gorm:"foreignKey:InvoiceHeaderID;references:ID" json:"invoice_detail" yaml:"invoice_detail"`
 	Company        Company           `gorm:"references:ID" json:"company" yaml:"company"`
 	Branch         Branch            `gorm:"references:ID" json:"branch" yaml:"branch"`
+
+	Approval *ApprovalRequest `gorm:"-" json:"approval,omitempty"`
+	State    string           `gorm:"-" json:"state"`
+	Actions  []string         `gorm:"-" json:"actions"`
 }
 
 type InvoiceHeaders []*InvoiceHeader
@@ -49,6 +84,7 @@ type InvoiceHeaderQueryParam struct {
 type InvoiceHeaderApproveQueryParam struct {
 	IDs        []string `json:"ids"`
 	QueryValue string   `json:"query_value"`
+	Comment    string   `json:"comment"`
 }
 
 type InvoiceHeaderQueryResult struct {
@@ -64,3 +100,23 @@ func (a InvoiceHeaders) ToMap() map[string]*InvoiceHeader {
 
 	return m
 }
+
+// GetState returns the state of the invoice in InvoiceStateMachine
+func (a *InvoiceHeader) GetState() string {
+	switch a.StatusApprove {
+	case InvoiceApproveReviewed:
+		return InvoiceStateReviewed
+	case InvoiceApproveFinal:
+		return InvoiceStateApproved
+	case InvoiceApproveRejected, InvoiceRejectFinal:
+		return InvoiceStateRejected
+	default:
+		return InvoiceStatePending
+	}
+}
+
+// IsReimbursement tells whether the invoice claims the paid BKKs back to refill petty cash, the type is
+// keyed in as Reimburse or Reimbursement
+func (a *InvoiceHeader) IsReimbursement() bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(a.Type)), "reimburs")
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// SourceType - BKK: BKKHeader, TRD: TarikDana, INV: InvoiceHeader, CCT: CashCount, KBS: Kasbon
+const (
+	JournalSourceBKK       = "BKK"
+	JournalSourceTarikDana = "TRD"
+	JournalSourceInvoice   = "INV"
+	JournalSourceCashCount = "CCT"
+	JournalSourceKasbon    = "KBS"
+)
+
+// GLExportID - the GLExport that sent the journal to Oracle GL, empty until exported
+type JournalHeader struct {
+	database.Model
+	database.ModelTrans
+	ID           string            `gorm:"column:id;size:36;not null;index:idx_id_journal,unique;" json:"id"`
+	CompanyID    string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID     string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	SourceType   string            `gorm:"column:source_type;size:5;index:idx_journal_source;not null;" json:"source_type"`
+	SourceID     string            `gorm:"column:source_id;size:36;index:idx_journal_source;not null;" json:"source_id"`
+	SourceNum    string            `gorm:"column:source_num;size:50;index;not null;" json:"source_num"`
+	Description  string            `gorm:"column:description;not null;" json:"description"`
+	JournalDate  database.Datetime `gorm:"column:journal_date;index;" json:"journal_date"`
+	TotalDebit   int64             `gorm:"column:total_debit;default:0;" json:"total_debit"`
+	TotalCredit  int64             `gorm:"column:total_credit;default:0;" json:"total_credit"`
+	ReversalOf   string            `gorm:"column:reversal_of;size:36;index;not null;" json:"reversal_of"`
+	GLExportID   string            `gorm:"column:gl_export_id;size:36;index;not null;" json:"gl_export_id"`
+	JournalLines JournalLines      `gorm:"foreignKey:JournalHeaderID;references:ID" json:"journal_lines" yaml:"journal_lines"`
+	Company      Company           `gorm:"references:ID" json:"company" yaml:"company"`
+	Branch       Branch            `gorm:"references:ID" json:"branch" yaml:"branch"`
+}
+
+// CashFlag marks the petty cash leg of the journal
+type JournalLine struct {
+	database.Model
+	JournalHeaderID string `gorm:"column:journal_header_id;size:36;index;not null;" json:"journal_header_id"`
+	LineNum         int    `gorm:"column:line_num;not null;" json:"line_num"`
+	TrxID           string `gorm:"column:trx_id;size:36;index;not null;" json:"trx_id"`
+	AccID           string `gorm:"column:account_id;size:36;index;not null;" json:"account_id"`
+	CCID            string `gorm:"column:cc_id;size:36;index;not null;" json:"cc_id"`
+	DeptID          string `gorm:"column:department_id;size:36;index;not null;" json:"department_id"`
+	SegmentedValue  string `gorm:"column:segmented_value;not null;" json:"segmented_value"`
+	Description     string `gorm:"column:description;not null;" json:"description"`
+	Debit           int64  `gorm:"column:debit;default:0;" json:"debit"`
+	Credit          int64  `gorm:"column:credit;default:0;" json:"credit"`
+	CashFlag        bool   `gorm:"column:cash_flag;default:false;" json:"cash_flag"`
+}
+
+type JournalHeaders []*JournalHeader
+
+type JournalLines []*JournalLine
+
+type JournalHeaderQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	IDs        []string `query:"ids"`
+	CompanyID  string   `query:"company_id"`
+	BranchID   string   `query:"branch_id"`
+	SourceType string   `query:"source_type"`
+	SourceID   string   `query:"source_id"`
+	SourceNum  string   `query:"source_num"`
+	DateQuery  []string `query:"date_query"`
+	QueryValue string   `query:"query_value"`
+}
+
+type JournalHeaderQueryResult struct {
+	List       JournalHeaders  `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
+
+func (a JournalHeaders) ToMap() map[string]*JournalHeader {
+	m := make(map[string]*JournalHeader)
+	for _, item := range a {
+		m[item.ID] = item
+	}
+
+	return m
+}
+
+// CashTotals returns the amount flowing into (debit) and out of (credit) the petty cash box
+func (a JournalLines) CashTotals() (in int64, out int64) {
+	for _, item := range a {
+		if item.CashFlag {
+			in += item.Debit
+			out += item.Credit
+		}
+	}
+
+	return in, out
+}
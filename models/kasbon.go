@@ -3,6 +3,27 @@ package models
 import (
 	"github.com/Aguztinus/petty-cash-backend/models/database"
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/fsm"
+)
+
+// Status - Open: requested, Released: cash handed out and counted in Saldo.UsedKBS, Settled: accounted for,
+// Rejected: refused by an approver
+const (
+	KasbonStatusOpen     = "Open"
+	KasbonStatusReleased = "Released"
+	KasbonStatusSettled  = "Settled"
+	KasbonStatusRejected = "Rejected"
+)
+
+// KasbonStateMachine - the release also needs the approval, the state is Status
+var KasbonStateMachine = fsm.New("Kasbon",
+	fsm.Transition{Action: DocActionUpdate, From: []string{KasbonStatusOpen, KasbonStatusRejected}},
+	fsm.Transition{Action: DocActionDelete, From: []string{KasbonStatusOpen}},
+	fsm.Transition{Action: DocActionApprove, From: []string{KasbonStatusOpen}},
+	fsm.Transition{Action: DocActionReject, From: []string{KasbonStatusOpen}, To: KasbonStatusRejected},
+	fsm.Transition{Action: DocActionResubmit, From: []string{KasbonStatusRejected}, To: KasbonStatusOpen},
+	fsm.Transition{Action: DocActionRelease, From: []string{KasbonStatusOpen}, To: KasbonStatusReleased},
+	fsm.Transition{Action: DocActionSettle, From: []string{KasbonStatusReleased}, To: KasbonStatusSettled},
 )
 
 // Status - 1: Enable -1: Disable
@@ -10,7 +31,7 @@ type Kasbon struct {
 	database.Model
 	database.ModelTrans
 	ID          string            `gorm:"column:id;size:36;not null;index;" json:"id"`
-	Num         string            `gorm:"column:num;size:10;not null;index:idx_num,unique;" json:"num"`
+	Num         string            `gorm:"column:num;size:50;not null;index:idx_num,unique;" json:"num"`
 	Type        string            `gorm:"column:type;size:15;index;not null;" json:"type"`
 	Amount      int64             `gorm:"column:amount;default:0;" json:"amount"`
 	Description string            `gorm:"column:description;not null;" json:"description"`
@@ -20,6 +41,7 @@ type Kasbon struct {
 	ReleaseDate database.Datetime `gorm:"column:release_date;" json:"release_date"`
 	PaideDate   database.Datetime `gorm:"column:paid_date;" json:"paid_date"`
 	LunasDate   database.Datetime `gorm:"column:lunas_date;" json:"lunas_date"`
+	DueDate     database.Datetime `gorm:"column:due_date;index;" json:"due_date"`
 	EmployeeID  string            `gorm:"column:employee_id;size:36;index;not null;" json:"employee_id"`
 	BKKHeaderID string            `gorm:"column:bkk_header_id;size:36;index;not null;" json:"bkk_header_id"`
 	CompanyID   string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
@@ -31,10 +53,29 @@ type Kasbon struct {
 	BKKHeader  BKKHeader  `gorm:"foreignKey:BKKHeaderID;references:ID" json:"bkkHeader" yaml:"bkkHeader"`
 	Employee   Employee   `gorm:"references:ID" json:"employee" yaml:"employee"`
 	Department Department `gorm:"foreignKey:DeptID;references:ID" json:"department" yaml:"department"`
+
+	Approval *ApprovalRequest `gorm:"-" json:"approval,omitempty"`
+	Actions  []string         `gorm:"-" json:"actions"`
 }
 
 type Kasbons []*Kasbon
 
+// IsApproved tells whether the approval of the kasbon is decided, Approval must be loaded
+func (a *Kasbon) IsApproved() bool {
+	return a.Approval != nil && a.Approval.Status == ApprovalStatusApproved
+}
+
+// KasbonSettlement is the outcome of a settlement, Refund goes back into the cash box and Payout is paid on top of the kasbon
+type KasbonSettlement struct {
+	KasbonID    string `json:"kasbon_id"`
+	BKKHeaderID string `json:"bkk_header_id"`
+	BKKNum      string `json:"bkk_num"`
+	Amount      int64  `json:"amount"`
+	Expense     int64  `json:"expense"`
+	Refund      int64  `json:"refund"`
+	Payout      int64  `json:"payout"`
+}
+
 type KasbonQueryParam struct {
 	dto.PaginationParam
 	dto.OrderParam
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+)
+
+// Aging buckets by days since the kasbon was released
+const (
+	KasbonAging0To7   = "0-7"
+	KasbonAging8To14  = "8-14"
+	KasbonAging15To30 = "15-30"
+	KasbonAgingOver30 = ">30"
+)
+
+var KasbonAgingBuckets = []string{KasbonAging0To7, KasbonAging8To14, KasbonAging15To30, KasbonAgingOver30}
+
+func KasbonAgingBucketOf(age int) string {
+	switch {
+	case age <= 7:
+		return KasbonAging0To7
+	case age <= 14:
+		return KasbonAging8To14
+	case age <= 30:
+		return KasbonAging15To30
+	default:
+		return KasbonAgingOver30
+	}
+}
+
+// Date - the aging is computed as of this date (2006-01-02), default today
+type KasbonAgingParam struct {
+	CompanyID  string `query:"company_id" json:"company_id"`
+	BranchID   string `query:"branch_id" json:"branch_id"`
+	EmployeeID string `query:"employee_id" json:"employee_id"`
+	Date       string `query:"date" json:"date"`
+}
+
+// AsOf parses Date, an empty or invalid date is today
+func (a KasbonAgingParam) AsOf() time.Time {
+	if t, err := time.ParseInLocation("2006-01-02", a.Date, time.Local); err == nil {
+		return t
+	}
+
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Age is counted in days from ReleaseDate, OverdueDays from DueDate
+type KasbonAgingLine struct {
+	KasbonID     string            `json:"kasbon_id"`
+	Num          string            `json:"num"`
+	Type         string            `json:"type"`
+	Description  string            `json:"description"`
+	EmployeeID   string            `json:"employee_id"`
+	EmployeeName string            `json:"employee_name"`
+	CompanyID    string            `json:"company_id"`
+	CompanyName  string            `json:"company_name"`
+	BranchID     string            `json:"branch_id"`
+	BranchName   string            `json:"branch_name"`
+	Amount       int64             `json:"amount"`
+	ReleaseDate  database.Datetime `json:"release_date"`
+	DueDate      database.Datetime `json:"due_date"`
+	Age          int               `json:"age"`
+	OverdueDays  int               `json:"overdue_days"`
+	Bucket       string            `json:"bucket"`
+}
+
+type KasbonAgingLines []*KasbonAgingLine
+
+type KasbonAgingAmounts struct {
+	Days0To7   int64 `json:"days_0_7"`
+	Days8To14  int64 `json:"days_8_14"`
+	Days15To30 int64 `json:"days_15_30"`
+	Over30     int64 `json:"over_30"`
+	Total      int64 `json:"total"`
+	Overdue    int64 `json:"overdue"`
+	Count      int   `json:"count"`
+}
+
+func (a *KasbonAgingAmounts) Add(line *KasbonAgingLine) {
+	switch line.Bucket {
+	case KasbonAging0To7:
+		a.Days0To7 += line.Amount
+	case KasbonAging8To14:
+		a.Days8To14 += line.Amount
+	case KasbonAging15To30:
+		a.Days15To30 += line.Amount
+	default:
+		a.Over30 += line.Amount
+	}
+
+	if line.OverdueDays > 0 {
+		a.Overdue += line.Amount
+	}
+	a.Total += line.Amount
+	a.Count++
+}
+
+// Values returns the bucket amounts in the order of KasbonAgingBuckets followed by the total
+func (a KasbonAgingAmounts) Values() []int64 {
+	return []int64{a.Days0To7, a.Days8To14, a.Days15To30, a.Over30, a.Total}
+}
+
+type KasbonAgingGroup struct {
+	KasbonAgingAmounts
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type KasbonAgingGroups []*KasbonAgingGroup
+
+type KasbonAgingResult struct {
+	Date      string             `json:"date"`
+	Lines     KasbonAgingLines   `json:"lines"`
+	Employees KasbonAgingGroups  `json:"employees"`
+	Branches  KasbonAgingGroups  `json:"branches"`
+	Companies KasbonAgingGroups  `json:"companies"`
+	Total     KasbonAgingAmounts `json:"total"`
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Event - the workflow events a user is notified of
+const (
+	NotificationApprovalPending  = "approval_pending"
+	NotificationApprovalApproved = "approval_approved"
+	NotificationApprovalRejected = "approval_rejected"
+	NotificationSaldoLow         = "saldo_low"
+	NotificationKasbonOverdue    = "kasbon_overdue"
+)
+
+var NotificationEvents = []string{
+	NotificationApprovalPending,
+	NotificationApprovalApproved,
+	NotificationApprovalRejected,
+	NotificationSaldoLow,
+	NotificationKasbonOverdue,
+}
+
+// EmailStatus - empty when the channel is off, Pending until the dispatcher sends it
+const (
+	NotificationStatusPending = "Pending"
+	NotificationStatusSending = "Sending"
+	NotificationStatusSent    = "Sent"
+	NotificationStatusFailed  = "Failed"
+)
+
+// Notification is stored with the document change and sent by email or webhook after the commit.
+// InApp - listed to the user, a notification wanted only by email or webhook is kept for the dispatcher.
+// WebhookDeliveryID - the webhook delivery queued for it, empty when the channel is off
+type Notification struct {
+	database.Model
+	ID                string            `gorm:"column:id;size:36;not null;index:idx_id_notification,unique;" json:"id"`
+	UserID            string            `gorm:"column:user_id;size:36;index:idx_notification_user;not null;" json:"user_id"`
+	Event             string            `gorm:"column:event;size:30;index;not null;" json:"event"`
+	Title             string            `gorm:"column:title;not null;" json:"title"`
+	Message           string            `gorm:"column:message;size:500;not null;" json:"message"`
+	DocType           string            `gorm:"column:doc_type;size:5;index:idx_notification_doc;" json:"doc_type"`
+	DocID             string            `gorm:"column:doc_id;size:36;index:idx_notification_doc;" json:"doc_id"`
+	DocNum            string            `gorm:"column:doc_num;size:100;" json:"doc_num"`
+	InApp             bool              `gorm:"column:in_app;index:idx_notification_user;" json:"-"`
+	ReadAt            database.Datetime `gorm:"column:read_at;" json:"read_at"`
+	Email             string            `gorm:"column:email;size:100;" json:"-"`
+	EmailStatus       string            `gorm:"column:email_status;size:10;index;" json:"email_status"`
+	WebhookDeliveryID string            `gorm:"column:webhook_delivery_id;size:36;" json:"webhook_delivery_id"`
+	LastError         string            `gorm:"column:last_error;size:500;" json:"-"`
+}
+
+type Notifications []*Notification
+
+// NotificationPreference - the channels of one event for a user, an event without preference
+// is only notified in app. WebhookEndpointID is the endpoint of WebhookURL, WebhookSecret is only
+// answered by the update that registered the url
+type NotificationPreference struct {
+	database.Model
+	UserID            string `gorm:"column:user_id;size:36;index:idx_notification_preference,unique;not null;" json:"-"`
+	Event             string `gorm:"column:event;size:30;index:idx_notification_preference,unique;not null;" json:"event" validate:"required"`
+	InApp             bool   `gorm:"column:in_app;" json:"in_app"`
+	Email             bool   `gorm:"column:email;" json:"email"`
+	Webhook           bool   `gorm:"column:webhook;" json:"webhook"`
+	WebhookURL        string `gorm:"column:webhook_url;size:500;" json:"webhook_url"`
+	WebhookEndpointID string `gorm:"column:webhook_endpoint_id;size:36;" json:"-"`
+	WebhookSecret     string `gorm:"-" json:"webhook_secret,omitempty"`
+}
+
+type NotificationPreferences []*NotificationPreference
+
+// NotificationEvent is raised by the services, every user of Usernames is notified through the channels
+// of its preference
+type NotificationEvent struct {
+	Event     string
+	Usernames []string
+	Title     string
+	Message   string
+	DocType   string
+	DocID     string
+	DocNum    string
+}
+
+type NotificationQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	UserID string `query:"-"`
+	Event  string `query:"event"`
+	Unread bool   `query:"unread"`
+}
+
+type NotificationQueryResult struct {
+	List       Notifications   `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
+
+type NotificationUnread struct {
+	Count int64 `json:"count"`
+}
+
+func (a NotificationPreferences) ToMap() map[string]*NotificationPreference {
+	m := make(map[string]*NotificationPreference)
+	for _, item := range a {
+		m[item.Event] = item
+	}
+
+	return m
+}
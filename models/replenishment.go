@@ -0,0 +1,105 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Source - MANUAL: raised by the branch, THRESHOLD: Saldo.SaldoAkhir dropped below Saldo.MinSaldo,
+// INVOICE: a reimbursement InvoiceHeader was final approved
+const (
+	ReplenishmentSourceManual    = "MANUAL"
+	ReplenishmentSourceThreshold = "THRESHOLD"
+	ReplenishmentSourceInvoice   = "INVOICE"
+)
+
+// Status - Pending, Approved, Rejected
+const (
+	ReplenishmentStatusPending  = "Pending"
+	ReplenishmentStatusApproved = "Approved"
+	ReplenishmentStatusRejected = "Rejected"
+)
+
+// TarikDanaTypeReplenishment is the TarikDana.Type posted when HQ approves a replenishment
+const TarikDanaTypeReplenishment = "Replenishment"
+
+// SaldoAkhir is the branch balance when the request was raised
+type Replenishment struct {
+	database.Model
+	database.ModelTrans
+	ID                    string                `gorm:"column:id;size:36;not null;index;" json:"id"`
+	Num                   string                `gorm:"column:num;size:50;not null;index:idx_replenishment_num,unique;" json:"num"`
+	CompanyID             string                `gorm:"column:company_id;size:36;index;not null;" json:"company_id" validate:"required"`
+	BranchID              string                `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id" validate:"required"`
+	Source                string                `gorm:"column:source;size:15;index;not null;" json:"source"`
+	Amount                int64                 `gorm:"column:amount;default:0;" json:"amount"`
+	SaldoAkhir            int64                 `gorm:"column:saldo_akhir;default:0;" json:"saldo_akhir"`
+	Description           string                `gorm:"column:description;" json:"description"`
+	Status                string                `gorm:"column:status;size:15;index;not null;" json:"status"`
+	ApprovedBy            string                `gorm:"column:approved_by;size:64;" json:"approved_by"`
+	ApprovedDate          database.Datetime     `gorm:"column:approved_date;" json:"approved_date"`
+	RejectReason          string                `gorm:"column:reject_reason;" json:"reject_reason"`
+	TarikDanaID           string                `gorm:"column:tarik_dana_id;size:36;index;" json:"tarik_dana_id"`
+	ReplenishmentInvoices ReplenishmentInvoices `gorm:"foreignKey:ReplenishmentID;references:ID" json:"replenishment_invoices" yaml:"replenishment_invoices"`
+	TarikDana             *TarikDana            `gorm:"foreignKey:TarikDanaID;references:ID" json:"tarik_dana,omitempty" yaml:"tarik_dana"`
+	Company               Company               `gorm:"references:ID" json:"company" yaml:"company"`
+	Branch                Branch                `gorm:"references:ID" json:"branch" yaml:"branch"`
+}
+
+// ReplenishmentInvoice - an invoice is reimbursed by one request, the links of a rejected request are
+// removed so its invoices can be requested again
+type ReplenishmentInvoice struct {
+	database.Model
+	ReplenishmentID string        `gorm:"column:replenishment_id;size:36;index;not null;" json:"replenishment_id"`
+	InvoiceHeaderID string        `gorm:"column:invoice_header_id;size:36;index:idx_replenishment_invoice,unique;not null;" json:"invoice_header_id"`
+	Amount          int64         `gorm:"column:amount;default:0;" json:"amount"`
+	InvoiceHeader   InvoiceHeader `gorm:"foreignKey:InvoiceHeaderID;references:ID" json:"invoice_header" yaml:"invoice_header"`
+}
+
+type Replenishments []*Replenishment
+
+type ReplenishmentInvoices []*ReplenishmentInvoice
+
+type ReplenishmentQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	IDs             []string `query:"ids"`
+	Num             string   `query:"num"`
+	CompanyID       string   `query:"company_id"`
+	BranchID        string   `query:"branch_id"`
+	Source          string   `query:"source"`
+	Status          string   `query:"status"`
+	InvoiceHeaderID string   `query:"invoice_header_id"`
+	DateQuery       []string `query:"date_query"`
+	QueryValue      string   `query:"query_value"`
+}
+
+// ReplenishmentParam is the manual request, Amount defaults to topping the float back up to Saldo.ImprestAmount
+type ReplenishmentParam struct {
+	CompanyID        string   `json:"company_id" validate:"required"`
+	BranchID         string   `json:"branch_id" validate:"required"`
+	Amount           int64    `json:"amount"`
+	Description      string   `json:"description"`
+	InvoiceHeaderIDs []string `json:"invoice_header_ids"`
+}
+
+// ReplenishmentApproveParam lets HQ approve a different amount, Reason is required for a rejection
+type ReplenishmentApproveParam struct {
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+type ReplenishmentQueryResult struct {
+	List       Replenishments  `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
+
+func (a Replenishments) ToMap() map[string]*Replenishment {
+	m := make(map[string]*Replenishment)
+	for _, item := range a {
+		m[item.ID] = item
+	}
+
+	return m
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Type - the reports generated by the report job queue
+const (
+	ReportJobLmdp = "lmdp"
+	ReportJobLrdp = "lrdp"
+)
+
+// Format - the files a report job writes, csv is streamed by the export instead
+const (
+	ReportFormatPdf  = "pdf"
+	ReportFormatXlsx = "xlsx"
+	ReportFormatCsv  = "csv"
+)
+
+// ReportJobParam - Type is lmdp or lrdp, Format is pdf (the default) or xlsx, DateParams holds
+// the first and the last day in RFC3339
+type ReportJobParam struct {
+	Type   string `json:"type" query:"type"`
+	Format string `json:"format" query:"format"`
+	dto.ReportMonitoring
+}
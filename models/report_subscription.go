@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+	"github.com/Aguztinus/petty-cash-backend/pkg/schedule"
+)
+
+// Report - the reports that can be emailed on a schedule, besides ReportJobLmdp and ReportJobLrdp
+const (
+	ReportExpenseAnalysis = "expense_analysis"
+	ReportKasbonAging     = "kasbon_aging"
+)
+
+// ReportSubscription emails a report on a schedule, a run reports on the days since the run before.
+// Frequency - daily, weekly or monthly, Day - the weekday (0 is Sunday) or the day of the month,
+// Hour - 0-23, Recipients - email addresses separated by commas, Paused - not sent until resumed.
+// NextRunAt is claimed by the scheduler so only one instance runs a subscription
+type ReportSubscription struct {
+	database.Model
+	database.ModelTrans
+	ID         string            `gorm:"column:id;size:36;not null;index:idx_id_report_subscription,unique;" json:"id"`
+	Name       string            `gorm:"column:name;not null;" json:"name" validate:"required"`
+	Report     string            `gorm:"column:report;size:20;not null;" json:"report" validate:"required"`
+	Format     string            `gorm:"column:format;size:10;not null;" json:"format"`
+	CompanyID  string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID   string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	Frequency  string            `gorm:"column:frequency;size:10;not null;" json:"frequency" validate:"required"`
+	Day        int               `gorm:"column:day;default:0;" json:"day"`
+	Hour       int               `gorm:"column:hour;default:0;" json:"hour"`
+	Recipients string            `gorm:"column:recipients;size:1000;not null;" json:"recipients" validate:"required"`
+	Paused     bool              `gorm:"column:paused;index;" json:"paused"`
+	OwnerID    string            `gorm:"column:owner_id;size:36;index;not null;" json:"owner_id"`
+	NextRunAt  database.Datetime `gorm:"column:next_run_at;index;" json:"next_run_at"`
+	LastRunAt  database.Datetime `gorm:"column:last_run_at;" json:"last_run_at"`
+	LastError  string            `gorm:"column:last_error;size:500;not null;" json:"last_error"`
+}
+
+type ReportSubscriptions []*ReportSubscription
+
+type ReportSubscriptionQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	OwnerID   string `query:"owner_id"`
+	CompanyID string `query:"company_id"`
+	BranchID  string `query:"branch_id"`
+	Report    string `query:"report"`
+}
+
+type ReportSubscriptionQueryResult struct {
+	List       ReportSubscriptions `json:"list"`
+	Pagination *dto.Pagination     `json:"pagination"`
+}
+
+func (a ReportSubscription) Schedule() schedule.Schedule {
+	return schedule.Schedule{Frequency: a.Frequency, Day: a.Day, Hour: a.Hour}
+}
+
+// RecipientList splits Recipients, empty entries are dropped
+func (a ReportSubscription) RecipientList() []string {
+	var list []string
+	for _, v := range strings.Split(a.Recipients, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
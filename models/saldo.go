@@ -6,22 +6,29 @@ import (
 )
 
 // Status - 1: Enable -1: Disable
+// ImprestAmount is the float of the branch, falling below MinSaldo raises a replenishment request
 type Saldo struct {
 	database.Model
 	database.ModelMaster
-	ID         string  `gorm:"column:id;size:36;not null;index;" json:"id"`
-	CompanyID  string  `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
-	BranchID   string  `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
-	SaldoAwal  int64   `gorm:"column:saldo_awal;default:0;" json:"saldo_awal"`
-	SaldoIn    int64   `gorm:"column:saldo_in;default:0;" json:"saldo_in"`
-	LimitBKK   int64   `gorm:"column:limit_bkk;default:0;" json:"limit_bkk"`
-	LimitKBS   int64   `gorm:"column:limit_kbs;default:0;" json:"limit_kbs"`
-	UsedBKK    int64   `gorm:"column:used_bkk;default:0;" json:"used_bkk"`
-	UsedKBS    int64   `gorm:"column:used_kbs;default:0;" json:"used_kbs"`
-	SaldoAkhir int64   `gorm:"column:saldo_akhir;default:0;" json:"saldo_akhir"`
-	MonthYear  string  `gorm:"column:month_year;size:10;index;not null;" json:"month_year"`
-	Company    Company `gorm:"-" json:"company" yaml:"company"`
-	Branch     Branch  `gorm:"-" json:"branch" yaml:"branch"`
+	ID            string  `gorm:"column:id;size:36;not null;index;" json:"id"`
+	CompanyID     string  `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID      string  `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	SaldoAwal     int64   `gorm:"column:saldo_awal;default:0;" json:"saldo_awal"`
+	SaldoIn       int64   `gorm:"column:saldo_in;default:0;" json:"saldo_in"`
+	LimitBKK      int64   `gorm:"column:limit_bkk;default:0;" json:"limit_bkk"`
+	LimitKBS      int64   `gorm:"column:limit_kbs;default:0;" json:"limit_kbs"`
+	UsedBKK       int64   `gorm:"column:used_bkk;default:0;" json:"used_bkk"`
+	UsedKBS       int64   `gorm:"column:used_kbs;default:0;" json:"used_kbs"`
+	SaldoAkhir    int64   `gorm:"column:saldo_akhir;default:0;" json:"saldo_akhir"`
+	MonthYear     string  `gorm:"column:month_year;size:10;index;not null;" json:"month_year"`
+	CashTrxID     string  `gorm:"column:cash_trx_id;size:36;" json:"cash_trx_id"`
+	FundTrxID     string  `gorm:"column:fund_trx_id;size:36;" json:"fund_trx_id"`
+	VarianceTrxID string  `gorm:"column:variance_trx_id;size:36;" json:"variance_trx_id"`
+	KasbonTrxID   string  `gorm:"column:kasbon_trx_id;size:36;" json:"kasbon_trx_id"`
+	MinSaldo      int64   `gorm:"column:min_saldo;default:0;" json:"min_saldo"`
+	ImprestAmount int64   `gorm:"column:imprest_amount;default:0;" json:"imprest_amount"`
+	Company       Company `gorm:"-" json:"company" yaml:"company"`
+	Branch        Branch  `gorm:"-" json:"branch" yaml:"branch"`
 }
 
 type Saldos []*Saldo
@@ -9,19 +9,22 @@ import (
 type SaldoMonth struct {
 	database.Model
 	database.ModelMaster
-	ID         string  `gorm:"column:id;size:36;not null;index;" json:"id"`
-	CompanyID  string  `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
-	BranchID   string  `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
-	SaldoAwal  int64   `gorm:"column:saldo_awal;default:0;" json:"saldo_awal"`
-	SaldoIn    int64   `gorm:"column:saldo_in;default:0;" json:"saldo_in"`
-	UsedBKK    int64   `gorm:"column:used_bkk;default:0;" json:"used_bkk"`
-	UsedKBS    int64   `gorm:"column:used_kbs;default:0;" json:"used_kbs"`
-	SaldoAkhir int64   `gorm:"column:saldo_akhir;default:0;" json:"saldo_akhir"`
-	MonthYear  string  `gorm:"column:month_year;size:10;index;not null;" json:"month_year"`
-	Month      int     `gorm:"column:month;index;not null;" json:"month"`
-	Year       int     `gorm:"column:year;index;not null;" json:"year"`
-	Company    Company `gorm:"-" json:"company" yaml:"company"`
-	Branch     Branch  `gorm:"-" json:"branch" yaml:"branch"`
+	ID         string            `gorm:"column:id;size:36;not null;index;" json:"id"`
+	CompanyID  string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID   string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	SaldoAwal  int64             `gorm:"column:saldo_awal;default:0;" json:"saldo_awal"`
+	SaldoIn    int64             `gorm:"column:saldo_in;default:0;" json:"saldo_in"`
+	UsedBKK    int64             `gorm:"column:used_bkk;default:0;" json:"used_bkk"`
+	UsedKBS    int64             `gorm:"column:used_kbs;default:0;" json:"used_kbs"`
+	SaldoAkhir int64             `gorm:"column:saldo_akhir;default:0;" json:"saldo_akhir"`
+	MonthYear  string            `gorm:"column:month_year;size:10;index;not null;" json:"month_year"`
+	Month      int               `gorm:"column:month;index;not null;" json:"month"`
+	Year       int               `gorm:"column:year;index;not null;" json:"year"`
+	ClosedFlag bool              `gorm:"column:closed_flag;default:false;" json:"closed_flag"`
+	ClosedDate database.Datetime `gorm:"column:closed_date;" json:"closed_date"`
+	ClosedBy   string            `gorm:"column:closed_by;size:64;" json:"closed_by"`
+	Company    Company           `gorm:"-" json:"company" yaml:"company"`
+	Branch     Branch            `gorm:"-" json:"branch" yaml:"branch"`
 }
 
 type SaldoMonths []*SaldoMonth
@@ -42,9 +45,17 @@ type SaldoMonthQueryParam struct {
 	MonthYear  string   `query:"month_year"`
 	Month      int      `query:"month"`
 	Year       int      `query:"year"`
+	ClosedFlag string   `query:"closed_flag"`
 	QueryValue string   `query:"query_value"`
 }
 
+type SaldoMonthCloseParam struct {
+	CompanyID string `json:"company_id" validate:"required"`
+	BranchID  string `json:"branch_id" validate:"required"`
+	MonthYear string `json:"month_year" validate:"required"`
+	Reason    string `json:"reason"`
+}
+
 type SaldoMonthQueryResult struct {
 	List       SaldoMonths     `json:"list"`
 	Pagination *dto.Pagination `json:"pagination"`
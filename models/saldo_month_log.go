@@ -0,0 +1,45 @@
+package models
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Action - CLOSE: period closed REOPEN: period reopened
+const (
+	SaldoMonthActionClose  = "CLOSE"
+	SaldoMonthActionReopen = "REOPEN"
+)
+
+type SaldoMonthLog struct {
+	database.Model
+	ID           string `gorm:"column:id;size:36;not null;index;" json:"id"`
+	SaldoMonthID string `gorm:"column:saldo_month_id;size:36;index;not null;" json:"saldo_month_id"`
+	CompanyID    string `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID     string `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	MonthYear    string `gorm:"column:month_year;size:10;index;not null;" json:"month_year"`
+	Action       string `gorm:"column:action;size:10;not null;" json:"action"`
+	Reason       string `gorm:"column:reason;not null;" json:"reason"`
+	SaldoAwal    int64  `gorm:"column:saldo_awal;default:0;" json:"saldo_awal"`
+	SaldoAkhir   int64  `gorm:"column:saldo_akhir;default:0;" json:"saldo_akhir"`
+	ActionBy     string `gorm:"column:action_by;size:64;not null;" json:"action_by"`
+	IP           string `gorm:"column:ip;size:45;" json:"ip"`
+}
+
+type SaldoMonthLogs []*SaldoMonthLog
+
+type SaldoMonthLogQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	SaldoMonthID string `query:"saldo_month_id"`
+	CompanyID    string `query:"company_id"`
+	BranchID     string `query:"branch_id"`
+	MonthYear    string `query:"month_year"`
+	Action       string `query:"action"`
+}
+
+type SaldoMonthLogQueryResult struct {
+	List       SaldoMonthLogs  `json:"list"`
+	Pagination *dto.Pagination `json:"pagination"`
+}
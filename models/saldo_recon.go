@@ -0,0 +1,53 @@
+package models
+
+// Table - saldo: Saldo, saldo_month: SaldoMonth, saldo_history: SaldoHistory
+const (
+	SaldoReconTableSaldo   = "saldo"
+	SaldoReconTableMonth   = "saldo_month"
+	SaldoReconTableHistory = "saldo_history"
+)
+
+type SaldoReconParam struct {
+	CompanyID string `query:"company_id" json:"company_id"`
+	BranchID  string `query:"branch_id" json:"branch_id"`
+	Fix       bool   `query:"fix" json:"fix"`
+}
+
+// SaldoReconLine is one drifting column, RefID is the row id (record_id for SaldoHistory)
+// and Skipped marks lines that were not rewritten because their month is closed
+type SaldoReconLine struct {
+	CompanyID string `json:"company_id"`
+	BranchID  string `json:"branch_id"`
+	Table     string `json:"table"`
+	RefID     string `json:"ref_id"`
+	MonthYear string `json:"month_year"`
+	Field     string `json:"field"`
+	Stored    int64  `json:"stored"`
+	Computed  int64  `json:"computed"`
+	Drift     int64  `json:"drift"`
+	Skipped   bool   `json:"skipped"`
+}
+
+type SaldoReconLines []*SaldoReconLine
+
+type SaldoReconResult struct {
+	Branches int             `json:"branches"`
+	Fixed    bool            `json:"fixed"`
+	Lines    SaldoReconLines `json:"lines"`
+}
+
+// Append adds a line for field when the stored value differs from the computed one
+func (a *SaldoReconLines) Append(base SaldoReconLine, field string, stored int64, computed int64) bool {
+	if stored == computed {
+		return false
+	}
+
+	line := base
+	line.Field = field
+	line.Stored = stored
+	line.Computed = computed
+	line.Drift = stored - computed
+	*a = append(*a, &line)
+
+	return true
+}
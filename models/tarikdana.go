@@ -5,21 +5,38 @@ import (
 	"github.com/Aguztinus/petty-cash-backend/models/dto"
 )
 
+// PostStatus - Pending: waiting for approval, Posted: booked into Saldo, Rejected, Voided: posting
+// reversed. Rows from before the approval engine have no PostStatus and are posted.
+const (
+	TarikDanaPostPending  = "Pending"
+	TarikDanaPostPosted   = "Posted"
+	TarikDanaPostRejected = "Rejected"
+	TarikDanaPostVoided   = "Voided"
+)
+
 // Status - 1: Enable -1: Disable
 type TarikDana struct {
 	database.Model
 	database.ModelTrans
-	ID          string            `gorm:"column:id;size:36;not null;index;" json:"id"`
-	Type        string            `gorm:"column:type;size:15;index;not null;" json:"type"`
-	Amount      int64             `gorm:"column:amount;default:0;" json:"amount"`
-	Description string            `gorm:"column:description;not null;" json:"description"`
-	Date        database.Datetime `gorm:"column:date;" json:"date"`
-	File        string            `gorm:"column:file;not null;" json:"file"`
-	CompanyID   string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
-	BranchID    string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	ID              string            `gorm:"column:id;size:36;not null;index;" json:"id"`
+	Num             string            `gorm:"column:num;size:50;not null;index;" json:"num"`
+	Type            string            `gorm:"column:type;size:15;index;not null;" json:"type"`
+	Amount          int64             `gorm:"column:amount;default:0;" json:"amount"`
+	Description     string            `gorm:"column:description;not null;" json:"description"`
+	Date            database.Datetime `gorm:"column:date;" json:"date"`
+	File            string            `gorm:"column:file;not null;" json:"file"`
+	CompanyID       string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	BranchID        string            `gorm:"column:branch_id;size:36;index;not null;" json:"branch_id"`
+	ReplenishmentID string            `gorm:"column:replenishment_id;size:36;index;" json:"replenishment_id"`
+	PostStatus      string            `gorm:"column:post_status;size:15;index;" json:"post_status"`
+	VoidDate        database.Datetime `gorm:"column:void_date;" json:"void_date"`
+	VoidReason      string            `gorm:"column:void_reason;size:500;not null;" json:"void_reason"`
+	VoidBy          string            `gorm:"column:void_by;size:50;not null;" json:"void_by"`
 
 	Company Company `gorm:"references:ID" json:"company" yaml:"company"`
 	Branch  Branch  `gorm:"references:ID" json:"branch" yaml:"branch"`
+
+	Approval *ApprovalRequest `gorm:"-" json:"approval,omitempty"`
 }
 
 type TarikDanas []*TarikDana
@@ -28,15 +45,17 @@ type TarikDanaQueryParam struct {
 	dto.PaginationParam
 	dto.OrderParam
 
-	IDs         []string `query:"ids"`
-	Type        string   `query:"type"`
-	Amount      int64    `query:"amount"`
-	Description string   `query:"description"`
-	Date        string   `query:"date"`
-	File        string   `query:"file"`
-	CompanyID   string   `query:"company_id"`
-	BranchID    string   `query:"branch_id"`
-	QueryValue  string   `query:"query_value"`
+	IDs             []string `query:"ids"`
+	Num             string   `query:"num"`
+	Type            string   `query:"type"`
+	Amount          int64    `query:"amount"`
+	Description     string   `query:"description"`
+	Date            string   `query:"date"`
+	File            string   `query:"file"`
+	CompanyID       string   `query:"company_id"`
+	BranchID        string   `query:"branch_id"`
+	ReplenishmentID string   `query:"replenishment_id"`
+	QueryValue      string   `query:"query_value"`
 }
 
 type TarikDanaQueryResult struct {
@@ -44,6 +63,11 @@ type TarikDanaQueryResult struct {
 	Pagination *dto.Pagination `json:"pagination"`
 }
 
+// IsPosted - rows without PostStatus were posted before the approval engine
+func (a *TarikDana) IsPosted() bool {
+	return a.PostStatus == TarikDanaPostPosted || a.PostStatus == ""
+}
+
 func (a TarikDanas) ToMap() map[string]*TarikDana {
 	m := make(map[string]*TarikDana)
 	for _, item := range a {
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/Aguztinus/petty-cash-backend/models/database"
+	"github.com/Aguztinus/petty-cash-backend/models/dto"
+)
+
+// Event - the domain events a webhook endpoint can subscribe to
+const (
+	WebhookBKKCreated           = "bkk.created"
+	WebhookBKKPaid              = "bkk.paid"
+	WebhookInvoiceFinalApproved = "invoice.final_approved"
+	WebhookTarikDanaCreated     = "tarikdana.created"
+	WebhookSaldoBelowLimit      = "saldo.below_limit"
+)
+
+var WebhookEvents = []string{
+	WebhookBKKCreated,
+	WebhookBKKPaid,
+	WebhookInvoiceFinalApproved,
+	WebhookTarikDanaCreated,
+	WebhookSaldoBelowLimit,
+}
+
+// WebhookEndpoint receives the events of a company it subscribes to, Events are separated by commas.
+// Secret signs the deliveries, it is generated on create and only shown then. UserID is set on the
+// endpoint of a user's notification webhook, which only gets the notifications of that user
+type WebhookEndpoint struct {
+	database.Model
+	database.ModelMaster
+	ID        string `gorm:"column:id;size:36;not null;index:idx_id_webhook_endpoint,unique;" json:"id"`
+	CompanyID string `gorm:"column:company_id;size:36;index;not null;" json:"company_id" validate:"required"`
+	UserID    string `gorm:"column:user_id;size:36;index;not null;default:'';" json:"-"`
+	Name      string `gorm:"column:name;not null;" json:"name" validate:"required"`
+	URL       string `gorm:"column:url;size:500;not null;" json:"url" validate:"required"`
+	Secret    string `gorm:"column:secret;size:100;not null;" json:"-" audit:"-"`
+	Events    string `gorm:"column:events;size:500;not null;" json:"events" validate:"required"`
+}
+
+type WebhookEndpoints []*WebhookEndpoint
+
+// WebhookEndpointCreated is the created endpoint with its secret, which is not shown afterwards
+type WebhookEndpointCreated struct {
+	*WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// Status - Pending: waiting for its next attempt, Success: answered 2xx, Failed: out of attempts
+const (
+	WebhookDeliveryStatusPending = "Pending"
+	WebhookDeliveryStatusSuccess = "Success"
+	WebhookDeliveryStatusFailed  = "Failed"
+)
+
+// WebhookDelivery is one event sent to an endpoint, Pending until it succeeds or runs out of attempts.
+// NextAttemptAt is moved forward by the dispatcher when it claims an attempt, ReplayOf is the delivery
+// replayed by this one
+type WebhookDelivery struct {
+	database.Model
+	ID                string            `gorm:"column:id;size:36;not null;index:idx_id_webhook_delivery,unique;" json:"id"`
+	WebhookEndpointID string            `gorm:"column:webhook_endpoint_id;size:36;index;not null;" json:"webhook_endpoint_id"`
+	CompanyID         string            `gorm:"column:company_id;size:36;index;not null;" json:"company_id"`
+	Event             string            `gorm:"column:event;size:30;index;not null;" json:"event"`
+	DocID             string            `gorm:"column:doc_id;size:36;index;" json:"doc_id"`
+	Payload           string            `gorm:"column:payload;type:text;not null;" json:"payload"`
+	Status            string            `gorm:"column:status;size:10;index:idx_webhook_delivery_due;not null;" json:"status"`
+	Attempts          int               `gorm:"column:attempts;default:0;" json:"attempts"`
+	NextAttemptAt     database.Datetime `gorm:"column:next_attempt_at;index:idx_webhook_delivery_due;" json:"next_attempt_at"`
+	LastStatusCode    int               `gorm:"column:last_status_code;default:0;" json:"last_status_code"`
+	LastError         string            `gorm:"column:last_error;size:500;" json:"last_error"`
+	DeliveredAt       database.Datetime `gorm:"column:delivered_at;" json:"delivered_at"`
+	ReplayOf          string            `gorm:"column:replay_of;size:36;" json:"replay_of"`
+}
+
+type WebhookDeliveries []*WebhookDelivery
+
+// WebhookPayload is the body of a delivery, Data is the document of the event
+type WebhookPayload struct {
+	ID        string            `json:"id"`
+	Event     string            `json:"event"`
+	CompanyID string            `json:"company_id"`
+	CreatedAt database.Datetime `json:"created_at"`
+	Data      interface{}       `json:"data"`
+}
+
+type WebhookEndpointQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	CompanyID string `query:"company_id"`
+	Event     string `query:"event"`
+}
+
+type WebhookEndpointQueryResult struct {
+	List       WebhookEndpoints `json:"list"`
+	Pagination *dto.Pagination  `json:"pagination"`
+}
+
+type WebhookDeliveryQueryParam struct {
+	dto.PaginationParam
+	dto.OrderParam
+
+	WebhookEndpointID string `query:"webhook_endpoint_id"`
+	CompanyID         string `query:"company_id"`
+	Event             string `query:"event"`
+	Status            string `query:"status"`
+	DocID             string `query:"doc_id"`
+}
+
+type WebhookDeliveryQueryResult struct {
+	List       WebhookDeliveries `json:"list"`
+	Pagination *dto.Pagination   `json:"pagination"`
+}
+
+// EventList splits Events, empty entries are dropped
+func (a WebhookEndpoint) EventList() []string {
+	var list []string
+	for _, v := range strings.Split(a.Events, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
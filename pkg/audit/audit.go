@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Action - the write that produced an Entry
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Actor is who made the request, the core middleware puts it in the request context with the request ID
+// and the IP, the auth middleware fills in the user once the token is parsed
+type Actor struct {
+	UserID    string
+	Username  string
+	RequestID string
+	IPAddress string
+}
+
+type actorKey struct{}
+
+// NewContext returns a copy of ctx carrying actor
+func NewContext(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// FromContext returns the actor of ctx, writes made outside a request have none
+func FromContext(ctx context.Context) *Actor {
+	if ctx == nil {
+		return nil
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(*Actor)
+	return actor
+}
+
+// Change is the value of a column before and after the write
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Entry is one audited row
+type Entry struct {
+	Entity   string
+	EntityID string
+	Action   string
+	Changes  map[string]Change
+	Actor    Actor
+}
+
+// Diff returns the columns whose value differs between before and after, a nil map stands for a row
+// that does not exist. Columns listed in ignore are left out.
+func Diff(before map[string]interface{}, after map[string]interface{}, ignore ...string) map[string]Change {
+	skip := make(map[string]bool, len(ignore))
+	for _, column := range ignore {
+		skip[column] = true
+	}
+
+	changes := make(map[string]Change)
+	for column, from := range before {
+		if to := after[column]; !skip[column] && !equal(from, to) {
+			changes[column] = Change{From: from, To: to}
+		}
+	}
+
+	for column, to := range after {
+		if _, ok := before[column]; !ok && !skip[column] && to != nil {
+			changes[column] = Change{To: to}
+		}
+	}
+
+	return changes
+}
+
+func equal(a interface{}, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	} else if a == nil || b == nil {
+		return false
+	}
+
+	// values read back from the database may not have the type of the model field
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
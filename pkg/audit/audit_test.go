@@ -0,0 +1,50 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/schema"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{"id": "a", "status": "Pending", "amount": int64(10), "updated_at": "2021-01-01 00:00:00"}
+	after := map[string]interface{}{"id": "a", "status": "Paid", "amount": int64(10), "updated_at": "2021-01-02 00:00:00"}
+
+	changes := Diff(before, after, "updated_at")
+	assert.Equal(t, map[string]Change{"status": {From: "Pending", To: "Paid"}}, changes)
+
+	changes = Diff(nil, map[string]interface{}{"id": "a", "void_date": nil})
+	assert.Equal(t, map[string]Change{"id": {To: "a"}}, changes)
+
+	changes = Diff(map[string]interface{}{"id": "a"}, nil)
+	assert.Equal(t, map[string]Change{"id": {From: "a"}}, changes)
+
+	// a column read back as another type is not a change
+	changes = Diff(map[string]interface{}{"status_approve": int8(1)}, map[string]interface{}{"status_approve": int64(1)})
+	assert.Empty(t, changes)
+}
+
+func TestContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+
+	actor := &Actor{RequestID: "r1", IPAddress: "127.0.0.1"}
+	ctx := NewContext(context.Background(), actor)
+	actor.Username = "admin"
+
+	assert.Equal(t, "admin", FromContext(ctx).Username)
+}
+
+func TestIgnored(t *testing.T) {
+	type endpoint struct {
+		ID        string
+		Secret    string `audit:"-"`
+		UpdatedAt string
+	}
+
+	s, err := schema.Parse(&endpoint{}, &sync.Map{}, schema.NamingStrategy{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"updated_at", "secret"}, ignored(s))
+}
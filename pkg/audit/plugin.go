@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const beforeKey = "audit:before"
+
+// Store writes an Entry within the transaction of the audited statement
+type Store func(tx *gorm.DB, entry *Entry) error
+
+// Plugin audits every create, update and delete of a model that has the created_by and update_by
+// columns of database.ModelTrans and database.ModelMaster, the fields tagged audit:"-" are never logged
+type Plugin struct {
+	store Store
+}
+
+// NewPlugin creates the plugin, register it with gorm.DB.Use
+func NewPlugin(store Store) *Plugin {
+	return &Plugin{store: store}
+}
+
+func (a *Plugin) Name() string {
+	return "audit"
+}
+
+func (a *Plugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	if err := callback.Create().After("gorm:create").Register("audit:after_create", a.afterCreate); err != nil {
+		return err
+	}
+
+	if err := callback.Update().Before("gorm:update").Register("audit:before_update", a.before); err != nil {
+		return err
+	}
+
+	if err := callback.Update().After("gorm:update").Register("audit:after_update", a.afterUpdate); err != nil {
+		return err
+	}
+
+	if err := callback.Delete().Before("gorm:delete").Register("audit:before_delete", a.before); err != nil {
+		return err
+	}
+
+	return callback.Delete().After("gorm:delete").Register("audit:after_delete", a.afterDelete)
+}
+
+func audited(db *gorm.DB) bool {
+	s := db.Statement.Schema
+	return db.Error == nil && s != nil && s.LookUpField("created_by") != nil && s.LookUpField("update_by") != nil
+}
+
+// before keeps the rows the statement is about to change
+func (a *Plugin) before(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	tx := a.session(db)
+	if where, ok := db.Statement.Clauses["WHERE"]; ok {
+		tx = tx.Clauses(where.Expression)
+	} else if field := db.Statement.Schema.PrioritizedPrimaryField; field != nil && db.Statement.ReflectValue.Kind() == reflect.Struct {
+		value, zero := field.ValueOf(db.Statement.ReflectValue)
+		if zero {
+			return
+		}
+		tx = tx.Where(clause.Eq{Column: clause.Column{Name: field.DBName}, Value: value})
+	} else {
+		return
+	}
+
+	rows := make([]map[string]interface{}, 0)
+	if err := tx.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+
+	db.InstanceSet(beforeKey, rows)
+}
+
+func (a *Plugin) afterCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	value := reflect.Indirect(db.Statement.ReflectValue)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			a.write(db, ActionCreate, nil, values(db.Statement.Schema, reflect.Indirect(value.Index(i))))
+		}
+	case reflect.Struct:
+		a.write(db, ActionCreate, nil, values(db.Statement.Schema, value))
+	}
+}
+
+func (a *Plugin) afterUpdate(db *gorm.DB) {
+	rows := a.beforeRows(db)
+	if len(rows) == 0 {
+		return
+	}
+
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return
+	}
+
+	keys := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row[field.DBName])
+	}
+
+	after := make([]map[string]interface{}, 0)
+	if err := a.session(db).Unscoped().Where(clause.IN{Column: clause.Column{Name: field.DBName}, Values: keys}).Find(&after).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+
+	afterByKey := make(map[string]map[string]interface{}, len(after))
+	for _, row := range after {
+		afterByKey[fmt.Sprint(row[field.DBName])] = row
+	}
+
+	for _, row := range rows {
+		a.write(db, ActionUpdate, row, afterByKey[fmt.Sprint(row[field.DBName])])
+	}
+}
+
+func (a *Plugin) afterDelete(db *gorm.DB) {
+	for _, row := range a.beforeRows(db) {
+		a.write(db, ActionDelete, row, nil)
+	}
+}
+
+func (a *Plugin) beforeRows(db *gorm.DB) []map[string]interface{} {
+	if !audited(db) || db.RowsAffected == 0 {
+		return nil
+	}
+
+	value, ok := db.InstanceGet(beforeKey)
+	if !ok {
+		return nil
+	}
+
+	rows, _ := value.([]map[string]interface{})
+	return rows
+}
+
+func (a *Plugin) write(db *gorm.DB, action string, before map[string]interface{}, after map[string]interface{}) {
+	changes := Diff(before, after, ignored(db.Statement.Schema)...)
+	if action == ActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	entry := &Entry{
+		Entity:   db.Statement.Schema.Name,
+		EntityID: entityID(before, after),
+		Action:   action,
+		Changes:  changes,
+	}
+
+	if actor := FromContext(db.Statement.Context); actor != nil {
+		entry.Actor = *actor
+	}
+
+	if err := a.store(db.Session(&gorm.Session{NewDB: true}), entry); err != nil {
+		db.AddError(err)
+	}
+}
+
+// ignored lists updated_at and the columns of the fields tagged audit:"-", such as secrets
+func ignored(s *schema.Schema) []string {
+	columns := []string{"updated_at"}
+	for _, field := range s.Fields {
+		if field.DBName != "" && field.Tag.Get("audit") == "-" {
+			columns = append(columns, field.DBName)
+		}
+	}
+
+	return columns
+}
+
+// session is a new statement on the model of db within the same transaction
+func (a *Plugin) session(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(db.Statement.Schema.ModelType).Interface())
+}
+
+// values reads the columns of a model the way they are written to the database
+func values(s *schema.Schema, value reflect.Value) map[string]interface{} {
+	row := make(map[string]interface{}, len(s.DBNames))
+	for _, name := range s.DBNames {
+		v, _ := s.FieldsByDBName[name].ValueOf(value)
+		if valuer, ok := v.(driver.Valuer); ok {
+			v, _ = valuer.Value()
+		}
+		row[name] = v
+	}
+
+	return row
+}
+
+// entityID is the business id of the row, record_id for the models without one
+func entityID(rows ...map[string]interface{}) string {
+	for _, row := range rows {
+		if id, ok := row["id"]; ok && id != nil && id != "" {
+			return fmt.Sprint(id)
+		}
+	}
+
+	for _, row := range rows {
+		if id, ok := row["record_id"]; ok && id != nil {
+			return fmt.Sprint(id)
+		}
+	}
+
+	return ""
+}
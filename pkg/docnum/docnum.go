@@ -0,0 +1,95 @@
+package docnum
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reset of a sequence
+const (
+	ResetNone    = ""
+	ResetYearly  = "yearly"
+	ResetMonthly = "monthly"
+)
+
+// DefaultFormat is the numbering used before the templates, e.g. BKKJKT0001
+const DefaultFormat = "{prefix}{branch}{seq:4}"
+
+var seqToken = regexp.MustCompile(`\{seq(?::(\d+))?\}`)
+
+// Values fill the tokens of a format
+type Values struct {
+	Prefix  string
+	Company string
+	Branch  string
+	Date    time.Time
+}
+
+// Validate checks the format has one {seq} token and shows the period it resets on
+func Validate(format string, reset string) error {
+	if n := len(seqToken.FindAllString(format, -1)); n != 1 {
+		return fmt.Errorf("number format %q needs one {seq} token", format)
+	}
+
+	switch strings.ToLower(reset) {
+	case ResetNone:
+	case ResetYearly:
+		if !hasYear(format) {
+			return fmt.Errorf("number format %q resets yearly without {yyyy} or {yy}", format)
+		}
+	case ResetMonthly:
+		if !hasYear(format) || !strings.Contains(format, "{mm}") {
+			return fmt.Errorf("number format %q resets monthly without the year and {mm}", format)
+		}
+	default:
+		return fmt.Errorf("number reset %q is not valid", reset)
+	}
+
+	return nil
+}
+
+// Render returns the document number of seq
+func Render(format string, v Values, seq int) string {
+	s := seqToken.ReplaceAllStringFunc(format, func(token string) string {
+		width := 0
+		if m := seqToken.FindStringSubmatch(token); m[1] != "" {
+			width, _ = strconv.Atoi(m[1])
+		}
+		return fmt.Sprintf("%0*d", width, seq)
+	})
+
+	return replacer(v).Replace(s)
+}
+
+// Key identifies the sequence of a number: the format without its {seq} token, followed by the
+// period when the sequence resets
+func Key(format string, reset string, v Values) string {
+	key := replacer(v).Replace(seqToken.ReplaceAllString(format, "#"))
+
+	switch strings.ToLower(reset) {
+	case ResetYearly:
+		key += ":" + v.Date.Format("2006")
+	case ResetMonthly:
+		key += ":" + v.Date.Format("200601")
+	}
+
+	return key
+}
+
+func replacer(v Values) *strings.Replacer {
+	return strings.NewReplacer(
+		"{prefix}", v.Prefix,
+		"{company}", v.Company,
+		"{branch}", v.Branch,
+		"{yyyy}", v.Date.Format("2006"),
+		"{yy}", v.Date.Format("06"),
+		"{mm}", v.Date.Format("01"),
+	)
+}
+
+func hasYear(format string) bool {
+	return strings.Contains(format, "{yyyy}") || strings.Contains(format, "{yy}")
+}
@@ -0,0 +1,37 @@
+package docnum
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	v := Values{Prefix: "BKK", Company: "PT1", Branch: "JKT", Date: time.Date(2026, 3, 9, 0, 0, 0, 0, time.Local)}
+
+	assert.Equal(t, "BKKJKT0001", Render(DefaultFormat, v, 1))
+	assert.Equal(t, "BKKJKT12345", Render(DefaultFormat, v, 12345))
+	assert.Equal(t, "PT1/JKT/BKK/2026/03/00042", Render("{company}/{branch}/{prefix}/{yyyy}/{mm}/{seq:5}", v, 42))
+	assert.Equal(t, "BKK-26-7", Render("{prefix}-{yy}-{seq}", v, 7))
+}
+
+func TestKey(t *testing.T) {
+	v := Values{Prefix: "BKK", Company: "PT1", Branch: "JKT", Date: time.Date(2026, 3, 9, 0, 0, 0, 0, time.Local)}
+
+	assert.Equal(t, "BKKJKT#", Key(DefaultFormat, ResetNone, v))
+	assert.Equal(t, "BKK/2026/#:2026", Key("{prefix}/{yyyy}/{seq:4}", ResetYearly, v))
+	assert.Equal(t, "BKK/2026/03/#:202603", Key("{prefix}/{yyyy}/{mm}/{seq:4}", "Monthly", v))
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate(DefaultFormat, ResetNone))
+	assert.Nil(t, Validate("{prefix}/{yyyy}/{seq:4}", ResetYearly))
+	assert.Nil(t, Validate("{prefix}/{yy}{mm}/{seq:4}", ResetMonthly))
+
+	assert.NotNil(t, Validate("{prefix}{branch}", ResetNone))
+	assert.NotNil(t, Validate("{seq}{seq}", ResetNone))
+	assert.NotNil(t, Validate("{prefix}{seq:4}", ResetYearly))
+	assert.NotNil(t, Validate("{prefix}{yyyy}{seq:4}", ResetMonthly))
+	assert.NotNil(t, Validate(DefaultFormat, "weekly"))
+}
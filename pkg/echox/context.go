@@ -0,0 +1,14 @@
+package echox
+
+import (
+	"github.com/Aguztinus/petty-cash-backend/constants"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AfterCommit runs fn once the database transaction of the request is committed, fn is dropped
+// when the transaction is rolled back
+func AfterCommit(ctx echo.Context, fn func()) {
+	fns, _ := ctx.Get(constants.AfterCommit).([]func())
+	ctx.Set(constants.AfterCommit, append(fns, fn))
+}
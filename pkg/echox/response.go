@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/Aguztinus/petty-cash-backend/errors"
+	"github.com/Aguztinus/petty-cash-backend/pkg/fsm"
 
 	"github.com/labstack/echo/v4"
 )
@@ -27,10 +28,23 @@ func (a Response) JSON(ctx echo.Context) error {
 			a.Code = http.StatusInternalServerError
 		}
 
-		if errors.Is(err, errors.DatabaseRecordNotFound) {
+		if errors.Is(err, errors.DatabaseRecordNotFound) || errors.Is(err, errors.ReportJobNotFound) ||
+			errors.Is(err, errors.ReportSubscriptionNotFound) || errors.Is(err, errors.NotificationNotFound) ||
+			errors.Is(err, errors.WebhookEndpointNotFound) || errors.Is(err, errors.WebhookDeliveryNotFound) ||
+			errors.Is(err, errors.GLExportNotFound) {
 			a.Code = http.StatusNotFound
 		}
 
+		if errors.Is(err, errors.AttachmentAccessForbidden) || errors.Is(err, errors.ReportJobAccessForbidden) ||
+			errors.Is(err, errors.ReportSubscriptionAccessForbidden) {
+			a.Code = http.StatusForbidden
+		}
+
+		var transitionErr *fsm.TransitionError
+		if errors.As(err, &transitionErr) {
+			a.Code = http.StatusConflict
+		}
+
 		a.Message = err.Error()
 	}
 
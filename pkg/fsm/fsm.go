@@ -0,0 +1,77 @@
+package fsm
+
+import (
+	"fmt"
+)
+
+// Transition moves a document from one of From to To when Action is taken, an empty To keeps the state
+type Transition struct {
+	Action string
+	From   []string
+	To     string
+}
+
+// Machine is the declared life cycle of a document type
+type Machine struct {
+	Name        string
+	transitions []Transition
+}
+
+// TransitionError is returned for an action that is not allowed in the current state
+type TransitionError struct {
+	Name   string
+	State  string
+	Action string
+}
+
+func (a *TransitionError) Error() string {
+	return fmt.Sprintf("%s cannot %s when %s", a.Name, a.Action, a.State)
+}
+
+// New declares a machine, the order of the transitions is the order of Actions
+func New(name string, transitions ...Transition) *Machine {
+	return &Machine{Name: name, transitions: transitions}
+}
+
+// Next returns the state after action, or a *TransitionError
+func (a *Machine) Next(state string, action string) (string, error) {
+	for _, t := range a.transitions {
+		if t.Action != action || !contains(t.From, state) {
+			continue
+		}
+
+		if t.To == "" {
+			return state, nil
+		}
+		return t.To, nil
+	}
+
+	return "", &TransitionError{Name: a.Name, State: state, Action: action}
+}
+
+// Check returns a *TransitionError when action is not allowed in state
+func (a *Machine) Check(state string, action string) error {
+	_, err := a.Next(state, action)
+	return err
+}
+
+// Actions lists the actions allowed in state
+func (a *Machine) Actions(state string) []string {
+	actions := []string{}
+	for _, t := range a.transitions {
+		if contains(t.From, state) && !contains(actions, t.Action) {
+			actions = append(actions, t.Action)
+		}
+	}
+
+	return actions
+}
+
+func contains(items []string, item string) bool {
+	for _, v := range items {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package fsm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachine(t *testing.T) {
+	m := New("BKK",
+		Transition{Action: "update", From: []string{"Pending", "Rejected"}},
+		Transition{Action: "reject", From: []string{"Pending"}, To: "Rejected"},
+		Transition{Action: "pay", From: []string{"Approved"}, To: "Paid"},
+	)
+
+	state, err := m.Next("Approved", "pay")
+	assert.Nil(t, err)
+	assert.Equal(t, "Paid", state)
+
+	state, err = m.Next("Rejected", "update")
+	assert.Nil(t, err)
+	assert.Equal(t, "Rejected", state)
+
+	err = m.Check("Paid", "pay")
+	var te *TransitionError
+	assert.ErrorAs(t, err, &te)
+	assert.Equal(t, "Paid", te.State)
+	assert.Equal(t, "BKK cannot pay when Paid", err.Error())
+
+	assert.Equal(t, []string{"update", "reject"}, m.Actions("Pending"))
+	assert.Equal(t, []string{}, m.Actions("Paid"))
+}
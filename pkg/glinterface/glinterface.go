@@ -0,0 +1,131 @@
+package glinterface
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StatusNew    = "NEW"
+	ActualFlag   = "A"
+	DateLayout   = "2006-01-02"
+	maxSegments  = 30
+	maxReference = 240
+)
+
+// Row is one journal line of the Oracle EBS GL_INTERFACE table, loaded from the CSV by SQL*Loader and
+// imported by Journal Import. Segments are SEGMENT1 onwards. References follow Journal Import:
+// REFERENCE1 batch name, REFERENCE2 batch description, REFERENCE4 journal name, REFERENCE5 journal
+// description, REFERENCE10 line description, REFERENCE21 to 23 free for the source document
+type Row struct {
+	LedgerID           int64
+	AccountingDate     time.Time
+	CurrencyCode       string
+	DateCreated        time.Time
+	CreatedBy          int64
+	UserJeCategoryName string
+	UserJeSourceName   string
+	Segments           []string
+	EnteredDr          int64
+	EnteredCr          int64
+	BatchName          string
+	BatchDescription   string
+	JournalName        string
+	JournalDescription string
+	LineDescription    string
+	VoucherNum         string
+	SourceType         string
+	SourceID           string
+	GroupID            int64
+	Attribute1         string
+}
+
+type Rows []*Row
+
+// SplitSegments splits a segmented value into n segments, fewer segments are padded with empty ones
+func SplitSegments(value string, separator string, n int) ([]string, error) {
+	if n <= 0 || n > maxSegments {
+		return nil, fmt.Errorf("gl interface has 1 to %d segments, got %d", maxSegments, n)
+	}
+
+	segments := strings.Split(strings.TrimSpace(value), separator)
+	if len(segments) > n {
+		return nil, fmt.Errorf("segmented value %q has more than %d segments", value, n)
+	}
+
+	for i := range segments {
+		segments[i] = strings.TrimSpace(segments[i])
+	}
+
+	return append(segments, make([]string, n-len(segments))...), nil
+}
+
+// Columns returns the header of a file with n segments
+func Columns(n int) []string {
+	columns := []string{"STATUS", "LEDGER_ID", "ACCOUNTING_DATE", "CURRENCY_CODE", "DATE_CREATED", "CREATED_BY",
+		"ACTUAL_FLAG", "USER_JE_CATEGORY_NAME", "USER_JE_SOURCE_NAME"}
+	for i := 1; i <= n; i++ {
+		columns = append(columns, "SEGMENT"+strconv.Itoa(i))
+	}
+
+	return append(columns, "ENTERED_DR", "ENTERED_CR", "ACCOUNTED_DR", "ACCOUNTED_CR", "REFERENCE1", "REFERENCE2",
+		"REFERENCE4", "REFERENCE5", "REFERENCE10", "REFERENCE21", "REFERENCE22", "REFERENCE23", "GROUP_ID", "ATTRIBUTE1")
+}
+
+// Record returns the row in the order of Columns, a zero amount is left empty as Journal Import expects
+// only one of DR and CR
+func (a *Row) Record(n int) []string {
+	record := []string{StatusNew, strconv.FormatInt(a.LedgerID, 10), a.AccountingDate.Format(DateLayout), a.CurrencyCode,
+		a.DateCreated.Format(DateLayout), strconv.FormatInt(a.CreatedBy, 10), ActualFlag, a.UserJeCategoryName, a.UserJeSourceName}
+	for i := 0; i < n; i++ {
+		segment := ""
+		if i < len(a.Segments) {
+			segment = a.Segments[i]
+		}
+		record = append(record, segment)
+	}
+
+	dr, cr := amount(a.EnteredDr), amount(a.EnteredCr)
+	return append(record, dr, cr, dr, cr, truncate(a.BatchName, 100), truncate(a.BatchDescription, 100),
+		truncate(a.JournalName, 100), truncate(a.JournalDescription, maxReference), truncate(a.LineDescription, maxReference),
+		truncate(a.VoucherNum, maxReference), truncate(a.SourceID, maxReference), truncate(a.SourceType, maxReference),
+		strconv.FormatInt(a.GroupID, 10), truncate(a.Attribute1, 150))
+}
+
+// WriteCsv writes the header and the rows with n segments
+func (a Rows) WriteCsv(w io.Writer, n int) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns(n)); err != nil {
+		return err
+	}
+
+	for _, row := range a {
+		if err := cw.Write(row.Record(n)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func amount(v int64) string {
+	if v == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(v, 10)
+}
+
+// truncate cuts s to the width of its GL_INTERFACE column, counted in characters
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+
+	return s
+}
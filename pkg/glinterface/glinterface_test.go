@@ -0,0 +1,73 @@
+package glinterface
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSegments(t *testing.T) {
+	segments, err := SplitSegments("01.101. 5101 .000", ".", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"01", "101", "5101", "000", ""}, segments)
+
+	_, err = SplitSegments("01.101.5101.000.00.1", ".", 5)
+	assert.NotNil(t, err)
+
+	_, err = SplitSegments("01", ".", 0)
+	assert.NotNil(t, err)
+}
+
+func TestWriteCsv(t *testing.T) {
+	date := time.Date(2021, 5, 31, 0, 0, 0, 0, time.Local)
+	row := &Row{
+		LedgerID:           2021,
+		AccountingDate:     date,
+		CurrencyCode:       "IDR",
+		DateCreated:        date,
+		CreatedBy:          -1,
+		UserJeCategoryName: "Petty Cash",
+		UserJeSourceName:   "Petty Cash",
+		Segments:           []string{"01", "101", "5101"},
+		EnteredDr:          150000,
+		BatchName:          "PC 01 JKT 20210501-20210531 7",
+		JournalName:        "BKK BKKJKT0001",
+		JournalDescription: strings.Repeat("x", 300),
+		LineDescription:    "Bensin, \"operasional\"",
+		VoucherNum:         "BKKJKT0001",
+		SourceType:         "BKK",
+		SourceID:           "9b2f",
+		GroupID:            7,
+		Attribute1:         "81",
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, Rows{row}.WriteCsv(&buf, 3))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, len(records[0]), len(records[1]))
+
+	header := make(map[string]string)
+	for i, column := range records[0] {
+		header[column] = records[1][i]
+	}
+
+	assert.Equal(t, "NEW", header["STATUS"])
+	assert.Equal(t, "2021-05-31", header["ACCOUNTING_DATE"])
+	assert.Equal(t, "5101", header["SEGMENT3"])
+	assert.Equal(t, "150000", header["ENTERED_DR"])
+	assert.Equal(t, "", header["ENTERED_CR"])
+	assert.Equal(t, "150000", header["ACCOUNTED_DR"])
+	assert.Equal(t, "BKKJKT0001", header["REFERENCE21"])
+	assert.Equal(t, "Bensin, \"operasional\"", header["REFERENCE10"])
+	assert.Len(t, header["REFERENCE5"], 240)
+	assert.Equal(t, "7", header["GROUP_ID"])
+	_, ok := header["SEGMENT4"]
+	assert.False(t, ok)
+}
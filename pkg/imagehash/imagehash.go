@@ -0,0 +1,90 @@
+package imagehash
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	// decoders of the receipt formats
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Difference returns the 64 bit difference hash (dHash) of an image as 16 hex digits. The image is
+// shrunk to 9x8 grey levels and every bit tells whether a pixel is brighter than its right neighbour,
+// so a rescaled or recompressed copy of a photo keeps almost the same hash.
+func Difference(img image.Image) string {
+	const w, h = 9, 8
+
+	bounds := img.Bounds()
+	var grey [h][w]uint64
+	for y := 0; y < h; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/h
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/h
+		for x := 0; x < w; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/w
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/w
+			grey[y][x] = average(img, x0, y0, x1, y1)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if grey[y][x] > grey[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash)
+}
+
+// DifferenceOf decodes a JPEG or PNG and returns its Difference hash
+func DifferenceOf(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return Difference(img), nil
+}
+
+// Distance is the number of differing bits of two hashes, -1 when one of them is not a hash
+func Distance(a string, b string) int {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return -1
+	}
+
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return -1
+	}
+
+	return bits.OnesCount64(x ^ y)
+}
+
+// average is the mean luminance of the block, an empty block takes its top left pixel
+func average(img image.Image, x0, y0, x1, y1 int) uint64 {
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	if y1 <= y0 {
+		y1 = y0 + 1
+	}
+
+	var sum, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			sum += (299*uint64(r) + 587*uint64(g) + 114*uint64(b)) / 1000
+			n++
+		}
+	}
+
+	return sum / n
+}
@@ -0,0 +1,67 @@
+package imagehash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gradient(w, h int, invert bool) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((x*7 + y*3) % 256)
+			if (x/8+y/8)%2 == 0 {
+				v = 255 - v
+			}
+			if invert {
+				v = 255 - v
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// scale enlarges img by factor with nearest neighbour sampling
+func scale(img image.Image, factor int) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx()*factor, b.Dy()*factor))
+	for y := 0; y < b.Dy()*factor; y++ {
+		for x := 0; x < b.Dx()*factor; x++ {
+			out.Set(x, y, img.At(x/factor, y/factor))
+		}
+	}
+	return out
+}
+
+func TestDifference(t *testing.T) {
+	a := Difference(gradient(180, 160, false))
+	assert.Len(t, a, 16)
+
+	// a rescaled copy stays close
+	b := Difference(scale(gradient(180, 160, false), 2))
+	assert.True(t, Distance(a, b) <= 6)
+
+	// another image is far away
+	c := Difference(gradient(180, 160, true))
+	assert.True(t, Distance(a, c) > 20)
+
+	assert.Equal(t, -1, Distance(a, "receipt"))
+}
+
+func TestDifferenceOf(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, jpeg.Encode(&buf, gradient(180, 160, false), &jpeg.Options{Quality: 60}))
+
+	hash, err := DifferenceOf(buf.Bytes())
+	assert.Nil(t, err)
+	assert.True(t, Distance(hash, Difference(gradient(180, 160, false))) <= 6)
+
+	_, err = DifferenceOf([]byte("%PDF-1.4"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,161 @@
+package jobqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Status - Queued: waiting for a worker, Running: picked by a worker, Done: File is ready, Failed: see Error
+const (
+	StatusQueued  = "Queued"
+	StatusRunning = "Running"
+	StatusDone    = "Done"
+	StatusFailed  = "Failed"
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// Job is kept until ExpiresAt, File is the name of the output below the directory of the worker
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Status     string          `json:"status"`
+	Owner      string          `json:"owner"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	File       string          `json:"file,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
+
+// Finish ends the job, Err is recorded when it failed and the job expires ttl after it finished
+func (a *Job) Finish(err error, now time.Time, ttl time.Duration) {
+	a.Status = StatusDone
+	if err != nil {
+		a.Status = StatusFailed
+		a.Error = err.Error()
+		a.File = ""
+	}
+
+	a.FinishedAt = &now
+	a.ExpiresAt = now.Add(ttl)
+}
+
+// Queue keeps jobs in redis, the pending ids in a list and every job until it expires in a sorted set
+// so its output can be removed
+type Queue struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// New creates a queue, ttl is how long a job and its output are kept
+func New(client *redis.Client, prefix string, ttl time.Duration) *Queue {
+	return &Queue{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (a *Queue) key(name string) string {
+	return a.prefix + ":jobs:" + name
+}
+
+// TTL is how long a job is kept after it finished
+func (a *Queue) TTL() time.Duration {
+	return a.ttl
+}
+
+// Enqueue stores the job as queued and hands it to the next free worker
+func (a *Queue) Enqueue(ctx context.Context, job *Job) error {
+	now := time.Now()
+	job.Status = StatusQueued
+	job.CreatedAt = now
+	job.ExpiresAt = now.Add(a.ttl)
+
+	if err := a.Save(ctx, job); err != nil {
+		return err
+	}
+
+	return a.client.LPush(ctx, a.key("pending"), job.ID).Err()
+}
+
+// Save stores the job until it expires
+func (a *Queue) Save(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Until(job.ExpiresAt)
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+
+	_, err = a.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, a.key(job.ID), data, ttl)
+		pipe.ZAdd(ctx, a.key("expiry"), &redis.Z{Score: float64(job.ExpiresAt.Unix()), Member: job.ID})
+		return nil
+	})
+
+	return err
+}
+
+func (a *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	data, err := a.client.Get(ctx, a.key(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	job := new(Job)
+	if err = json.Unmarshal(data, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Next waits up to timeout for a pending job and marks it running, it returns nil when none came.
+// A job that expired while it was pending is skipped.
+func (a *Queue) Next(ctx context.Context, timeout time.Duration) (*Job, error) {
+	result, err := a.client.BRPop(ctx, timeout, a.key("pending")).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	job, err := a.Get(ctx, result[1])
+	if err == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	job.Status = StatusRunning
+	if err = a.Save(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Expired removes and returns the ids of the jobs that expired before now
+func (a *Queue) Expired(ctx context.Context, now time.Time) ([]string, error) {
+	max := strconv.FormatInt(now.Unix(), 10)
+	ids, err := a.client.ZRangeByScore(ctx, a.key("expiry"), &redis.ZRangeBy{Min: "-inf", Max: max}).Result()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	if err = a.client.ZRemRangeByScore(ctx, a.key("expiry"), "-inf", max).Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
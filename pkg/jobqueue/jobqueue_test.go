@@ -0,0 +1,37 @@
+package jobqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinish(t *testing.T) {
+	now := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	job := &Job{ID: "a", Status: StatusRunning, File: "a.pdf"}
+	job.Finish(nil, now, time.Hour)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, "a.pdf", job.File)
+	assert.Equal(t, now.Add(time.Hour), job.ExpiresAt)
+
+	job = &Job{ID: "b", Status: StatusRunning, File: "b.pdf"}
+	job.Finish(errors.New("no branch"), now, time.Hour)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Equal(t, "no branch", job.Error)
+	assert.Empty(t, job.File)
+}
+
+func TestJobJSON(t *testing.T) {
+	job := &Job{ID: "a", Type: "lmdp", Payload: json.RawMessage(`{"branch_id":"b"}`)}
+	data, err := json.Marshal(job)
+	assert.Nil(t, err)
+
+	decoded := new(Job)
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, job.Payload, decoded.Payload)
+	assert.Nil(t, decoded.FinishedAt)
+}
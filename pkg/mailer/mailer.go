@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config - Username empty sends without authentication, as a local SMTP catcher such as MailHog expects
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Mailer sends messages through an SMTP server, STARTTLS is used when the server offers it
+type Mailer struct {
+	config Config
+}
+
+func New(config Config) *Mailer {
+	return &Mailer{config: config}
+}
+
+func (a *Mailer) Send(msg *Message) error {
+	data, err := msg.Bytes(a.config.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if a.config.Username != "" {
+		auth = smtp.PlainAuth("", a.config.Username, a.config.Password, a.config.Host)
+	}
+
+	addr := a.config.Host + ":" + strconv.Itoa(a.config.Port)
+	return smtp.SendMail(addr, auth, a.config.From, msg.To, data)
+}
+
+// Bytes encodes the message as multipart/mixed with a text body followed by the attachments
+func (a *Message) Bytes(from string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+
+	header := []string{
+		"From: " + from,
+		"To: " + strings.Join(a.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", a.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + w.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err = qp.Write([]byte(a.Body)); err != nil {
+		return nil, err
+	}
+	if err = qp.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range a.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		part, err = w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = writeBase64(part, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeBase64 wraps the encoding at 76 characters a line as RFC 2045 requires
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := 76
+		if len(encoded) < n {
+			n = len(encoded)
+		}
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:n]); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"bufio"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBytes(t *testing.T) {
+	msg := &Message{
+		To:          []string{"manager@example.com", "finance@example.com"},
+		Subject:     "Laporan Monitoring Dana Petty Cash",
+		Body:        "Terlampir laporan bulan Mei.",
+		Attachments: []Attachment{{Name: "lmdp.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.3 report")}},
+	}
+
+	data, err := msg.Bytes("pettycash@example.com", time.Date(2021, 6, 1, 6, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	m, err := mail.ReadMessage(strings.NewReader(string(data)))
+	assert.Nil(t, err)
+	assert.Equal(t, "manager@example.com, finance@example.com", m.Header.Get("To"))
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	assert.Equal(t, msg.Subject, subject)
+
+	_, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	r := multipart.NewReader(m.Body, params["boundary"])
+
+	part, err := r.NextPart()
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(part)
+	assert.Equal(t, msg.Body, string(body))
+
+	part, err = r.NextPart()
+	assert.Nil(t, err)
+	assert.Equal(t, "lmdp.pdf", part.FileName())
+}
+
+// TestSend delivers to a minimal SMTP catcher, the way a local MailHog is used in development
+func TestSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 catcher")
+
+		var lines []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				for {
+					line, err = r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					lines = append(lines, strings.TrimRight(line, "\r\n"))
+				}
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	m := New(Config{Host: "127.0.0.1", Port: addr.Port, From: "pettycash@example.com"})
+	err = m.Send(&Message{To: []string{"manager@example.com"}, Subject: "LRDP", Body: "report"})
+	assert.Nil(t, err)
+
+	lines := <-received
+	assert.Contains(t, lines, "MAIL FROM:<pettycash@example.com>")
+	assert.Contains(t, lines, "RCPT TO:<manager@example.com>")
+	assert.Contains(t, lines, "Subject: LRDP")
+}
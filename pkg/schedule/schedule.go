@@ -0,0 +1,101 @@
+package schedule
+
+import (
+	"errors"
+	"time"
+)
+
+// Frequency of a schedule
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+var ErrInvalid = errors.New("schedule is invalid")
+
+// Schedule runs at Hour:00 every day, every week on weekday Day (0 is Sunday) or every month on day
+// Day, a Day past the end of a month runs on the last day of that month
+type Schedule struct {
+	Frequency string
+	Day       int
+	Hour      int
+}
+
+func (a Schedule) Validate() error {
+	if a.Hour < 0 || a.Hour > 23 {
+		return ErrInvalid
+	}
+
+	switch a.Frequency {
+	case Daily:
+		return nil
+	case Weekly:
+		if a.Day < 0 || a.Day > 6 {
+			return ErrInvalid
+		}
+		return nil
+	case Monthly:
+		if a.Day < 1 || a.Day > 31 {
+			return ErrInvalid
+		}
+		return nil
+	default:
+		return ErrInvalid
+	}
+}
+
+// Next returns the first run after t, in the location of t
+func (a Schedule) Next(t time.Time) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+
+	switch a.Frequency {
+	case Weekly:
+		run := time.Date(y, m, d+(a.Day-int(t.Weekday())+7)%7, a.Hour, 0, 0, 0, loc)
+		if !run.After(t) {
+			run = run.AddDate(0, 0, 7)
+		}
+		return run
+	case Monthly:
+		run := a.monthly(y, m, loc)
+		if !run.After(t) {
+			run = a.monthly(y, m+1, loc)
+		}
+		return run
+	default:
+		run := time.Date(y, m, d, a.Hour, 0, 0, 0, loc)
+		if !run.After(t) {
+			run = run.AddDate(0, 0, 1)
+		}
+		return run
+	}
+}
+
+// Previous returns the run before run, a report sent at run covers the days from Previous(run) up to run
+func (a Schedule) Previous(run time.Time) time.Time {
+	y, m, d := run.Date()
+	loc := run.Location()
+
+	switch a.Frequency {
+	case Weekly:
+		return time.Date(y, m, d-7, a.Hour, 0, 0, 0, loc)
+	case Monthly:
+		return a.monthly(y, m-1, loc)
+	default:
+		return time.Date(y, m, d-1, a.Hour, 0, 0, 0, loc)
+	}
+}
+
+// monthly is the run in month m of year y, time.Date normalizes a month out of range
+func (a Schedule) monthly(y int, m time.Month, loc *time.Location) time.Time {
+	first := time.Date(y, m, 1, a.Hour, 0, 0, 0, loc)
+	last := first.AddDate(0, 1, -1).Day()
+
+	day := a.Day
+	if day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
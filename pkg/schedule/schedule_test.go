@@ -0,0 +1,48 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d int, h int) time.Time {
+	return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Schedule{Frequency: Daily, Hour: 6}.Validate())
+	assert.Nil(t, Schedule{Frequency: Weekly, Day: 1}.Validate())
+	assert.Nil(t, Schedule{Frequency: Monthly, Day: 31, Hour: 23}.Validate())
+
+	for _, s := range []Schedule{{Frequency: "hourly"}, {Frequency: Daily, Hour: 24}, {Frequency: Weekly, Day: 7}, {Frequency: Monthly}} {
+		assert.Equal(t, ErrInvalid, s.Validate(), s)
+	}
+}
+
+func TestNext(t *testing.T) {
+	daily := Schedule{Frequency: Daily, Hour: 6}
+	assert.Equal(t, date(2021, 5, 10, 6), daily.Next(date(2021, 5, 10, 5)))
+	assert.Equal(t, date(2021, 5, 11, 6), daily.Next(date(2021, 5, 10, 6)))
+
+	// 2021-05-10 is a Monday
+	weekly := Schedule{Frequency: Weekly, Day: 1, Hour: 6}
+	assert.Equal(t, date(2021, 5, 17, 6), weekly.Next(date(2021, 5, 10, 6)))
+	assert.Equal(t, date(2021, 5, 10, 6), weekly.Next(date(2021, 5, 9, 23)))
+
+	monthly := Schedule{Frequency: Monthly, Day: 1, Hour: 6}
+	assert.Equal(t, date(2021, 6, 1, 6), monthly.Next(date(2021, 5, 1, 6)))
+	assert.Equal(t, date(2022, 1, 1, 6), monthly.Next(date(2021, 12, 15, 0)))
+
+	endOfMonth := Schedule{Frequency: Monthly, Day: 31}
+	assert.Equal(t, date(2021, 2, 28, 0), endOfMonth.Next(date(2021, 1, 31, 0)))
+	assert.Equal(t, date(2021, 3, 31, 0), endOfMonth.Next(date(2021, 2, 28, 0)))
+}
+
+func TestPrevious(t *testing.T) {
+	assert.Equal(t, date(2021, 5, 9, 6), Schedule{Frequency: Daily, Hour: 6}.Previous(date(2021, 5, 10, 6)))
+	assert.Equal(t, date(2021, 5, 3, 6), Schedule{Frequency: Weekly, Day: 1, Hour: 6}.Previous(date(2021, 5, 10, 6)))
+	assert.Equal(t, date(2021, 5, 1, 6), Schedule{Frequency: Monthly, Day: 1, Hour: 6}.Previous(date(2021, 6, 1, 6)))
+	assert.Equal(t, date(2021, 2, 28, 0), Schedule{Frequency: Monthly, Day: 31}.Previous(date(2021, 3, 31, 0)))
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Local stores objects as files below Root
+type Local struct {
+	Root string
+}
+
+// NewLocal creates a local storage, Root is created on the first Put
+func NewLocal(root string) *Local {
+	return &Local{Root: root}
+}
+
+func (a *Local) path(key string) (string, error) {
+	if err := CheckKey(key); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(a.Root, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first so a reader never sees a partial object
+func (a *Local) Put(key string, data []byte, contentType string) error {
+	name, err := a.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(name), ".upload-")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+func (a *Local) Get(key string) (io.ReadCloser, error) {
+	name, err := a.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return f, err
+}
+
+func (a *Local) Delete(key string) error {
+	name, err := a.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(name); os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
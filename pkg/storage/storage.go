@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrNotFound   = errors.New("storage object not found")
+	ErrInvalidKey = errors.New("storage key is invalid")
+)
+
+// Storage keeps objects by key, a key is a relative slash separated path such as "ab/abcdef"
+type Storage interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// CheckKey rejects keys that are absolute or would leave the storage root
+func CheckKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
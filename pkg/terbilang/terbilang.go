@@ -0,0 +1,82 @@
+package terbilang
+
+import (
+	"strings"
+)
+
+var units = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas"}
+
+// scales are the names of each group of three digits, from the lowest
+var scales = []string{"", "ribu", "juta", "miliar", "triliun", "kuadriliun", "kuintiliun"}
+
+// Of spells a number in Indonesian, e.g. 1250000 is "satu juta dua ratus lima puluh ribu"
+func Of(n int64) string {
+	if n == 0 {
+		return "nol"
+	}
+
+	// the magnitude of the smallest int64 does not fit an int64
+	u := uint64(n)
+	prefix := ""
+	if n < 0 {
+		u = uint64(-(n + 1)) + 1
+		prefix = "minus "
+	}
+
+	var groups []string
+	for scale := 0; u > 0; scale++ {
+		group := int(u % 1000)
+		u /= 1000
+		if group == 0 {
+			continue
+		}
+
+		switch {
+		case scale == 1 && group == 1:
+			groups = append(groups, "seribu")
+		case scale == 0:
+			groups = append(groups, hundreds(group))
+		default:
+			groups = append(groups, hundreds(group)+" "+scales[scale])
+		}
+	}
+
+	for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
+		groups[i], groups[j] = groups[j], groups[i]
+	}
+
+	return prefix + strings.Join(groups, " ")
+}
+
+// Rupiah spells an amount with the currency and a capital first letter, as written on vouchers
+func Rupiah(n int64) string {
+	s := Of(n) + " rupiah"
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// hundreds spells 1 to 999
+func hundreds(n int) string {
+	var words []string
+
+	switch h := n / 100; {
+	case h == 1:
+		words = append(words, "seratus")
+	case h > 1:
+		words = append(words, units[h]+" ratus")
+	}
+
+	switch r := n % 100; {
+	case r == 0:
+	case r < 12:
+		words = append(words, units[r])
+	case r < 20:
+		words = append(words, units[r%10]+" belas")
+	default:
+		words = append(words, units[r/10]+" puluh")
+		if r%10 > 0 {
+			words = append(words, units[r%10])
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,43 @@
+package terbilang
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOf(t *testing.T) {
+	for n, words := range map[int64]string{
+		0:             "nol",
+		1:             "satu",
+		10:            "sepuluh",
+		11:            "sebelas",
+		15:            "lima belas",
+		20:            "dua puluh",
+		99:            "sembilan puluh sembilan",
+		100:           "seratus",
+		111:           "seratus sebelas",
+		250:           "dua ratus lima puluh",
+		1000:          "seribu",
+		1001:          "seribu satu",
+		2000:          "dua ribu",
+		11000:         "sebelas ribu",
+		100000:        "seratus ribu",
+		1000000:       "satu juta",
+		1250000:       "satu juta dua ratus lima puluh ribu",
+		1000001000:    "satu miliar seribu",
+		2000000000000: "dua triliun",
+		-7500:         "minus tujuh ribu lima ratus",
+	} {
+		assert.Equal(t, words, Of(n), n)
+	}
+
+	assert.Equal(t, "minus sembilan kuintiliun dua ratus dua puluh tiga kuadriliun tiga ratus tujuh puluh dua triliun "+
+		"tiga puluh enam miliar delapan ratus lima puluh empat juta tujuh ratus tujuh puluh lima ribu delapan ratus delapan",
+		Of(math.MinInt64))
+}
+
+func TestRupiah(t *testing.T) {
+	assert.Equal(t, "Seratus lima puluh ribu rupiah", Rupiah(150000))
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery, SignatureHeader is "t=<unix time>,v1=<hex hmac>"
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+var ErrSignature = errors.New("webhook signature does not match")
+
+// NewSecret returns 32 random bytes hex encoded
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header of body sent at t, the HMAC-SHA256 covers "<unix time>.<body>"
+// so a captured delivery can't be replayed with another time
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + digest(secret, ts, body)
+}
+
+// Verify checks a signature header made by Sign, tolerance 0 accepts any age
+func Verify(secret string, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			sig = kv[1]
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return ErrSignature
+	}
+
+	if tolerance > 0 {
+		if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return ErrSignature
+		}
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(digest(secret, ts, body))) {
+		return ErrSignature
+	}
+
+	return nil
+}
+
+func digest(secret string, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is the wait before the retry following attempt (1 for the first attempt),
+// base doubled on every attempt up to max
+func Backoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempt; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+
+	if wait > max {
+		return max
+	}
+	return wait
+}
@@ -0,0 +1,42 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"bkk.created"}`)
+	now := time.Unix(1622505600, 0)
+
+	header := Sign("secret", now, body)
+	assert.Equal(t, "t=1622505600,v1=", header[:16])
+	assert.Len(t, header, 16+64)
+
+	assert.Nil(t, Verify("secret", header, body, now, 0))
+	assert.Nil(t, Verify("secret", header, body, now.Add(4*time.Minute), 5*time.Minute))
+
+	assert.Equal(t, ErrSignature, Verify("other", header, body, now, 0))
+	assert.Equal(t, ErrSignature, Verify("secret", header, []byte(`{"event":"bkk.paid"}`), now, 0))
+	assert.Equal(t, ErrSignature, Verify("secret", header, body, now.Add(6*time.Minute), 5*time.Minute))
+	assert.Equal(t, ErrSignature, Verify("secret", "v1=abc", body, now, 0))
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	assert.Nil(t, err)
+	assert.Len(t, a, 64)
+
+	b, _ := NewSecret()
+	assert.NotEqual(t, a, b)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, Backoff(1, time.Minute, time.Hour))
+	assert.Equal(t, 2*time.Minute, Backoff(2, time.Minute, time.Hour))
+	assert.Equal(t, 16*time.Minute, Backoff(5, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(7, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(100, time.Minute, time.Hour))
+}
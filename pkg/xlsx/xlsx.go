@@ -0,0 +1,291 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the MIME type of a workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// cell styles, index into cellXfs of styles.xml
+const (
+	styleDefault = iota
+	styleBold
+	styleNumber
+	styleBoldNumber
+)
+
+const (
+	kindString = iota
+	kindNumber
+	kindFormula
+)
+
+// Cell is a single spreadsheet cell, build it with Str, Int, Float or Formula
+type Cell struct {
+	kind  int
+	value string
+	bold  bool
+}
+
+// Str is a text cell
+func Str(v string) Cell {
+	return Cell{kind: kindString, value: v}
+}
+
+// Int is a numeric cell shown with thousand separators
+func Int(v int64) Cell {
+	return Cell{kind: kindNumber, value: strconv.FormatInt(v, 10)}
+}
+
+// Float is a numeric cell shown with thousand separators
+func Float(v float64) Cell {
+	return Cell{kind: kindNumber, value: strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+// Formula is a numeric cell computed by the spreadsheet, e.g. SUM(B2:B10)
+func Formula(f string) Cell {
+	return Cell{kind: kindFormula, value: strings.TrimPrefix(f, "=")}
+}
+
+// Bold returns the cell in bold
+func (a Cell) Bold() Cell {
+	a.bold = true
+	return a
+}
+
+func (a Cell) style() int {
+	switch {
+	case a.kind == kindString && a.bold:
+		return styleBold
+	case a.kind == kindString:
+		return styleDefault
+	case a.bold:
+		return styleBoldNumber
+	default:
+		return styleNumber
+	}
+}
+
+// Sheet is a worksheet, rows are numbered from 1 in the order they are added
+type Sheet struct {
+	Name   string
+	Widths []float64
+	rows   [][]Cell
+}
+
+// AddRow appends a row and returns its 1-based row number
+func (a *Sheet) AddRow(cells ...Cell) int {
+	a.rows = append(a.rows, cells)
+	return len(a.rows)
+}
+
+// File is an in-memory workbook
+type File struct {
+	sheets []*Sheet
+}
+
+// New creates an empty workbook
+func New() *File {
+	return &File{}
+}
+
+// AddSheet appends a worksheet, names are cut to the 31 characters allowed by Excel
+func (a *File) AddSheet(name string) *Sheet {
+	if len(name) > 31 {
+		name = name[:31]
+	}
+
+	sheet := &Sheet{Name: name}
+	a.sheets = append(a.sheets, sheet)
+	return sheet
+}
+
+// Save writes the workbook to filePath, creating the directory when needed
+func (a *File) Save(filePath string) (err error) {
+	if err = os.MkdirAll(path.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	fw, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if cerr := fw.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	return a.Write(fw)
+}
+
+// Write writes the workbook as an Office Open XML package
+func (a *File) Write(w io.Writer) error {
+	if len(a.sheets) == 0 {
+		a.AddSheet("Sheet1")
+	}
+
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", a.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", a.workbook()},
+		{"xl/_rels/workbook.xml.rels", a.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for _, p := range parts {
+		if err := writePart(zw, p.name, p.content); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range a.sheets {
+		fw, err := zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err = sheet.write(fw); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// CellName returns the A1 reference of a 0-based column and a 1-based row
+func CellName(col int, row int) string {
+	return ColName(col) + strconv.Itoa(row)
+}
+
+// ColName returns the letters of a 0-based column: 0 is A, 26 is AA
+func ColName(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+
+	return name
+}
+
+func (a *Sheet) write(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if len(a.Widths) > 0 {
+		b.WriteString("<cols>")
+		for i, width := range a.Widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString("</cols>")
+	}
+
+	b.WriteString("<sheetData>")
+	for r, row := range a.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := CellName(c, r+1)
+			switch cell.kind {
+			case kindString:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, cell.style(), escape(cell.value))
+			case kindNumber:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.style(), cell.value)
+			case kindFormula:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><f>%s</f></c>`, ref, cell.style(), escape(cell.value))
+			}
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData></worksheet>")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (a *File) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range a.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+
+	return b.String()
+}
+
+func (a *File) workbook() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range a.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(sheet.Name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+
+	return b.String()
+}
+
+func (a *File) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range a.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(a.sheets)+1)
+	b.WriteString(`</Relationships>`)
+
+	return b.String()
+}
+
+func writePart(zw *zip.Writer, name string, content string) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(fw, content)
+	return err
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// numFmtId 3 is the built-in #,##0 format
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="3" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+	`</cellXfs></styleSheet>`
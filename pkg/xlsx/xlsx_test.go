@@ -0,0 +1,56 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColName(t *testing.T) {
+	assert.Equal(t, "A", ColName(0))
+	assert.Equal(t, "Z", ColName(25))
+	assert.Equal(t, "AA", ColName(26))
+	assert.Equal(t, "AZ", ColName(51))
+	assert.Equal(t, "BA", ColName(52))
+	assert.Equal(t, "C7", CellName(2, 7))
+}
+
+func TestWrite(t *testing.T) {
+	f := New()
+	sheet := f.AddSheet("Aging")
+	sheet.AddRow(Str("Nama").Bold(), Str("Jumlah").Bold())
+	sheet.AddRow(Str("A & B"), Int(150000))
+	last := sheet.AddRow(Str("C"), Float(2.5))
+	total := sheet.AddRow(Str("Total").Bold(), Formula("=SUM(B2:"+CellName(1, last)+")").Bold())
+	assert.Equal(t, 4, total)
+
+	var buf bytes.Buffer
+	assert.Nil(t, f.Write(&buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+
+	parts := make(map[string]string)
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		assert.Nil(t, err)
+		b, err := ioutil.ReadAll(rc)
+		assert.Nil(t, err)
+		rc.Close()
+		parts[zf.Name] = string(b)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		assert.Contains(t, parts, name)
+	}
+
+	data := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, data, `<c r="B2" s="2"><v>150000</v></c>`)
+	assert.Contains(t, data, `<c r="B3" s="2"><v>2.5</v></c>`)
+	assert.Contains(t, data, `<c r="B4" s="3"><f>SUM(B2:B3)</f></c>`)
+	assert.Contains(t, data, "A &amp; B")
+	assert.Contains(t, parts["xl/workbook.xml"], `name="Aging"`)
+}